@@ -3,37 +3,79 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/actionlink"
 	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/attachment"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/cache"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/config"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/feed"
 	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/logger"
 	internalgrpc "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server/grpc"
 	internalhttp "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server/http"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/webhook"
 )
 
-var configFile string
+var (
+	configFile    string
+	migrationsDir string
+)
 
 func init() {
 	flag.StringVar(&configFile, "config", "./configs/config_calendar.toml", "Path to configuration file")
+	flag.StringVar(&migrationsDir, "migrations-dir", "./migrations", "Directory for migrations made by migrate create")
 }
 
 func main() {
 	flag.Parse()
 
-	if flag.Arg(0) == "version" {
+	switch flag.Arg(0) {
+	case "version":
 		printVersion()
 		return
+	case "migrate":
+		if err := runMigrate(flag.Args()[1:]); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	cfg, err := config.NewCalendar(configFile)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	log := logger.New(cfg.Logger.Level)
+
+	hub := feed.NewHub(cfg.Feed.HistorySize)
+	backend := app.NewStorage(cfg.Storage.Mode, cfg.Storage.Options())
+
+	var eventCache *cache.Storage
+	if cfg.Cache.Enabled {
+		eventCache = cache.NewStorage(backend, cfg.Cache.Options())
+		backend = eventCache
+	}
+
+	blobs, err := attachment.NewStore(cfg.Attachments.Store, cfg.Attachments.Dir)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
 	}
 
-	config := NewConfig(configFile)
-	log := logger.New(config.Logger.Level)
+	storage := attachment.NewStorage(feed.NewStorage(backend, hub), blobs, log)
 
-	storage := app.NewStorage(config.Storage.Mode, config.StorageConnectionString())
+	ctx, cancel := signal.NotifyContext(context.Background(),
+		syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
 
-	err := storage.Open()
+	err = storage.Open(ctx)
 	if err != nil {
 		log.Error("failed to open storage connection: " + err.Error())
 		return
@@ -41,30 +83,45 @@ func main() {
 
 	calendar := app.New(log, storage)
 
-	serverHTTP := internalhttp.NewServer(log, calendar, config.HTTPServerAddress())
-	serverGRPC := internalgrpc.NewServer(log, storage, config.Server.GRPC.Port)
+	serverGRPC := internalgrpc.NewServer(log, storage, hub, cfg.Server.GRPC.Port)
 
-	ctx, cancel := signal.NotifyContext(context.Background(),
-		syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
-	defer cancel()
+	gateway, err := serverGRPC.Gateway(ctx)
+	if err != nil {
+		log.Error("failed to create grpc gateway: " + err.Error())
+		return
+	}
 
-	go func() {
-		<-ctx.Done()
+	serverHTTP := internalhttp.NewServer(log, calendar, cfg.HTTPServerAddress())
+	serverHTTP.SetRateLimit(cfg.Server.HTTP.RateLimit, cfg.Server.HTTP.RateBurst)
+	serverHTTP.Handle(internalgrpc.GatewayPrefix, gateway)
+	serverHTTP.Handle(internalgrpc.OpenAPIPath, internalgrpc.OpenAPIHandler())
+	serverHTTP.Handle("/"+internalhttp.LocationEventsStream, internalhttp.FeedSSEHandler(hub))
+	serverHTTP.Handle("/"+internalhttp.LocationEventsWS, internalhttp.FeedWebSocketHandler(hub))
+	attachments := internalhttp.AttachmentsHandler(storage, cfg.Attachments.MaxSize())
+	serverHTTP.Handle("/"+internalhttp.LocationAttachments, attachments)
+	serverHTTP.Handle("/"+internalhttp.LocationAttachments+"/", attachments)
+	if eventCache != nil {
+		serverHTTP.Handle("/"+internalhttp.LocationCacheStats, internalhttp.CacheStatsHandler(eventCache.Stats))
+	}
+	if cfg.Actions.Secret != "" {
+		serverHTTP.Handle(actionlink.Path, internalhttp.ReminderActionHandler(storage,
+			actionlink.NewSigner(cfg.Actions.Secret)))
+	}
 
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
-		defer cancel()
+	go webhook.NewEnqueuer(storage, hub, log).Run(ctx)
 
-		err := storage.Close()
+	go config.NotifyReload(ctx, func() {
+		c, err := config.NewCalendar(configFile)
 		if err != nil {
-			log.Error("failed to close storage: " + err.Error())
+			log.Error("failed to reload config: " + err.Error())
+			return
 		}
 
-		if err := serverHTTP.Stop(ctx); err != nil {
-			log.Error("failed to stop http server: " + err.Error())
-		}
+		log.SetLevel(c.Logger.Level)
+		serverHTTP.SetRateLimit(c.Server.HTTP.RateLimit, c.Server.HTTP.RateBurst)
 
-		serverGRPC.Stop()
-	}()
+		log.Info("config reloaded")
+	})
 
 	go func() {
 		serverGRPC.Start()
@@ -77,4 +134,18 @@ func main() {
 		cancel()
 		os.Exit(1) //nolint:gocritic
 	}
+
+	// Хранилище закрывается после серверов, чтобы начатые запросы успели записать изменения.
+	stopCtx, stopCancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer stopCancel()
+
+	if err := serverHTTP.Stop(stopCtx); err != nil {
+		log.Error("failed to stop http server: " + err.Error())
+	}
+
+	serverGRPC.Stop(stopCtx)
+
+	if err := storage.Close(); err != nil {
+		log.Error("failed to close storage: " + err.Error())
+	}
 }
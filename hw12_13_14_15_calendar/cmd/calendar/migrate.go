@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/config"
+	sqlstorage "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage/sql"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/migrations"
+)
+
+var errMigrateUsage = errors.New("usage: calendar [-config file] migrate up|down|status|create NAME")
+
+// runMigrate выполняет подкоманду migrate. create работает без базы и конфигурации.
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errMigrateUsage
+	}
+
+	if args[0] == "create" {
+		if len(args) != 2 {
+			return errMigrateUsage
+		}
+
+		file, err := migrations.Create(migrationsDir, args[1], time.Now())
+		if err != nil {
+			return err
+		}
+		fmt.Println("created " + file)
+
+		return nil
+	}
+
+	if len(args) != 1 {
+		return errMigrateUsage
+	}
+
+	cfg, err := config.NewCalendar(configFile)
+	if err != nil {
+		return err
+	}
+	if cfg.Storage.Mode != app.DBModeSQL {
+		return fmt.Errorf("migrate: storage.mode must be %q, %q applies migrations on open",
+			app.DBModeSQL, app.DBModeSQLite)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	storage := sqlstorage.New(cfg.Storage.ConnectionString(), cfg.Storage.Timeouts())
+	storage.Retry = cfg.Storage.Retry()
+
+	err = storage.Open(ctx)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	switch args[0] {
+	case "up":
+		applied, err := storage.MigrateUp(ctx)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("no migrations to apply")
+		}
+		for _, m := range applied {
+			fmt.Println("applied " + m.Name)
+		}
+	case "down":
+		m, err := storage.MigrateDown(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Println("reverted " + m.Name)
+	case "status":
+		statuses, err := storage.MigrationStatus(ctx)
+		if err != nil {
+			return err
+		}
+		printMigrationStatus(statuses)
+	default:
+		return errMigrateUsage
+	}
+
+	return nil
+}
+
+func printMigrationStatus(statuses []sqlstorage.MigrationStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "APPLIED AT\tMIGRATION")
+
+	for _, s := range statuses {
+		appliedAt := s.AppliedAt
+		if appliedAt == "" {
+			appliedAt = "pending"
+		}
+		fmt.Fprintf(w, "%s\t%s\n", appliedAt, s.Name)
+	}
+
+	w.Flush()
+}
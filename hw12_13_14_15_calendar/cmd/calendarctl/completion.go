@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+var shells = []string{"bash", "zsh"}
+
+// completionScript дополняет команды, их флаги и первый аргумент. В zsh
+// используется тот же сценарий через bashcompinit.
+var completionScript = template.Must(template.New("completion").Parse(`{{if .Zsh -}}
+autoload -U +X bashcompinit && bashcompinit
+{{end -}}
+_calendarctl() {
+    local cur cmd i
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    cmd=""
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case "${COMP_WORDS[i]}" in
+            {{.GlobalValueFlags}}) ((i++)) ;;
+            -*) ;;
+            *) cmd="${COMP_WORDS[i]}"; break ;;
+        esac
+    done
+
+    case "$cmd" in
+        "")
+            if [[ "$cur" == -* ]]; then
+                COMPREPLY=($(compgen -W "{{.GlobalFlags}}" -- "$cur"))
+            else
+                COMPREPLY=($(compgen -W "{{.Commands}}" -- "$cur"))
+            fi
+            ;;
+{{- range .Completions}}
+        {{.Name}})
+            if [[ "$cur" == -* ]]; then
+                COMPREPLY=($(compgen -W "{{.Flags}}" -- "$cur"))
+            else
+                COMPREPLY=($(compgen -W "{{.Words}}" -- "$cur"))
+            fi
+            ;;
+{{- end}}
+    esac
+}
+complete -o default -F _calendarctl calendarctl
+`))
+
+type completion struct {
+	Name  string
+	Flags string
+	Words string
+}
+
+func (c *ctl) completion(_ *flag.FlagSet) func(ctx context.Context, args []string) error {
+	return func(_ context.Context, args []string) error {
+		if len(args) != 1 || (args[0] != "bash" && args[0] != "zsh") {
+			return errUsage
+		}
+
+		global := []string{"-config", "-addr", "-token", "-o"}
+
+		data := struct {
+			Zsh              bool
+			GlobalFlags      string
+			GlobalValueFlags string
+			Commands         string
+			Completions      []completion
+		}{
+			Zsh:              args[0] == "zsh",
+			GlobalFlags:      strings.Join(global, " "),
+			GlobalValueFlags: strings.Join(global, "|"),
+		}
+
+		names := make([]string, len(commands))
+		for i, cmd := range commands {
+			names[i] = cmd.name
+
+			// Флаги берутся из самой команды, поэтому сценарий не расходится с ними.
+			fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+			cmd.setup(&ctl{}, fs)
+
+			var flags []string
+			fs.VisitAll(func(f *flag.Flag) { flags = append(flags, "-"+f.Name) })
+
+			data.Completions = append(data.Completions, completion{
+				Name:  cmd.name,
+				Flags: strings.Join(flags, " "),
+				Words: strings.Join(cmd.words, " "),
+			})
+		}
+		data.Commands = strings.Join(names, " ")
+
+		err := completionScript.Execute(c.out, data)
+		if err != nil {
+			return fmt.Errorf("completion: %w", err)
+		}
+
+		return nil
+	}
+}
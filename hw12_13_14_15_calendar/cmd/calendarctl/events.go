@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server/grpc/pbv2"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	periodDay   = "day"
+	periodWeek  = "week"
+	periodMonth = "month"
+)
+
+var periods = []string{periodDay, periodWeek, periodMonth}
+
+// eventFlags - поля события в флагах create и update.
+type eventFlags struct {
+	title, start, end, description, user, remind, category, color, tags *string
+	allDay                                                              *bool
+
+	fs *flag.FlagSet
+}
+
+func newEventFlags(fs *flag.FlagSet) eventFlags {
+	return eventFlags{
+		fs:          fs,
+		allDay:      fs.Bool("all-day", false, "All-day event, start and end are aligned to whole days"),
+		title:       fs.String("title", "", "Event title"),
+		start:       fs.String("start", "", "Start time, \"2006-01-02 15:04:05\""),
+		end:         fs.String("end", "", "End time, \"2006-01-02 15:04:05\""),
+		description: fs.String("description", "", "Event description"),
+		user:        fs.String("user", "", "Owner user id"),
+		remind:      fs.String("remind", "", "Reminder time, \"2006-01-02 15:04:05\""),
+		category:    fs.String("category", "", "Event category"),
+		color:       fs.String("color", "", "Event color, \"#rrggbb\""),
+		tags:        fs.String("tags", "", "Comma separated tags, replace current tags on update"),
+	}
+}
+
+func (f eventFlags) event() *pbv2.Event {
+	e := &pbv2.Event{
+		Title:       *f.title,
+		DateStart:   *f.start,
+		DateEnd:     *f.end,
+		Description: *f.description,
+		UserId:      *f.user,
+		DatePost:    *f.remind,
+		Category:    *f.category,
+		Color:       *f.color,
+		Tags:        splitTags(*f.tags),
+	}
+
+	// Флаг передается, только если указан: update без -all-day не меняет событие.
+	f.fs.Visit(func(fl *flag.Flag) {
+		if fl.Name == "all-day" {
+			e.AllDay = proto.Bool(*f.allDay)
+		}
+	})
+
+	return e
+}
+
+// splitTags разбирает значение флага со списком меток через запятую.
+func splitTags(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, storage.TagSeparator)
+}
+
+func (c *ctl) create(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	f := newEventFlags(fs)
+
+	return func(ctx context.Context, args []string) error {
+		if len(args) != 0 {
+			return errUsage
+		}
+
+		ctx, cancel := c.call(ctx)
+		defer cancel()
+
+		resp, err := c.client.CreateEvent(ctx, &pbv2.CreateEventRequest{Event: f.event()})
+		if err != nil {
+			return err
+		}
+
+		return c.printID(resp.GetId())
+	}
+}
+
+func (c *ctl) update(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	f := newEventFlags(fs)
+
+	return func(ctx context.Context, args []string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		ctx, cancel := c.call(ctx)
+		defer cancel()
+
+		resp, err := c.client.UpdateEvent(ctx, &pbv2.UpdateEventRequest{Id: args[0], Event: f.event()})
+		if err != nil {
+			return err
+		}
+
+		return c.printEvent(fromPb(resp.GetEvent()))
+	}
+}
+
+func (c *ctl) delete(_ *flag.FlagSet) func(ctx context.Context, args []string) error {
+	return func(ctx context.Context, args []string) error {
+		if len(args) == 0 {
+			return errUsage
+		}
+
+		for _, id := range args {
+			ctx, cancel := c.call(ctx)
+			_, err := c.client.DeleteEvent(ctx, &pbv2.DeleteEventRequest{Id: id})
+			cancel()
+			if err != nil {
+				return fmt.Errorf("delete %s: %w", id, err)
+			}
+		}
+
+		return nil
+	}
+}
+
+func (c *ctl) get(_ *flag.FlagSet) func(ctx context.Context, args []string) error {
+	return func(ctx context.Context, args []string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		ctx, cancel := c.call(ctx)
+		defer cancel()
+
+		resp, err := c.client.GetEvent(ctx, &pbv2.GetEventRequest{Id: args[0]})
+		if err != nil {
+			return err
+		}
+
+		return c.printEvent(fromPb(resp.GetEvent()))
+	}
+}
+
+func (c *ctl) list(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	tags := fs.String("tags", "", "Only events with all of these comma separated tags")
+
+	return func(ctx context.Context, args []string) error {
+		events, err := c.listPeriod(ctx, args, splitTags(*tags))
+		if err != nil {
+			return err
+		}
+
+		return c.printEvents(events)
+	}
+}
+
+// listPeriod возвращает события периода из аргументов: day|week|month [DATE].
+// Непустой tags оставляет события со всеми указанными метками.
+func (c *ctl) listPeriod(ctx context.Context, args []string, tags []string) ([]storage.Event, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return nil, errUsage
+	}
+
+	date := time.Now().Format(time.DateOnly)
+	if len(args) == 2 {
+		date = args[1]
+	}
+
+	list := map[string]func(ctx context.Context, in *pbv2.ListEventsRequest, opts ...grpc.CallOption) (
+		*pbv2.ListEventsResponse, error,
+	){
+		periodDay:   c.client.ListEventDay,
+		periodWeek:  c.client.ListEventWeek,
+		periodMonth: c.client.ListEventMonth,
+	}[args[0]]
+	if list == nil {
+		return nil, errUsage
+	}
+
+	ctx, cancel := c.call(ctx)
+	defer cancel()
+
+	resp, err := list(ctx, &pbv2.ListEventsRequest{DateStart: date, Tags: tags})
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]storage.Event, len(resp.GetEvents()))
+	for i, e := range resp.GetEvents() {
+		events[i] = fromPb(e)
+	}
+
+	return events, nil
+}
+
+func fromPb(e *pbv2.Event) storage.Event {
+	return storage.Event{
+		ID:          e.GetId(),
+		Title:       e.GetTitle(),
+		DateStart:   e.GetDateStart(),
+		DateEnd:     e.GetDateEnd(),
+		Description: e.GetDescription(),
+		UserID:      e.GetUserId(),
+		DatePost:    e.GetDatePost(),
+		Category:    e.GetCategory(),
+		Color:       e.GetColor(),
+		Tags:        e.GetTags(),
+		AllDay:      e.GetAllDay(),
+	}
+}
+
+func toPb(e storage.Event) *pbv2.Event {
+	return &pbv2.Event{
+		Id:          e.ID,
+		Title:       e.Title,
+		DateStart:   e.DateStart,
+		DateEnd:     e.DateEnd,
+		Description: e.Description,
+		UserId:      e.UserID,
+		DatePost:    e.DatePost,
+		Category:    e.Category,
+		Color:       e.Color,
+		Tags:        e.Tags,
+		AllDay:      proto.Bool(e.AllDay),
+	}
+}
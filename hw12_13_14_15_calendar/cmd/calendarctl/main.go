@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/config"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server/grpc/pbv2"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+var errUsage = errors.New("usage")
+
+// ctl выполняет команды calendarctl через gRPC EventService v2.
+type ctl struct {
+	client  pbv2.EventServiceClient
+	in      io.Reader
+	out     io.Writer
+	output  string
+	timeout time.Duration
+}
+
+// command - подкоманда calendarctl. setup регистрирует флаги команды и
+// возвращает функцию, которая выполняет ее с оставшимися аргументами.
+type command struct {
+	name    string
+	args    string
+	help    string
+	words   []string
+	offline bool
+	setup   func(c *ctl, fs *flag.FlagSet) func(ctx context.Context, args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "create", args: "[flags]", help: "create an event and print its id", setup: (*ctl).create},
+		{name: "update", args: "[flags] ID", help: "change the given fields of an event", setup: (*ctl).update},
+		{name: "delete", args: "ID...", help: "delete events", setup: (*ctl).delete},
+		{name: "get", args: "ID", help: "print an event", setup: (*ctl).get},
+		{
+			name: "list", args: "day|week|month [DATE]", help: "list events of a period, today by default",
+			words: periods, setup: (*ctl).list,
+		},
+		{
+			name: "export", args: "[flags] day|week|month [DATE]", help: "export events of a period as JSON or ICS",
+			words: periods, setup: (*ctl).export,
+		},
+		{name: "import", args: "[flags] FILE|-", help: "create events from a JSON or ICS file", setup: (*ctl).importEvents},
+		{name: "watch", args: "[flags]", help: "print changes of events until interrupted", setup: (*ctl).watch},
+		{
+			name: "completion", args: "bash|zsh", help: "print a shell completion script",
+			words: shells, offline: true, setup: (*ctl).completion,
+		},
+	}
+}
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	if errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp) {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "calendarctl: "+describe(err))
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, in io.Reader, out io.Writer, errOut io.Writer) error {
+	fs := flag.NewFlagSet("calendarctl", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+
+	configFile := fs.String("config", defaultConfigFile(), "Path to configuration file")
+	address := fs.String("addr", "", "gRPC server address, overrides client.address")
+	token := fs.String("token", "", "Auth token, overrides client.token")
+	output := fs.String("o", "", "Output format: table or json, overrides client.output")
+
+	fs.Usage = func() { usage(fs) }
+
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	cmd, ok := findCommand(fs.Arg(0))
+	if !ok {
+		fs.Usage()
+		return errUsage
+	}
+
+	c := &ctl{in: in, out: out}
+
+	cmdFlags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	cmdFlags.SetOutput(errOut)
+	cmdFlags.Usage = func() {
+		fmt.Fprintf(errOut, "usage: calendarctl %s %s\n\n%s\n", cmd.name, cmd.args, cmd.help)
+		cmdFlags.PrintDefaults()
+	}
+
+	exec := cmd.setup(c, cmdFlags)
+
+	err = cmdFlags.Parse(fs.Args()[1:])
+	if err != nil {
+		return err
+	}
+
+	if !cmd.offline {
+		cfg, err := config.NewCtl(*configFile)
+		if err != nil {
+			return err
+		}
+
+		override(&cfg.Client.Address, *address)
+		override(&cfg.Client.Token, *token)
+		override(&cfg.Client.Output, strings.ToLower(*output))
+
+		err = cfg.Validate()
+		if err != nil {
+			return err
+		}
+
+		conn, err := dial(cfg.Client)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		c.client = pbv2.NewEventServiceClient(conn)
+		c.output = cfg.Client.Output
+		c.timeout = time.Duration(cfg.Client.TimeoutSeconds) * time.Second
+	}
+
+	err = exec(ctx, cmdFlags.Args())
+	if errors.Is(err, errUsage) {
+		cmdFlags.Usage()
+	}
+
+	return err
+}
+
+func usage(fs *flag.FlagSet) {
+	w := fs.Output()
+
+	fmt.Fprintln(w, "usage: calendarctl [flags] COMMAND [args]")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-11s %s\n", cmd.name, cmd.help)
+	}
+	fmt.Fprintln(w, "\nFlags:")
+	fs.PrintDefaults()
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+
+	return command{}, false
+}
+
+// defaultConfigFile возвращает файл конфигурации пользователя, если он есть.
+func defaultConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	file := filepath.Join(dir, "calendarctl", "config.toml")
+	if _, err := os.Stat(file); err != nil {
+		return ""
+	}
+
+	return file
+}
+
+func override(value *string, flagValue string) {
+	if flagValue != "" {
+		*value = flagValue
+	}
+}
+
+func dial(c config.ClientConf) (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	if c.TLS {
+		var err error
+		creds, err = tlsCredentials(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if c.Token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(bearerToken(c.Token)))
+	}
+
+	return grpc.Dial(c.Address, opts...)
+}
+
+func tlsCredentials(caFile string) (credentials.TransportCredentials, error) {
+	if caFile == "" {
+		return credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12}), nil
+	}
+
+	return credentials.NewClientTLSFromFile(caFile, "")
+}
+
+// bearerToken передает токен в метаданных каждого запроса.
+type bearerToken string
+
+func (t bearerToken) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+// RequireTransportSecurity не дает gRPC отправить токен без TLS.
+func (t bearerToken) RequireTransportSecurity() bool {
+	return true
+}
+
+// describe добавляет к ошибке gRPC нарушения полей из деталей статуса.
+func describe(err error) string {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err.Error()
+	}
+
+	msg := st.Code().String() + ": " + st.Message()
+	for _, d := range st.Details() {
+		br, ok := d.(*errdetails.BadRequest)
+		if !ok {
+			continue
+		}
+		for _, v := range br.GetFieldViolations() {
+			msg += "\n  " + v.GetField() + ": " + v.GetDescription()
+		}
+	}
+
+	return msg
+}
+
+// call ограничивает время одного запроса к серверу.
+func (c *ctl) call(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, c.timeout)
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/feed"
+	internalgrpc "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server/grpc"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server/grpc/pbv2"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+const testUserID = "d5095366-ea13-4c9d-ae72-9c83d2d93040"
+
+// startServer запускает EventService v2 на свободном порту и возвращает его адрес.
+func startServer(t *testing.T, interceptors ...grpc.UnaryServerInterceptor) string {
+	t.Helper()
+
+	return startServerWith(t, grpc.ChainUnaryInterceptor(interceptors...))
+}
+
+func startServerWith(t *testing.T, opts ...grpc.ServerOption) string {
+	t.Helper()
+
+	hub := feed.NewHub(feed.DefaultHistorySize)
+	s := feed.NewStorage(memorystorage.New(), hub)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer(opts...)
+	pbv2.RegisterEventServiceServer(srv, internalgrpc.NewEventServiceV2(s, hub))
+
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	return lis.Addr().String()
+}
+
+func runCtl(t *testing.T, addr string, args ...string) (string, error) {
+	t.Helper()
+
+	var out, errOut bytes.Buffer
+	err := run(context.Background(), append([]string{"-config", "", "-addr", addr}, args...),
+		strings.NewReader(""), &out, &errOut)
+
+	return out.String(), err
+}
+
+func TestEvents(t *testing.T) {
+	addr := startServer(t)
+
+	out, err := runCtl(t, addr, "create", "-title", "standup", "-start", "2024-06-03 10:00:00",
+		"-end", "2024-06-03 10:15:00", "-remind", "2024-06-03 09:45:00", "-user", testUserID, "-tags", "Team,daily")
+	require.NoError(t, err)
+	id := strings.TrimSpace(out)
+
+	out, err = runCtl(t, addr, "update", "-title", "retro", id)
+	require.NoError(t, err)
+	require.Contains(t, out, "retro")
+
+	out, err = runCtl(t, addr, "-o", "json", "get", id)
+	require.NoError(t, err)
+
+	var e storage.Event
+	require.NoError(t, json.Unmarshal([]byte(out), &e))
+	require.Equal(t, "retro", e.Title)
+	require.Equal(t, "2024-06-03 10:00:00", e.DateStart)
+	require.Equal(t, []string{"daily", "team"}, e.Tags)
+	require.False(t, e.AllDay)
+
+	out, err = runCtl(t, addr, "-o", "json", "update", "-all-day", id)
+	require.NoError(t, err)
+
+	e = storage.Event{}
+	require.NoError(t, json.Unmarshal([]byte(out), &e))
+	require.True(t, e.AllDay)
+	require.Equal(t, "2024-06-03 00:00:00", e.DateStart)
+	require.Equal(t, "2024-06-04 00:00:00", e.DateEnd)
+
+	out, err = runCtl(t, addr, "list", "-tags", "release", "week", "2024-06-03")
+	require.NoError(t, err)
+	require.Equal(t, 1, strings.Count(strings.TrimSpace(out), "\n")+1, "only header")
+
+	out, err = runCtl(t, addr, "list", "week", "2024-06-03")
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 2)
+	require.True(t, strings.HasPrefix(lines[0], "ID"))
+	require.Contains(t, lines[1], id)
+
+	_, err = runCtl(t, addr, "delete", id)
+	require.NoError(t, err)
+
+	_, err = runCtl(t, addr, "get", id)
+	require.Error(t, err)
+	require.Contains(t, describe(err), "NotFound")
+
+	_, err = runCtl(t, addr, "create", "-title", "no dates")
+	require.Error(t, err)
+	require.Contains(t, describe(err), "DateStart")
+}
+
+func TestImportExport(t *testing.T) {
+	addr := startServer(t)
+	dir := t.TempDir()
+
+	ics := filepath.Join(dir, "events.ics")
+	require.NoError(t, os.WriteFile(ics, []byte(strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"UID:foreign@example.com",
+		"DTSTART:20240603T100000",
+		"DTEND:20240603T110000",
+		"SUMMARY:planning",
+		"BEGIN:VALARM",
+		"TRIGGER:-PT10M",
+		"END:VALARM",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")), 0o600))
+
+	_, err := runCtl(t, addr, "import", ics)
+	require.Error(t, err, "owner is required")
+
+	out, err := runCtl(t, addr, "import", "-user", testUserID, ics)
+	require.NoError(t, err)
+	require.Len(t, strings.Fields(out), 1)
+
+	exported := filepath.Join(dir, "export.json")
+	_, err = runCtl(t, addr, "export", "-file", exported, "day", "2024-06-03")
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(exported)
+	require.NoError(t, err)
+
+	var events []storage.Event
+	require.NoError(t, json.Unmarshal(data, &events))
+	require.Len(t, events, 1)
+	require.Equal(t, "planning", events[0].Title)
+	require.Equal(t, "2024-06-03 09:50:00", events[0].DatePost)
+
+	out, err = runCtl(t, addr, "export", "-format", "ics", "day", "2024-06-03")
+	require.NoError(t, err)
+	require.Contains(t, out, "SUMMARY:planning\r\n")
+
+	_, err = runCtl(t, addr, "import", exported)
+	require.Error(t, err, "the same start time is busy")
+}
+
+func TestWatch(t *testing.T) {
+	addr := startServer(t)
+
+	_, err := runCtl(t, addr, "create", "-title", "standup", "-start", "2024-06-03 10:00:00",
+		"-end", "2024-06-03 10:15:00", "-remind", "2024-06-03 09:45:00", "-user", testUserID)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	var out bytes.Buffer
+	err = run(ctx, []string{"-config", "", "-addr", addr, "-o", "json", "watch", "-period", "day", "-date", "2024-06-03"},
+		nil, &out, &bytes.Buffer{})
+	require.NoError(t, err)
+
+	var ch change
+	require.NoError(t, json.Unmarshal(bytes.TrimSpace(out.Bytes()), &ch))
+	require.Equal(t, "created", ch.Type)
+	require.Equal(t, "standup", ch.Event.Title)
+}
+
+func TestToken(t *testing.T) {
+	// Сертификат тестового HTTPS сервера выписан на 127.0.0.1.
+	https := httptest.NewTLSServer(nil)
+	cert := https.TLS.Certificates[0]
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: https.Certificate().Raw}), 0o600))
+	https.Close()
+
+	var got []string
+	addr := startServerWith(t,
+		grpc.Creds(credentials.NewServerTLSFromCert(&cert)),
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo,
+			h grpc.UnaryHandler,
+		) (interface{}, error) {
+			md, _ := metadata.FromIncomingContext(ctx)
+			got = md.Get("authorization")
+			return h(ctx, req)
+		}))
+
+	_, err := runCtl(t, addr, "-token", "s3cr3t", "list", "day")
+	require.ErrorContains(t, err, "client.tls", "token is not sent without tls")
+
+	t.Setenv("CALENDAR_CLIENT_TLS", "true")
+	t.Setenv("CALENDAR_CLIENT_CA_FILE", caFile)
+
+	_, err = runCtl(t, addr, "-token", "s3cr3t", "list", "day")
+	require.NoError(t, err)
+	require.Equal(t, []string{"Bearer s3cr3t"}, got)
+}
+
+func TestUsage(t *testing.T) {
+	_, err := runCtl(t, "localhost:0", "unknown")
+	require.ErrorIs(t, err, errUsage)
+
+	_, err = runCtl(t, "localhost:0", "list", "year")
+	require.ErrorIs(t, err, errUsage)
+
+	out, err := runCtl(t, "localhost:0", "completion", "zsh")
+	require.NoError(t, err)
+	require.Contains(t, out, "bashcompinit")
+	require.Contains(t, out, "-format -user")
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/config"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+const eventsHeader = "ID\tSTART\tEND\tTITLE\tUSER\tREMIND\tTAGS"
+
+// change - изменение события в выводе watch.
+type change struct {
+	ID    uint64        `json:"id"`
+	Type  string        `json:"type"`
+	Event storage.Event `json:"event"`
+}
+
+func (c *ctl) printID(id string) error {
+	if c.output == config.OutputJSON {
+		return c.printJSON(struct {
+			ID string `json:"id"`
+		}{id})
+	}
+
+	_, err := fmt.Fprintln(c.out, id)
+
+	return err
+}
+
+func (c *ctl) printEvent(e storage.Event) error {
+	if c.output == config.OutputJSON {
+		return c.printJSON(e)
+	}
+
+	return c.printTable([]storage.Event{e})
+}
+
+func (c *ctl) printEvents(events []storage.Event) error {
+	if c.output == config.OutputJSON {
+		return c.printJSON(events)
+	}
+
+	return c.printTable(events)
+}
+
+func (c *ctl) printTable(events []storage.Event) error {
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, eventsHeader)
+	for _, e := range events {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", e.ID, e.DateStart, e.DateEnd, e.Title, e.UserID, e.DatePost,
+			strings.Join(e.Tags, storage.TagSeparator))
+	}
+
+	return w.Flush()
+}
+
+// printChange выводит изменение одной строкой, чтобы вывод можно было читать построчно.
+func (c *ctl) printChange(ch change) error {
+	if c.output == config.OutputJSON {
+		data, err := json.Marshal(ch)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(c.out, string(data))
+
+		return err
+	}
+
+	_, err := fmt.Fprintf(c.out, "%d %s %s %s %s\n", ch.ID, ch.Type, ch.Event.ID, ch.Event.DateStart, ch.Event.Title)
+
+	return err
+}
+
+func (c *ctl) printJSON(v interface{}) error {
+	enc := json.NewEncoder(c.out)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(v)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/ical"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server/grpc/pbv2"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+const (
+	formatJSON = "json"
+	formatICS  = "ics"
+)
+
+var errFormat = errors.New("format must be json or ics")
+
+func (c *ctl) export(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	format := fs.String("format", formatJSON, "File format: json or ics")
+	file := fs.String("file", "", "Output file, stdout by default")
+	tags := fs.String("tags", "", "Only events with all of these comma separated tags")
+
+	return func(ctx context.Context, args []string) error {
+		if *format != formatJSON && *format != formatICS {
+			return errFormat
+		}
+
+		events, err := c.listPeriod(ctx, args, splitTags(*tags))
+		if err != nil {
+			return err
+		}
+
+		w := c.out
+		if *file != "" {
+			f, err := os.Create(*file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+
+		if *format == formatICS {
+			return ical.Encode(w, events, time.Now())
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(events)
+	}
+}
+
+// importEvents создает события из файла одним пакетом: либо все, либо ни одного.
+// Идентификаторы из файла не сохраняются, у событий появляются новые.
+func (c *ctl) importEvents(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	format := fs.String("format", "", "File format: json or ics, by file extension by default")
+	user := fs.String("user", "", "Owner user id for events without one")
+
+	return func(ctx context.Context, args []string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		events, err := c.readEvents(args[0], *format)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return errors.New("no events to import")
+		}
+
+		ops := make([]*pbv2.BatchOperation, len(events))
+		for i, e := range events {
+			e.ID = ""
+			if e.UserID == "" {
+				e.UserID = *user
+			}
+
+			ops[i] = &pbv2.BatchOperation{Type: pbv2.BatchOperationType_BATCH_OPERATION_TYPE_CREATE, Event: toPb(e)}
+		}
+
+		ctx, cancel := c.call(ctx)
+		defer cancel()
+
+		resp, err := c.client.ApplyBatch(ctx, &pbv2.ApplyBatchRequest{Operations: ops})
+		if err != nil {
+			return err
+		}
+
+		for _, r := range resp.GetResults() {
+			err = c.printID(r.GetId())
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+func (c *ctl) readEvents(file string, format string) ([]storage.Event, error) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(file)), ".")
+		if format == "ical" {
+			format = formatICS
+		}
+	}
+	if format != formatJSON && format != formatICS {
+		return nil, fmt.Errorf("%s: %w", file, errFormat)
+	}
+
+	var r io.Reader = c.in
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	if format == formatICS {
+		return ical.Decode(r)
+	}
+
+	var events []storage.Event
+
+	err := json.NewDecoder(r).Decode(&events)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	return events, nil
+}
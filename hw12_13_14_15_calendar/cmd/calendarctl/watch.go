@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"strings"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server/grpc/pbv2"
+)
+
+var watchPeriods = map[string]pbv2.Period{
+	"":          pbv2.Period_PERIOD_UNSPECIFIED,
+	periodDay:   pbv2.Period_PERIOD_DAY,
+	periodWeek:  pbv2.Period_PERIOD_WEEK,
+	periodMonth: pbv2.Period_PERIOD_MONTH,
+}
+
+func (c *ctl) watch(fs *flag.FlagSet) func(ctx context.Context, args []string) error {
+	user := fs.String("user", "", "Only events of this user")
+	period := fs.String("period", "", "Only events of the period from -date: day, week or month")
+	date := fs.String("date", "", "Start of the period, \"2006-01-02\"")
+	lastID := fs.Uint64("last-id", 0, "Resume after this change id instead of printing current events")
+
+	return func(ctx context.Context, args []string) error {
+		p, ok := watchPeriods[*period]
+		if len(args) != 0 || !ok {
+			return errUsage
+		}
+
+		stream, err := c.client.WatchEvents(ctx, &pbv2.WatchEventsRequest{
+			DateStart:   *date,
+			Period:      p,
+			UserId:      *user,
+			LastEventId: *lastID,
+		})
+		if err != nil {
+			return err
+		}
+
+		for {
+			resp, err := stream.Recv()
+			// Прерывание команды - обычный способ завершить watch.
+			if errors.Is(err, io.EOF) || ctx.Err() != nil {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			err = c.printChange(change{
+				ID:    resp.GetId(),
+				Type:  changeName(resp.GetType()),
+				Event: fromPb(resp.GetEvent()),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// changeName возвращает тип изменения без префикса перечисления: created, updated, deleted.
+// Текущие события периода приходят как created с id 0.
+func changeName(t pbv2.ChangeType) string {
+	return strings.ToLower(strings.TrimPrefix(t.String(), "CHANGE_TYPE_"))
+}
@@ -2,14 +2,26 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
+	"net/http"
+	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/attachment"
 	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/broker"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/config"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/jobs"
 	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/logger"
-	sqlstorage "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage/sql"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/sender"
+	internalhttp "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server/http"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/webhook"
 )
 
 var configFile string
@@ -21,42 +33,181 @@ func init() {
 func main() {
 	flag.Parse()
 
-	config := NewConfig(configFile)
-	log := logger.New(config.Logger.Level)
+	cfg, err := config.NewScheduler(configFile)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	log := logger.New(cfg.Logger.Level)
+
+	ctx, cancel := signal.NotifyContext(context.Background(),
+		syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
 
-	storage := sqlstorage.New(config.StorageConnectionString())
+	storage := app.NewSchedulerStorage(cfg.Storage.Mode, cfg.Storage.Options())
 
-	err := storage.Open()
+	err = storage.Open(ctx)
 	if err != nil {
 		log.Error("failed to open storage connection: " + err.Error())
 		return
 	}
 
-	b := broker.New(config.BrokerConnectionString())
+	b := broker.New(cfg.Broker.ConnectionString())
 	err = b.Open()
 	if err != nil {
 		log.Error("failed to open broker connection: " + err.Error())
 		return
 	}
 
-	err = b.SetQueue(config.Broker.Queue)
+	err = b.SetQueue(cfg.Broker.Queue)
 	if err != nil {
 		log.Error("failed to set queue: " + err.Error())
 		return
 	}
 
+	err = b.SetDigestQueue(cfg.Broker.DigestQueue)
+	if err != nil {
+		log.Error("failed to set digest queue: " + err.Error())
+		return
+	}
+
+	for _, queue := range []string{cfg.Broker.WebhookQueue, cfg.Broker.WebhookResultQueue, cfg.Broker.StatusQueue} {
+		err = b.DeclareQueue(queue)
+		if err != nil {
+			log.Error("failed to declare queue: " + err.Error())
+			return
+		}
+	}
+
+	results, err := b.ConsumeMessage(cfg.Broker.WebhookResultQueue)
+	if err != nil {
+		log.Error("failed to start consuming webhook results: " + err.Error())
+		return
+	}
+
+	statuses, err := b.ConsumeMessage(cfg.Broker.StatusQueue)
+	if err != nil {
+		log.Error("failed to start consuming notification statuses: " + err.Error())
+		return
+	}
+
+	blobs, err := attachment.NewStore(cfg.Attachments.Store, cfg.Attachments.Dir)
+	if err != nil {
+		log.Error("failed to create attachment store: " + err.Error())
+		return
+	}
+
 	scheduler := app.NewScheduler(storage, b, log)
+	scheduler.SetBlobRemover(blobs)
 
-	ctx, cancel := signal.NotifyContext(context.Background(),
-		syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
-	defer cancel()
+	dispatcher := webhook.NewDispatcher(storage, b, log, webhook.DispatchConfig{
+		Queue:       cfg.Broker.WebhookQueue,
+		MaxAttempts: cfg.Webhook.MaxAttempts,
+		Backoff:     time.Duration(cfg.Webhook.BackoffSeconds) * time.Second,
+		MaxBackoff:  time.Duration(cfg.Webhook.MaxBackoffSeconds) * time.Second,
+		Lease:       time.Duration(cfg.Webhook.LeaseSeconds) * time.Second,
+		BatchSize:   cfg.Webhook.BatchSize,
+	})
+
+	go config.NotifyReload(ctx, func() {
+		c, err := config.NewScheduler(configFile)
+		if err != nil {
+			log.Error("failed to reload config: " + err.Error())
+			return
+		}
+
+		log.SetLevel(c.Logger.Level)
 
-	go scheduler.ProcessNotifications(ctx, config.Storage.PollTimeSeconds, config.Storage.OutdatedEventDays)
+		log.Info("config reloaded")
+	})
+
+	runner := jobs.NewRunner(log)
+
+	err = addJobs(runner, cfg, scheduler)
+	if err != nil {
+		log.Error("failed to register jobs: " + err.Error())
+		return
+	}
+
+	leader := app.NewLeader(storage, log, app.LeaderConfig{
+		Name:          app.SchedulerLease,
+		Holder:        leaseHolder(),
+		TTL:           time.Duration(cfg.Leader.LeaseSeconds) * time.Second,
+		RenewInterval: time.Duration(cfg.Leader.RenewSeconds) * time.Second,
+	})
+
+	leaderDone := make(chan struct{})
+	go func() {
+		defer close(leaderDone)
+		leader.Run(ctx, func(ctx context.Context) {
+			runner.Run(ctx)
+			scheduler.ResetDigests()
+		})
+	}()
+
+	admin := newAdminServer(cfg.AdminServerAddress(), runner)
+	go func() {
+		log.Info("starting admin server on http://" + admin.Addr)
+		err := admin.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("admin server error: " + err.Error())
+		}
+	}()
+
+	go dispatcher.Run(ctx, time.Duration(cfg.Storage.PollTimeSeconds)*time.Second)
+
+	go func() {
+		for msg := range results {
+			var r webhook.Result
+
+			err := json.Unmarshal(msg.Body, &r)
+			if err != nil {
+				log.Error("failed to decode webhook result: " + err.Error())
+				continue
+			}
+
+			err = dispatcher.HandleResult(ctx, r)
+			if err != nil {
+				log.Error("failed to save webhook result: " + err.Error())
+			}
+		}
+	}()
+
+	go func() {
+		for msg := range statuses {
+			var st sender.Status
+
+			err := json.Unmarshal(msg.Body, &st)
+			if err != nil {
+				log.Error("failed to decode notification status: " + err.Error())
+				continue
+			}
+
+			text := fmt.Sprintf("%s %s for %s via %s: %s", st.Kind, st.ID, st.UserID, st.Channel, st.Status)
+			if st.Status == sender.StatusSent {
+				log.Info(text)
+			} else {
+				log.Error(text + " " + st.Error)
+			}
+		}
+	}()
 
 	log.Info("scheduler is running...")
 
 	<-ctx.Done()
 
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer shutdownCancel()
+
+	err = admin.Shutdown(shutdownCtx)
+	if err != nil {
+		log.Error("failed to stop admin server: " + err.Error())
+	}
+
+	// Аренду нужно освободить до закрытия хранилища.
+	<-leaderDone
+
 	err = storage.Close()
 	if err != nil {
 		log.Error("failed to close storage: " + err.Error())
@@ -69,3 +220,47 @@ func main() {
 
 	log.Info("shutting down scheduler...")
 }
+
+// leaseHolder возвращает имя реплики для аренды: хост и случайный суффикс,
+// чтобы реплики на одном хосте не считали аренду общей.
+func leaseHolder() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "scheduler"
+	}
+
+	return host + "-" + uuid.NewString()[:8]
+}
+
+// addJobs регистрирует задачи планировщика с расписанием из секции jobs.
+func addJobs(runner *jobs.Runner, cfg config.Scheduler, scheduler *app.Scheduler) error {
+	list := map[string]func(ctx context.Context) error{
+		app.JobNotifications: scheduler.SendNotifications,
+		app.JobPurge: func(ctx context.Context) error {
+			return scheduler.RemoveOutdatedEvents(ctx, cfg.Storage.OutdatedEventDays)
+		},
+		app.JobDigest: scheduler.SendDigests,
+	}
+
+	for _, name := range app.SchedulerJobs {
+		err := runner.Add(cfg.Jobs[name].Job(name, list[name]))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newAdminServer(address string, runner *jobs.Runner) *http.Server {
+	mux := http.NewServeMux()
+	handler := internalhttp.JobsHandler(runner)
+	mux.Handle("/"+internalhttp.LocationJobs, handler)
+	mux.Handle("/"+internalhttp.LocationJobs+"/", handler)
+
+	return &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: 2 * time.Second,
+	}
+}
@@ -2,14 +2,22 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
 	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/broker"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/config"
 	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/logger"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/sender"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/webhook"
 )
 
 var configFile string
@@ -21,11 +29,16 @@ func init() {
 func main() {
 	flag.Parse()
 
-	config := NewConfig(configFile)
-	log := logger.New(config.Logger.Level)
+	cfg, err := config.NewSender(configFile)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 
-	b := broker.New(config.BrokerConnectionString())
-	err := b.Open()
+	log := logger.New(cfg.Logger.Level)
+
+	b := broker.New(cfg.Broker.ConnectionString())
+	err = b.Open()
 	if err != nil {
 		log.Error("failed to open broker connection: " + err.Error())
 		return
@@ -33,24 +46,153 @@ func main() {
 
 	log.Info("sender is running...")
 
-	msgChan, err := b.ConsumeMessage(config.Broker.Queue)
+	msgChan, err := b.ConsumeMessage(cfg.Broker.Queue)
 	if err != nil {
 		log.Error("Failed to start consuming messages: " + err.Error())
 		return
 	}
 
+	queues := []string{
+		cfg.Broker.WebhookQueue, cfg.Broker.WebhookResultQueue, cfg.Broker.DigestQueue, cfg.Broker.StatusQueue,
+	}
+	for _, queue := range queues {
+		err = b.DeclareQueue(queue)
+		if err != nil {
+			log.Error("failed to declare queue: " + err.Error())
+			return
+		}
+	}
+
+	webhookChan, err := b.ConsumeMessage(cfg.Broker.WebhookQueue)
+	if err != nil {
+		log.Error("Failed to start consuming webhooks: " + err.Error())
+		return
+	}
+
+	digestChan, err := b.ConsumeManualAck(cfg.Broker.DigestQueue)
+	if err != nil {
+		log.Error("failed to start consuming digests: " + err.Error())
+		return
+	}
+
+	templates, err := sender.LoadTemplates(cfg.Templates.Dir)
+	if err != nil {
+		log.Error("failed to load templates: " + err.Error())
+		return
+	}
+
+	dispatcher := sender.NewDispatcher(templates, newChannels(cfg, log), cfg.Channels.Default,
+		func(ctx context.Context, s sender.Status) error {
+			return b.Publish(ctx, cfg.Broker.StatusQueue, s)
+		})
+
+	reminders := sender.NewReminderSender(dispatcher, time.Duration(cfg.Reminder.DedupTTLMinutes)*time.Minute)
+	if cfg.Actions.BaseURL != "" {
+		reminders.SetActionLinks(cfg.Actions.Links())
+	}
+	digests := sender.NewDigestSender(dispatcher, time.Duration(cfg.Digest.MaxDelayMinutes)*time.Minute)
+
+	client := webhook.NewClient(time.Duration(cfg.Webhook.TimeoutSeconds) * time.Second)
+
 	ctx, cancel := signal.NotifyContext(context.Background(),
-		syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+		syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
+	go config.NotifyReload(ctx, func() {
+		c, err := config.NewSender(configFile)
+		if err != nil {
+			log.Error("failed to reload config: " + err.Error())
+			return
+		}
+
+		log.SetLevel(c.Logger.Level)
+
+		log.Info("config reloaded")
+	})
+
 	go func() {
 		for msg := range msgChan {
-			log.Info(fmt.Sprintf("%s Received message: %s",
-				time.Now().Format("2006-01-02 15:04:05"), msg.Body))
+			var n app.Notification
+
+			err := json.Unmarshal(msg.Body, &n)
+			if err != nil {
+				log.Error("failed to decode notification: " + err.Error())
+				continue
+			}
+
+			err = reminders.Send(ctx, n)
+			if err != nil {
+				log.Error(fmt.Sprintf("failed to send reminder %s: %s", n.Key, err))
+			}
+		}
+	}()
+
+	go func() {
+		for msg := range webhookChan {
+			var m webhook.Message
+
+			err := json.Unmarshal(msg.Body, &m)
+			if err != nil {
+				log.Error("failed to decode webhook message: " + err.Error())
+				continue
+			}
+
+			r := client.Deliver(ctx, m)
+			log.Info(fmt.Sprintf("webhook delivery %s attempt %d: status %d %s",
+				r.DeliveryID, r.Attempt, r.StatusCode, r.Error))
+
+			err = b.Publish(ctx, cfg.Broker.WebhookResultQueue, r)
+			if err != nil {
+				log.Error("failed to publish webhook result: " + err.Error())
+			}
+		}
+	}()
+
+	// Планировщик публикует сводку, когда подошло ее время, поэтому сводки доставляются
+	// по очереди. Сводка подтверждается после доставки: прерванная остановкой вернется в очередь.
+	digestsDone := make(chan struct{})
+	go func() {
+		defer close(digestsDone)
+
+		for {
+			var msg amqp.Delivery
+			var ok bool
+
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok = <-digestChan:
+				if !ok {
+					return
+				}
+			}
+
+			var d app.Digest
+
+			err := json.Unmarshal(msg.Body, &d)
+			if err != nil {
+				log.Error("failed to decode digest: " + err.Error())
+				_ = msg.Nack(false, false)
+				continue
+			}
+
+			err = digests.Send(ctx, d)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				log.Error(fmt.Sprintf("failed to send %s digest to %s: %s", d.Period, d.UserID, err))
+			}
+
+			err = msg.Ack(false)
+			if err != nil {
+				log.Error("failed to ack digest: " + err.Error())
+			}
 		}
 	}()
 
 	<-ctx.Done()
+	<-digestsDone
 
 	err = b.Close()
 	if err != nil {
@@ -59,3 +201,23 @@ func main() {
 
 	log.Info("shutting down sender...")
 }
+
+// newChannels создает настроенные каналы доставки.
+func newChannels(cfg config.Sender, log *logger.Logger) map[string]sender.Channel {
+	channels := map[string]sender.Channel{
+		storage.ChannelLog:     sender.NewLogChannel(log),
+		storage.ChannelWebhook: sender.NewWebhookChannel(time.Duration(cfg.Webhook.TimeoutSeconds) * time.Second),
+	}
+
+	if cfg.Channels.SMTP.Host != "" {
+		channels[storage.ChannelEmail] = sender.NewSMTPChannel(cfg.Channels.SMTP.Config())
+	}
+	if cfg.Channels.File.Path != "" {
+		channels[storage.ChannelFile] = sender.NewFileChannel(cfg.Channels.File.Path)
+	}
+	if cfg.Channels.Bot.Token != "" {
+		channels[storage.ChannelBot] = sender.NewBotChannel(cfg.Channels.Bot.Config())
+	}
+
+	return channels
+}
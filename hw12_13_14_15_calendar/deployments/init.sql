@@ -1 +0,0 @@
-CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
\ No newline at end of file
@@ -0,0 +1,109 @@
+// Package actionlink формирует и проверяет подписанные ссылки, по которым
+// получатель напоминания откладывает или отклоняет его без авторизации.
+package actionlink
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Path - путь обработчика ссылок на сервере календаря.
+const Path = "/reminders/action"
+
+const (
+	ActionSnooze  = "snooze"
+	ActionDismiss = "dismiss"
+)
+
+var (
+	ErrBadLink      = errors.New("malformed action link")
+	ErrBadSignature = errors.New("invalid action link signature")
+	ErrExpired      = errors.New("action link has expired")
+)
+
+// Link - действие над напоминанием. Minutes задается только для откладывания.
+type Link struct {
+	Action  string
+	EventID string
+	UserID  string
+	Minutes int
+	Expires time.Time
+}
+
+// Signer подписывает ссылки общим секретом календаря и сервиса рассылки.
+type Signer struct {
+	secret []byte
+}
+
+func NewSigner(secret string) *Signer {
+	return &Signer{secret: []byte(secret)}
+}
+
+// URL возвращает подписанную ссылку на обработчик по адресу baseURL.
+func (s *Signer) URL(baseURL string, l Link) string {
+	q := l.values()
+	q.Set("sig", s.sign(q))
+
+	return strings.TrimSuffix(baseURL, "/") + Path + "?" + q.Encode()
+}
+
+// Parse проверяет подпись и срок действия ссылки из параметров запроса.
+func (s *Signer) Parse(q url.Values, now time.Time) (Link, error) {
+	expires, err := strconv.ParseInt(q.Get("expires"), 10, 64)
+	if err != nil {
+		return Link{}, ErrBadLink
+	}
+
+	l := Link{
+		Action:  q.Get("action"),
+		EventID: q.Get("event"),
+		UserID:  q.Get("user"),
+		Expires: time.Unix(expires, 0),
+	}
+
+	if l.Action == ActionSnooze {
+		l.Minutes, err = strconv.Atoi(q.Get("minutes"))
+		if err != nil {
+			return Link{}, ErrBadLink
+		}
+	}
+	if l.Action != ActionSnooze && l.Action != ActionDismiss {
+		return Link{}, ErrBadLink
+	}
+
+	if !hmac.Equal([]byte(q.Get("sig")), []byte(s.sign(l.values()))) {
+		return Link{}, ErrBadSignature
+	}
+	if !now.Before(l.Expires) {
+		return Link{}, ErrExpired
+	}
+
+	return l, nil
+}
+
+func (l Link) values() url.Values {
+	q := url.Values{}
+	q.Set("action", l.Action)
+	q.Set("event", l.EventID)
+	q.Set("user", l.UserID)
+	if l.Action == ActionSnooze {
+		q.Set("minutes", strconv.Itoa(l.Minutes))
+	}
+	q.Set("expires", strconv.FormatInt(l.Expires.Unix(), 10))
+
+	return q
+}
+
+// sign подписывает параметры ссылки в порядке url.Values.Encode.
+func (s *Signer) sign(q url.Values) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(q.Encode()))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
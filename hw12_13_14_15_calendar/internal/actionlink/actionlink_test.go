@@ -0,0 +1,81 @@
+package actionlink
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner(t *testing.T) {
+	now := time.Date(2024, 6, 3, 9, 45, 0, 0, time.UTC)
+	s := NewSigner("0123456789abcdef")
+
+	link := Link{
+		Action:  ActionSnooze,
+		EventID: "eb0af540-6f23-4305-a719-fb65271fca1f",
+		UserID:  "d5095366-ea13-4c9d-ae72-9c83d2d93040",
+		Minutes: 10,
+		Expires: now.Add(time.Hour),
+	}
+
+	parse := func(t *testing.T, raw string) url.Values {
+		t.Helper()
+
+		u, err := url.Parse(raw)
+		require.NoError(t, err)
+		require.Equal(t, Path, u.Path)
+
+		return u.Query()
+	}
+
+	raw := s.URL("http://calendar.local/", link)
+	require.True(t, strings.HasPrefix(raw, "http://calendar.local"+Path+"?"))
+
+	t.Run("valid", func(t *testing.T) {
+		got, err := s.Parse(parse(t, raw), now)
+		require.NoError(t, err)
+		require.Equal(t, link.EventID, got.EventID)
+		require.Equal(t, link.UserID, got.UserID)
+		require.Equal(t, 10, got.Minutes)
+		require.True(t, link.Expires.Equal(got.Expires))
+	})
+
+	t.Run("dismiss", func(t *testing.T) {
+		dismiss := link
+		dismiss.Action = ActionDismiss
+
+		got, err := s.Parse(parse(t, s.URL("http://calendar.local", dismiss)), now)
+		require.NoError(t, err)
+		require.Equal(t, ActionDismiss, got.Action)
+		require.Zero(t, got.Minutes)
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		q := parse(t, raw)
+		q.Set("minutes", "1440")
+
+		_, err := s.Parse(q, now)
+		require.ErrorIs(t, err, ErrBadSignature)
+	})
+
+	t.Run("other secret", func(t *testing.T) {
+		_, err := NewSigner("fedcba9876543210").Parse(parse(t, raw), now)
+		require.ErrorIs(t, err, ErrBadSignature)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		_, err := s.Parse(parse(t, raw), link.Expires)
+		require.ErrorIs(t, err, ErrExpired)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		q := parse(t, raw)
+		q.Set("action", "delete")
+
+		_, err := s.Parse(q, now)
+		require.ErrorIs(t, err, ErrBadLink)
+	})
+}
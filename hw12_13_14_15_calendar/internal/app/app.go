@@ -1,16 +1,25 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
 	memorystorage "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage/memory"
 	sqlstorage "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage/sql"
+	sqlitestorage "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage/sqlite"
 )
 
 const (
 	DBModeSQL      = "sql"
 	DBModeInMemory = "in-memory"
+	DBModeSQLite   = "sqlite"
 )
 
+// ErrWebhooksUnsupported - доставки отправляет scheduler, а хранилище в памяти есть только у процесса calendar.
+var ErrWebhooksUnsupported = errors.New("webhooks are not supported in in-memory storage mode")
+
 type App struct {
 	logger  Logger
 	storage Storager
@@ -22,30 +31,124 @@ type Logger interface {
 }
 
 type StorageEvent interface {
-	CreateEvent(event storage.Event) error
-	UpdateEvent(id string, event storage.Event) error
-	DeleteEvent(id string) error
-	GetEvent(id string) (storage.Event, error)
-	ListEventDay(date string) ([]storage.Event, error)
-	ListEventWeek(date string) ([]storage.Event, error)
-	ListEventMonth(date string) ([]storage.Event, error)
+	CreateEvent(ctx context.Context, event storage.Event) (string, error)
+	UpdateEvent(ctx context.Context, id string, event storage.Event) error
+	DeleteEvent(ctx context.Context, id string) error
+	GetEvent(ctx context.Context, id string) (storage.Event, error)
+	ListEventDay(ctx context.Context, date string) ([]storage.Event, error)
+	ListEventWeek(ctx context.Context, date string) ([]storage.Event, error)
+	ListEventMonth(ctx context.Context, date string) ([]storage.Event, error)
+	ListEventRange(ctx context.Context, dateFrom string, dateTo string) ([]storage.Event, error)
+	ApplyBatch(ctx context.Context, ops []storage.BatchOperation) ([]storage.BatchResult, error)
+}
+
+type StorageWebhook interface {
+	CreateWebhook(ctx context.Context, w storage.Webhook) (string, error)
+	GetWebhook(ctx context.Context, id string) (storage.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]storage.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	CreateDelivery(ctx context.Context, d storage.WebhookDelivery) (string, error)
+	ListDeliveries(ctx context.Context, webhookID string) ([]storage.WebhookDelivery, error)
+	ClaimDeliveries(ctx context.Context, now string, leaseUntil string, limit int, maxAttempts int) (
+		[]storage.WebhookDelivery, error,
+	)
+	// UpdateDelivery сохраняет итог попытки d.Attempts. Если доставка уже не отправляется
+	// в этой попытке, итог устарел, и возвращается storage.ErrDeliveryNotExist.
+	UpdateDelivery(ctx context.Context, d storage.WebhookDelivery) error
+}
+
+type StoragePreferences interface {
+	GetPreferences(ctx context.Context, userID string) (storage.Preferences, error)
+	SavePreferences(ctx context.Context, p storage.Preferences) error
+}
+
+// StorageReminder хранит реакции пользователей на напоминания и ключи отправленных напоминаний.
+type StorageReminder interface {
+	GetReminderState(ctx context.Context, eventID string, userID string) (storage.ReminderState, error)
+	SaveReminderState(ctx context.Context, r storage.ReminderState) error
+	MarkReminderNotified(ctx context.Context, eventID string, userID string, key string) error
+}
+
+// StorageTag управляет метками событий.
+type StorageTag interface {
+	ListTags(ctx context.Context) ([]storage.Tag, error)
+	ListEventTagged(ctx context.Context, tags []string, dateFrom string, dateTo string) ([]storage.Event, error)
+	RenameTag(ctx context.Context, name string, newName string) error
+	DeleteTag(ctx context.Context, name string) error
+}
+
+// StorageAttachment хранит описания вложений событий. Вложения удаляются вместе с событием.
+type StorageAttachment interface {
+	CreateAttachment(ctx context.Context, a storage.Attachment) (string, error)
+	GetAttachment(ctx context.Context, id string) (storage.Attachment, error)
+	ListAttachments(ctx context.Context, eventID string) ([]storage.Attachment, error)
+	DeleteAttachment(ctx context.Context, id string) error
+}
+
+// StorageResource хранит бронируемые ресурсы. Бронь ресурса, пересекающуюся с бронью
+// другого события, хранилище отклоняет с storage.ErrResourceBusy.
+type StorageResource interface {
+	CreateResource(ctx context.Context, r storage.Resource) (string, error)
+	GetResource(ctx context.Context, id string) (storage.Resource, error)
+	ListResources(ctx context.Context) ([]storage.Resource, error)
+	UpdateResource(ctx context.Context, id string, r storage.Resource) error
+	DeleteResource(ctx context.Context, id string) error
+	ListBookings(ctx context.Context, dateFrom string, dateTo string) ([]storage.Booking, error)
 }
 
 type StorageConnector interface {
-	Open() error
+	Open(ctx context.Context) error
 	Close() error
 }
 
 type StorageScheduler interface {
-	DeleteEventsBeforeDate(date string) error
-	ListEventWithNotification() ([]storage.Event, error)
+	// DeleteEventsBeforeDate удаляет события, начавшиеся раньше date, и возвращает их вложения.
+	DeleteEventsBeforeDate(ctx context.Context, date string) ([]storage.Attachment, error)
+	ListEventWithNotification(ctx context.Context) ([]storage.Event, error)
+	ListEventDay(ctx context.Context, date string) ([]storage.Event, error)
+	ListEventWeek(ctx context.Context, date string) ([]storage.Event, error)
+	GetPreferences(ctx context.Context, userID string) (storage.Preferences, error)
+	GetReminderState(ctx context.Context, eventID string, userID string) (storage.ReminderState, error)
+	MarkReminderNotified(ctx context.Context, eventID string, userID string, key string) error
+}
+
+// StorageLeader хранит аренды, по которым реплики выбирают лидера.
+type StorageLeader interface {
+	AcquireLease(ctx context.Context, name string, holder string, ttl time.Duration) (bool, error)
+	ReleaseLease(ctx context.Context, name string, holder string) error
 }
 
 type Storager interface {
 	StorageEvent
+	StorageWebhook
+	StoragePreferences
+	StorageReminder
+	StorageTag
+	StorageAttachment
+	StorageResource
+	StorageConnector
+}
+
+// SchedulerStorager - хранилище планировщика. Режим in-memory его не поддерживает.
+type SchedulerStorager interface {
+	StorageScheduler
+	StorageLeader
+	StorageWebhook
 	StorageConnector
 }
 
+// StorageOptions содержит параметры всех режимов хранилища, NewStorage использует нужные.
+type StorageOptions struct {
+	DSN         string
+	Path        string
+	Timeouts    sqlstorage.Timeouts
+	Persistence memorystorage.Persistence
+	AutoMigrate bool
+	Pool        sqlstorage.Pool
+	Retry       sqlstorage.Retry
+	Breaker     sqlstorage.Breaker
+}
+
 func (a *App) GetStorage() Storager {
 	return a.storage
 }
@@ -57,13 +160,49 @@ func New(logger Logger, storage Storager) *App {
 	}
 }
 
-func NewStorage(mode string, dsn string) Storager {
+func NewStorage(mode string, o StorageOptions) Storager {
 	var s Storager
-	if mode == DBModeSQL {
-		s = sqlstorage.New(dsn)
-	}
-	if mode == DBModeInMemory {
+	switch mode {
+	case DBModeSQL:
+		s = newSQLStorage(o)
+	case DBModeSQLite:
+		s = sqlitestorage.New(o.Path, o.Timeouts)
+	case DBModeInMemory:
 		s = memorystorage.New()
+		if o.Persistence.Dir != "" {
+			s = memorystorage.NewPersistent(o.Persistence)
+		}
+		s = memoryStorage{s}
 	}
 	return s
 }
+
+// memoryStorage отклоняет новые webhooks: поставленные в память calendar доставки никто бы не отправил.
+type memoryStorage struct {
+	Storager
+}
+
+func (memoryStorage) CreateWebhook(context.Context, storage.Webhook) (string, error) {
+	return "", ErrWebhooksUnsupported
+}
+
+func NewSchedulerStorage(mode string, o StorageOptions) SchedulerStorager {
+	var s SchedulerStorager
+	switch mode {
+	case DBModeSQL:
+		s = newSQLStorage(o)
+	case DBModeSQLite:
+		s = sqlitestorage.New(o.Path, o.Timeouts)
+	}
+	return s
+}
+
+func newSQLStorage(o StorageOptions) *sqlstorage.Storage {
+	s := sqlstorage.New(o.DSN, o.Timeouts)
+	s.AutoMigrate = o.AutoMigrate
+	s.Pool = o.Pool
+	s.Retry = o.Retry
+	s.Breaker = o.Breaker
+
+	return s
+}
@@ -0,0 +1,20 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStorageInMemoryWebhooks(t *testing.T) {
+	s := NewStorage(DBModeInMemory, StorageOptions{})
+
+	_, err := s.CreateWebhook(context.Background(), storage.Webhook{URL: "https://example.com/hook"})
+	require.ErrorIs(t, err, ErrWebhooksUnsupported)
+
+	webhooks, err := s.ListWebhooks(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, webhooks)
+}
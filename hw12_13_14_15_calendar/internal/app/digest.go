@@ -0,0 +1,200 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+// Периоды сводок. Недельная сводка отправляется по понедельникам.
+const (
+	DigestDay  = "day"
+	DigestWeek = "week"
+)
+
+// Digest - сводка событий пользователя за день или за неделю, начиная с Date.
+// DeliverAt, Channel и Address - время и канал доставки по настройкам пользователя.
+type Digest struct {
+	Period    string        `json:"period"`
+	Date      string        `json:"date"`
+	UserID    string        `json:"userId"`
+	DeliverAt string        `json:"deliverAt"`
+	Channel   string        `json:"channel,omitempty"`
+	Address   string        `json:"address,omitempty"`
+	Events    []DigestEvent `json:"events"`
+}
+
+type DigestEvent struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	DateStart   string `json:"dateStart"`
+	DateEnd     string `json:"dateEnd"`
+	Description string `json:"description,omitempty"`
+}
+
+// SendDigests отправляет сводки, время доставки которых пришлось на интервал
+// с предыдущего вызова. Первый вызов после запуска или ResetDigests только
+// отмечает начало интервала. При ошибке интервал не сдвигается, и часть сводок
+// может быть отправлена повторно.
+func (s *Scheduler) SendDigests(ctx context.Context) error {
+	s.digestsMu.Lock()
+	defer s.digestsMu.Unlock()
+
+	now := s.now()
+
+	if s.digestsFrom.IsZero() {
+		s.digestsFrom = now
+		return nil
+	}
+
+	err := s.sendDigests(ctx, s.digestsFrom, now)
+	if err != nil {
+		return err
+	}
+
+	s.digestsFrom = now
+
+	return nil
+}
+
+// ResetDigests забывает начало интервала сводок. Вызывается при потере лидерства:
+// сводки, которые пока реплика не была лидером, отправил другой лидер.
+func (s *Scheduler) ResetDigests() {
+	s.digestsMu.Lock()
+	defer s.digestsMu.Unlock()
+
+	s.digestsFrom = time.Time{}
+}
+
+// sendDigests отправляет сводки со временем доставки в интервале [from, to).
+func (s *Scheduler) sendDigests(ctx context.Context, from time.Time, to time.Time) error {
+	prefs := make(map[string]storage.Preferences)
+	sent := 0
+
+	for day := startOfDay(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+		periods := []string{DigestDay}
+		if day.Weekday() == time.Monday {
+			periods = append(periods, DigestWeek)
+		}
+
+		for _, period := range periods {
+			n, err := s.sendPeriodDigests(ctx, period, day, from, to, prefs)
+			sent += n
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if sent > 0 {
+		s.logger.Info(fmt.Sprintf("sent digests: %d", sent))
+	}
+
+	return nil
+}
+
+func (s *Scheduler) sendPeriodDigests(ctx context.Context, period string, day time.Time, from time.Time,
+	to time.Time, prefs map[string]storage.Preferences,
+) (int, error) {
+	date := day.Format(time.DateOnly)
+
+	var events []storage.Event
+	var err error
+	if period == DigestWeek {
+		events, err = s.storage.ListEventWeek(ctx, date)
+	} else {
+		events, err = s.storage.ListEventDay(ctx, date)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	byUser := make(map[string][]DigestEvent)
+	users := make([]string, 0)
+	for _, e := range events {
+		if _, ok := byUser[e.UserID]; !ok {
+			users = append(users, e.UserID)
+		}
+		byUser[e.UserID] = append(byUser[e.UserID], DigestEvent{
+			ID:          e.ID,
+			Title:       e.Title,
+			DateStart:   e.DateStart,
+			DateEnd:     e.DateEnd,
+			Description: e.Description,
+		})
+	}
+
+	sent := 0
+
+	for _, userID := range users {
+		p, err := s.preferences(ctx, userID, prefs)
+		if err != nil {
+			return sent, err
+		}
+		if (period == DigestDay && !p.DailyDigest) || (period == DigestWeek && !p.WeeklyDigest) {
+			continue
+		}
+
+		deliverAt, err := digestTime(day, p.DigestTime)
+		if err != nil {
+			return sent, fmt.Errorf("user %s: %w", userID, err)
+		}
+		if deliverAt.Before(from) || !deliverAt.Before(to) {
+			continue
+		}
+
+		err = s.broker.SendDigest(ctx, Digest{
+			Period:    period,
+			Date:      date,
+			UserID:    userID,
+			DeliverAt: deliverAt.Format(time.DateTime),
+			Channel:   p.Channel,
+			Address:   p.Address,
+			Events:    byUser[userID],
+		})
+		if err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// preferences возвращает настройки пользователя, запоминая их в known на время одного прохода.
+func (s *Scheduler) preferences(ctx context.Context, userID string, known map[string]storage.Preferences) (
+	storage.Preferences, error,
+) {
+	if p, ok := known[userID]; ok {
+		return p, nil
+	}
+
+	p, err := s.storage.GetPreferences(ctx, userID)
+	if errors.Is(err, storage.ErrPreferencesNotExist) {
+		p, err = storage.DefaultPreferences(userID), nil
+	}
+	if err != nil {
+		return storage.Preferences{}, err
+	}
+
+	known[userID] = p
+
+	return p, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// digestTime возвращает момент доставки сводки за day во время clock вида 15:04.
+func digestTime(day time.Time, clock string) (time.Time, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("bad digest time %q", clock)
+	}
+
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location()), nil
+}
@@ -0,0 +1,154 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	digestUser   = "user-1"
+	digestSilent = "user-2"
+	digestLate   = "user-3"
+)
+
+// digestStorage отдает события по дате начала и сохраненные настройки.
+type digestStorage struct {
+	StorageScheduler
+	events      []storage.Event
+	preferences map[string]storage.Preferences
+}
+
+func (s *digestStorage) ListEventDay(_ context.Context, date string) ([]storage.Event, error) {
+	return s.list(date, 1), nil
+}
+
+func (s *digestStorage) ListEventWeek(_ context.Context, date string) ([]storage.Event, error) {
+	return s.list(date, 7), nil
+}
+
+func (s *digestStorage) list(date string, days int) []storage.Event {
+	from, _ := time.Parse(time.DateOnly, date)
+	to := from.AddDate(0, 0, days).Format(time.DateOnly)
+
+	var events []storage.Event
+	for _, e := range s.events {
+		if e.DateStart >= date && e.DateStart < to {
+			events = append(events, e)
+		}
+	}
+
+	return events
+}
+
+func (s *digestStorage) GetPreferences(_ context.Context, userID string) (storage.Preferences, error) {
+	p, ok := s.preferences[userID]
+	if !ok {
+		return storage.Preferences{}, storage.ErrPreferencesNotExist
+	}
+
+	return p, nil
+}
+
+type digestBroker struct {
+	Broker
+	digests       []Digest
+	notifications []Notification
+}
+
+func (b *digestBroker) SendMessage(_ context.Context, n Notification) error {
+	b.notifications = append(b.notifications, n)
+	return nil
+}
+
+func (b *digestBroker) SendDigest(_ context.Context, d Digest) error {
+	b.digests = append(b.digests, d)
+	return nil
+}
+
+func TestSendDigests(t *testing.T) {
+	s := &digestStorage{
+		events: []storage.Event{
+			{ID: "1", Title: "standup", DateStart: "2024-06-03 10:00:00", DateEnd: "2024-06-03 10:15:00", UserID: digestUser},
+			{ID: "2", Title: "review", DateStart: "2024-06-05 15:00:00", DateEnd: "2024-06-05 16:00:00", UserID: digestUser},
+			{ID: "3", Title: "gym", DateStart: "2024-06-03 19:00:00", DateEnd: "2024-06-03 20:00:00", UserID: digestSilent},
+			{ID: "4", Title: "dinner", DateStart: "2024-06-03 20:00:00", DateEnd: "2024-06-03 21:00:00", UserID: digestLate},
+		},
+		preferences: map[string]storage.Preferences{
+			digestSilent: {UserID: digestSilent, WeeklyDigest: true, DigestTime: "07:00"},
+			digestLate:   {UserID: digestLate, DailyDigest: true, DigestTime: "18:00"},
+		},
+	}
+	b := &digestBroker{}
+
+	l := mocks.NewLogger(t)
+	l.On("Info", mock.AnythingOfType("string")).Maybe()
+
+	// 2024-06-03 - понедельник.
+	now := time.Date(2024, 6, 3, 6, 0, 0, 0, time.Local)
+	scheduler := NewScheduler(s, b, l)
+	scheduler.now = func() time.Time { return now }
+
+	ctx := context.Background()
+
+	require.NoError(t, scheduler.SendDigests(ctx))
+	require.Empty(t, b.digests, "first call only marks the interval start")
+
+	now = now.Add(3 * time.Hour)
+	require.NoError(t, scheduler.SendDigests(ctx))
+
+	require.Equal(t, []Digest{
+		{
+			Period:    DigestDay,
+			Date:      "2024-06-03",
+			UserID:    digestUser,
+			DeliverAt: "2024-06-03 08:00:00",
+			Events: []DigestEvent{
+				{ID: "1", Title: "standup", DateStart: "2024-06-03 10:00:00", DateEnd: "2024-06-03 10:15:00"},
+			},
+		},
+		{
+			Period:    DigestWeek,
+			Date:      "2024-06-03",
+			UserID:    digestUser,
+			DeliverAt: "2024-06-03 08:00:00",
+			Events: []DigestEvent{
+				{ID: "1", Title: "standup", DateStart: "2024-06-03 10:00:00", DateEnd: "2024-06-03 10:15:00"},
+				{ID: "2", Title: "review", DateStart: "2024-06-05 15:00:00", DateEnd: "2024-06-05 16:00:00"},
+			},
+		},
+		{
+			Period:    DigestWeek,
+			Date:      "2024-06-03",
+			UserID:    digestSilent,
+			DeliverAt: "2024-06-03 07:00:00",
+			Events: []DigestEvent{
+				{ID: "3", Title: "gym", DateStart: "2024-06-03 19:00:00", DateEnd: "2024-06-03 20:00:00"},
+			},
+		},
+	}, b.digests)
+
+	b.digests = nil
+	now = now.Add(10 * time.Hour)
+	require.NoError(t, scheduler.SendDigests(ctx))
+
+	require.Len(t, b.digests, 1)
+	require.Equal(t, digestLate, b.digests[0].UserID)
+	require.Equal(t, "2024-06-03 18:00:00", b.digests[0].DeliverAt)
+
+	// После потери лидерства сводки за время чужого лидерства не отправляются.
+	b.digests = nil
+	scheduler.ResetDigests()
+	now = now.Add(24 * time.Hour)
+	require.NoError(t, scheduler.SendDigests(ctx))
+	require.Empty(t, b.digests, "first call after reset only marks the interval start")
+
+	now = now.Add(time.Hour)
+	require.NoError(t, scheduler.SendDigests(ctx))
+	require.Empty(t, b.digests)
+}
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"context"
+	"time"
+)
+
+// SchedulerLease - имя аренды, под которой реплика планировщика рассылает уведомления.
+const SchedulerLease = "scheduler"
+
+const leaderReleaseTimeout = 3 * time.Second
+
+// LeaderConfig задает аренду: Holder должен быть уникален для реплики. Лидер продлевает
+// аренду на TTL каждые RenewInterval, резервная реплика с той же периодичностью
+// пытается ее захватить и сменяет упавшего лидера не позже чем через TTL + RenewInterval.
+type LeaderConfig struct {
+	Name          string
+	Holder        string
+	TTL           time.Duration
+	RenewInterval time.Duration
+}
+
+type Leader struct {
+	storage StorageLeader
+	logger  Logger
+	config  LeaderConfig
+}
+
+func NewLeader(storage StorageLeader, logger Logger, config LeaderConfig) *Leader {
+	return &Leader{
+		storage: storage,
+		logger:  logger,
+		config:  config,
+	}
+}
+
+// Run выполняет fn, пока реплика удерживает аренду, и возвращается после отмены ctx.
+// Если продлить аренду не удалось, контекст fn отменяется: лучше пропустить
+// несколько циклов, чем работать одновременно с новым лидером.
+func (l *Leader) Run(ctx context.Context, fn func(ctx context.Context)) {
+	ticker := time.NewTicker(l.config.RenewInterval)
+	defer ticker.Stop()
+
+	var stop func()
+	defer func() {
+		if stop != nil {
+			stop()
+			l.release()
+		}
+	}()
+
+	for {
+		acquired, err := l.storage.AcquireLease(ctx, l.config.Name, l.config.Holder, l.config.TTL)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			l.logger.Error("failed to renew leader lease: " + err.Error())
+		}
+
+		switch {
+		case acquired && stop == nil:
+			l.logger.Info("became leader: " + l.config.Holder)
+			stop = l.start(ctx, fn)
+		case !acquired && stop != nil:
+			l.logger.Info("lost leadership: " + l.config.Holder)
+			stop()
+			stop = nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// start запускает fn и возвращает функцию, которая отменяет его и дожидается завершения.
+func (l *Leader) start(ctx context.Context, fn func(ctx context.Context)) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		fn(ctx)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// release освобождает аренду при остановке, чтобы резервная реплика не ждала TTL.
+func (l *Leader) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), leaderReleaseTimeout)
+	defer cancel()
+
+	err := l.storage.ReleaseLease(ctx, l.config.Name, l.config.Holder)
+	if err != nil {
+		l.logger.Error("failed to release leader lease: " + err.Error())
+	}
+}
@@ -0,0 +1,159 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type lease struct {
+	holder    string
+	expiresAt time.Time
+}
+
+// leaseStorage хранит аренды в памяти, failing имитирует недоступность БД.
+type leaseStorage struct {
+	mu      sync.Mutex
+	leases  map[string]lease
+	failing bool
+}
+
+func (s *leaseStorage) AcquireLease(_ context.Context, name string, holder string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failing {
+		return false, errors.New("connection refused")
+	}
+
+	l, ok := s.leases[name]
+	if ok && l.holder != holder && time.Now().Before(l.expiresAt) {
+		return false, nil
+	}
+	s.leases[name] = lease{holder: holder, expiresAt: time.Now().Add(ttl)}
+
+	return true, nil
+}
+
+func (s *leaseStorage) ReleaseLease(_ context.Context, name string, holder string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.leases[name].holder == holder {
+		delete(s.leases, name)
+	}
+
+	return nil
+}
+
+func (s *leaseStorage) setFailing(failing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failing = failing
+}
+
+func newTestLeader(t *testing.T, s StorageLeader, holder string) *Leader {
+	t.Helper()
+
+	l := mocks.NewLogger(t)
+	l.On("Info", mock.AnythingOfType("string")).Maybe()
+	l.On("Error", mock.AnythingOfType("string")).Maybe()
+
+	return NewLeader(s, l, LeaderConfig{
+		Name:          SchedulerLease,
+		Holder:        holder,
+		TTL:           200 * time.Millisecond,
+		RenewInterval: 20 * time.Millisecond,
+	})
+}
+
+// worker считает реплики, выполняющие работу одновременно.
+type worker struct {
+	running int32
+	maxSeen int32
+	started int32
+}
+
+func (w *worker) run(ctx context.Context) {
+	atomic.AddInt32(&w.started, 1)
+	n := atomic.AddInt32(&w.running, 1)
+	for {
+		seen := atomic.LoadInt32(&w.maxSeen)
+		if n <= seen || atomic.CompareAndSwapInt32(&w.maxSeen, seen, n) {
+			break
+		}
+	}
+
+	<-ctx.Done()
+	atomic.AddInt32(&w.running, -1)
+}
+
+func TestLeader(t *testing.T) {
+	t.Run("single leader and takeover", func(t *testing.T) {
+		s := &leaseStorage{leases: make(map[string]lease)}
+		w := &worker{}
+
+		ctxA, cancelA := context.WithCancel(context.Background())
+		doneA := make(chan struct{})
+		go func() {
+			defer close(doneA)
+			newTestLeader(t, s, "a").Run(ctxA, w.run)
+		}()
+
+		require.Eventually(t, func() bool { return atomic.LoadInt32(&w.running) == 1 }, time.Second, 5*time.Millisecond)
+
+		ctxB, cancelB := context.WithCancel(context.Background())
+		defer cancelB()
+		doneB := make(chan struct{})
+		go func() {
+			defer close(doneB)
+			newTestLeader(t, s, "b").Run(ctxB, w.run)
+		}()
+
+		time.Sleep(100 * time.Millisecond)
+		require.Equal(t, int32(1), atomic.LoadInt32(&w.started), "standby must wait")
+
+		// Лидер останавливается и освобождает аренду, резервная реплика ее подхватывает.
+		cancelA()
+		<-doneA
+
+		require.Eventually(t, func() bool { return atomic.LoadInt32(&w.started) == 2 }, time.Second, 5*time.Millisecond)
+		require.Equal(t, int32(1), atomic.LoadInt32(&w.maxSeen))
+
+		cancelB()
+		<-doneB
+		require.Empty(t, s.leases)
+	})
+
+	t.Run("steps down when lease cannot be renewed", func(t *testing.T) {
+		s := &leaseStorage{leases: make(map[string]lease)}
+		w := &worker{}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			newTestLeader(t, s, "a").Run(ctx, w.run)
+		}()
+
+		require.Eventually(t, func() bool { return atomic.LoadInt32(&w.running) == 1 }, time.Second, 5*time.Millisecond)
+
+		s.setFailing(true)
+		require.Eventually(t, func() bool { return atomic.LoadInt32(&w.running) == 0 }, time.Second, 5*time.Millisecond)
+
+		s.setFailing(false)
+		require.Eventually(t, func() bool { return atomic.LoadInt32(&w.running) == 1 }, time.Second, 5*time.Millisecond)
+
+		cancel()
+		<-done
+		require.Equal(t, int32(0), atomic.LoadInt32(&w.running))
+	})
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+// NotificationVersion - версия схемы Notification. Схема описана в api/notification.schema.json,
+// при несовместимом изменении версия увеличивается.
+const NotificationVersion = 2
+
+// Роли получателей напоминания.
+const (
+	RoleOwner    = "owner"
+	RoleAttendee = "attendee"
+)
+
+var ErrNotificationVersion = errors.New("unsupported notification version")
+
+// Notification - напоминание о событии, которое планировщик передает сервису рассылки.
+// Даты указаны в локальном времени планировщика, TimeZone - его смещение от UTC
+// на момент начала события. Key одинаков для повторных отправок одного
+// напоминания, по нему сервис рассылки отбрасывает дубликаты.
+type Notification struct {
+	Version     int                     `json:"version"`
+	Key         string                  `json:"key" validate:"required"`
+	EventID     string                  `json:"eventId" validate:"required"`
+	Title       string                  `json:"title" validate:"required"`
+	DateStart   string                  `json:"dateStart" validate:"required,datetime=2006-01-02 15:04:05"`
+	DateEnd     string                  `json:"dateEnd" validate:"required,datetime=2006-01-02 15:04:05"`
+	Description string                  `json:"description,omitempty"`
+	TimeZone    string                  `json:"timeZone" validate:"required,datetime=-07:00"`
+	RemindAt    string                  `json:"remindAt" validate:"required,datetime=2006-01-02 15:04:05"`
+	Recipients  []NotificationRecipient `json:"recipients" validate:"required,min=1,dive"`
+}
+
+// NotificationRecipient - получатель напоминания с каналом из его настроек.
+// Пустой Channel означает канал по умолчанию сервиса рассылки.
+type NotificationRecipient struct {
+	UserID  string `json:"userId" validate:"required"`
+	Role    string `json:"role" validate:"oneof=owner attendee"`
+	Channel string `json:"channel,omitempty"`
+	Address string `json:"address,omitempty"`
+}
+
+// NewNotification готовит напоминание о событии для владельца. Участников
+// у событий пока нет, поэтому владелец - единственный получатель.
+func NewNotification(e storage.Event, owner storage.Preferences) Notification {
+	n := Notification{
+		Version:     NotificationVersion,
+		Key:         "reminder:" + e.ID + ":" + e.DatePost,
+		EventID:     e.ID,
+		Title:       e.Title,
+		DateStart:   e.DateStart,
+		DateEnd:     e.DateEnd,
+		Description: e.Description,
+		RemindAt:    e.DatePost,
+		Recipients: []NotificationRecipient{{
+			UserID:  e.UserID,
+			Role:    RoleOwner,
+			Channel: owner.Channel,
+			Address: owner.Address,
+		}},
+	}
+
+	start, err := time.ParseInLocation(time.DateTime, e.DateStart, time.Local)
+	if err == nil {
+		n.TimeZone = start.Format("-07:00")
+	}
+
+	return n
+}
+
+// ApplyState учитывает реакцию владельца на напоминание и возвращает false,
+// если отправлять его сейчас не нужно или оно с тем же Key уже отправлено.
+// Напоминание после откладывания получает новый Key, чтобы сервис рассылки
+// не счел его дубликатом.
+func (n *Notification) ApplyState(st storage.ReminderState, now time.Time) bool {
+	if st.Dismissed {
+		return false
+	}
+
+	if st.SnoozedUntil != "" {
+		until, err := time.ParseInLocation(time.DateTime, st.SnoozedUntil, time.Local)
+		if err == nil {
+			if until.After(now) {
+				return false
+			}
+
+			n.Key += ":" + st.SnoozedUntil
+			n.RemindAt = st.SnoozedUntil
+		}
+	}
+
+	return n.Key != st.NotifiedKey
+}
+
+// Validate проверяет напоминание по схеме версии NotificationVersion.
+func (n Notification) Validate() error {
+	if n.Version != NotificationVersion {
+		return fmt.Errorf("%w: %d, expected %d", ErrNotificationVersion, n.Version, NotificationVersion)
+	}
+
+	return validator.New().Struct(n)
+}
@@ -0,0 +1,150 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+var testEvent = storage.Event{
+	ID:          "eb0af540-6f23-4305-a719-fb65271fca1f",
+	Title:       "standup",
+	DateStart:   "2024-06-03 10:00:00",
+	DateEnd:     "2024-06-03 10:15:00",
+	Description: "daily",
+	UserID:      "d5095366-ea13-4c9d-ae72-9c83d2d93040",
+	DatePost:    "2024-06-03 09:45:00",
+}
+
+func TestNewNotification(t *testing.T) {
+	owner := storage.Preferences{UserID: testEvent.UserID, Channel: storage.ChannelEmail, Address: "owner@example.com"}
+
+	n := NewNotification(testEvent, owner)
+	require.NoError(t, n.Validate())
+
+	require.Equal(t, NotificationVersion, n.Version)
+	require.Equal(t, "reminder:"+testEvent.ID+":2024-06-03 09:45:00", n.Key)
+	require.Equal(t, testEvent.DatePost, n.RemindAt)
+	require.Equal(t, []NotificationRecipient{{
+		UserID:  testEvent.UserID,
+		Role:    RoleOwner,
+		Channel: storage.ChannelEmail,
+		Address: "owner@example.com",
+	}}, n.Recipients)
+
+	require.Equal(t, n.Key, NewNotification(testEvent, owner).Key, "key is stable across resends")
+}
+
+func TestNotificationValidate(t *testing.T) {
+	valid := NewNotification(testEvent, storage.DefaultPreferences(testEvent.UserID))
+
+	cases := []struct {
+		name   string
+		modify func(n *Notification)
+	}{
+		{"old version", func(n *Notification) { n.Version = 1 }},
+		{"no key", func(n *Notification) { n.Key = "" }},
+		{"bad date start", func(n *Notification) { n.DateStart = "2024-06-03T10:00:00Z" }},
+		{"bad time zone", func(n *Notification) { n.TimeZone = "Europe/Moscow" }},
+		{"no recipients", func(n *Notification) { n.Recipients = nil }},
+		{"bad role", func(n *Notification) { n.Recipients[0].Role = "guest" }},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			n := valid
+			n.Recipients = slices.Clone(valid.Recipients)
+			tc.modify(&n)
+
+			require.Error(t, n.Validate())
+		})
+	}
+}
+
+func TestNotificationApplyState(t *testing.T) {
+	now := time.Date(2024, 6, 3, 9, 50, 0, 0, time.Local)
+
+	cases := []struct {
+		name     string
+		state    storage.ReminderState
+		send     bool
+		remindAt string
+	}{
+		{"no state", storage.ReminderState{}, true, testEvent.DatePost},
+		{"dismissed", storage.ReminderState{Dismissed: true}, false, ""},
+		{"snoozed", storage.ReminderState{SnoozedUntil: "2024-06-03 09:55:00"}, false, ""},
+		{"snooze expired", storage.ReminderState{SnoozedUntil: "2024-06-03 09:50:00"}, true, "2024-06-03 09:50:00"},
+		{"notified", storage.ReminderState{NotifiedKey: "reminder:" + testEvent.ID + ":" + testEvent.DatePost}, false, ""},
+		{"snooze expired after notified", storage.ReminderState{
+			SnoozedUntil: "2024-06-03 09:50:00", NotifiedKey: "reminder:" + testEvent.ID + ":" + testEvent.DatePost,
+		}, true, "2024-06-03 09:50:00"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			n := NewNotification(testEvent, storage.DefaultPreferences(testEvent.UserID))
+			key := n.Key
+
+			require.Equal(t, tc.send, n.ApplyState(tc.state, now))
+			if !tc.send {
+				return
+			}
+
+			require.Equal(t, tc.remindAt, n.RemindAt)
+			if tc.state.SnoozedUntil != "" {
+				require.NotEqual(t, key, n.Key, "snoozed reminder is not a duplicate")
+			}
+		})
+	}
+}
+
+// TestNotificationSchema сверяет JSON представление Notification с документированной схемой.
+func TestNotificationSchema(t *testing.T) {
+	data, err := os.ReadFile("../../api/notification.schema.json")
+	require.NoError(t, err)
+
+	type object struct {
+		Required   []string                   `json:"required"`
+		Properties map[string]json.RawMessage `json:"properties"`
+		Items      *object                    `json:"items"`
+	}
+
+	var schema object
+	require.NoError(t, json.Unmarshal(data, &schema))
+
+	var recipients object
+	require.NoError(t, json.Unmarshal(schema.Properties["recipients"], &recipients))
+
+	owner := storage.Preferences{Channel: storage.ChannelBot, Address: "100500"}
+	data, err = json.Marshal(NewNotification(testEvent, owner))
+	require.NoError(t, err)
+
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &fields))
+
+	var recipientFields []map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(fields["recipients"], &recipientFields))
+
+	check := func(s object, fields map[string]json.RawMessage) {
+		for _, name := range s.Required {
+			require.Contains(t, fields, name, "required field is missing")
+		}
+		for name := range fields {
+			require.Contains(t, s.Properties, name, "field is not documented")
+		}
+	}
+
+	check(schema, fields)
+	check(*recipients.Items, recipientFields[0])
+
+	var version struct {
+		Const int `json:"const"`
+	}
+	require.NoError(t, json.Unmarshal(schema.Properties["version"], &version))
+	require.Equal(t, NotificationVersion, version.Const)
+}
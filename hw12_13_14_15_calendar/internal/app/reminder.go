@@ -0,0 +1,41 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+// SnoozeReminder откладывает напоминание о событии для пользователя на d
+// от текущего момента. Отложенное напоминание снова становится активным.
+func SnoozeReminder(ctx context.Context, s StorageReminder, eventID string, userID string, d time.Duration) (
+	storage.ReminderState, error,
+) {
+	r := storage.ReminderState{
+		EventID:      eventID,
+		UserID:       userID,
+		SnoozedUntil: time.Now().Add(d).Format(time.DateTime),
+	}
+
+	err := s.SaveReminderState(ctx, r)
+	if err != nil {
+		return storage.ReminderState{}, err
+	}
+
+	return r, nil
+}
+
+// DismissReminder отключает напоминания о событии для пользователя.
+func DismissReminder(ctx context.Context, s StorageReminder, eventID string, userID string) (
+	storage.ReminderState, error,
+) {
+	r := storage.ReminderState{EventID: eventID, UserID: userID, Dismissed: true}
+
+	err := s.SaveReminderState(ctx, r)
+	if err != nil {
+		return storage.ReminderState{}, err
+	}
+
+	return r, nil
+}
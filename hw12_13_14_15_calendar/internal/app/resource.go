@@ -0,0 +1,76 @@
+package app
+
+import (
+	"context"
+	"sort"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+// ListResourceAvailability возвращает ресурсы вида q.Kind (или все) с их бронями за [q.DateFrom, q.DateTo).
+// Ресурс свободен, если броней за интервал нет и в нем не меньше q.Capacity мест.
+func ListResourceAvailability(ctx context.Context, s StorageResource, q storage.ResourceQueryValidation) (
+	[]storage.ResourceAvailability, error,
+) {
+	resources, err := s.ListResources(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bookings, err := s.ListBookings(ctx, q.DateFrom, q.DateTo)
+	if err != nil {
+		return nil, err
+	}
+
+	byResource := make(map[string][]storage.Booking)
+	for _, b := range bookings {
+		byResource[b.ResourceID] = append(byResource[b.ResourceID], b)
+	}
+
+	result := make([]storage.ResourceAvailability, 0, len(resources))
+
+	for _, r := range resources {
+		if q.Kind != "" && r.Kind != q.Kind {
+			continue
+		}
+
+		booked := byResource[r.ID]
+		if booked == nil {
+			booked = make([]storage.Booking, 0)
+		}
+
+		result = append(result, storage.ResourceAvailability{
+			Resource: r,
+			Bookings: booked,
+			Free:     len(booked) == 0 && r.Capacity >= q.Capacity,
+		})
+	}
+
+	return result, nil
+}
+
+// FindFreeRooms возвращает переговорные, свободные в [q.DateFrom, q.DateTo) и вмещающие q.Capacity человек.
+// Первыми идут самые маленькие подходящие комнаты, чтобы большие оставались для больших встреч.
+func FindFreeRooms(ctx context.Context, s StorageResource, q storage.ResourceQueryValidation) (
+	[]storage.Resource, error,
+) {
+	q.Kind = storage.ResourceRoom
+
+	availability, err := ListResourceAvailability(ctx, s, q)
+	if err != nil {
+		return nil, err
+	}
+
+	rooms := make([]storage.Resource, 0)
+	for _, a := range availability {
+		if a.Free {
+			rooms = append(rooms, a.Resource)
+		}
+	}
+
+	sort.SliceStable(rooms, func(i, j int) bool {
+		return rooms[i].Capacity < rooms[j].Capacity
+	})
+
+	return rooms, nil
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindFreeRooms(t *testing.T) {
+	ctx := context.Background()
+	q := storage.ResourceQueryValidation{DateFrom: "2024-05-06 10:00:00", DateTo: "2024-05-06 11:00:00", Capacity: 4}
+
+	hall := storage.Resource{ID: "hall", Name: "Hall", Kind: storage.ResourceRoom, Capacity: 40}
+	orion := storage.Resource{ID: "orion", Name: "Orion", Kind: storage.ResourceRoom, Capacity: 8}
+	booth := storage.Resource{ID: "booth", Name: "Phone booth", Kind: storage.ResourceRoom, Capacity: 1}
+	lyra := storage.Resource{ID: "lyra", Name: "Lyra", Kind: storage.ResourceRoom, Capacity: 6}
+	projector := storage.Resource{ID: "projector", Name: "Projector", Kind: storage.ResourceEquipment}
+
+	booking := storage.Booking{ResourceID: lyra.ID, EventID: "standup", DateStart: q.DateFrom, DateEnd: q.DateTo}
+
+	s := mocks.NewStorager(t)
+	s.On("ListResources", ctx).Return([]storage.Resource{booth, hall, lyra, orion, projector}, nil)
+	s.On("ListBookings", ctx, q.DateFrom, q.DateTo).Return([]storage.Booking{booking}, nil)
+
+	rooms, err := FindFreeRooms(ctx, s, q)
+	require.NoError(t, err)
+	require.Equal(t, []storage.Resource{orion, hall}, rooms)
+
+	availability, err := ListResourceAvailability(ctx, s, storage.ResourceQueryValidation{
+		DateFrom: q.DateFrom, DateTo: q.DateTo, Kind: storage.ResourceRoom,
+	})
+	require.NoError(t, err)
+	require.Len(t, availability, 4)
+	require.Equal(t, storage.ResourceAvailability{
+		Resource: lyra, Bookings: []storage.Booking{booking}, Free: false,
+	}, availability[2])
+	require.True(t, availability[0].Free)
+	require.NotNil(t, availability[0].Bookings)
+}
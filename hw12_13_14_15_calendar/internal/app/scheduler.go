@@ -2,23 +2,39 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
-	"github.com/google/uuid"
 	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
 )
 
+// Имена задач планировщика в секции jobs конфигурации.
+const (
+	JobNotifications = "notifications"
+	JobPurge         = "purge"
+	JobDigest        = "digest"
+)
+
+var SchedulerJobs = []string{JobNotifications, JobPurge, JobDigest}
+
 type Scheduler struct {
 	storage StorageScheduler
 	broker  Broker
 	logger  Logger
+	blobs   BlobRemover
+	now     func() time.Time
+
+	// digestsFrom - начало интервала, сводки за который еще не отправлены.
+	digestsMu   sync.Mutex
+	digestsFrom time.Time
 }
-type Notification struct {
-	ID        string
-	Title     string
-	DateStart string
-	UserID    string
+
+// BlobRemover удаляет содержимое загруженных вложений, которое хранится вне хранилища событий.
+type BlobRemover interface {
+	Delete(ctx context.Context, key string) error
 }
 
 type Broker interface {
@@ -26,33 +42,15 @@ type Broker interface {
 	Close() error
 	SetQueue(queueName string) error
 	SendMessage(ctx context.Context, n Notification) error
+	SendDigest(ctx context.Context, d Digest) error
 	ConsumeMessage(queueName string) (<-chan amqp.Delivery, error)
 }
 
-func (s *Scheduler) ProcessNotifications(ctx context.Context, pollTime int, outdatedEventDays int) {
-	ticker := time.NewTicker(time.Duration(pollTime) * time.Second)
-
-	for {
-		select {
-		case <-ctx.Done():
-			ticker.Stop()
-			return
-		case <-ticker.C:
-			err := s.sendNotifications()
-			if err != nil {
-				s.logger.Error(err.Error())
-			}
-
-			err = s.removeOutdatedEvents(outdatedEventDays)
-			if err != nil {
-				s.logger.Error(err.Error())
-			}
-		}
-	}
-}
-
-func (s *Scheduler) sendNotifications() error {
-	events, err := s.storage.ListEventWithNotification()
+// SendNotifications отправляет в брокер уведомления о событиях, по которым подошло время.
+// Ключ отправленного напоминания сохраняется в хранилище, поэтому следующие запуски
+// и перезапуск планировщика его не повторяют.
+func (s *Scheduler) SendNotifications(ctx context.Context) error {
+	events, err := s.storage.ListEventWithNotification(ctx)
 	if err != nil {
 		return err
 	}
@@ -62,42 +60,91 @@ func (s *Scheduler) sendNotifications() error {
 
 	s.logger.Info(fmt.Sprintf("sending notifications: %d", len(events)))
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
+	prefs := make(map[string]storage.Preferences)
+	notifications := make([]Notification, 0, len(events))
 
 	for _, event := range events {
-		n := Notification{
-			ID:        event.ID,
-			Title:     event.Title,
-			DateStart: event.DateStart,
-			UserID:    uuid.NewString(),
+		owner, err := s.preferences(ctx, event.UserID, prefs)
+		if err != nil {
+			return err
+		}
+
+		state, err := s.storage.GetReminderState(ctx, event.ID, event.UserID)
+		if err != nil && !errors.Is(err, storage.ErrReminderStateNotExist) {
+			return err
+		}
+
+		n := NewNotification(event, owner)
+		if !n.ApplyState(state, s.now()) {
+			continue
+		}
+
+		// Событие с некорректными данными не должно блокировать остальные.
+		err = n.Validate()
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("skip notification for event %s: %s", event.ID, err))
+			continue
 		}
 
-		err = s.broker.SendMessage(ctx, n)
+		notifications = append(notifications, n)
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	for _, n := range notifications {
+		err = s.broker.SendMessage(sendCtx, n)
 		if err != nil {
 			return err
 		}
+
+		// Владелец - первый получатель. Если ключ не сохранится, напоминание уйдет повторно,
+		// и дубликат отбросит сервис рассылки.
+		err = s.storage.MarkReminderNotified(ctx, n.EventID, n.Recipients[0].UserID, n.Key)
+		if err != nil && !errors.Is(err, storage.ErrEventNotExist) {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func (s *Scheduler) removeOutdatedEvents(days int) error {
-	currentTime := time.Now()
-	date := currentTime.AddDate(0, 0, -days).Format("2006-01-02 15:04:05")
+// RemoveOutdatedEvents удаляет события, начавшиеся раньше чем days дней назад.
+func (s *Scheduler) RemoveOutdatedEvents(ctx context.Context, days int) error {
+	date := s.now().AddDate(0, 0, -days).Format(time.DateTime)
 
-	err := s.storage.DeleteEventsBeforeDate(date)
+	attachments, err := s.storage.DeleteEventsBeforeDate(ctx, date)
 	if err != nil {
 		return err
 	}
+	if s.blobs == nil {
+		return nil
+	}
+
+	// Содержимое удаляется после событий: сбой оставит лишний файл, но не вложение без файла.
+	for _, a := range attachments {
+		if !a.Stored() {
+			continue
+		}
+		err = s.blobs.Delete(ctx, a.BlobKey)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("delete attachment %s content: %s", a.ID, err))
+		}
+	}
 
 	return nil
 }
 
+// SetBlobRemover включает удаление содержимого вложений удаляемых событий.
+func (s *Scheduler) SetBlobRemover(b BlobRemover) {
+	s.blobs = b
+}
+
 func NewScheduler(storage StorageScheduler, broker Broker, logger Logger) *Scheduler {
 	return &Scheduler{
 		storage: storage,
 		broker:  broker,
 		logger:  logger,
+		now:     time.Now,
 	}
 }
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type notificationStorage struct {
+	digestStorage
+	states map[string]storage.ReminderState
+}
+
+func (s *notificationStorage) GetReminderState(_ context.Context, eventID string, _ string) (
+	storage.ReminderState, error,
+) {
+	r, ok := s.states[eventID]
+	if !ok {
+		return storage.ReminderState{}, storage.ErrReminderStateNotExist
+	}
+
+	return r, nil
+}
+
+func (s *notificationStorage) MarkReminderNotified(_ context.Context, eventID string, userID string, key string) error {
+	r := s.states[eventID]
+	r.EventID, r.UserID, r.NotifiedKey = eventID, userID, key
+	s.states[eventID] = r
+
+	return nil
+}
+
+func (s *notificationStorage) ListEventWithNotification(context.Context) ([]storage.Event, error) {
+	return s.events, nil
+}
+
+func TestSendNotifications(t *testing.T) {
+	broken := testEvent
+	broken.ID = "broken"
+	broken.DateEnd = ""
+
+	owner := testEvent
+	owner.UserID = digestSilent
+
+	dismissed := testEvent
+	dismissed.ID = "dismissed"
+
+	s := &notificationStorage{
+		digestStorage: digestStorage{
+			events: []storage.Event{broken, owner, dismissed},
+			preferences: map[string]storage.Preferences{
+				digestSilent: {UserID: digestSilent, Channel: storage.ChannelBot, Address: "100500", DigestTime: "07:00"},
+			},
+		},
+		states: map[string]storage.ReminderState{
+			dismissed.ID: {EventID: dismissed.ID, UserID: dismissed.UserID, Dismissed: true},
+		},
+	}
+	b := &digestBroker{}
+
+	l := mocks.NewLogger(t)
+	l.On("Info", mock.AnythingOfType("string")).Maybe()
+	l.On("Error", mock.MatchedBy(func(msg string) bool {
+		return strings.HasPrefix(msg, "skip notification for event broken")
+	})).Twice()
+
+	scheduler := NewScheduler(s, b, l)
+
+	require.NoError(t, scheduler.SendNotifications(context.Background()))
+
+	require.Len(t, b.notifications, 1, "invalid and dismissed notifications are skipped")
+
+	n := b.notifications[0]
+	require.Equal(t, testEvent.ID, n.EventID)
+	require.Equal(t, []NotificationRecipient{
+		{UserID: digestSilent, Role: RoleOwner, Channel: storage.ChannelBot, Address: "100500"},
+	}, n.Recipients)
+	require.Equal(t, n.Key, s.states[testEvent.ID].NotifiedKey)
+
+	require.NoError(t, scheduler.SendNotifications(context.Background()))
+	require.Len(t, b.notifications, 1, "sent notification is not repeated")
+}
+
+type purgeStorage struct {
+	digestStorage
+	attachments []storage.Attachment
+	deletedTill string
+}
+
+func (s *purgeStorage) DeleteEventsBeforeDate(_ context.Context, date string) ([]storage.Attachment, error) {
+	s.deletedTill = date
+	return s.attachments, nil
+}
+
+type blobRemover struct {
+	deleted []string
+}
+
+func (b *blobRemover) Delete(_ context.Context, key string) error {
+	if key == "broken" {
+		return errors.New("permission denied")
+	}
+	b.deleted = append(b.deleted, key)
+
+	return nil
+}
+
+func TestRemoveOutdatedEvents(t *testing.T) {
+	s := &purgeStorage{attachments: []storage.Attachment{
+		{ID: "file", BlobKey: "key"},
+		{ID: "link", URL: "https://example.com"},
+		{ID: "broken", BlobKey: "broken"},
+	}}
+	b := &blobRemover{}
+
+	l := mocks.NewLogger(t)
+	l.On("Error", "delete attachment broken content: permission denied").Once()
+
+	scheduler := NewScheduler(s, nil, l)
+	scheduler.SetBlobRemover(b)
+	scheduler.now = func() time.Time { return time.Date(2024, 6, 3, 6, 0, 0, 0, time.Local) }
+
+	require.NoError(t, scheduler.RemoveOutdatedEvents(context.Background(), 365))
+	require.Equal(t, "2023-06-04 06:00:00", s.deletedTill)
+	require.Equal(t, []string{"key"}, b.deleted, "only stored content is removed")
+}
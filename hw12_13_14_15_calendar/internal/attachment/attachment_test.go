@@ -0,0 +1,135 @@
+package attachment
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+func read(t *testing.T, store Store, key string) string {
+	t.Helper()
+
+	r, err := store.Open(context.Background(), key)
+	require.NoError(t, err)
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	return string(data)
+}
+
+func TestFSStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store := NewFSStore(dir)
+
+	key, size, err := store.Put(ctx, strings.NewReader("agenda"))
+	require.NoError(t, err)
+	require.Equal(t, int64(6), size)
+	require.Equal(t, "agenda", read(t, store, key))
+
+	require.NoError(t, store.Delete(ctx, key))
+	require.NoError(t, store.Delete(ctx, key), "missing content is not an error")
+
+	_, err = store.Open(ctx, key)
+	require.ErrorIs(t, err, ErrNotExist)
+
+	_, err = store.Open(ctx, "../../etc/passwd")
+	require.ErrorIs(t, err, ErrBadKey)
+	require.ErrorIs(t, store.Delete(ctx, "../"+key), ErrBadKey)
+
+	entries, err := os.ReadDir(dir + "/" + key[:2])
+	require.NoError(t, err)
+	require.Empty(t, entries, "no temporary files left")
+}
+
+func newEvent(title string, dateStart string) storage.Event {
+	return storage.Event{
+		Title:     title,
+		DateStart: dateStart,
+		DateEnd:   dateStart,
+		UserID:    "5f8e5e4c-9f0c-4c43-a1d7-1b0a4e4b1f20",
+	}
+}
+
+func TestStorage(t *testing.T) {
+	ctx := context.Background()
+
+	backend := memorystorage.New()
+	store := NewFSStore(t.TempDir())
+	s := NewStorage(backend, store, mocks.NewLogger(t))
+
+	eventID, err := s.CreateEvent(ctx, newEvent("planning", "2024-04-02 10:00:00"))
+	require.NoError(t, err)
+
+	upload := func(content string) storage.Attachment {
+		t.Helper()
+
+		a, err := s.Upload(ctx, storage.Attachment{EventID: eventID, Name: "notes.txt", ContentType: "text/plain"},
+			strings.NewReader(content))
+		require.NoError(t, err)
+
+		return a
+	}
+
+	t.Run("upload and open", func(t *testing.T) {
+		a := upload("first")
+		require.True(t, a.Stored())
+		require.Equal(t, int64(5), a.Size)
+
+		r, err := s.OpenContent(ctx, a)
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		require.Equal(t, "first", string(data))
+
+		_, err = s.OpenContent(ctx, storage.Attachment{URL: "https://example.com"})
+		require.ErrorIs(t, err, ErrNotExist, "links have no content")
+	})
+
+	t.Run("upload fail: no event", func(t *testing.T) {
+		_, err := s.Upload(ctx, storage.Attachment{EventID: "missing", Name: "lost"}, strings.NewReader("lost"))
+		require.ErrorIs(t, err, storage.ErrEventNotExist)
+	})
+
+	t.Run("delete attachment removes content", func(t *testing.T) {
+		a := upload("second")
+
+		require.NoError(t, s.DeleteAttachment(ctx, a.ID))
+
+		_, err := store.Open(ctx, a.BlobKey)
+		require.ErrorIs(t, err, ErrNotExist)
+	})
+
+	t.Run("delete event removes content", func(t *testing.T) {
+		a := upload("third")
+
+		require.NoError(t, s.DeleteEvent(ctx, eventID))
+
+		_, err := store.Open(ctx, a.BlobKey)
+		require.ErrorIs(t, err, ErrNotExist)
+	})
+
+	t.Run("batch delete removes content", func(t *testing.T) {
+		id, err := s.CreateEvent(ctx, newEvent("review", "2024-04-03 10:00:00"))
+		require.NoError(t, err)
+
+		a, err := s.Upload(ctx, storage.Attachment{EventID: id, Name: "review.txt"}, strings.NewReader("review"))
+		require.NoError(t, err)
+
+		_, err = s.ApplyBatch(ctx, []storage.BatchOperation{{Op: storage.BatchDelete, ID: id}})
+		require.NoError(t, err)
+
+		_, err = store.Open(ctx, a.BlobKey)
+		require.ErrorIs(t, err, ErrNotExist)
+	})
+}
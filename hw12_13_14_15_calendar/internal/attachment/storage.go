@@ -0,0 +1,130 @@
+package attachment
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+// Storage оборачивает хранилище: сохраняет содержимое загруженных вложений в Store
+// и удаляет его вместе с вложением или событием. Содержимое удаляется после описания,
+// поэтому сбой оставляет лишний файл, но не вложение без содержимого.
+type Storage struct {
+	app.Storager
+	store  Store
+	logger app.Logger
+}
+
+func NewStorage(s app.Storager, store Store, logger app.Logger) *Storage {
+	return &Storage{
+		Storager: s,
+		store:    store,
+		logger:   logger,
+	}
+}
+
+// Upload сохраняет содержимое r и создает вложение a с размером и ключом содержимого.
+func (s *Storage) Upload(ctx context.Context, a storage.Attachment, r io.Reader) (storage.Attachment, error) {
+	// Событие проверяется до записи, чтобы не принимать содержимое впустую.
+	_, err := s.Storager.GetEvent(ctx, a.EventID)
+	if err != nil {
+		return storage.Attachment{}, err
+	}
+
+	a.URL = ""
+	a.BlobKey, a.Size, err = s.store.Put(ctx, r)
+	if err != nil {
+		return storage.Attachment{}, err
+	}
+
+	id, err := s.Storager.CreateAttachment(ctx, a)
+	if err != nil {
+		s.remove(ctx, a)
+		return storage.Attachment{}, err
+	}
+
+	return s.Storager.GetAttachment(ctx, id)
+}
+
+// OpenContent открывает содержимое загруженного вложения.
+func (s *Storage) OpenContent(ctx context.Context, a storage.Attachment) (io.ReadCloser, error) {
+	if !a.Stored() {
+		return nil, ErrNotExist
+	}
+
+	return s.store.Open(ctx, a.BlobKey)
+}
+
+func (s *Storage) DeleteAttachment(ctx context.Context, id string) error {
+	a, err := s.Storager.GetAttachment(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.Storager.DeleteAttachment(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	s.remove(ctx, a)
+
+	return nil
+}
+
+func (s *Storage) DeleteEvent(ctx context.Context, id string) error {
+	attachments, err := s.Storager.ListAttachments(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.Storager.DeleteEvent(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	s.remove(ctx, attachments...)
+
+	return nil
+}
+
+func (s *Storage) ApplyBatch(ctx context.Context, ops []storage.BatchOperation) ([]storage.BatchResult, error) {
+	var attachments []storage.Attachment
+
+	for _, op := range ops {
+		if op.Op != storage.BatchDelete {
+			continue
+		}
+
+		list, err := s.Storager.ListAttachments(ctx, op.ID)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, list...)
+	}
+
+	results, err := s.Storager.ApplyBatch(ctx, ops)
+	if err != nil {
+		return results, err
+	}
+
+	s.remove(ctx, attachments...)
+
+	return results, nil
+}
+
+// remove удаляет содержимое вложений. Ошибки только пишутся в журнал: описания уже удалены.
+func (s *Storage) remove(ctx context.Context, attachments ...storage.Attachment) {
+	for _, a := range attachments {
+		if !a.Stored() {
+			continue
+		}
+
+		err := s.store.Delete(ctx, a.BlobKey)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("delete attachment %s content: %s", a.ID, err))
+		}
+	}
+}
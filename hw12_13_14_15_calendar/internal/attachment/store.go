@@ -0,0 +1,126 @@
+// Package attachment хранит содержимое загруженных вложений событий.
+package attachment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+)
+
+// Виды хранилища содержимого в конфигурации.
+const (
+	StoreFS = "fs"
+)
+
+var Stores = []string{StoreFS}
+
+var (
+	ErrNotExist     = errors.New("attachment content not found")
+	ErrBadKey       = errors.New("invalid attachment content key")
+	ErrUnknownStore = errors.New("unknown attachment store")
+)
+
+// Store хранит содержимое вложений под ключами, которые выдает Put.
+type Store interface {
+	Put(ctx context.Context, r io.Reader) (key string, size int64, err error)
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete не считает ошибкой отсутствие содержимого: оно могло быть удалено раньше.
+	Delete(ctx context.Context, key string) error
+}
+
+// NewStore создает хранилище содержимого вида kind. Для StoreFS dir - каталог с файлами.
+func NewStore(kind string, dir string) (Store, error) {
+	switch kind {
+	case StoreFS:
+		return NewFSStore(dir), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownStore, kind)
+	}
+}
+
+// FSStore хранит содержимое файлами в каталоге на локальном диске.
+type FSStore struct {
+	dir string
+}
+
+func NewFSStore(dir string) *FSStore {
+	return &FSStore{dir: dir}
+}
+
+// Put записывает содержимое во временный файл и переименовывает его, поэтому
+// прерванная загрузка не оставляет файл под выданным ключом.
+func (s *FSStore) Put(ctx context.Context, r io.Reader) (string, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return "", 0, err
+	}
+
+	key := uuid.NewString()
+	path := s.path(key)
+
+	err := os.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		return "", 0, err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return "", 0, err
+	}
+
+	size, err := io.Copy(file, r)
+	if err == nil {
+		err = file.Sync()
+	}
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", 0, err
+	}
+
+	return key, size, nil
+}
+
+func (s *FSStore) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	if _, err := uuid.Parse(key); err != nil {
+		return nil, ErrBadKey
+	}
+
+	file, err := os.Open(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func (s *FSStore) Delete(_ context.Context, key string) error {
+	if _, err := uuid.Parse(key); err != nil {
+		return ErrBadKey
+	}
+
+	err := os.Remove(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+// path раскладывает файлы по подкаталогам из первых символов ключа, чтобы не держать их все в одном каталоге.
+func (s *FSStore) path(key string) string {
+	return filepath.Join(s.dir, key[:2], key)
+}
@@ -14,6 +14,8 @@ type Broker struct {
 	connection *amqp.Connection
 	channel    *amqp.Channel
 	queue      amqp.Queue
+
+	digestQueue string
 }
 
 func New(dsn string) *Broker {
@@ -60,7 +62,41 @@ func (b *Broker) SetQueue(queueName string) error {
 }
 
 func (b *Broker) SendMessage(ctx context.Context, n app.Notification) error {
-	jData, err := json.Marshal(&n)
+	return b.Publish(ctx, b.queue.Name, n)
+}
+
+// SetDigestQueue объявляет очередь сводок, в которую пишет SendDigest.
+func (b *Broker) SetDigestQueue(queueName string) error {
+	err := b.DeclareQueue(queueName)
+	if err != nil {
+		return err
+	}
+
+	b.digestQueue = queueName
+
+	return nil
+}
+
+func (b *Broker) SendDigest(ctx context.Context, d app.Digest) error {
+	return b.Publish(ctx, b.digestQueue, d)
+}
+
+// DeclareQueue объявляет дополнительную очередь, не меняя основную.
+func (b *Broker) DeclareQueue(queueName string) error {
+	_, err := b.channel.QueueDeclare(
+		queueName,
+		false,
+		false,
+		false,
+		false,
+		nil,
+	)
+
+	return err
+}
+
+func (b *Broker) Publish(ctx context.Context, queueName string, v interface{}) error {
+	jData, err := json.Marshal(v)
 	if err != nil {
 		return errors.Errorf("JSON Marshal error: %v", err)
 	}
@@ -68,7 +104,7 @@ func (b *Broker) SendMessage(ctx context.Context, n app.Notification) error {
 	err = b.channel.PublishWithContext(
 		ctx,
 		"",
-		b.queue.Name,
+		queueName,
 		false,
 		false,
 		amqp.Publishing{
@@ -84,10 +120,21 @@ func (b *Broker) SendMessage(ctx context.Context, n app.Notification) error {
 }
 
 func (b *Broker) ConsumeMessage(queueName string) (<-chan amqp.Delivery, error) {
+	return b.consume(queueName, true)
+}
+
+// ConsumeManualAck читает очередь без автоматического подтверждения: сообщение
+// подтверждается через Ack после обработки, а неподтвержденное вернется в очередь
+// при закрытии соединения.
+func (b *Broker) ConsumeManualAck(queueName string) (<-chan amqp.Delivery, error) {
+	return b.consume(queueName, false)
+}
+
+func (b *Broker) consume(queueName string, autoAck bool) (<-chan amqp.Delivery, error) {
 	msgs, err := b.channel.Consume(
 		queueName,
 		"",
-		true,
+		autoAck,
 		false,
 		false,
 		false,
@@ -0,0 +1,309 @@
+// Package cache кэширует чтения событий перед хранилищем.
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+const (
+	DefaultSize     = 10000
+	DefaultEventTTL = time.Minute
+	DefaultListTTL  = 30 * time.Second
+)
+
+const dateLayout = "2006-01-02"
+
+// maxSpanDays - события длиннее сбрасывают весь кэш вместо списков по каждому дню.
+const maxSpanDays = 62
+
+// Options задает размер кэша в записях и сроки жизни событий и списков.
+type Options struct {
+	Size     int
+	EventTTL time.Duration
+	ListTTL  time.Duration
+}
+
+type Stats struct {
+	Hits      uint64  `json:"hits"`
+	Misses    uint64  `json:"misses"`
+	Evictions uint64  `json:"evictions"`
+	Size      int     `json:"size"`
+	HitRatio  float64 `json:"hitRatio"`
+}
+
+// Storage оборачивает хранилище и кэширует GetEvent и списки за день, неделю и месяц.
+// Изменения через Storage сбрасывают затронутые записи, изменения в обход него
+// (другие реплики, планировщик) видны после истечения TTL.
+type Storage struct {
+	app.Storager
+	opts Options
+
+	mu         sync.Mutex
+	lru        *lru
+	generation uint64
+	hits       uint64
+	misses     uint64
+}
+
+func NewStorage(s app.Storager, o Options) *Storage {
+	if o.Size <= 0 {
+		o.Size = DefaultSize
+	}
+	if o.EventTTL <= 0 {
+		o.EventTTL = DefaultEventTTL
+	}
+	if o.ListTTL <= 0 {
+		o.ListTTL = DefaultListTTL
+	}
+
+	return &Storage{
+		Storager: s,
+		opts:     o,
+		lru:      newLRU(o.Size),
+	}
+}
+
+func (s *Storage) GetEvent(ctx context.Context, id string) (storage.Event, error) {
+	v, err := s.load("event:"+id, s.opts.EventTTL, func() (interface{}, error) {
+		return s.Storager.GetEvent(ctx, id)
+	})
+	if err != nil {
+		return storage.Event{}, err
+	}
+
+	return v.(storage.Event), nil
+}
+
+func (s *Storage) ListEventDay(ctx context.Context, date string) ([]storage.Event, error) {
+	return s.list("day:", date, func() ([]storage.Event, error) {
+		return s.Storager.ListEventDay(ctx, date)
+	})
+}
+
+func (s *Storage) ListEventWeek(ctx context.Context, date string) ([]storage.Event, error) {
+	return s.list("week:", date, func() ([]storage.Event, error) {
+		return s.Storager.ListEventWeek(ctx, date)
+	})
+}
+
+func (s *Storage) ListEventMonth(ctx context.Context, date string) ([]storage.Event, error) {
+	return s.list("month:", date, func() ([]storage.Event, error) {
+		return s.Storager.ListEventMonth(ctx, date)
+	})
+}
+
+func (s *Storage) CreateEvent(ctx context.Context, event storage.Event) (string, error) {
+	id, err := s.Storager.CreateEvent(ctx, event)
+	if err != nil {
+		return "", err
+	}
+
+	s.invalidate(nil, event)
+
+	return id, nil
+}
+
+func (s *Storage) UpdateEvent(ctx context.Context, id string, event storage.Event) error {
+	// Прежние даты нужны, чтобы сбросить списки, из которых событие уходит.
+	old, oldErr := s.Storager.GetEvent(ctx, id)
+
+	err := s.Storager.UpdateEvent(ctx, id, event)
+	if err != nil {
+		return err
+	}
+
+	if oldErr != nil {
+		s.Purge()
+		return nil
+	}
+
+	s.invalidate([]string{id}, old, event)
+
+	return nil
+}
+
+func (s *Storage) DeleteEvent(ctx context.Context, id string) error {
+	old, oldErr := s.Storager.GetEvent(ctx, id)
+
+	err := s.Storager.DeleteEvent(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if oldErr != nil {
+		s.Purge()
+		return nil
+	}
+
+	s.invalidate([]string{id}, old)
+
+	return nil
+}
+
+// ApplyBatch сбрасывает весь кэш: пакет может затронуть произвольные даты.
+func (s *Storage) ApplyBatch(ctx context.Context, ops []storage.BatchOperation) ([]storage.BatchResult, error) {
+	results, err := s.Storager.ApplyBatch(ctx, ops)
+	if err != nil {
+		return results, err
+	}
+
+	s.Purge()
+
+	return results, nil
+}
+
+// RenameTag сбрасывает весь кэш: метка может быть у событий в любых датах.
+func (s *Storage) RenameTag(ctx context.Context, name string, newName string) error {
+	err := s.Storager.RenameTag(ctx, name, newName)
+	if err != nil {
+		return err
+	}
+
+	s.Purge()
+
+	return nil
+}
+
+func (s *Storage) DeleteTag(ctx context.Context, name string) error {
+	err := s.Storager.DeleteTag(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	s.Purge()
+
+	return nil
+}
+
+// DeleteResource сбрасывает весь кэш: ресурс снимается с броней событий в любых датах.
+func (s *Storage) DeleteResource(ctx context.Context, id string) error {
+	err := s.Storager.DeleteResource(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	s.Purge()
+
+	return nil
+}
+
+func (s *Storage) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := Stats{
+		Hits:      s.hits,
+		Misses:    s.misses,
+		Evictions: s.lru.evictions,
+		Size:      s.lru.len(),
+	}
+	if total := s.hits + s.misses; total > 0 {
+		stats.HitRatio = float64(s.hits) / float64(total)
+	}
+
+	return stats
+}
+
+func (s *Storage) Purge() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.generation++
+	s.lru.purge()
+}
+
+// list кэширует список по дате. Даты в другом формате передаются хранилищу без кэширования.
+func (s *Storage) list(prefix string, date string, fetch func() ([]storage.Event, error)) ([]storage.Event, error) {
+	if _, err := time.Parse(dateLayout, date); err != nil {
+		return fetch()
+	}
+
+	v, err := s.load(prefix+date, s.opts.ListTTL, func() (interface{}, error) {
+		return fetch()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Копия не дает вызывающему изменить закэшированный список.
+	events := v.([]storage.Event)
+	result := make([]storage.Event, len(events))
+	copy(result, events)
+
+	return result, nil
+}
+
+// load возвращает значение из кэша или читает его через fetch. Результат чтения,
+// начатого до изменения данных, не кэшируется: он мог устареть.
+func (s *Storage) load(key string, ttl time.Duration, fetch func() (interface{}, error)) (interface{}, error) {
+	s.mu.Lock()
+	if v, ok := s.lru.get(key); ok {
+		s.hits++
+		s.mu.Unlock()
+		return v, nil
+	}
+	s.misses++
+	generation := s.generation
+	s.mu.Unlock()
+
+	v, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.generation == generation {
+		s.lru.set(key, v, ttl)
+	}
+	s.mu.Unlock()
+
+	return v, nil
+}
+
+// invalidate сбрасывает события ids и все списки, в которые попадают дни событий events.
+func (s *Storage) invalidate(ids []string, events ...storage.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.generation++
+
+	for _, id := range ids {
+		s.lru.remove("event:" + id)
+	}
+
+	for _, e := range events {
+		if _, err := time.Parse(time.DateTime, e.DateStart); err != nil {
+			s.lru.purge()
+			return
+		}
+
+		e.Normalize()
+		days := e.Days()
+		if len(days) > maxSpanDays {
+			s.lru.purge()
+			return
+		}
+
+		first := time.Unix(days[0], 0).UTC()
+		last := time.Unix(days[len(days)-1], 0).UTC()
+
+		// Неделя начинается с даты запроса, поэтому событие входит в недели,
+		// начатые за 0-6 дней до первого его дня, и в месяцы, начатые за 0-31 день.
+		for day := first.AddDate(0, 0, -31); !day.After(last); day = day.AddDate(0, 0, 1) {
+			date := day.Format(dateLayout)
+
+			s.lru.remove("month:" + date)
+			if !day.Before(first.AddDate(0, 0, -6)) {
+				s.lru.remove("week:" + date)
+			}
+			if !day.Before(first) {
+				s.lru.remove("day:" + date)
+			}
+		}
+	}
+}
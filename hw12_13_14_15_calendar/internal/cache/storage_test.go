@@ -0,0 +1,278 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var errBackend = errors.New("backend failed")
+
+func newEvent(id string, dateStart string) storage.Event {
+	return storage.Event{ID: id, Title: "event " + id, DateStart: dateStart, DateEnd: dateStart}
+}
+
+func TestGetEvent(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("read through", func(t *testing.T) {
+		m := mocks.NewStorager(t)
+		m.On("GetEvent", mock.Anything, "1").Return(newEvent("1", "2024-01-10 10:00:00"), nil).Once()
+
+		s := NewStorage(m, Options{})
+
+		for i := 0; i < 3; i++ {
+			e, err := s.GetEvent(ctx, "1")
+			require.NoError(t, err)
+			require.Equal(t, "1", e.ID)
+		}
+
+		stats := s.Stats()
+		require.Equal(t, uint64(2), stats.Hits)
+		require.Equal(t, uint64(1), stats.Misses)
+		require.Equal(t, 1, stats.Size)
+		require.InDelta(t, 2.0/3.0, stats.HitRatio, 0.001)
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		m := mocks.NewStorager(t)
+		m.On("GetEvent", mock.Anything, "1").Return(storage.Event{}, storage.ErrEventNotExist).Twice()
+
+		s := NewStorage(m, Options{})
+
+		for i := 0; i < 2; i++ {
+			_, err := s.GetEvent(ctx, "1")
+			require.ErrorIs(t, err, storage.ErrEventNotExist)
+		}
+		require.Equal(t, 0, s.Stats().Size)
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		m := mocks.NewStorager(t)
+		m.On("GetEvent", mock.Anything, "1").Return(newEvent("1", "2024-01-10 10:00:00"), nil).Twice()
+
+		now := time.Now()
+		s := NewStorage(m, Options{EventTTL: time.Minute})
+		s.lru.now = func() time.Time { return now }
+
+		_, err := s.GetEvent(ctx, "1")
+		require.NoError(t, err)
+
+		now = now.Add(59 * time.Second)
+		_, err = s.GetEvent(ctx, "1")
+		require.NoError(t, err)
+
+		now = now.Add(time.Second)
+		_, err = s.GetEvent(ctx, "1")
+		require.NoError(t, err)
+
+		require.Equal(t, uint64(1), s.Stats().Hits)
+	})
+}
+
+func TestListCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("lists are copied", func(t *testing.T) {
+		m := mocks.NewStorager(t)
+		m.On("ListEventDay", mock.Anything, "2024-01-10").
+			Return([]storage.Event{newEvent("1", "2024-01-10 10:00:00")}, nil).Once()
+
+		s := NewStorage(m, Options{})
+
+		events, err := s.ListEventDay(ctx, "2024-01-10")
+		require.NoError(t, err)
+		events[0].Title = "changed"
+
+		events, err = s.ListEventDay(ctx, "2024-01-10")
+		require.NoError(t, err)
+		require.Equal(t, "event 1", events[0].Title)
+	})
+
+	t.Run("bad date is not cached", func(t *testing.T) {
+		m := mocks.NewStorager(t)
+		m.On("ListEventWeek", mock.Anything, "10.01.2024").Return(nil, errBackend).Twice()
+
+		s := NewStorage(m, Options{})
+
+		for i := 0; i < 2; i++ {
+			_, err := s.ListEventWeek(ctx, "10.01.2024")
+			require.ErrorIs(t, err, errBackend)
+		}
+		require.Equal(t, uint64(0), s.Stats().Misses)
+	})
+}
+
+func TestInvalidation(t *testing.T) {
+	ctx := context.Background()
+
+	// warm заполняет кэш списками, куда попадает событие 2024-01-10.
+	warm := func(t *testing.T, s *Storage) {
+		t.Helper()
+
+		_, err := s.ListEventDay(ctx, "2024-01-10")
+		require.NoError(t, err)
+		_, err = s.ListEventWeek(ctx, "2024-01-04")
+		require.NoError(t, err)
+		_, err = s.ListEventMonth(ctx, "2023-12-15")
+		require.NoError(t, err)
+		_, err = s.ListEventDay(ctx, "2024-01-11")
+		require.NoError(t, err)
+		_, err = s.ListEventWeek(ctx, "2024-01-03")
+		require.NoError(t, err)
+	}
+
+	lists := func(m *mocks.Storager) {
+		m.On("ListEventDay", mock.Anything, mock.Anything).Return([]storage.Event{}, nil)
+		m.On("ListEventWeek", mock.Anything, mock.Anything).Return([]storage.Event{}, nil)
+		m.On("ListEventMonth", mock.Anything, mock.Anything).Return([]storage.Event{}, nil)
+	}
+
+	t.Run("create", func(t *testing.T) {
+		m := mocks.NewStorager(t)
+		lists(m)
+		e := newEvent("", "2024-01-10 10:00:00")
+		m.On("CreateEvent", mock.Anything, e).Return("1", nil).Once()
+
+		s := NewStorage(m, Options{})
+		warm(t, s)
+
+		_, err := s.CreateEvent(ctx, e)
+		require.NoError(t, err)
+
+		// Остались только день 2024-01-11 и неделя с 2024-01-03, в которые событие не входит.
+		require.Equal(t, 2, s.Stats().Size)
+	})
+
+	t.Run("create multi-day event", func(t *testing.T) {
+		m := mocks.NewStorager(t)
+		lists(m)
+		e := newEvent("", "2024-01-09 10:00:00")
+		e.DateEnd = "2024-01-11 10:00:00"
+		m.On("CreateEvent", mock.Anything, e).Return("1", nil).Once()
+
+		s := NewStorage(m, Options{})
+		warm(t, s)
+
+		_, err := s.CreateEvent(ctx, e)
+		require.NoError(t, err)
+
+		// Событие занимает дни с 2024-01-09 по 2024-01-11 и попадает во все списки.
+		require.Equal(t, 0, s.Stats().Size)
+	})
+
+	t.Run("update moves event", func(t *testing.T) {
+		m := mocks.NewStorager(t)
+		lists(m)
+		e := newEvent("1", "2024-01-11 10:00:00")
+		m.On("GetEvent", mock.Anything, "1").Return(newEvent("1", "2024-01-10 10:00:00"), nil).Once()
+		m.On("UpdateEvent", mock.Anything, "1", e).Return(nil).Once()
+
+		s := NewStorage(m, Options{})
+		warm(t, s)
+
+		err := s.UpdateEvent(ctx, "1", e)
+		require.NoError(t, err)
+
+		// Неделя с 2024-01-03 не содержит ни старую, ни новую дату.
+		require.Equal(t, 1, s.Stats().Size)
+	})
+
+	t.Run("failed write keeps cache", func(t *testing.T) {
+		m := mocks.NewStorager(t)
+		lists(m)
+		m.On("GetEvent", mock.Anything, "1").Return(newEvent("1", "2024-01-10 10:00:00"), nil).Once()
+		m.On("DeleteEvent", mock.Anything, "1").Return(errBackend).Once()
+
+		s := NewStorage(m, Options{})
+		warm(t, s)
+
+		err := s.DeleteEvent(ctx, "1")
+		require.ErrorIs(t, err, errBackend)
+		require.Equal(t, 5, s.Stats().Size)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		m := mocks.NewStorager(t)
+		lists(m)
+		m.On("GetEvent", mock.Anything, "1").Return(newEvent("1", "2024-01-10 10:00:00"), nil).Twice()
+		m.On("DeleteEvent", mock.Anything, "1").Return(nil).Once()
+
+		s := NewStorage(m, Options{})
+		warm(t, s)
+
+		_, err := s.GetEvent(ctx, "1")
+		require.NoError(t, err)
+
+		err = s.DeleteEvent(ctx, "1")
+		require.NoError(t, err)
+		require.Equal(t, 2, s.Stats().Size)
+	})
+
+	t.Run("batch purges", func(t *testing.T) {
+		m := mocks.NewStorager(t)
+		lists(m)
+		m.On("ApplyBatch", mock.Anything, mock.Anything).Return([]storage.BatchResult{}, nil).Once()
+
+		s := NewStorage(m, Options{})
+		warm(t, s)
+
+		_, err := s.ApplyBatch(ctx, []storage.BatchOperation{})
+		require.NoError(t, err)
+		require.Equal(t, 0, s.Stats().Size)
+	})
+
+	t.Run("tag changes purge", func(t *testing.T) {
+		m := mocks.NewStorager(t)
+		lists(m)
+		m.On("RenameTag", mock.Anything, "ops", "oncall").Return(nil).Once()
+		m.On("DeleteTag", mock.Anything, "release").Return(storage.ErrTagNotExist).Once()
+
+		s := NewStorage(m, Options{})
+		warm(t, s)
+
+		err := s.DeleteTag(ctx, "release")
+		require.ErrorIs(t, err, storage.ErrTagNotExist)
+		require.Equal(t, 5, s.Stats().Size)
+
+		err = s.RenameTag(ctx, "ops", "oncall")
+		require.NoError(t, err)
+		require.Equal(t, 0, s.Stats().Size)
+	})
+
+	t.Run("resource delete purges", func(t *testing.T) {
+		m := mocks.NewStorager(t)
+		lists(m)
+		m.On("DeleteResource", mock.Anything, "room").Return(nil).Once()
+
+		s := NewStorage(m, Options{})
+		warm(t, s)
+
+		require.NoError(t, s.DeleteResource(ctx, "room"))
+		require.Equal(t, 0, s.Stats().Size)
+	})
+}
+
+func TestEviction(t *testing.T) {
+	m := mocks.NewStorager(t)
+	m.On("ListEventDay", mock.Anything, mock.Anything).Return([]storage.Event{}, nil)
+
+	s := NewStorage(m, Options{Size: 2})
+
+	for _, date := range []string{"2024-01-01", "2024-01-02", "2024-01-01", "2024-01-03", "2024-01-01"} {
+		_, err := s.ListEventDay(context.Background(), date)
+		require.NoError(t, err)
+	}
+
+	stats := s.Stats()
+	require.Equal(t, 2, stats.Size)
+	require.Equal(t, uint64(1), stats.Evictions)
+	require.Equal(t, uint64(2), stats.Hits)
+	m.AssertNumberOfCalls(t, "ListEventDay", 3)
+}
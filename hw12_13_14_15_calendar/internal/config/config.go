@@ -0,0 +1,1073 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/actionlink"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/attachment"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/cache"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/feed"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/jobs"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/logger"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/sender"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage/memory"
+	sqlstorage "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage/sql"
+)
+
+// minActionsSecret - минимальная длина секрета подписи ссылок.
+const minActionsSecret = 16
+
+// EnvPrefix задает префикс переменных окружения: storage.host -> CALENDAR_STORAGE_HOST.
+const EnvPrefix = "CALENDAR"
+
+var ErrInvalidConfig = errors.New("invalid config")
+
+type LoggerConf struct {
+	Level string
+}
+
+type StorageConf struct {
+	Mode                string
+	User                string
+	Password            string
+	PasswordFile        string `mapstructure:"password_file"`
+	Host                string
+	Port                int
+	Name                string
+	Path                string
+	QueryTimeoutSeconds int  `mapstructure:"query_timeout_seconds"`
+	BatchTimeoutSeconds int  `mapstructure:"batch_timeout_seconds"`
+	AutoMigrate         bool `mapstructure:"auto_migrate"`
+
+	MaxOpenConns           int `mapstructure:"max_open_conns"`
+	MaxIdleConns           int `mapstructure:"max_idle_conns"`
+	ConnMaxLifetimeSeconds int `mapstructure:"conn_max_lifetime_seconds"`
+	ConnectAttempts        int `mapstructure:"connect_attempts"`
+	ConnectBackoffSeconds  int `mapstructure:"connect_backoff_seconds"`
+	ReadAttempts           int `mapstructure:"read_attempts"`
+	ReadBackoffMs          int `mapstructure:"read_backoff_ms"`
+	BreakerFailures        int `mapstructure:"breaker_failures"`
+	BreakerCooldownSeconds int `mapstructure:"breaker_cooldown_seconds"`
+
+	DataDir              string `mapstructure:"data_dir"`
+	Fsync                string
+	FsyncIntervalSeconds int `mapstructure:"fsync_interval_seconds"`
+	SnapshotEvery        int `mapstructure:"snapshot_every"`
+}
+
+type MessageBrokerConf struct {
+	User         string
+	Password     string
+	PasswordFile string `mapstructure:"password_file"`
+	Host         string
+	Port         int
+	Queue        string
+
+	WebhookQueue       string `mapstructure:"webhook_queue"`
+	WebhookResultQueue string `mapstructure:"webhook_result_queue"`
+	DigestQueue        string `mapstructure:"digest_queue"`
+	StatusQueue        string `mapstructure:"status_queue"`
+}
+
+type HTTPServerConf struct {
+	Host      string
+	Port      int
+	RateLimit float64 `mapstructure:"rate_limit"`
+	RateBurst int     `mapstructure:"rate_burst"`
+}
+
+type GRPCServerConf struct {
+	Port int
+}
+
+type ServerConf struct {
+	HTTP HTTPServerConf
+	GRPC GRPCServerConf
+}
+
+type FeedConf struct {
+	HistorySize int `mapstructure:"history_size"`
+}
+
+type CacheConf struct {
+	Enabled         bool
+	Size            int
+	EventTTLSeconds int `mapstructure:"event_ttl_seconds"`
+	ListTTLSeconds  int `mapstructure:"list_ttl_seconds"`
+}
+
+// AttachmentsConf задает хранилище содержимого загруженных вложений. Календарь и
+// планировщик должны использовать одно хранилище, чтобы очистка удаляла файлы.
+type AttachmentsConf struct {
+	Store     string
+	Dir       string
+	MaxSizeMB int `mapstructure:"max_size_mb"`
+}
+
+type WebhookDispatchConf struct {
+	MaxAttempts       int `mapstructure:"max_attempts"`
+	BackoffSeconds    int `mapstructure:"backoff_seconds"`
+	MaxBackoffSeconds int `mapstructure:"max_backoff_seconds"`
+	LeaseSeconds      int `mapstructure:"lease_seconds"`
+	BatchSize         int `mapstructure:"batch_size"`
+}
+
+type ReminderConf struct {
+	DedupTTLMinutes int `mapstructure:"dedup_ttl_minutes"`
+}
+
+// ActionsConf задает подписанные ссылки отложить и отклонить в напоминаниях.
+// Календарь обслуживает ссылки, если задан secret, сервис рассылки добавляет
+// их в напоминания, если задан base_url - адрес HTTP сервера календаря.
+type ActionsConf struct {
+	BaseURL       string `mapstructure:"base_url"`
+	Secret        string
+	SecretFile    string `mapstructure:"secret_file"`
+	SnoozeMinutes int    `mapstructure:"snooze_minutes"`
+	TTLHours      int    `mapstructure:"ttl_hours"`
+}
+
+type DigestConf struct {
+	MaxDelayMinutes int `mapstructure:"max_delay_minutes"`
+}
+
+type TemplatesConf struct {
+	Dir string
+}
+
+type SMTPConf struct {
+	Host           string
+	Port           int
+	From           string
+	Username       string
+	Password       string
+	PasswordFile   string `mapstructure:"password_file"`
+	TimeoutSeconds int    `mapstructure:"timeout_seconds"`
+}
+
+type FileChannelConf struct {
+	Path string
+}
+
+type BotConf struct {
+	URL            string
+	Token          string
+	TokenFile      string `mapstructure:"token_file"`
+	TimeoutSeconds int    `mapstructure:"timeout_seconds"`
+}
+
+// ChannelsConf задает каналы доставки. Каналы log и webhook доступны всегда,
+// email, file и bot - если заданы smtp.host, file.path и bot.token.
+type ChannelsConf struct {
+	Default string
+	SMTP    SMTPConf
+	File    FileChannelConf
+	Bot     BotConf
+}
+
+type WebhookClientConf struct {
+	TimeoutSeconds int `mapstructure:"timeout_seconds"`
+}
+
+type JobConf struct {
+	Schedule       string
+	JitterSeconds  int `mapstructure:"jitter_seconds"`
+	TimeoutSeconds int `mapstructure:"timeout_seconds"`
+}
+
+type AdminServerConf struct {
+	Host string
+	Port int
+}
+
+type LeaderConf struct {
+	LeaseSeconds int `mapstructure:"lease_seconds"`
+	RenewSeconds int `mapstructure:"renew_seconds"`
+}
+
+type SchedulerStorageConf struct {
+	StorageConf       `mapstructure:",squash"`
+	PollTimeSeconds   int `mapstructure:"poll_time_seconds"`
+	OutdatedEventDays int `mapstructure:"outdated_event_days"`
+}
+
+type Calendar struct {
+	Logger      LoggerConf
+	Storage     StorageConf
+	Server      ServerConf
+	Feed        FeedConf
+	Cache       CacheConf
+	Actions     ActionsConf
+	Attachments AttachmentsConf
+}
+
+type Scheduler struct {
+	Logger      LoggerConf
+	Storage     SchedulerStorageConf
+	Broker      MessageBrokerConf
+	Webhook     WebhookDispatchConf
+	Leader      LeaderConf
+	Jobs        map[string]JobConf
+	Admin       AdminServerConf
+	Attachments AttachmentsConf
+}
+
+type Sender struct {
+	Logger    LoggerConf
+	Broker    MessageBrokerConf
+	Webhook   WebhookClientConf
+	Reminder  ReminderConf
+	Actions   ActionsConf
+	Digest    DigestConf
+	Templates TemplatesConf
+	Channels  ChannelsConf
+}
+
+// Форматы вывода calendarctl.
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+)
+
+// ClientConf - параметры подключения calendarctl к gRPC серверу календаря.
+// Token передается в метаданных authorization как Bearer токен и только по TLS.
+// CAFile задает корневой сертификат сервера, пустой - системные корневые сертификаты.
+type ClientConf struct {
+	Address        string
+	Token          string
+	TokenFile      string `mapstructure:"token_file"`
+	TLS            bool
+	CAFile         string `mapstructure:"ca_file"`
+	Output         string
+	TimeoutSeconds int `mapstructure:"timeout_seconds"`
+}
+
+type Ctl struct {
+	Client ClientConf
+}
+
+var (
+	loggerDefaults = map[string]interface{}{
+		"logger.level": logger.LevelInfo,
+	}
+	storageDefaults = map[string]interface{}{
+		"storage.mode":                      app.DBModeSQL,
+		"storage.user":                      "",
+		"storage.password":                  "",
+		"storage.password_file":             "",
+		"storage.host":                      "localhost",
+		"storage.port":                      5432,
+		"storage.name":                      "calendar",
+		"storage.path":                      "calendar.db",
+		"storage.query_timeout_seconds":     3,
+		"storage.batch_timeout_seconds":     30,
+		"storage.auto_migrate":              false,
+		"storage.max_open_conns":            10,
+		"storage.max_idle_conns":            5,
+		"storage.conn_max_lifetime_seconds": 300,
+		"storage.connect_attempts":          10,
+		"storage.connect_backoff_seconds":   1,
+		"storage.read_attempts":             3,
+		"storage.read_backoff_ms":           100,
+		"storage.breaker_failures":          5,
+		"storage.breaker_cooldown_seconds":  10,
+		"storage.data_dir":                  "",
+		"storage.fsync":                     memorystorage.SyncInterval,
+		"storage.fsync_interval_seconds":    1,
+		"storage.snapshot_every":            memorystorage.DefaultSnapshotEvery,
+	}
+	brokerDefaults = map[string]interface{}{
+		"broker.user":                 "",
+		"broker.password":             "",
+		"broker.password_file":        "",
+		"broker.host":                 "localhost",
+		"broker.port":                 5672,
+		"broker.queue":                "calendar_events",
+		"broker.webhook_queue":        "calendar_webhooks",
+		"broker.webhook_result_queue": "calendar_webhook_results",
+		"broker.digest_queue":         "calendar_digests",
+		"broker.status_queue":         "calendar_notification_status",
+	}
+	serverDefaults = map[string]interface{}{
+		"server.http.host":       "localhost",
+		"server.http.port":       8080,
+		"server.http.rate_limit": 0,
+		"server.http.rate_burst": 0,
+		"server.grpc.port":       50051,
+	}
+	feedDefaults = map[string]interface{}{
+		"feed.history_size": feed.DefaultHistorySize,
+	}
+	cacheDefaults = map[string]interface{}{
+		"cache.enabled":           false,
+		"cache.size":              cache.DefaultSize,
+		"cache.event_ttl_seconds": int(cache.DefaultEventTTL / time.Second),
+		"cache.list_ttl_seconds":  int(cache.DefaultListTTL / time.Second),
+	}
+	clientDefaults = map[string]interface{}{
+		"client.address":         "localhost:50051",
+		"client.token":           "",
+		"client.token_file":      "",
+		"client.tls":             false,
+		"client.ca_file":         "",
+		"client.output":          OutputTable,
+		"client.timeout_seconds": 10,
+	}
+	attachmentsDefaults = map[string]interface{}{
+		"attachments.store":       attachment.StoreFS,
+		"attachments.dir":         "attachments",
+		"attachments.max_size_mb": 10,
+	}
+	actionsDefaults = map[string]interface{}{
+		"actions.secret":      "",
+		"actions.secret_file": "",
+	}
+	schedulerDefaults = map[string]interface{}{
+		"storage.poll_time_seconds":   5,
+		"storage.outdated_event_days": 365,
+		"webhook.max_attempts":        8,
+		"webhook.backoff_seconds":     10,
+		"webhook.max_backoff_seconds": 3600,
+		"webhook.lease_seconds":       60,
+		"webhook.batch_size":          100,
+		"leader.lease_seconds":        15,
+		"leader.renew_seconds":        5,
+		"admin.host":                  "localhost",
+		"admin.port":                  8081,
+
+		"jobs." + app.JobNotifications + ".schedule":        "@every 5s",
+		"jobs." + app.JobNotifications + ".jitter_seconds":  0,
+		"jobs." + app.JobNotifications + ".timeout_seconds": 60,
+		"jobs." + app.JobPurge + ".schedule":                "@hourly",
+		"jobs." + app.JobPurge + ".jitter_seconds":          60,
+		"jobs." + app.JobPurge + ".timeout_seconds":         300,
+		"jobs." + app.JobDigest + ".schedule":               "*/5 * * * *",
+		"jobs." + app.JobDigest + ".jitter_seconds":         0,
+		"jobs." + app.JobDigest + ".timeout_seconds":        120,
+	}
+	senderDefaults = map[string]interface{}{
+		"webhook.timeout_seconds":       10,
+		"reminder.dedup_ttl_minutes":    1440,
+		"actions.base_url":              "",
+		"actions.snooze_minutes":        10,
+		"actions.ttl_hours":             24,
+		"digest.max_delay_minutes":      120,
+		"templates.dir":                 "",
+		"channels.default":              storage.ChannelLog,
+		"channels.smtp.host":            "",
+		"channels.smtp.port":            25,
+		"channels.smtp.from":            "calendar@localhost",
+		"channels.smtp.username":        "",
+		"channels.smtp.password":        "",
+		"channels.smtp.password_file":   "",
+		"channels.smtp.timeout_seconds": 10,
+		"channels.file.path":            "",
+		"channels.bot.url":              "https://api.telegram.org",
+		"channels.bot.token":            "",
+		"channels.bot.token_file":       "",
+		"channels.bot.timeout_seconds":  10,
+	}
+)
+
+// NewCtl читает конфигурацию calendarctl. Без файла используются
+// значения по умолчанию и переменные окружения.
+func NewCtl(configFile string) (Ctl, error) {
+	var c Ctl
+
+	err := load(configFile, &c, clientDefaults)
+	if err != nil {
+		return c, err
+	}
+
+	c.Client.Output = strings.ToLower(c.Client.Output)
+
+	c.Client.Token, err = readSecret(c.Client.Token, c.Client.TokenFile)
+	if err != nil {
+		return c, err
+	}
+
+	return c, c.Validate()
+}
+
+func NewCalendar(configFile string) (Calendar, error) {
+	var c Calendar
+
+	err := load(configFile, &c, loggerDefaults, storageDefaults, serverDefaults, feedDefaults, cacheDefaults,
+		actionsDefaults, attachmentsDefaults)
+	if err != nil {
+		return c, err
+	}
+
+	c.Logger.Level = strings.ToLower(c.Logger.Level)
+
+	c.Storage.Password, err = readSecret(c.Storage.Password, c.Storage.PasswordFile)
+	if err != nil {
+		return c, err
+	}
+
+	c.Actions.Secret, err = readSecret(c.Actions.Secret, c.Actions.SecretFile)
+	if err != nil {
+		return c, err
+	}
+
+	return c, c.Validate()
+}
+
+func NewScheduler(configFile string) (Scheduler, error) {
+	var c Scheduler
+
+	err := load(configFile, &c, loggerDefaults, storageDefaults, schedulerDefaults, brokerDefaults,
+		attachmentsDefaults)
+	if err != nil {
+		return c, err
+	}
+
+	c.Logger.Level = strings.ToLower(c.Logger.Level)
+
+	c.Storage.Password, err = readSecret(c.Storage.Password, c.Storage.PasswordFile)
+	if err != nil {
+		return c, err
+	}
+
+	c.Broker.Password, err = readSecret(c.Broker.Password, c.Broker.PasswordFile)
+	if err != nil {
+		return c, err
+	}
+
+	return c, c.Validate()
+}
+
+func NewSender(configFile string) (Sender, error) {
+	var c Sender
+
+	err := load(configFile, &c, loggerDefaults, brokerDefaults, senderDefaults, actionsDefaults)
+	if err != nil {
+		return c, err
+	}
+
+	c.Logger.Level = strings.ToLower(c.Logger.Level)
+
+	c.Broker.Password, err = readSecret(c.Broker.Password, c.Broker.PasswordFile)
+	if err != nil {
+		return c, err
+	}
+
+	c.Channels.SMTP.Password, err = readSecret(c.Channels.SMTP.Password, c.Channels.SMTP.PasswordFile)
+	if err != nil {
+		return c, err
+	}
+
+	c.Channels.Bot.Token, err = readSecret(c.Channels.Bot.Token, c.Channels.Bot.TokenFile)
+	if err != nil {
+		return c, err
+	}
+
+	c.Actions.Secret, err = readSecret(c.Actions.Secret, c.Actions.SecretFile)
+	if err != nil {
+		return c, err
+	}
+
+	return c, c.Validate()
+}
+
+func (c Ctl) Validate() error {
+	var errs []error
+
+	if c.Client.Address == "" {
+		errs = append(errs, errors.New("client.address: must not be empty"))
+	}
+	if c.Client.Output != OutputTable && c.Client.Output != OutputJSON {
+		errs = append(errs, fmt.Errorf("client.output: unsupported value %q, expected %q or %q",
+			c.Client.Output, OutputTable, OutputJSON))
+	}
+	if c.Client.TimeoutSeconds < 1 {
+		errs = append(errs, errors.New("client.timeout_seconds: must be > 0"))
+	}
+	if c.Client.Token != "" && !c.Client.TLS {
+		errs = append(errs, errors.New("client.token: requires client.tls, the token must not be sent in plain text"))
+	}
+
+	return joinErrors(errs...)
+}
+
+func (c Calendar) Validate() error {
+	errs := []error{
+		validateLogger(c.Logger),
+		validateStorage(c.Storage),
+		validatePort("server.http.port", c.Server.HTTP.Port),
+		validatePort("server.grpc.port", c.Server.GRPC.Port),
+	}
+
+	if c.Server.HTTP.RateLimit < 0 {
+		errs = append(errs, errors.New("server.http.rate_limit: must be >= 0"))
+	}
+	if c.Server.HTTP.RateBurst < 0 {
+		errs = append(errs, errors.New("server.http.rate_burst: must be >= 0"))
+	}
+	if c.Feed.HistorySize < 1 {
+		errs = append(errs, errors.New("feed.history_size: must be > 0"))
+	}
+	if c.Cache.Enabled {
+		errs = append(errs, validateCache(c.Cache))
+	}
+	if c.Actions.Secret != "" {
+		errs = append(errs, validateActionsSecret(c.Actions))
+	}
+	if c.Attachments.MaxSizeMB < 1 {
+		errs = append(errs, errors.New("attachments.max_size_mb: must be > 0"))
+	}
+
+	errs = append(errs, validateAttachments(c.Attachments))
+
+	return joinErrors(errs...)
+}
+
+func (c Scheduler) Validate() error {
+	errs := []error{
+		validateLogger(c.Logger),
+		validateSchedulerStorage(c.Storage.StorageConf),
+		validateBroker(c.Broker),
+	}
+
+	if c.Storage.PollTimeSeconds < 1 {
+		errs = append(errs, errors.New("storage.poll_time_seconds: must be > 0"))
+	}
+	if c.Storage.OutdatedEventDays < 1 {
+		errs = append(errs, errors.New("storage.outdated_event_days: must be > 0"))
+	}
+	if c.Webhook.MaxAttempts < 1 {
+		errs = append(errs, errors.New("webhook.max_attempts: must be > 0"))
+	}
+	if c.Webhook.BackoffSeconds < 1 {
+		errs = append(errs, errors.New("webhook.backoff_seconds: must be > 0"))
+	}
+	if c.Webhook.MaxBackoffSeconds < c.Webhook.BackoffSeconds {
+		errs = append(errs, errors.New("webhook.max_backoff_seconds: must be >= webhook.backoff_seconds"))
+	}
+	if c.Webhook.LeaseSeconds < 1 {
+		errs = append(errs, errors.New("webhook.lease_seconds: must be > 0"))
+	}
+	if c.Webhook.BatchSize < 1 {
+		errs = append(errs, errors.New("webhook.batch_size: must be > 0"))
+	}
+	if c.Leader.RenewSeconds < 1 {
+		errs = append(errs, errors.New("leader.renew_seconds: must be > 0"))
+	}
+	if c.Leader.LeaseSeconds <= c.Leader.RenewSeconds {
+		errs = append(errs, errors.New("leader.lease_seconds: must be > leader.renew_seconds"))
+	}
+
+	errs = append(errs, validatePort("admin.port", c.Admin.Port), validateJobs(c.Jobs),
+		validateAttachments(c.Attachments))
+
+	return joinErrors(errs...)
+}
+
+func (c Sender) Validate() error {
+	errs := []error{
+		validateLogger(c.Logger),
+		validateBroker(c.Broker),
+	}
+
+	if c.Webhook.TimeoutSeconds < 1 {
+		errs = append(errs, errors.New("webhook.timeout_seconds: must be > 0"))
+	}
+	if c.Reminder.DedupTTLMinutes < 1 {
+		errs = append(errs, errors.New("reminder.dedup_ttl_minutes: must be > 0"))
+	}
+	if c.Digest.MaxDelayMinutes < 1 {
+		errs = append(errs, errors.New("digest.max_delay_minutes: must be > 0"))
+	}
+
+	if c.Actions.BaseURL != "" {
+		errs = append(errs, validateActions(c.Actions))
+	}
+
+	errs = append(errs, validateChannels(c.Channels))
+
+	return joinErrors(errs...)
+}
+
+func (c SMTPConf) Config() sender.SMTPConfig {
+	return sender.SMTPConfig{
+		Host:     c.Host,
+		Port:     c.Port,
+		From:     c.From,
+		Username: c.Username,
+		Password: c.Password,
+		Timeout:  time.Duration(c.TimeoutSeconds) * time.Second,
+	}
+}
+
+func (c ActionsConf) Links() sender.ActionLinks {
+	return sender.ActionLinks{
+		BaseURL: c.BaseURL,
+		Signer:  actionlink.NewSigner(c.Secret),
+		Snooze:  time.Duration(c.SnoozeMinutes) * time.Minute,
+		TTL:     time.Duration(c.TTLHours) * time.Hour,
+	}
+}
+
+func (c BotConf) Config() sender.BotConfig {
+	return sender.BotConfig{
+		URL:     c.URL,
+		Token:   c.Token,
+		Timeout: time.Duration(c.TimeoutSeconds) * time.Second,
+	}
+}
+
+// Enabled возвращает каналы, для которых задана конфигурация.
+func (c ChannelsConf) Enabled() []string {
+	enabled := []string{storage.ChannelLog, storage.ChannelWebhook}
+	if c.SMTP.Host != "" {
+		enabled = append(enabled, storage.ChannelEmail)
+	}
+	if c.File.Path != "" {
+		enabled = append(enabled, storage.ChannelFile)
+	}
+	if c.Bot.Token != "" {
+		enabled = append(enabled, storage.ChannelBot)
+	}
+
+	return enabled
+}
+
+func (c Scheduler) AdminServerAddress() string {
+	return fmt.Sprintf("%s:%d", c.Admin.Host, c.Admin.Port)
+}
+
+// Job возвращает задачу name с расписанием из конфигурации.
+func (c JobConf) Job(name string, run func(ctx context.Context) error) jobs.Job {
+	return jobs.Job{
+		Name:     name,
+		Schedule: c.Schedule,
+		Jitter:   time.Duration(c.JitterSeconds) * time.Second,
+		Timeout:  time.Duration(c.TimeoutSeconds) * time.Second,
+		Run:      run,
+	}
+}
+
+func (c Calendar) HTTPServerAddress() string {
+	return fmt.Sprintf("%s:%d", c.Server.HTTP.Host, c.Server.HTTP.Port)
+}
+
+func (c StorageConf) ConnectionString() string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s",
+		c.Host, c.Port, c.User, c.Password, c.Name)
+}
+
+// Options возвращает параметры для app.NewStorage.
+func (c StorageConf) Options() app.StorageOptions {
+	return app.StorageOptions{
+		DSN:         c.ConnectionString(),
+		Path:        c.Path,
+		Timeouts:    c.Timeouts(),
+		Persistence: c.Persistence(),
+		AutoMigrate: c.AutoMigrate,
+		Pool:        c.Pool(),
+		Retry:       c.Retry(),
+		Breaker:     c.Breaker(),
+	}
+}
+
+// Pool возвращает ограничения пула соединений с Postgres.
+func (c StorageConf) Pool() sqlstorage.Pool {
+	return sqlstorage.Pool{
+		MaxOpenConns:    c.MaxOpenConns,
+		MaxIdleConns:    c.MaxIdleConns,
+		ConnMaxLifetime: time.Duration(c.ConnMaxLifetimeSeconds) * time.Second,
+	}
+}
+
+// Retry возвращает параметры повторов подключения и чтения.
+func (c StorageConf) Retry() sqlstorage.Retry {
+	return sqlstorage.Retry{
+		ConnectAttempts: c.ConnectAttempts,
+		ConnectBackoff:  time.Duration(c.ConnectBackoffSeconds) * time.Second,
+		ReadAttempts:    c.ReadAttempts,
+		ReadBackoff:     time.Duration(c.ReadBackoffMs) * time.Millisecond,
+	}
+}
+
+// Breaker возвращает параметры circuit breaker.
+func (c StorageConf) Breaker() sqlstorage.Breaker {
+	return sqlstorage.Breaker{
+		Failures: c.BreakerFailures,
+		Cooldown: time.Duration(c.BreakerCooldownSeconds) * time.Second,
+	}
+}
+
+// Timeouts возвращает предельное время операций с БД.
+func (c StorageConf) Timeouts() sqlstorage.Timeouts {
+	return sqlstorage.Timeouts{
+		Query: time.Duration(c.QueryTimeoutSeconds) * time.Second,
+		Batch: time.Duration(c.BatchTimeoutSeconds) * time.Second,
+	}
+}
+
+// Persistence возвращает параметры сохранения на диск для режима in-memory.
+func (c StorageConf) Persistence() memorystorage.Persistence {
+	return memorystorage.Persistence{
+		Dir:           c.DataDir,
+		Sync:          c.Fsync,
+		SyncInterval:  time.Duration(c.FsyncIntervalSeconds) * time.Second,
+		SnapshotEvery: c.SnapshotEvery,
+	}
+}
+
+// MaxSize возвращает предельный размер загружаемого файла в байтах.
+func (c AttachmentsConf) MaxSize() int64 {
+	return int64(c.MaxSizeMB) << 20
+}
+
+// Options возвращает параметры для cache.NewStorage.
+func (c CacheConf) Options() cache.Options {
+	return cache.Options{
+		Size:     c.Size,
+		EventTTL: time.Duration(c.EventTTLSeconds) * time.Second,
+		ListTTL:  time.Duration(c.ListTTLSeconds) * time.Second,
+	}
+}
+
+func (c MessageBrokerConf) ConnectionString() string {
+	return fmt.Sprintf("amqp://%s:%s@%s:%d/",
+		c.User, c.Password, c.Host, c.Port)
+}
+
+// NotifyReload вызывает reload на каждый SIGHUP, пока не завершен ctx.
+func NotifyReload(ctx context.Context, reload func()) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			reload()
+		}
+	}
+}
+
+func load(configFile string, config interface{}, defaults ...map[string]interface{}) error {
+	v := viper.New()
+
+	for _, d := range defaults {
+		for key, value := range d {
+			v.SetDefault(key, value)
+		}
+	}
+
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	if configFile != "" {
+		v.SetConfigFile(configFile)
+		if err := v.ReadInConfig(); err != nil {
+			return fmt.Errorf("config read error: %w", err)
+		}
+	}
+
+	if err := v.Unmarshal(config); err != nil {
+		return fmt.Errorf("config unmarshal error: %w", err)
+	}
+
+	return nil
+}
+
+func readSecret(value string, file string) (string, error) {
+	if file == "" {
+		return value, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("secret read error: %w", err)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+func validateLogger(c LoggerConf) error {
+	switch c.Level {
+	case logger.LevelDebug, logger.LevelInfo, logger.LevelWarn, logger.LevelError:
+		return nil
+	default:
+		return fmt.Errorf("logger.level: unsupported value %q, expected one of %q, %q, %q, %q",
+			c.Level, logger.LevelDebug, logger.LevelInfo, logger.LevelWarn, logger.LevelError)
+	}
+}
+
+func validateStorage(c StorageConf) error {
+	switch c.Mode {
+	case app.DBModeSQL, app.DBModeSQLite:
+		return validateDatabase(c)
+	case app.DBModeInMemory:
+		return validatePersistence(c)
+	default:
+		return fmt.Errorf("storage.mode: unsupported value %q, expected one of %q, %q, %q",
+			c.Mode, app.DBModeSQL, app.DBModeSQLite, app.DBModeInMemory)
+	}
+}
+
+// validateSchedulerStorage проверяет хранилище планировщика: in-memory для него не подходит.
+func validateSchedulerStorage(c StorageConf) error {
+	switch c.Mode {
+	case app.DBModeSQL, app.DBModeSQLite:
+		return validateDatabase(c)
+	default:
+		return fmt.Errorf("storage.mode: unsupported value %q, expected one of %q, %q",
+			c.Mode, app.DBModeSQL, app.DBModeSQLite)
+	}
+}
+
+func validateDatabase(c StorageConf) error {
+	var err error
+	if c.Mode == app.DBModeSQLite {
+		if strings.TrimSpace(c.Path) == "" {
+			err = errors.New("storage.path: must not be empty")
+		}
+	} else {
+		err = errors.Join(validatePort("storage.port", c.Port), validateResilience(c))
+	}
+
+	return errors.Join(err, validateStorageTimeouts(c))
+}
+
+func validateResilience(c StorageConf) error {
+	var errs []error
+
+	if c.MaxOpenConns < 0 {
+		errs = append(errs, errors.New("storage.max_open_conns: must be >= 0"))
+	}
+	if c.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("storage.max_idle_conns: must be >= 0"))
+	}
+	if c.MaxOpenConns > 0 && c.MaxIdleConns > c.MaxOpenConns {
+		errs = append(errs, errors.New("storage.max_idle_conns: must not exceed storage.max_open_conns"))
+	}
+	if c.ConnMaxLifetimeSeconds < 0 {
+		errs = append(errs, errors.New("storage.conn_max_lifetime_seconds: must be >= 0"))
+	}
+	if c.ConnectAttempts < 1 {
+		errs = append(errs, errors.New("storage.connect_attempts: must be > 0"))
+	}
+	if c.ConnectBackoffSeconds < 1 {
+		errs = append(errs, errors.New("storage.connect_backoff_seconds: must be > 0"))
+	}
+	if c.ReadAttempts < 1 {
+		errs = append(errs, errors.New("storage.read_attempts: must be > 0"))
+	}
+	if c.ReadBackoffMs < 1 {
+		errs = append(errs, errors.New("storage.read_backoff_ms: must be > 0"))
+	}
+	if c.BreakerFailures < 0 {
+		errs = append(errs, errors.New("storage.breaker_failures: must be >= 0"))
+	}
+	if c.BreakerFailures > 0 && c.BreakerCooldownSeconds < 1 {
+		errs = append(errs, errors.New("storage.breaker_cooldown_seconds: must be > 0"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateStorageTimeouts(c StorageConf) error {
+	var errs []error
+
+	if c.QueryTimeoutSeconds < 1 {
+		errs = append(errs, errors.New("storage.query_timeout_seconds: must be > 0"))
+	}
+	if c.BatchTimeoutSeconds < 1 {
+		errs = append(errs, errors.New("storage.batch_timeout_seconds: must be > 0"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func validatePersistence(c StorageConf) error {
+	var errs []error
+
+	switch c.Fsync {
+	case memorystorage.SyncAlways, memorystorage.SyncInterval, memorystorage.SyncNever:
+	default:
+		errs = append(errs, fmt.Errorf("storage.fsync: unsupported value %q, expected one of %q, %q, %q",
+			c.Fsync, memorystorage.SyncAlways, memorystorage.SyncInterval, memorystorage.SyncNever))
+	}
+	if c.FsyncIntervalSeconds < 1 {
+		errs = append(errs, errors.New("storage.fsync_interval_seconds: must be > 0"))
+	}
+	if c.SnapshotEvery < 1 {
+		errs = append(errs, errors.New("storage.snapshot_every: must be > 0"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateCache(c CacheConf) error {
+	var errs []error
+
+	if c.Size < 1 {
+		errs = append(errs, errors.New("cache.size: must be > 0"))
+	}
+	if c.EventTTLSeconds < 1 {
+		errs = append(errs, errors.New("cache.event_ttl_seconds: must be > 0"))
+	}
+	if c.ListTTLSeconds < 1 {
+		errs = append(errs, errors.New("cache.list_ttl_seconds: must be > 0"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateAttachments(c AttachmentsConf) error {
+	var errs []error
+
+	if !slices.Contains(attachment.Stores, c.Store) {
+		errs = append(errs, fmt.Errorf("attachments.store: unsupported value %q, expected one of %v",
+			c.Store, attachment.Stores))
+	}
+	if c.Store == attachment.StoreFS && c.Dir == "" {
+		errs = append(errs, errors.New("attachments.dir: must not be empty"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateJobs(c map[string]JobConf) error {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var errs []error
+
+	for _, name := range names {
+		key := "jobs." + name
+
+		if !slices.Contains(app.SchedulerJobs, name) {
+			errs = append(errs, fmt.Errorf("%s: unknown job, expected one of %q", key, app.SchedulerJobs))
+			continue
+		}
+
+		j := c[name]
+		if _, err := jobs.ParseSchedule(j.Schedule); err != nil {
+			errs = append(errs, fmt.Errorf("%s.schedule: %w", key, err))
+		}
+		if j.JitterSeconds < 0 {
+			errs = append(errs, fmt.Errorf("%s.jitter_seconds: must be >= 0", key))
+		}
+		if j.TimeoutSeconds < 1 {
+			errs = append(errs, fmt.Errorf("%s.timeout_seconds: must be > 0", key))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateChannels(c ChannelsConf) error {
+	var errs []error
+
+	if !slices.Contains(c.Enabled(), c.Default) {
+		errs = append(errs, fmt.Errorf("channels.default: channel %q is unknown or not configured, expected one of %q",
+			c.Default, c.Enabled()))
+	}
+	if c.SMTP.Host != "" {
+		errs = append(errs, validatePort("channels.smtp.port", c.SMTP.Port))
+		if c.SMTP.From == "" {
+			errs = append(errs, errors.New("channels.smtp.from: must not be empty"))
+		}
+		if c.SMTP.TimeoutSeconds < 1 {
+			errs = append(errs, errors.New("channels.smtp.timeout_seconds: must be > 0"))
+		}
+	}
+	if c.Bot.Token != "" {
+		if c.Bot.URL == "" {
+			errs = append(errs, errors.New("channels.bot.url: must not be empty"))
+		}
+		if c.Bot.TimeoutSeconds < 1 {
+			errs = append(errs, errors.New("channels.bot.timeout_seconds: must be > 0"))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateActions(c ActionsConf) error {
+	var errs []error
+
+	u, err := url.Parse(c.BaseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, errors.New("actions.base_url: must be an absolute http(s) url"))
+	}
+	if c.SnoozeMinutes < 1 || c.SnoozeMinutes > storage.MaxSnoozeMinutes {
+		errs = append(errs, fmt.Errorf("actions.snooze_minutes: must be in [1, %d]", storage.MaxSnoozeMinutes))
+	}
+	if c.TTLHours < 1 {
+		errs = append(errs, errors.New("actions.ttl_hours: must be > 0"))
+	}
+
+	return errors.Join(append(errs, validateActionsSecret(c))...)
+}
+
+// validateActionsSecret требует секрет, который нельзя подобрать перебором.
+func validateActionsSecret(c ActionsConf) error {
+	if len(c.Secret) < minActionsSecret {
+		return fmt.Errorf("actions.secret: must be at least %d characters", minActionsSecret)
+	}
+
+	return nil
+}
+
+func validateBroker(c MessageBrokerConf) error {
+	errs := []error{validatePort("broker.port", c.Port)}
+
+	if c.Queue == "" {
+		errs = append(errs, errors.New("broker.queue: must not be empty"))
+	}
+	if c.WebhookQueue == "" {
+		errs = append(errs, errors.New("broker.webhook_queue: must not be empty"))
+	}
+	if c.WebhookResultQueue == "" {
+		errs = append(errs, errors.New("broker.webhook_result_queue: must not be empty"))
+	}
+	if c.DigestQueue == "" {
+		errs = append(errs, errors.New("broker.digest_queue: must not be empty"))
+	}
+	if c.StatusQueue == "" {
+		errs = append(errs, errors.New("broker.status_queue: must not be empty"))
+	}
+
+	return joinErrors(errs...)
+}
+
+func validatePort(key string, port int) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("%s: %d is out of range 1-65535", key, port)
+	}
+
+	return nil
+}
+
+func joinErrors(errs ...error) error {
+	err := errors.Join(errs...)
+	if err == nil {
+		return nil
+	}
+
+	return fmt.Errorf("%w:\n%w", ErrInvalidConfig, err)
+}
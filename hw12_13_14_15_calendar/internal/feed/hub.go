@@ -0,0 +1,206 @@
+package feed
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+type ChangeType string
+
+const (
+	ChangeCreated ChangeType = "created"
+	ChangeUpdated ChangeType = "updated"
+	ChangeDeleted ChangeType = "deleted"
+)
+
+const (
+	DefaultHistorySize = 1024
+	subscriberBuffer   = 64
+)
+
+// ErrHistoryExpired - запрошенный last event id уже вытеснен из истории,
+// клиенту нужно перечитать события целиком и подписаться заново.
+var ErrHistoryExpired = errors.New("last event id is no longer available")
+
+// Change - изменение события. Для обновления Previous хранит версию до изменения, если она известна.
+type Change struct {
+	ID       uint64         `json:"id"`
+	Type     ChangeType     `json:"type"`
+	Event    storage.Event  `json:"event"`
+	Previous *storage.Event `json:"previous,omitempty"`
+}
+
+// matches сообщает, касается ли изменение подписки. Обновление касается ее, если фильтру подходит
+// новая или прежняя версия: так подписчик узнает и о событии, которое ушло из его выборки.
+func (c Change) matches(f Filter) bool {
+	return f.Match(c.Event) || c.Previous != nil && f.Match(*c.Previous)
+}
+
+// Filter ограничивает подписку владельцем события и днями, которые занимает событие
+// (формат 2006-01-02, границы включительно). Пустые поля не фильтруют.
+type Filter struct {
+	UserID   string
+	DateFrom string
+	DateTo   string
+}
+
+func (f Filter) Match(e storage.Event) bool {
+	if f.UserID != "" && f.UserID != e.UserID {
+		return false
+	}
+
+	days := e.Days()
+	first := time.Unix(days[0], 0).UTC().Format(time.DateOnly)
+	last := time.Unix(days[len(days)-1], 0).UTC().Format(time.DateOnly)
+
+	if f.DateFrom != "" && last < f.DateFrom {
+		return false
+	}
+	if f.DateTo != "" && first > f.DateTo {
+		return false
+	}
+
+	return true
+}
+
+// Hub раздает изменения событий подписчикам и хранит последние изменения,
+// чтобы переподключившийся клиент мог продолжить с last event id.
+type Hub struct {
+	mu          sync.Mutex
+	lastID      uint64
+	history     []Change
+	historySize int
+	subscribers map[*Subscription]struct{}
+}
+
+type Subscription struct {
+	C <-chan Change
+
+	ch     chan Change
+	filter Filter
+	hub    *Hub
+	once   sync.Once
+}
+
+func NewHub(historySize int) *Hub {
+	if historySize < 1 {
+		historySize = DefaultHistorySize
+	}
+
+	return &Hub{
+		historySize: historySize,
+		history:     make([]Change, 0, historySize),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+func (h *Hub) Publish(t ChangeType, e storage.Event) Change {
+	return h.publish(Change{Type: t, Event: e})
+}
+
+// PublishUpdate публикует обновление события prev до версии e.
+func (h *Hub) PublishUpdate(prev storage.Event, e storage.Event) Change {
+	return h.publish(Change{Type: ChangeUpdated, Event: e, Previous: &prev})
+}
+
+func (h *Hub) publish(c Change) Change {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	c.ID = h.lastID
+
+	if len(h.history) == h.historySize {
+		copy(h.history, h.history[1:])
+		h.history = h.history[:len(h.history)-1]
+	}
+	h.history = append(h.history, c)
+
+	for sub := range h.subscribers {
+		if !c.matches(sub.filter) {
+			continue
+		}
+
+		select {
+		case sub.ch <- c:
+		default:
+			// Подписчик не успевает читать: отключаем его, клиент переподключится с last event id.
+			h.unsubscribe(sub)
+		}
+	}
+
+	return c
+}
+
+// Subscribe подписывает на изменения. Если lastID > 0, сначала будут отправлены
+// изменения из истории, произошедшие после lastID.
+func (h *Hub) Subscribe(f Filter, lastID uint64) (*Subscription, error) {
+	return h.subscribe(f, lastID, lastID > 0)
+}
+
+// SubscribeAfter подписывает на изменения после lastID. В отличие от Subscribe, нулевой lastID
+// тоже курсор: подписчик получит всю историю, если из нее еще ничего не вытеснено.
+func (h *Hub) SubscribeAfter(f Filter, lastID uint64) (*Subscription, error) {
+	return h.subscribe(f, lastID, true)
+}
+
+func (h *Hub) subscribe(f Filter, lastID uint64, replayHistory bool) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var replay []Change
+
+	if replayHistory {
+		if lastID > h.lastID {
+			return nil, ErrHistoryExpired
+		}
+		if len(h.history) > 0 && lastID+1 < h.history[0].ID {
+			return nil, ErrHistoryExpired
+		}
+
+		for _, c := range h.history {
+			if c.ID > lastID && c.matches(f) {
+				replay = append(replay, c)
+			}
+		}
+	}
+
+	ch := make(chan Change, subscriberBuffer+len(replay))
+	for _, c := range replay {
+		ch <- c
+	}
+
+	sub := &Subscription{
+		C:      ch,
+		ch:     ch,
+		filter: f,
+		hub:    h,
+	}
+	h.subscribers[sub] = struct{}{}
+
+	return sub, nil
+}
+
+func (h *Hub) LastID() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.lastID
+}
+
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	s.hub.unsubscribe(s)
+}
+
+func (h *Hub) unsubscribe(s *Subscription) {
+	s.once.Do(func() {
+		delete(h.subscribers, s)
+		close(s.ch)
+	})
+}
@@ -0,0 +1,241 @@
+package feed
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testUserID = "d5095366-ea13-4c9d-ae72-9c83d2d93040"
+
+func TestFilter(t *testing.T) {
+	e := storage.Event{UserID: testUserID, DateStart: "2022-10-11 12:00:00"}
+
+	cases := []struct {
+		name   string
+		filter Filter
+		match  bool
+	}{
+		{name: "empty", filter: Filter{}, match: true},
+		{name: "user", filter: Filter{UserID: testUserID}, match: true},
+		{name: "other user", filter: Filter{UserID: "other"}, match: false},
+		{name: "range", filter: Filter{DateFrom: "2022-10-11", DateTo: "2022-10-11"}, match: true},
+		{name: "before range", filter: Filter{DateFrom: "2022-10-12"}, match: false},
+		{name: "after range", filter: Filter{DateTo: "2022-10-10"}, match: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.match, tc.filter.Match(e))
+		})
+	}
+
+	multiDay := storage.Event{DateStart: "2022-10-09 12:00:00", DateEnd: "2022-10-11 09:00:00"}
+	require.True(t, Filter{DateFrom: "2022-10-10", DateTo: "2022-10-10"}.Match(multiDay), "event spans the range")
+	require.True(t, Filter{DateFrom: "2022-10-11"}.Match(multiDay), "event ends in the range")
+	require.False(t, Filter{DateFrom: "2022-10-12"}.Match(multiDay))
+}
+
+func TestHub(t *testing.T) {
+	t.Run("publish", func(t *testing.T) {
+		hub := NewHub(0)
+
+		sub, err := hub.Subscribe(Filter{UserID: testUserID}, 0)
+		require.NoError(t, err)
+		defer sub.Close()
+
+		hub.Publish(ChangeCreated, storage.Event{ID: "1", UserID: "other"})
+		hub.Publish(ChangeCreated, storage.Event{ID: "2", UserID: testUserID})
+
+		c := <-sub.C
+		require.Equal(t, uint64(2), c.ID)
+		require.Equal(t, "2", c.Event.ID)
+		require.Len(t, sub.C, 0)
+	})
+
+	t.Run("update matches previous version", func(t *testing.T) {
+		hub := NewHub(0)
+
+		sub, err := hub.Subscribe(Filter{UserID: testUserID}, 0)
+		require.NoError(t, err)
+		defer sub.Close()
+
+		hub.PublishUpdate(storage.Event{ID: "1", UserID: testUserID}, storage.Event{ID: "1", UserID: "other"})
+
+		c := <-sub.C
+		require.Equal(t, "other", c.Event.UserID)
+		require.Equal(t, testUserID, c.Previous.UserID)
+
+		replay, err := hub.SubscribeAfter(Filter{UserID: testUserID}, c.ID-1)
+		require.NoError(t, err)
+		defer replay.Close()
+
+		require.Equal(t, c.ID, (<-replay.C).ID, "replay matches the previous version too")
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		hub := NewHub(3)
+
+		for _, id := range []string{"1", "2", "3", "4", "5"} {
+			hub.Publish(ChangeUpdated, storage.Event{ID: id})
+		}
+
+		sub, err := hub.Subscribe(Filter{}, 3)
+		require.NoError(t, err)
+		defer sub.Close()
+
+		require.Equal(t, uint64(4), (<-sub.C).ID)
+		require.Equal(t, uint64(5), (<-sub.C).ID)
+
+		// Изменение 2 вытеснено из истории размером 3.
+		_, err = hub.Subscribe(Filter{}, 1)
+		require.ErrorIs(t, err, ErrHistoryExpired)
+
+		_, err = hub.Subscribe(Filter{}, 2)
+		require.NoError(t, err)
+
+		_, err = hub.Subscribe(Filter{}, 6)
+		require.ErrorIs(t, err, ErrHistoryExpired)
+	})
+
+	t.Run("subscribe after zero", func(t *testing.T) {
+		hub := NewHub(3)
+		hub.Publish(ChangeCreated, storage.Event{ID: "1"})
+
+		sub, err := hub.SubscribeAfter(Filter{}, 0)
+		require.NoError(t, err)
+		defer sub.Close()
+
+		require.Equal(t, uint64(1), (<-sub.C).ID, "zero cursor replays the whole history")
+
+		for _, id := range []string{"2", "3", "4"} {
+			hub.Publish(ChangeUpdated, storage.Event{ID: id})
+		}
+
+		_, err = hub.SubscribeAfter(Filter{}, 0)
+		require.ErrorIs(t, err, ErrHistoryExpired)
+	})
+
+	t.Run("slow subscriber", func(t *testing.T) {
+		hub := NewHub(0)
+
+		sub, err := hub.Subscribe(Filter{}, 0)
+		require.NoError(t, err)
+
+		for i := 0; i <= subscriberBuffer; i++ {
+			hub.Publish(ChangeCreated, storage.Event{})
+		}
+
+		n := 0
+		for range sub.C {
+			n++
+		}
+		require.Equal(t, subscriberBuffer, n)
+
+		sub.Close()
+	})
+}
+
+func TestStorage(t *testing.T) {
+	t.Run("publishes changes", func(t *testing.T) {
+		hub := NewHub(0)
+		s := NewStorage(memorystorage.New(), hub)
+
+		sub, err := hub.Subscribe(Filter{}, 0)
+		require.NoError(t, err)
+		defer sub.Close()
+
+		id, err := s.CreateEvent(context.Background(), storage.Event{Title: "event", UserID: testUserID, DateStart: "2022-10-11 12:00:00"})
+		require.NoError(t, err)
+
+		require.NoError(t, s.UpdateEvent(context.Background(), id, storage.Event{Title: "renamed", UserID: testUserID, DateStart: "2022-10-11 13:00:00"}))
+		require.NoError(t, s.DeleteEvent(context.Background(), id))
+
+		created, updated, deleted := <-sub.C, <-sub.C, <-sub.C
+
+		require.Equal(t, ChangeCreated, created.Type)
+		require.Equal(t, id, created.Event.ID)
+		require.Equal(t, ChangeUpdated, updated.Type)
+		require.Equal(t, "renamed", updated.Event.Title)
+		require.Equal(t, "event", updated.Previous.Title)
+		require.Equal(t, ChangeDeleted, deleted.Type)
+		require.Equal(t, testUserID, deleted.Event.UserID)
+	})
+
+	t.Run("publishes tag and resource changes", func(t *testing.T) {
+		ctx := context.Background()
+		hub := NewHub(0)
+		s := NewStorage(memorystorage.New(), hub)
+
+		resourceID, err := s.CreateResource(ctx, storage.Resource{Name: "room", Kind: "room"})
+		require.NoError(t, err)
+
+		id, err := s.CreateEvent(ctx, storage.Event{
+			UserID:    testUserID,
+			DateStart: "2022-10-11 12:00:00",
+			DateEnd:   "2022-10-11 13:00:00",
+			Tags:      []string{"work"},
+			Resources: []string{resourceID},
+		})
+		require.NoError(t, err)
+
+		sub, err := hub.Subscribe(Filter{}, 0)
+		require.NoError(t, err)
+		defer sub.Close()
+
+		require.NoError(t, s.RenameTag(ctx, "work", "job"))
+		c := <-sub.C
+		require.Equal(t, ChangeUpdated, c.Type)
+		require.Equal(t, id, c.Event.ID)
+		require.Equal(t, []string{"job"}, c.Event.Tags)
+		require.Equal(t, []string{"work"}, c.Previous.Tags)
+
+		require.NoError(t, s.DeleteTag(ctx, "job"))
+		c = <-sub.C
+		require.Empty(t, c.Event.Tags)
+		require.Equal(t, []string{"job"}, c.Previous.Tags)
+
+		require.NoError(t, s.DeleteResource(ctx, resourceID))
+		c = <-sub.C
+		require.Equal(t, id, c.Event.ID)
+		require.Empty(t, c.Event.Resources)
+		require.Equal(t, []string{resourceID}, c.Previous.Resources)
+		require.Len(t, sub.C, 0)
+	})
+
+	t.Run("failed operation is not published", func(t *testing.T) {
+		hub := NewHub(0)
+
+		m := mocks.NewStorager(t)
+		m.On("GetEvent", mock.Anything, "1").Return(storage.Event{}, storage.ErrEventNotExist)
+
+		err := NewStorage(m, hub).DeleteEvent(context.Background(), "1")
+		require.ErrorIs(t, err, storage.ErrEventNotExist)
+		require.Zero(t, hub.LastID())
+	})
+}
+
+func TestStorageApplyBatch(t *testing.T) {
+	hub := NewHub(0)
+	s := NewStorage(memorystorage.New(), hub)
+
+	_, err := s.ApplyBatch(context.Background(), []storage.BatchOperation{
+		{Op: storage.BatchCreate, Event: storage.Event{DateStart: "2022-10-11 12:00:00"}},
+		{Op: storage.BatchCreate, Event: storage.Event{DateStart: "2022-10-11 12:00:00"}},
+	})
+	require.ErrorIs(t, err, storage.ErrDateBusy)
+	require.Zero(t, hub.LastID())
+
+	results, err := s.ApplyBatch(context.Background(), []storage.BatchOperation{
+		{Op: storage.BatchCreate, Event: storage.Event{DateStart: "2022-10-11 12:00:00"}},
+		{Op: storage.BatchCreate, Event: storage.Event{DateStart: "2022-10-11 13:00:00"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, uint64(2), hub.LastID())
+}
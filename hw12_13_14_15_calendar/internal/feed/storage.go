@@ -0,0 +1,194 @@
+package feed
+
+import (
+	"context"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+// Границы дат, в которые попадает любое событие.
+const (
+	minDate = "0001-01-01"
+	maxDate = "9999-12-30"
+)
+
+// Storage оборачивает хранилище и публикует в Hub успешные изменения событий.
+type Storage struct {
+	app.Storager
+	hub *Hub
+}
+
+func NewStorage(s app.Storager, hub *Hub) *Storage {
+	return &Storage{
+		Storager: s,
+		hub:      hub,
+	}
+}
+
+func (s *Storage) CreateEvent(ctx context.Context, event storage.Event) (string, error) {
+	id, err := s.Storager.CreateEvent(ctx, event)
+	if err != nil {
+		return "", err
+	}
+
+	event.ID = id
+	event.Normalize()
+	s.hub.Publish(ChangeCreated, event)
+
+	return id, nil
+}
+
+func (s *Storage) UpdateEvent(ctx context.Context, id string, event storage.Event) error {
+	// Прежняя версия нужна подписчикам, из выборки которых событие уходит после изменения.
+	prev, err := s.Storager.GetEvent(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.Storager.UpdateEvent(ctx, id, event)
+	if err != nil {
+		return err
+	}
+
+	event.ID = id
+	event.Normalize()
+	s.hub.PublishUpdate(prev, event)
+
+	return nil
+}
+
+func (s *Storage) DeleteEvent(ctx context.Context, id string) error {
+	// Событие читается до удаления, чтобы подписчики могли отфильтровать его по пользователю и дате.
+	event, err := s.Storager.GetEvent(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.Storager.DeleteEvent(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	s.hub.Publish(ChangeDeleted, event)
+
+	return nil
+}
+
+func (s *Storage) ApplyBatch(ctx context.Context, ops []storage.BatchOperation) ([]storage.BatchResult, error) {
+	// Отсутствующее событие пакет отклонит сам, поэтому ошибки чтения здесь не важны.
+	prev := make(map[string]storage.Event)
+	for _, op := range ops {
+		if op.Op != storage.BatchUpdate {
+			continue
+		}
+		if e, err := s.Storager.GetEvent(ctx, op.ID); err == nil {
+			prev[op.ID] = e
+		}
+	}
+
+	results, err := s.Storager.ApplyBatch(ctx, ops)
+	if err != nil {
+		return results, err
+	}
+
+	for _, r := range results {
+		switch r.Op {
+		case storage.BatchCreate:
+			s.hub.Publish(ChangeCreated, r.Event)
+		case storage.BatchUpdate:
+			s.publishUpdate(prev, r.Event)
+		case storage.BatchDelete:
+			s.hub.Publish(ChangeDeleted, r.Event)
+		}
+	}
+
+	return results, nil
+}
+
+// RenameTag публикует обновления событий с переименованной меткой.
+func (s *Storage) RenameTag(ctx context.Context, name string, newName string) error {
+	prev, err := s.Storager.ListEventTagged(ctx, []string{name}, minDate, maxDate)
+	if err != nil {
+		return err
+	}
+
+	err = s.Storager.RenameTag(ctx, name, newName)
+	if err != nil {
+		return err
+	}
+
+	s.publishChanged(ctx, prev)
+
+	return nil
+}
+
+// DeleteTag публикует обновления событий, с которых снята метка.
+func (s *Storage) DeleteTag(ctx context.Context, name string) error {
+	prev, err := s.Storager.ListEventTagged(ctx, []string{name}, minDate, maxDate)
+	if err != nil {
+		return err
+	}
+
+	err = s.Storager.DeleteTag(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	s.publishChanged(ctx, prev)
+
+	return nil
+}
+
+// DeleteResource публикует обновления событий, с которых снята бронь ресурса.
+func (s *Storage) DeleteResource(ctx context.Context, id string) error {
+	bookings, err := s.Storager.ListBookings(ctx, minDate+" 00:00:00", maxDate+" 23:59:59")
+	if err != nil {
+		return err
+	}
+
+	prev := make([]storage.Event, 0)
+	for _, b := range bookings {
+		if b.ResourceID != id {
+			continue
+		}
+
+		e, err := s.Storager.GetEvent(ctx, b.EventID)
+		if err != nil {
+			return err
+		}
+		prev = append(prev, e)
+	}
+
+	err = s.Storager.DeleteResource(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	s.publishChanged(ctx, prev)
+
+	return nil
+}
+
+// publishChanged перечитывает измененные события и публикует их обновления.
+// Событие, удаленное между изменением и чтением, пропускается: его удаление опубликовано отдельно.
+func (s *Storage) publishChanged(ctx context.Context, prev []storage.Event) {
+	for _, p := range prev {
+		e, err := s.Storager.GetEvent(ctx, p.ID)
+		if err != nil {
+			continue
+		}
+
+		s.hub.PublishUpdate(p, e)
+	}
+}
+
+func (s *Storage) publishUpdate(prev map[string]storage.Event, e storage.Event) {
+	p, ok := prev[e.ID]
+	if !ok {
+		s.hub.Publish(ChangeUpdated, e)
+		return
+	}
+
+	s.hub.PublishUpdate(p, e)
+}
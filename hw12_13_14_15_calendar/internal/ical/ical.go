@@ -0,0 +1,456 @@
+// Package ical переводит события календаря в формат iCalendar (RFC 5545) и обратно.
+// Поддерживается подмножество формата, которое нужно для обмена событиями:
+// VEVENT с датами, описанием и одним напоминанием VALARM.
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+// PropUserID - нестандартное свойство с владельцем события.
+const PropUserID = "X-CALENDAR-USER-ID"
+
+// PropCategory хранит категорию события, метки передаются в стандартном CATEGORIES.
+const PropCategory = "X-CALENDAR-CATEGORY"
+
+const (
+	layoutLocal = "20060102T150405"
+	layoutUTC   = "20060102T150405Z"
+	layoutDate  = "20060102"
+
+	// maxLine - длина строки в октетах, после которой она переносится.
+	maxLine = 75
+)
+
+var ErrBadCalendar = errors.New("malformed icalendar data")
+
+// Encode записывает события в w. Даты событий - локальное время календаря,
+// поэтому они записываются без часового пояса.
+func Encode(w io.Writer, events []storage.Event, now time.Time) error {
+	b := &writer{w: bufio.NewWriter(w)}
+
+	b.line("BEGIN:VCALENDAR")
+	b.line("VERSION:2.0")
+	b.line("PRODID:-//otus-go-hw//calendar//EN")
+
+	for _, e := range events {
+		e.Normalize()
+
+		start, err := time.ParseInLocation(time.DateTime, e.DateStart, time.Local)
+		if err != nil {
+			return fmt.Errorf("event %s: %w", e.ID, err)
+		}
+		end, err := time.ParseInLocation(time.DateTime, e.DateEnd, time.Local)
+		if err != nil {
+			return fmt.Errorf("event %s: %w", e.ID, err)
+		}
+
+		b.line("BEGIN:VEVENT")
+		b.line("UID:" + e.ID)
+		b.line("DTSTAMP:" + now.UTC().Format(layoutUTC))
+		if e.AllDay {
+			b.line("DTSTART;VALUE=DATE:" + start.Format(layoutDate))
+			b.line("DTEND;VALUE=DATE:" + end.Format(layoutDate))
+		} else {
+			b.line("DTSTART:" + start.Format(layoutLocal))
+			b.line("DTEND:" + end.Format(layoutLocal))
+		}
+		b.line("SUMMARY:" + escape(e.Title))
+		if e.Description != "" {
+			b.line("DESCRIPTION:" + escape(e.Description))
+		}
+		b.line(PropUserID + ":" + e.UserID)
+		if len(e.Tags) > 0 {
+			tags := make([]string, len(e.Tags))
+			for i, t := range e.Tags {
+				tags[i] = escape(t)
+			}
+			b.line("CATEGORIES:" + strings.Join(tags, ","))
+		}
+		if e.Category != "" {
+			b.line(PropCategory + ":" + escape(e.Category))
+		}
+		if e.Color != "" {
+			b.line("COLOR:" + e.Color)
+		}
+
+		if e.DatePost != "" {
+			remind, err := time.ParseInLocation(time.DateTime, e.DatePost, time.Local)
+			if err != nil {
+				return fmt.Errorf("event %s: %w", e.ID, err)
+			}
+
+			b.line("BEGIN:VALARM")
+			b.line("ACTION:DISPLAY")
+			b.line("DESCRIPTION:" + escape(e.Title))
+			b.line("TRIGGER:" + formatDuration(remind.Sub(start)))
+			b.line("END:VALARM")
+		}
+
+		b.line("END:VEVENT")
+	}
+
+	b.line("END:VCALENDAR")
+
+	if b.err != nil {
+		return b.err
+	}
+
+	return b.w.Flush()
+}
+
+// Decode читает события VEVENT. Время в UTC и с TZID переводится в локальное,
+// событие с датой без времени (VALUE=DATE) становится событием на целые дни.
+func Decode(r io.Reader) ([]storage.Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []storage.Event
+	var cur *vevent
+	inAlarm := false
+
+	for i, raw := range lines {
+		p, err := parseLine(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrBadCalendar, i+1, err)
+		}
+
+		switch {
+		case p.name == "BEGIN" && p.value == "VEVENT":
+			cur = &vevent{}
+		case p.name == "END" && p.value == "VEVENT":
+			if cur == nil {
+				return nil, fmt.Errorf("%w: line %d: unexpected END:VEVENT", ErrBadCalendar, i+1)
+			}
+
+			e, err := cur.event()
+			if err != nil {
+				return nil, fmt.Errorf("%w: event ending on line %d: %w", ErrBadCalendar, i+1, err)
+			}
+			events = append(events, e)
+			cur = nil
+		case cur == nil:
+		case p.name == "BEGIN" && p.value == "VALARM":
+			inAlarm = true
+		case p.name == "END" && p.value == "VALARM":
+			inAlarm = false
+		case inAlarm:
+			// Учитывается только первое напоминание события.
+			if p.name == "TRIGGER" && cur.trigger == nil {
+				cur.trigger = &p
+			}
+		default:
+			cur.set(p)
+		}
+	}
+
+	if cur != nil {
+		return nil, fmt.Errorf("%w: unterminated VEVENT", ErrBadCalendar)
+	}
+
+	return events, nil
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+type vevent struct {
+	uid, summary, description, userID string
+	category, color                   string
+	tags                              []string
+	start, end                        *property
+	duration                          string
+	trigger                           *property
+}
+
+func (v *vevent) set(p property) {
+	switch p.name {
+	case "UID":
+		v.uid = p.value
+	case "SUMMARY":
+		v.summary = unescape(p.value)
+	case "DESCRIPTION":
+		v.description = unescape(p.value)
+	case PropUserID:
+		v.userID = p.value
+	case "CATEGORIES":
+		for _, t := range strings.Split(p.value, ",") {
+			v.tags = append(v.tags, unescape(t))
+		}
+	case PropCategory:
+		v.category = unescape(p.value)
+	case "COLOR":
+		v.color = p.value
+	case "DTSTART":
+		v.start = &p
+	case "DTEND":
+		v.end = &p
+	case "DURATION":
+		v.duration = p.value
+	}
+}
+
+func (v *vevent) event() (storage.Event, error) {
+	if v.start == nil {
+		return storage.Event{}, errors.New("no DTSTART")
+	}
+
+	start, allDay, err := parseTime(*v.start)
+	if err != nil {
+		return storage.Event{}, fmt.Errorf("DTSTART: %w", err)
+	}
+
+	var end time.Time
+	switch {
+	case v.end != nil:
+		end, _, err = parseTime(*v.end)
+		if err != nil {
+			return storage.Event{}, fmt.Errorf("DTEND: %w", err)
+		}
+	case v.duration != "":
+		d, err := parseDuration(v.duration)
+		if err != nil {
+			return storage.Event{}, fmt.Errorf("DURATION: %w", err)
+		}
+		end = start.Add(d)
+	case allDay:
+		end = start.AddDate(0, 0, 1)
+	default:
+		end = start
+	}
+
+	e := storage.Event{
+		ID:          v.uid,
+		Title:       v.summary,
+		DateStart:   start.Format(time.DateTime),
+		DateEnd:     end.Format(time.DateTime),
+		Description: v.description,
+		UserID:      v.userID,
+		Category:    v.category,
+		Color:       v.color,
+		Tags:        storage.NormalizeTags(v.tags),
+		AllDay:      allDay,
+	}
+
+	if v.trigger != nil {
+		remind, err := parseTrigger(*v.trigger, start)
+		if err != nil {
+			return storage.Event{}, fmt.Errorf("TRIGGER: %w", err)
+		}
+		e.DatePost = remind.Format(time.DateTime)
+	}
+
+	return e, nil
+}
+
+func parseTime(p property) (time.Time, bool, error) {
+	if p.params["VALUE"] == "DATE" || len(p.value) == len(layoutDate) {
+		t, err := time.ParseInLocation(layoutDate, p.value, time.Local)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(p.value, "Z") {
+		t, err := time.Parse(layoutUTC, p.value)
+		return t.In(time.Local), false, err
+	}
+
+	loc := time.Local
+	if tzid := p.params["TZID"]; tzid != "" {
+		l, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("unknown time zone %q", tzid)
+		}
+		loc = l
+	}
+
+	t, err := time.ParseInLocation(layoutLocal, p.value, loc)
+
+	return t.In(time.Local), false, err
+}
+
+func parseTrigger(p property, start time.Time) (time.Time, error) {
+	if p.params["VALUE"] == "DATE-TIME" {
+		t, _, err := parseTime(p)
+		return t, err
+	}
+	if p.params["RELATED"] == "END" {
+		return time.Time{}, errors.New("triggers related to the event end are not supported")
+	}
+
+	d, err := parseDuration(p.value)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return start.Add(d), nil
+}
+
+// parseDuration разбирает длительность вида [+-]P[nW][nD][T[nH][nM][nS]].
+func parseDuration(s string) (time.Duration, error) {
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = -1, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	if !strings.HasPrefix(s, "P") || len(s) < 3 {
+		return 0, fmt.Errorf("bad duration %q", s)
+	}
+
+	units := map[byte]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour}
+	timeUnits := map[byte]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+
+	var d time.Duration
+	num := ""
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			num += string(c)
+		case c == 'T':
+			units = timeUnits
+		default:
+			unit, ok := units[c]
+			if !ok || num == "" {
+				return 0, fmt.Errorf("bad duration %q", s)
+			}
+			n, _ := strconv.Atoi(num)
+			d += time.Duration(n) * unit
+			num = ""
+		}
+	}
+	if num != "" {
+		return 0, fmt.Errorf("bad duration %q", s)
+	}
+
+	return sign * d, nil
+}
+
+func formatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+
+	if d%time.Minute != 0 {
+		return fmt.Sprintf("%sPT%dS", sign, d/time.Second)
+	}
+
+	return fmt.Sprintf("%sPT%dM", sign, d/time.Minute)
+}
+
+// unfold читает строки, склеивая перенесенные: продолжение начинается с пробела или табуляции.
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSuffix(sc.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, sc.Err()
+}
+
+func parseLine(line string) (property, error) {
+	head, value, ok := cutUnquoted(line)
+	if !ok {
+		return property{}, fmt.Errorf("no value in %q", line)
+	}
+
+	parts := strings.Split(head, ";")
+	p := property{name: strings.ToUpper(parts[0]), params: make(map[string]string), value: value}
+
+	for _, param := range parts[1:] {
+		k, v, _ := strings.Cut(param, "=")
+		p.params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+
+	return p, nil
+}
+
+// cutUnquoted делит строку по первому двоеточию вне кавычек значения параметра.
+func cutUnquoted(line string) (string, string, bool) {
+	quoted := false
+	for i, c := range line {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == ':' && !quoted:
+			return line[:i], line[i+1:], true
+		}
+	}
+
+	return "", "", false
+}
+
+var (
+	escaper   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	unescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+func unescape(s string) string {
+	return unescaper.Replace(s)
+}
+
+type writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+// line записывает строку, перенося ее по maxLine октетов без разрыва символов UTF-8.
+func (b *writer) line(s string) {
+	if b.err != nil {
+		return
+	}
+
+	limit := maxLine
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(s[cut]) {
+			cut--
+		}
+
+		_, b.err = b.w.WriteString(s[:cut] + "\r\n ")
+		if b.err != nil {
+			return
+		}
+		s = s[cut:]
+		// Пробел в начале продолжения занимает один октет.
+		limit = maxLine - 1
+	}
+
+	_, b.err = b.w.WriteString(s + "\r\n")
+}
+
+func isRuneStart(c byte) bool {
+	return c&0xC0 != 0x80
+}
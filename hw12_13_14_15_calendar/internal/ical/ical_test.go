@@ -0,0 +1,146 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	events := []storage.Event{
+		{
+			ID:          "eb0af540-6f23-4305-a719-fb65271fca1f",
+			Title:       "standup; team, all",
+			DateStart:   "2024-06-03 10:00:00",
+			DateEnd:     "2024-06-03 10:15:00",
+			Description: "daily\nsync " + strings.Repeat("длинное описание ", 10),
+			UserID:      "d5095366-ea13-4c9d-ae72-9c83d2d93040",
+			DatePost:    "2024-06-03 09:45:00",
+			Category:    "meetings",
+			Color:       "#ff8800",
+			Tags:        []string{"1:1", "oncall"},
+		},
+		{
+			ID:        "9ba3a7e5-3ba4-4a1b-8dd6-5f53b8d1a0d4",
+			Title:     "no reminder",
+			DateStart: "2024-06-04 12:00:00",
+			DateEnd:   "2024-06-04 13:00:00",
+			UserID:    "d5095366-ea13-4c9d-ae72-9c83d2d93040",
+		},
+		{
+			ID:        "5f0c7d0e-5a4b-4a3c-9a57-0b2cb2a6c8d1",
+			Title:     "conference",
+			DateStart: "2024-06-05 00:00:00",
+			DateEnd:   "2024-06-07 00:00:00",
+			UserID:    "d5095366-ea13-4c9d-ae72-9c83d2d93040",
+			AllDay:    true,
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Encode(&buf, events, time.Now()))
+
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		require.LessOrEqual(t, len(line), maxLine, "long lines are folded")
+	}
+	require.Contains(t, buf.String(), "TRIGGER:-PT15M\r\n")
+	require.Contains(t, buf.String(), "CATEGORIES:1:1,oncall\r\n")
+	require.Contains(t, buf.String(), "DTSTART;VALUE=DATE:20240605\r\nDTEND;VALUE=DATE:20240607\r\n")
+
+	got, err := Decode(&buf)
+	require.NoError(t, err)
+	require.Equal(t, events, got)
+}
+
+func TestDecode(t *testing.T) {
+	t.Run("foreign calendar", func(t *testing.T) {
+		data := strings.Join([]string{
+			"BEGIN:VCALENDAR",
+			"BEGIN:VTIMEZONE",
+			"TZID:Europe/Berlin",
+			"BEGIN:STANDARD",
+			"DTSTART:19701025T030000",
+			"END:STANDARD",
+			"END:VTIMEZONE",
+			"BEGIN:VEVENT",
+			"UID:abc@example.com",
+			"DTSTART;TZID=\"Europe/Berlin\":20240603T100000",
+			"DURATION:PT1H30M",
+			"SUMMARY:review",
+			"BEGIN:VALARM",
+			"TRIGGER;VALUE=DATE-TIME:20240603T073000Z",
+			"END:VALARM",
+			"END:VEVENT",
+			"BEGIN:VEVENT",
+			"DTSTART;VALUE=DATE:20240605",
+			"SUMMARY:holi",
+			" day",
+			"END:VEVENT",
+			"END:VCALENDAR",
+		}, "\r\n")
+
+		events, err := Decode(strings.NewReader(data))
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		require.NoError(t, err)
+		start := time.Date(2024, 6, 3, 10, 0, 0, 0, berlin).In(time.Local)
+
+		require.Equal(t, storage.Event{
+			ID:        "abc@example.com",
+			Title:     "review",
+			DateStart: start.Format(time.DateTime),
+			DateEnd:   start.Add(90 * time.Minute).Format(time.DateTime),
+			DatePost:  time.Date(2024, 6, 3, 7, 30, 0, 0, time.UTC).In(time.Local).Format(time.DateTime),
+		}, events[0])
+
+		require.Equal(t, "holiday", events[1].Title)
+		require.Equal(t, "2024-06-05 00:00:00", events[1].DateStart)
+		require.Equal(t, "2024-06-06 00:00:00", events[1].DateEnd)
+		require.True(t, events[1].AllDay)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		cases := map[string]string{
+			"no start":     "BEGIN:VEVENT\nSUMMARY:x\nEND:VEVENT\n",
+			"bad start":    "BEGIN:VEVENT\nDTSTART:tomorrow\nEND:VEVENT\n",
+			"bad trigger":  "BEGIN:VEVENT\nDTSTART:20240603T100000\nBEGIN:VALARM\nTRIGGER:-15M\nEND:VALARM\nEND:VEVENT\n",
+			"unterminated": "BEGIN:VEVENT\nDTSTART:20240603T100000\n",
+			"no value":     "BEGIN:VEVENT\nSUMMARY\nEND:VEVENT\n",
+		}
+
+		for name, data := range cases {
+			t.Run(name, func(t *testing.T) {
+				_, err := Decode(strings.NewReader(data))
+				require.ErrorIs(t, err, ErrBadCalendar)
+			})
+		}
+	})
+}
+
+func TestParseDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"-PT15M":   -15 * time.Minute,
+		"PT0S":     0,
+		"-P1D":     -24 * time.Hour,
+		"-P1DT2H":  -26 * time.Hour,
+		"+P1W":     7 * 24 * time.Hour,
+		"-PT1H30M": -90 * time.Minute,
+	}
+
+	for s, want := range cases {
+		d, err := parseDuration(s)
+		require.NoError(t, err, s)
+		require.Equal(t, want, d, s)
+	}
+
+	for _, s := range []string{"15M", "P", "PT", "PT15", "P1H"} {
+		_, err := parseDuration(s)
+		require.Error(t, err, s)
+	}
+}
@@ -0,0 +1,261 @@
+// Package jobs запускает периодические задачи планировщика по расписанию cron.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	ResultOK     = "ok"
+	ResultFailed = "failed"
+)
+
+var (
+	ErrUnknownJob   = errors.New("unknown job")
+	ErrDuplicateJob = errors.New("job is already registered")
+	ErrJobRunning   = errors.New("job is already running")
+	ErrNotActive    = errors.New("job runner is not active on this replica")
+)
+
+type Logger interface {
+	Info(msg string)
+	Error(msg string)
+}
+
+// Job описывает задачу. Schedule - выражение cron из пяти полей или дескриптор
+// вида @hourly, @every 5s. К каждому запуску по расписанию добавляется случайная
+// задержка до Jitter, Timeout ограничивает время одного запуска.
+type Job struct {
+	Name     string
+	Schedule string
+	Jitter   time.Duration
+	Timeout  time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Status - состояние задачи для административного API.
+type Status struct {
+	Name       string `json:"name"`
+	Schedule   string `json:"schedule"`
+	Running    bool   `json:"running"`
+	NextRun    string `json:"nextRun,omitempty"`
+	LastStart  string `json:"lastStart,omitempty"`
+	LastEnd    string `json:"lastEnd,omitempty"`
+	LastResult string `json:"lastResult,omitempty"`
+	LastError  string `json:"lastError,omitempty"`
+	Runs       int    `json:"runs"`
+	Failures   int    `json:"failures"`
+	Skipped    int    `json:"skipped"`
+}
+
+type job struct {
+	Job
+	schedule cron.Schedule
+	status   Status
+}
+
+// Runner запускает задачи по расписанию и не допускает параллельных запусков одной задачи.
+type Runner struct {
+	logger Logger
+	now    func() time.Time
+
+	mu     sync.Mutex
+	jobs   []*job
+	byName map[string]*job
+	ctx    context.Context
+	runs   sync.WaitGroup
+}
+
+func NewRunner(logger Logger) *Runner {
+	return &Runner{
+		logger: logger,
+		now:    time.Now,
+		byName: make(map[string]*job),
+	}
+}
+
+// ParseSchedule проверяет выражение расписания.
+func ParseSchedule(spec string) (cron.Schedule, error) {
+	return cron.ParseStandard(spec)
+}
+
+// Add регистрирует задачу. Вызывается до Run.
+func (r *Runner) Add(j Job) error {
+	schedule, err := ParseSchedule(j.Schedule)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", j.Name, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.byName[j.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateJob, j.Name)
+	}
+
+	added := &job{Job: j, schedule: schedule, status: Status{Name: j.Name, Schedule: j.Schedule}}
+	r.jobs = append(r.jobs, added)
+	r.byName[j.Name] = added
+
+	return nil
+}
+
+// Run запускает задачи по расписанию до отмены ctx и дожидается завершения начатых запусков.
+func (r *Runner) Run(ctx context.Context) {
+	r.mu.Lock()
+	r.ctx = ctx
+	jobs := r.jobs
+	r.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, j := range jobs {
+		wg.Add(1)
+		go func(j *job) {
+			defer wg.Done()
+			r.loop(ctx, j)
+		}(j)
+	}
+	wg.Wait()
+
+	r.mu.Lock()
+	r.ctx = nil
+	for _, j := range r.jobs {
+		j.status.NextRun = ""
+	}
+	r.mu.Unlock()
+
+	r.runs.Wait()
+}
+
+// Trigger запускает задачу вне расписания. Задача выполняется в фоне, пока активен Run.
+func (r *Runner) Trigger(name string) error {
+	r.mu.Lock()
+	j, ok := r.byName[name]
+	r.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownJob, name)
+	}
+
+	return r.start(j)
+}
+
+// Active сообщает, выполняет ли реплика задачи.
+func (r *Runner) Active() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.ctx != nil
+}
+
+// Jobs возвращает состояние задач в порядке регистрации.
+func (r *Runner) Jobs() []Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	statuses := make([]Status, 0, len(r.jobs))
+	for _, j := range r.jobs {
+		statuses = append(statuses, j.status)
+	}
+
+	return statuses
+}
+
+func (r *Runner) loop(ctx context.Context, j *job) {
+	for {
+		now := r.now()
+		next := j.schedule.Next(now)
+		if j.Jitter > 0 {
+			next = next.Add(time.Duration(rand.Int63n(int64(j.Jitter)))) //nolint:gosec
+		}
+
+		r.mu.Lock()
+		j.status.NextRun = next.Format(time.DateTime)
+		r.mu.Unlock()
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if errors.Is(r.start(j), ErrJobRunning) {
+			r.logger.Info("job " + j.Name + " skipped: previous run is still in progress")
+		}
+	}
+}
+
+// start запускает задачу в фоне, если Run активен и задача не выполняется.
+// Пропущенный запуск учитывается в статусе.
+func (r *Runner) start(j *job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ctx := r.ctx
+	if ctx == nil {
+		return ErrNotActive
+	}
+	if j.status.Running {
+		j.status.Skipped++
+		return fmt.Errorf("%w: %s", ErrJobRunning, j.Name)
+	}
+
+	j.status.Running = true
+	j.status.LastStart = r.now().Format(time.DateTime)
+	r.runs.Add(1)
+
+	go func() {
+		defer r.runs.Done()
+		r.execute(ctx, j)
+	}()
+
+	return nil
+}
+
+func (r *Runner) execute(ctx context.Context, j *job) {
+	err := r.call(ctx, j)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	j.status.Running = false
+	j.status.LastEnd = r.now().Format(time.DateTime)
+	j.status.Runs++
+
+	if err != nil {
+		j.status.Failures++
+		j.status.LastResult = ResultFailed
+		j.status.LastError = err.Error()
+		r.logger.Error("job " + j.Name + " failed: " + err.Error())
+		return
+	}
+
+	j.status.LastResult = ResultOK
+	j.status.LastError = ""
+}
+
+// call выполняет задачу с таймаутом. Паника задачи считается ошибкой запуска.
+func (r *Runner) call(ctx context.Context, j *job) (err error) {
+	if j.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.Timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+
+	return j.Run(ctx)
+}
@@ -0,0 +1,170 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newRunner(t *testing.T) *Runner {
+	t.Helper()
+
+	l := mocks.NewLogger(t)
+	l.On("Info", mock.AnythingOfType("string")).Maybe()
+	l.On("Error", mock.AnythingOfType("string")).Maybe()
+
+	return NewRunner(l)
+}
+
+// start запускает Run и возвращает функцию, которая останавливает его и ждет завершения.
+func start(r *Runner) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		r.Run(ctx)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func status(r *Runner, name string) Status {
+	for _, s := range r.Jobs() {
+		if s.Name == name {
+			return s
+		}
+	}
+
+	return Status{}
+}
+
+func TestAdd(t *testing.T) {
+	r := newRunner(t)
+	noop := func(context.Context) error { return nil }
+
+	require.NoError(t, r.Add(Job{Name: "a", Schedule: "*/5 * * * *", Run: noop}))
+	require.NoError(t, r.Add(Job{Name: "b", Schedule: "@every 10s", Run: noop}))
+	require.ErrorIs(t, r.Add(Job{Name: "a", Schedule: "@hourly", Run: noop}), ErrDuplicateJob)
+	require.Error(t, r.Add(Job{Name: "c", Schedule: "every minute", Run: noop}))
+
+	statuses := r.Jobs()
+	require.Len(t, statuses, 2)
+	require.Equal(t, "a", statuses[0].Name)
+	require.Equal(t, "@every 10s", statuses[1].Schedule)
+}
+
+func TestRun(t *testing.T) {
+	t.Run("scheduled runs and status", func(t *testing.T) {
+		r := newRunner(t)
+
+		var runs int32
+		require.NoError(t, r.Add(Job{Name: "ok", Schedule: "@every 1s", Run: func(context.Context) error {
+			atomic.AddInt32(&runs, 1)
+			return nil
+		}}))
+		require.NoError(t, r.Add(Job{Name: "failing", Schedule: "@every 1s", Run: func(context.Context) error {
+			return errors.New("boom")
+		}}))
+
+		stop := start(r)
+		require.Eventually(t, func() bool { return atomic.LoadInt32(&runs) >= 1 }, 3*time.Second, 10*time.Millisecond)
+		require.Eventually(t, func() bool { return status(r, "failing").Runs >= 1 }, 3*time.Second, 10*time.Millisecond)
+		require.True(t, r.Active())
+		require.NotEmpty(t, status(r, "ok").NextRun)
+		stop()
+
+		ok := status(r, "ok")
+		require.Equal(t, ResultOK, ok.LastResult)
+		require.NotEmpty(t, ok.LastStart)
+		require.Empty(t, ok.NextRun)
+
+		failing := status(r, "failing")
+		require.Equal(t, ResultFailed, failing.LastResult)
+		require.Equal(t, "boom", failing.LastError)
+		require.Equal(t, failing.Runs, failing.Failures)
+		require.False(t, r.Active())
+	})
+
+	t.Run("no overlap", func(t *testing.T) {
+		r := newRunner(t)
+
+		release := make(chan struct{})
+		var running, maxRunning int32
+		require.NoError(t, r.Add(Job{Name: "slow", Schedule: "@every 1h", Run: func(context.Context) error {
+			n := atomic.AddInt32(&running, 1)
+			if n > atomic.LoadInt32(&maxRunning) {
+				atomic.StoreInt32(&maxRunning, n)
+			}
+			<-release
+			atomic.AddInt32(&running, -1)
+			return nil
+		}}))
+
+		stop := start(r)
+		defer stop()
+
+		require.Eventually(t, r.Active, time.Second, 5*time.Millisecond)
+		require.NoError(t, r.Trigger("slow"))
+		require.ErrorIs(t, r.Trigger("slow"), ErrJobRunning)
+
+		s := status(r, "slow")
+		require.True(t, s.Running)
+		require.Equal(t, 1, s.Skipped)
+
+		close(release)
+		require.Eventually(t, func() bool { return !status(r, "slow").Running }, time.Second, 5*time.Millisecond)
+		require.Equal(t, int32(1), atomic.LoadInt32(&maxRunning))
+	})
+
+	t.Run("timeout and panic", func(t *testing.T) {
+		r := newRunner(t)
+
+		require.NoError(t, r.Add(Job{Name: "stuck", Schedule: "@every 1h", Timeout: 20 * time.Millisecond,
+			Run: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}}))
+		require.NoError(t, r.Add(Job{Name: "panic", Schedule: "@every 1h", Run: func(context.Context) error {
+			panic("unexpected")
+		}}))
+
+		stop := start(r)
+		defer stop()
+
+		require.Eventually(t, r.Active, time.Second, 5*time.Millisecond)
+		require.NoError(t, r.Trigger("stuck"))
+		require.NoError(t, r.Trigger("panic"))
+
+		require.Eventually(t, func() bool {
+			return status(r, "stuck").Runs == 1 && status(r, "panic").Runs == 1
+		}, time.Second, 5*time.Millisecond)
+		require.Equal(t, context.DeadlineExceeded.Error(), status(r, "stuck").LastError)
+		require.Equal(t, "panic: unexpected", status(r, "panic").LastError)
+	})
+}
+
+func TestTrigger(t *testing.T) {
+	r := newRunner(t)
+	require.NoError(t, r.Add(Job{Name: "a", Schedule: "@hourly", Run: func(context.Context) error { return nil }}))
+
+	require.ErrorIs(t, r.Trigger("a"), ErrNotActive)
+	require.ErrorIs(t, r.Trigger("missing"), ErrUnknownJob)
+
+	stop := start(r)
+	require.Eventually(t, r.Active, time.Second, 5*time.Millisecond)
+	require.NoError(t, r.Trigger("a"))
+	stop()
+
+	require.Equal(t, 1, status(r, "a").Runs)
+	require.ErrorIs(t, r.Trigger("a"), ErrNotActive)
+}
@@ -1,6 +1,10 @@
 package logger
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
 
 const (
 	LevelDebug = "debug"
@@ -10,31 +14,47 @@ const (
 )
 
 type Logger struct {
-	Level string
+	mu    sync.RWMutex
+	level string
 }
 
 func New(level string) *Logger {
-	return &Logger{Level: level}
+	return &Logger{level: strings.ToLower(level)}
+}
+
+func (l *Logger) SetLevel(level string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.level = strings.ToLower(level)
+}
+
+func (l *Logger) Level() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.level
 }
 
-func (l Logger) Info(msg string) {
+func (l *Logger) Info(msg string) {
 	fmt.Println(msg)
 }
 
-func (l Logger) Warn(msg string) {
-	if l.Level != LevelError {
+func (l *Logger) Warn(msg string) {
+	if l.Level() != LevelError {
 		fmt.Printf("WARN: %s\n", msg)
 	}
 }
 
-func (l Logger) Debug(msg string) {
-	if l.Level == LevelDebug {
+func (l *Logger) Debug(msg string) {
+	if l.Level() == LevelDebug {
 		fmt.Printf("DEBUG: %s\n", msg)
 	}
 }
 
-func (l Logger) Error(msg string) {
-	if l.Level != LevelInfo && l.Level != LevelWarn {
+func (l *Logger) Error(msg string) {
+	level := l.Level()
+	if level != LevelInfo && level != LevelWarn {
 		fmt.Printf("ERROR: %s\n", msg)
 	}
 }
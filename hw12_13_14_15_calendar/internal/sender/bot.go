@@ -0,0 +1,88 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// BotConfig - параметры API бота в стиле Telegram: URL/bot<token>/sendMessage.
+type BotConfig struct {
+	URL     string
+	Token   string
+	Timeout time.Duration
+}
+
+// BotChannel отправляет сообщение в чат, идентификатор которого задан адресом получателя.
+type BotChannel struct {
+	cfg    BotConfig
+	client *http.Client
+}
+
+type botRequest struct {
+	ChatID string `json:"chat_id"` //nolint:tagliatelle
+	Text   string `json:"text"`
+}
+
+type botResponse struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description"`
+}
+
+func NewBotChannel(cfg BotConfig) *BotChannel {
+	return &BotChannel{cfg: cfg, client: &http.Client{Timeout: cfg.Timeout}}
+}
+
+func (c *BotChannel) Send(ctx context.Context, to Recipient, m Message) error {
+	if to.Address == "" {
+		return ErrNoAddress
+	}
+
+	text := m.Body
+	if m.Subject != "" {
+		text = m.Subject + "\n\n" + m.Body
+	}
+
+	data, err := json.Marshal(botRequest{ChatID: to.Address, Text: text})
+	if err != nil {
+		return err
+	}
+
+	endpoint := strings.TrimSuffix(c.cfg.URL, "/") + "/bot" + c.cfg.Token + "/sendMessage"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		// Ошибка клиента содержит URL, а в нем токен бота.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("bot api: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var r botResponse
+
+	err = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&r)
+	if err != nil {
+		return fmt.Errorf("bot api: status %d: %w", resp.StatusCode, err)
+	}
+	if !r.OK {
+		return fmt.Errorf("bot api: status %d: %s", resp.StatusCode, r.Description)
+	}
+
+	return nil
+}
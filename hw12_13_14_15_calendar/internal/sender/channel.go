@@ -0,0 +1,148 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Виды уведомлений. Вид задает имя шаблона сообщения.
+const (
+	KindReminder = "reminder"
+	KindDigest   = "digest"
+)
+
+// Результаты доставки в статусе.
+const (
+	StatusSent    = "sent"
+	StatusFailed  = "failed"
+	StatusExpired = "expired"
+)
+
+var (
+	ErrUnknownChannel = errors.New("unknown notification channel")
+	ErrNoAddress      = errors.New("recipient address is empty")
+)
+
+type Logger interface {
+	Info(msg string)
+	Error(msg string)
+}
+
+// Recipient - получатель уведомления. Пустой Channel означает канал по умолчанию.
+type Recipient struct {
+	UserID  string
+	Channel string
+	Address string
+}
+
+// Channel доставляет сообщение получателю.
+type Channel interface {
+	Send(ctx context.Context, to Recipient, m Message) error
+}
+
+// Status - результат доставки уведомления, который сервис рассылки публикует в очередь статусов.
+type Status struct {
+	Kind    string `json:"kind"`
+	ID      string `json:"id"`
+	UserID  string `json:"userId"`
+	Channel string `json:"channel"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	Time    string `json:"time"`
+}
+
+// StatusFunc сообщает о результате доставки.
+type StatusFunc func(ctx context.Context, s Status) error
+
+// Dispatcher выбирает канал получателя, готовит сообщение по шаблону канала
+// и сообщает о результате доставки.
+type Dispatcher struct {
+	templates      *Templates
+	channels       map[string]Channel
+	defaultChannel string
+	report         StatusFunc
+	now            func() time.Time
+}
+
+func NewDispatcher(templates *Templates, channels map[string]Channel, defaultChannel string,
+	report StatusFunc,
+) *Dispatcher {
+	return &Dispatcher{
+		templates:      templates,
+		channels:       channels,
+		defaultChannel: defaultChannel,
+		report:         report,
+		now:            time.Now,
+	}
+}
+
+// Deliver доставляет уведомление вида kind с идентификатором id. Ошибка доставки
+// попадает и в статус, и в результат.
+func (d *Dispatcher) Deliver(ctx context.Context, kind string, id string, to Recipient, data interface{}) error {
+	to = d.recipient(to)
+
+	err := d.send(ctx, kind, to, data)
+
+	status := StatusSent
+	if err != nil {
+		status = StatusFailed
+	}
+
+	return errors.Join(err, d.reportStatus(ctx, kind, id, to, status, err))
+}
+
+// Expire сообщает, что уведомление не доставлено, потому что устарело.
+func (d *Dispatcher) Expire(ctx context.Context, kind string, id string, to Recipient, err error) error {
+	return d.reportStatus(ctx, kind, id, d.recipient(to), StatusExpired, err)
+}
+
+func (d *Dispatcher) recipient(to Recipient) Recipient {
+	if to.Channel == "" {
+		to.Channel = d.defaultChannel
+	}
+
+	return to
+}
+
+func (d *Dispatcher) send(ctx context.Context, kind string, to Recipient, data interface{}) error {
+	ch, ok := d.channels[to.Channel]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownChannel, to.Channel)
+	}
+
+	m, err := d.templates.Render(kind, to.Channel, data)
+	if err != nil {
+		return err
+	}
+
+	return ch.Send(ctx, to, m)
+}
+
+func (d *Dispatcher) reportStatus(ctx context.Context, kind string, id string, to Recipient, status string,
+	err error,
+) error {
+	if d.report == nil {
+		return nil
+	}
+
+	s := Status{
+		Kind:    kind,
+		ID:      id,
+		UserID:  to.UserID,
+		Channel: to.Channel,
+		Status:  status,
+		Time:    d.now().Format(time.DateTime),
+	}
+	if err != nil {
+		s.Error = err.Error()
+	}
+
+	reportErr := d.report(ctx, s)
+	if reportErr != nil {
+		return fmt.Errorf("report status: %w", reportErr)
+	}
+
+	return nil
+}
@@ -0,0 +1,195 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/stretchr/testify/require"
+)
+
+type sentMessage struct {
+	to Recipient
+	m  Message
+}
+
+// recordChannel запоминает сообщения, err имитирует сбой доставки.
+type recordChannel struct {
+	sent []sentMessage
+	err  error
+}
+
+func (c *recordChannel) Send(_ context.Context, to Recipient, m Message) error {
+	if c.err != nil {
+		return c.err
+	}
+
+	c.sent = append(c.sent, sentMessage{to: to, m: m})
+
+	return nil
+}
+
+func newTestDispatcher(t *testing.T, ch Channel, statuses *[]Status) *Dispatcher {
+	t.Helper()
+
+	templates, err := LoadTemplates("")
+	require.NoError(t, err)
+
+	return NewDispatcher(templates, map[string]Channel{"log": ch, "bot": ch}, "log",
+		func(_ context.Context, s Status) error {
+			*statuses = append(*statuses, s)
+			return nil
+		})
+}
+
+var testNotification = app.Notification{
+	Version:    app.NotificationVersion,
+	Key:        "reminder:42:2024-06-03 09:45:00",
+	EventID:    "42",
+	Title:      "standup",
+	DateStart:  "2024-06-03 10:00:00",
+	DateEnd:    "2024-06-03 10:15:00",
+	TimeZone:   "+03:00",
+	RemindAt:   "2024-06-03 09:45:00",
+	Recipients: []app.NotificationRecipient{{UserID: "user", Role: app.RoleOwner}},
+}
+
+func TestDispatcher(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("default channel", func(t *testing.T) {
+		ch := &recordChannel{}
+		var statuses []Status
+
+		d := newTestDispatcher(t, ch, &statuses)
+
+		err := d.Deliver(ctx, KindReminder, "42", Recipient{UserID: "user"}, ReminderData{Notification: testNotification})
+		require.NoError(t, err)
+
+		require.Len(t, ch.sent, 1)
+		require.Equal(t, "Reminder: standup at 10:00", ch.sent[0].m.Subject)
+		require.Equal(t, "standup starts on Mon, 03 Jun at 10:00 and ends at 10:15 (UTC+03:00).", ch.sent[0].m.Body)
+
+		require.Len(t, statuses, 1)
+		require.Equal(t, Status{
+			Kind: KindReminder, ID: "42", UserID: "user", Channel: "log", Status: StatusSent, Time: statuses[0].Time,
+		}, statuses[0])
+	})
+
+	t.Run("channel template", func(t *testing.T) {
+		ch := &recordChannel{}
+		var statuses []Status
+
+		d := newTestDispatcher(t, ch, &statuses)
+
+		err := d.Deliver(ctx, KindReminder, "42", Recipient{UserID: "user", Channel: "bot"}, ReminderData{Notification: testNotification})
+		require.NoError(t, err)
+
+		require.Empty(t, ch.sent[0].m.Subject)
+		require.Equal(t, "10:00 standup", ch.sent[0].m.Body)
+	})
+
+	t.Run("failure is reported", func(t *testing.T) {
+		ch := &recordChannel{err: errors.New("connection refused")}
+		var statuses []Status
+
+		d := newTestDispatcher(t, ch, &statuses)
+
+		err := d.Deliver(ctx, KindReminder, "42", Recipient{UserID: "user"}, ReminderData{Notification: testNotification})
+		require.ErrorContains(t, err, "connection refused")
+
+		require.Equal(t, StatusFailed, statuses[0].Status)
+		require.Equal(t, "connection refused", statuses[0].Error)
+	})
+
+	t.Run("unknown channel", func(t *testing.T) {
+		var statuses []Status
+
+		d := newTestDispatcher(t, &recordChannel{}, &statuses)
+
+		err := d.Deliver(ctx, KindReminder, "42", Recipient{UserID: "user", Channel: "pigeon"}, ReminderData{Notification: testNotification})
+		require.ErrorIs(t, err, ErrUnknownChannel)
+		require.Equal(t, StatusFailed, statuses[0].Status)
+		require.Equal(t, "pigeon", statuses[0].Channel)
+	})
+}
+
+func TestFileChannel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.jsonl")
+	ch := NewFileChannel(path)
+
+	require.NoError(t, ch.Send(context.Background(), Recipient{UserID: "a"}, Message{Subject: "one", Body: "1"}))
+	require.NoError(t, ch.Send(context.Background(), Recipient{UserID: "b"}, Message{Subject: "two", Body: "2"}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+
+	var r FileRecord
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &r))
+	require.Equal(t, "b", r.UserID)
+	require.Equal(t, "two", r.Subject)
+}
+
+func TestWebhookChannel(t *testing.T) {
+	var got WebhookMessage
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+	}))
+	defer srv.Close()
+
+	ch := NewWebhookChannel(time.Second)
+	m := Message{Subject: "subject", Body: "body"}
+
+	require.NoError(t, ch.Send(context.Background(), Recipient{UserID: "user", Address: srv.URL + "/hook"}, m))
+	require.Equal(t, WebhookMessage{UserID: "user", Subject: "subject", Body: "body"}, got)
+
+	err := ch.Send(context.Background(), Recipient{UserID: "user", Address: srv.URL + "/broken"}, m)
+	require.ErrorContains(t, err, "502")
+
+	err = ch.Send(context.Background(), Recipient{UserID: "user"}, m)
+	require.ErrorIs(t, err, ErrNoAddress)
+}
+
+func TestBotChannel(t *testing.T) {
+	var got botRequest
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/botsecret/sendMessage" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = io.WriteString(w, `{"ok": false, "description": "Unauthorized"}`)
+			return
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = io.WriteString(w, `{"ok": true}`)
+	}))
+	defer srv.Close()
+
+	to := Recipient{UserID: "user", Address: "100500"}
+	m := Message{Subject: "subject", Body: "body"}
+
+	ch := NewBotChannel(BotConfig{URL: srv.URL, Token: "secret", Timeout: time.Second})
+	require.NoError(t, ch.Send(context.Background(), to, m))
+	require.Equal(t, botRequest{ChatID: "100500", Text: "subject\n\nbody"}, got)
+
+	ch = NewBotChannel(BotConfig{URL: srv.URL, Token: "wrong", Timeout: time.Second})
+	err := ch.Send(context.Background(), to, m)
+	require.ErrorContains(t, err, "Unauthorized")
+	require.NotContains(t, err.Error(), "wrong")
+}
@@ -0,0 +1,59 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+)
+
+var ErrDigestExpired = errors.New("digest delivery time has passed")
+
+// DigestSender доставляет сводки не раньше времени, выбранного пользователем,
+// и отбрасывает сводки, опоздавшие больше чем на MaxDelay: утренняя сводка
+// вечером уже не нужна.
+type DigestSender struct {
+	dispatcher *Dispatcher
+	maxDelay   time.Duration
+	now        func() time.Time
+}
+
+func NewDigestSender(dispatcher *Dispatcher, maxDelay time.Duration) *DigestSender {
+	return &DigestSender{
+		dispatcher: dispatcher,
+		maxDelay:   maxDelay,
+		now:        time.Now,
+	}
+}
+
+// Send ждет времени доставки сводки и доставляет ее в канал пользователя.
+func (s *DigestSender) Send(ctx context.Context, d app.Digest) error {
+	deliverAt, err := time.ParseInLocation(time.DateTime, d.DeliverAt, time.Local)
+	if err != nil {
+		return fmt.Errorf("bad digest delivery time: %w", err)
+	}
+
+	id := d.Period + ":" + d.Date
+	to := Recipient{UserID: d.UserID, Channel: d.Channel, Address: d.Address}
+
+	wait := deliverAt.Sub(s.now())
+	if -wait > s.maxDelay {
+		err = fmt.Errorf("%w: %s", ErrDigestExpired, d.DeliverAt)
+		return errors.Join(err, s.dispatcher.Expire(ctx, KindDigest, id, to, err))
+	}
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	return s.dispatcher.Deliver(ctx, KindDigest, id, to, d)
+}
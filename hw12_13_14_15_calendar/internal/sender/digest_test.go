@@ -0,0 +1,104 @@
+package sender
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/stretchr/testify/require"
+)
+
+var testDigest = app.Digest{
+	Period:    app.DigestDay,
+	Date:      "2024-06-03",
+	UserID:    "user",
+	DeliverAt: "2024-06-03 08:00:00",
+	Events: []app.DigestEvent{
+		{ID: "1", Title: "standup", DateStart: "2024-06-03 10:00:00", DateEnd: "2024-06-03 10:15:00"},
+		{ID: "2", Title: "review", DateStart: "2024-06-03 15:00:00", DateEnd: "2024-06-03 16:00:00", Description: "q2"},
+	},
+}
+
+func TestRenderDigest(t *testing.T) {
+	t.Run("built-in", func(t *testing.T) {
+		templates, err := LoadTemplates("")
+		require.NoError(t, err)
+
+		m, err := templates.Render(KindDigest, "email", testDigest)
+		require.NoError(t, err)
+
+		require.Equal(t, "Your agenda for Mon, 03 Jun: 2 event(s)", m.Subject)
+		require.Contains(t, m.Body, "10:00-10:15  standup")
+		require.Contains(t, m.Body, "15:00-16:00  review\n    q2")
+	})
+
+	t.Run("channel template", func(t *testing.T) {
+		templates, err := LoadTemplates("")
+		require.NoError(t, err)
+
+		m, err := templates.Render(KindDigest, "bot", testDigest)
+		require.NoError(t, err)
+
+		require.Empty(t, m.Subject)
+		require.Equal(t, "Mon, 03 Jun:\n10:00 standup\n15:00 review", m.Body)
+	})
+
+	t.Run("override from dir", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "subject.tmpl"),
+			[]byte(`{{define "digest.subject"}}Agenda {{.Date}}{{end}}`), 0o600))
+
+		templates, err := LoadTemplates(dir)
+		require.NoError(t, err)
+
+		m, err := templates.Render(KindDigest, "email", testDigest)
+		require.NoError(t, err)
+
+		require.Equal(t, "Agenda 2024-06-03", m.Subject)
+		require.Contains(t, m.Body, "standup")
+	})
+}
+
+func TestDigestSender(t *testing.T) {
+	deliverAt, err := time.ParseInLocation(time.DateTime, testDigest.DeliverAt, time.Local)
+	require.NoError(t, err)
+
+	ch := &recordChannel{}
+	var statuses []Status
+
+	s := NewDigestSender(newTestDispatcher(t, ch, &statuses), time.Hour)
+
+	t.Run("late within max delay", func(t *testing.T) {
+		s.now = func() time.Time { return deliverAt.Add(30 * time.Minute) }
+
+		require.NoError(t, s.Send(context.Background(), testDigest))
+		require.Len(t, ch.sent, 1)
+		require.Equal(t, "user", ch.sent[0].to.UserID)
+		require.Equal(t, StatusSent, statuses[0].Status)
+		require.Equal(t, "day:2024-06-03", statuses[0].ID)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		s.now = func() time.Time { return deliverAt.Add(2 * time.Hour) }
+
+		err := s.Send(context.Background(), testDigest)
+		require.ErrorIs(t, err, ErrDigestExpired)
+		require.Len(t, ch.sent, 1)
+		require.Equal(t, StatusExpired, statuses[1].Status)
+	})
+
+	t.Run("waits for delivery time", func(t *testing.T) {
+		s.now = func() time.Time { return deliverAt.Add(-time.Hour) }
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		err := s.Send(ctx, testDigest)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Len(t, ch.sent, 1)
+		require.Len(t, statuses, 2)
+	})
+}
@@ -0,0 +1,57 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileRecord - строка файла FileChannel в формате JSON Lines.
+type FileRecord struct {
+	Time    string `json:"time"`
+	UserID  string `json:"userId"`
+	Address string `json:"address,omitempty"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// FileChannel дописывает сообщения в файл, по одному JSON на строку.
+type FileChannel struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileChannel(path string) *FileChannel {
+	return &FileChannel{path: path}
+}
+
+func (c *FileChannel) Send(_ context.Context, to Recipient, m Message) error {
+	data, err := json.Marshal(FileRecord{
+		Time:    time.Now().Format(time.DateTime),
+		UserID:  to.UserID,
+		Address: to.Address,
+		Subject: m.Subject,
+		Body:    m.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f, err := os.OpenFile(c.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(append(data, '\n'))
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,20 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+)
+
+// LogChannel пишет сообщения в журнал сервиса. Подходит для отладки.
+type LogChannel struct {
+	logger Logger
+}
+
+func NewLogChannel(logger Logger) *LogChannel {
+	return &LogChannel{logger: logger}
+}
+
+func (c *LogChannel) Send(_ context.Context, to Recipient, m Message) error {
+	c.logger.Info(fmt.Sprintf("notification for %s: %s\n%s", to.UserID, m.Subject, m.Body))
+	return nil
+}
@@ -0,0 +1,137 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/actionlink"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+)
+
+// ActionLinks задает подписанные ссылки отложить и отклонить в напоминаниях.
+// Ссылки ведут на календарь по адресу BaseURL и действуют TTL с момента отправки.
+type ActionLinks struct {
+	BaseURL string
+	Signer  *actionlink.Signer
+	Snooze  time.Duration
+	TTL     time.Duration
+}
+
+// ReminderData - данные шаблонов reminder.* для одного получателя. Ссылки
+// пустые, если они не включены через SetActionLinks.
+type ReminderData struct {
+	app.Notification
+	SnoozeMinutes int
+	SnoozeURL     string
+	DismissURL    string
+}
+
+// ReminderSender доставляет напоминания всем получателям. Напоминание, уже
+// доставленное получателю в течение dedupTTL, повторно не отправляется.
+type ReminderSender struct {
+	dispatcher *Dispatcher
+	dedupTTL   time.Duration
+	links      ActionLinks
+	now        func() time.Time
+
+	mu        sync.Mutex
+	delivered map[string]time.Time
+	pruned    time.Time
+}
+
+func NewReminderSender(dispatcher *Dispatcher, dedupTTL time.Duration) *ReminderSender {
+	return &ReminderSender{
+		dispatcher: dispatcher,
+		dedupTTL:   dedupTTL,
+		now:        time.Now,
+		delivered:  make(map[string]time.Time),
+	}
+}
+
+// SetActionLinks включает ссылки отложить и отклонить. Вызывается до Send.
+func (s *ReminderSender) SetActionLinks(l ActionLinks) {
+	s.links = l
+}
+
+// Send проверяет напоминание и доставляет его получателям, которые его еще не получили.
+func (s *ReminderSender) Send(ctx context.Context, n app.Notification) error {
+	err := n.Validate()
+	if err != nil {
+		return fmt.Errorf("invalid notification: %w", err)
+	}
+
+	var errs []error
+
+	for _, r := range n.Recipients {
+		key := n.Key + ":" + r.UserID
+		if s.isDelivered(key) {
+			continue
+		}
+
+		to := Recipient{UserID: r.UserID, Channel: r.Channel, Address: r.Address}
+
+		err := s.dispatcher.Deliver(ctx, KindReminder, n.EventID, to, s.data(n, r.UserID))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("recipient %s: %w", r.UserID, err))
+			continue
+		}
+
+		s.markDelivered(key)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *ReminderSender) data(n app.Notification, userID string) ReminderData {
+	d := ReminderData{Notification: n}
+	if s.links.BaseURL == "" {
+		return d
+	}
+
+	link := actionlink.Link{
+		EventID: n.EventID,
+		UserID:  userID,
+		Expires: s.now().Add(s.links.TTL),
+	}
+
+	link.Action = actionlink.ActionDismiss
+	d.DismissURL = s.links.Signer.URL(s.links.BaseURL, link)
+
+	link.Action = actionlink.ActionSnooze
+	link.Minutes = int(s.links.Snooze / time.Minute)
+	d.SnoozeMinutes = link.Minutes
+	d.SnoozeURL = s.links.Signer.URL(s.links.BaseURL, link)
+
+	return d
+}
+
+func (s *ReminderSender) isDelivered(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	at, ok := s.delivered[key]
+
+	return ok && s.now().Sub(at) < s.dedupTTL
+}
+
+func (s *ReminderSender) markDelivered(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.delivered[key] = now
+
+	// Устаревшие ключи удаляются не чаще раза за dedupTTL.
+	if now.Sub(s.pruned) < s.dedupTTL {
+		return
+	}
+	for k, at := range s.delivered {
+		if now.Sub(at) >= s.dedupTTL {
+			delete(s.delivered, k)
+		}
+	}
+	s.pruned = now
+}
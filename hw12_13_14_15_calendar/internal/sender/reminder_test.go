@@ -0,0 +1,109 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/actionlink"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReminderSender(t *testing.T) {
+	ctx := context.Background()
+
+	n := testNotification
+	n.Recipients = []app.NotificationRecipient{
+		{UserID: "owner", Role: app.RoleOwner},
+		{UserID: "guest", Role: app.RoleAttendee, Channel: "bot", Address: "100500"},
+	}
+
+	t.Run("all recipients once", func(t *testing.T) {
+		ch := &recordChannel{}
+		var statuses []Status
+
+		s := NewReminderSender(newTestDispatcher(t, ch, &statuses), time.Hour)
+
+		require.NoError(t, s.Send(ctx, n))
+		require.NoError(t, s.Send(ctx, n), "duplicate is dropped")
+
+		require.Len(t, ch.sent, 2)
+		require.Equal(t, "owner", ch.sent[0].to.UserID)
+		require.Equal(t, Recipient{UserID: "guest", Channel: "bot", Address: "100500"}, ch.sent[1].to)
+
+		s.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+		require.NoError(t, s.Send(ctx, n))
+		require.Len(t, ch.sent, 4, "resent after dedup ttl")
+	})
+
+	t.Run("failed delivery is retried", func(t *testing.T) {
+		ch := &recordChannel{err: errors.New("timeout")}
+		var statuses []Status
+
+		s := NewReminderSender(newTestDispatcher(t, ch, &statuses), time.Hour)
+
+		require.ErrorContains(t, s.Send(ctx, n), "recipient owner: timeout")
+
+		ch.err = nil
+		require.NoError(t, s.Send(ctx, n))
+		require.Len(t, ch.sent, 2)
+	})
+
+	t.Run("action links", func(t *testing.T) {
+		ch := &recordChannel{}
+		var statuses []Status
+
+		signer := actionlink.NewSigner("0123456789abcdef")
+
+		s := NewReminderSender(newTestDispatcher(t, ch, &statuses), time.Hour)
+		s.SetActionLinks(ActionLinks{
+			BaseURL: "http://calendar.local",
+			Signer:  signer,
+			Snooze:  15 * time.Minute,
+			TTL:     time.Hour,
+		})
+
+		require.NoError(t, s.Send(ctx, n))
+		require.Len(t, ch.sent, 2)
+
+		body := ch.sent[0].m.Body
+		require.Contains(t, body, "Snooze for 15 minutes: http://calendar.local"+actionlink.Path+"?")
+
+		i := strings.Index(body, "Dismiss: ")
+		require.NotEqual(t, -1, i)
+
+		u, err := url.Parse(strings.TrimSpace(body[i+len("Dismiss: "):]))
+		require.NoError(t, err)
+
+		l, err := signer.Parse(u.Query(), time.Now())
+		require.NoError(t, err)
+		require.Equal(t, actionlink.Link{
+			Action:  actionlink.ActionDismiss,
+			EventID: n.EventID,
+			UserID:  "owner",
+			Expires: l.Expires,
+		}, l, "link is issued for the recipient")
+	})
+
+	t.Run("invalid notification", func(t *testing.T) {
+		ch := &recordChannel{}
+		var statuses []Status
+
+		s := NewReminderSender(newTestDispatcher(t, ch, &statuses), time.Hour)
+
+		old := n
+		old.Version = 1
+		require.ErrorIs(t, s.Send(ctx, old), app.ErrNotificationVersion)
+
+		noRecipients := n
+		noRecipients.Recipients = nil
+		require.Error(t, s.Send(ctx, noRecipients))
+
+		require.Empty(t, ch.sent)
+		require.Empty(t, statuses)
+	})
+}
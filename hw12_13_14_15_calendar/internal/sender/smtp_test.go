@@ -0,0 +1,135 @@
+package sender
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTP - минимальный SMTP сервер без STARTTLS и авторизации.
+// Принятые письма передаются в канал mail.
+type fakeSMTP struct {
+	listener net.Listener
+	mail     chan fakeMail
+}
+
+type fakeMail struct {
+	from string
+	to   []string
+	data string
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &fakeSMTP{listener: l, mail: make(chan fakeMail, 1)}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *fakeSMTP) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+
+	c := textproto.NewConn(conn)
+	_ = c.PrintfLine("220 fake ESMTP")
+
+	var m fakeMail
+
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch cmd {
+		case "EHLO", "HELO":
+			_ = c.PrintfLine("250 fake")
+		case "MAIL":
+			m.from = line
+			_ = c.PrintfLine("250 OK")
+		case "RCPT":
+			m.to = append(m.to, line)
+			_ = c.PrintfLine("250 OK")
+		case "DATA":
+			_ = c.PrintfLine("354 go ahead")
+			lines, err := c.ReadDotLines()
+			if err != nil {
+				return
+			}
+			m.data = strings.Join(lines, "\n")
+			s.mail <- m
+			_ = c.PrintfLine("250 queued")
+		case "QUIT":
+			_ = c.PrintfLine("221 bye")
+			return
+		default:
+			_ = c.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func TestSMTPChannel(t *testing.T) {
+	srv := newFakeSMTP(t)
+
+	ch := NewSMTPChannel(SMTPConfig{
+		Host:    "127.0.0.1",
+		Port:    srv.port(),
+		From:    "calendar@example.com",
+		Timeout: time.Second,
+	})
+
+	to := Recipient{UserID: "user", Address: "user@example.com"}
+
+	err := ch.Send(context.Background(), to, Message{Subject: "Встреча", Body: "standup at 10:00\n"})
+	require.NoError(t, err)
+
+	m := <-srv.mail
+	require.Equal(t, "MAIL FROM:<calendar@example.com>", m.from)
+	require.Equal(t, []string{"RCPT TO:<user@example.com>"}, m.to)
+	require.Contains(t, m.data, "To: user@example.com")
+	require.Contains(t, m.data, "Subject: =?utf-8?q?")
+	require.Contains(t, m.data, "\n\nstandup at 10:00")
+
+	t.Run("no address", func(t *testing.T) {
+		err := ch.Send(context.Background(), Recipient{UserID: "user"}, Message{})
+		require.ErrorIs(t, err, ErrNoAddress)
+	})
+
+	t.Run("server is down", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		port := l.Addr().(*net.TCPAddr).Port
+		require.NoError(t, l.Close())
+
+		down := NewSMTPChannel(SMTPConfig{Host: "127.0.0.1", Port: port, From: "a@b.c", Timeout: time.Second})
+
+		err = down.Send(context.Background(), to, Message{})
+		require.ErrorContains(t, err, strconv.Itoa(port))
+	})
+}
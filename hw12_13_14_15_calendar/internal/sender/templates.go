@@ -0,0 +1,100 @@
+// Package sender готовит и доставляет сообщения сервиса рассылки.
+package sender
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+// Message - готовое к доставке сообщение.
+type Message struct {
+	Subject string
+	Body    string
+}
+
+// Templates - шаблоны сообщений. Шаблон вида reminder.subject можно уточнить
+// для канала: reminder.bot.subject. Шаблоны из каталога переопределяют встроенные
+// с теми же именами.
+type Templates struct {
+	t *template.Template
+}
+
+var templateFuncs = template.FuncMap{
+	"clock": func(datetime string) string { return formatTime(datetime, "15:04") },
+	"day":   func(datetime string) string { return formatTime(datetime, "Mon, 02 Jan") },
+}
+
+// LoadTemplates загружает встроенные шаблоны и файлы *.tmpl из dir, если он задан.
+func LoadTemplates(dir string) (*Templates, error) {
+	t, err := template.New("").Funcs(templateFuncs).ParseFS(defaultTemplates, "templates/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	if dir != "" {
+		files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+		if err != nil {
+			return nil, err
+		}
+		if len(files) > 0 {
+			t, err = t.ParseFiles(files...)
+			if err != nil {
+				return nil, fmt.Errorf("parse templates: %w", err)
+			}
+		}
+	}
+
+	return &Templates{t: t}, nil
+}
+
+// Render готовит сообщение вида kind для канала channel.
+func (t *Templates) Render(kind string, channel string, data interface{}) (Message, error) {
+	subject, err := t.execute(kind, channel, "subject", data)
+	if err != nil {
+		return Message{}, err
+	}
+
+	body, err := t.execute(kind, channel, "body", data)
+	if err != nil {
+		return Message{}, err
+	}
+
+	return Message{Subject: strings.TrimSpace(subject), Body: body}, nil
+}
+
+// execute выполняет шаблон канала kind.channel.part, а если его нет - общий kind.part.
+func (t *Templates) execute(kind string, channel string, part string, data interface{}) (string, error) {
+	name := kind + "." + channel + "." + part
+	if t.t.Lookup(name) == nil {
+		name = kind + "." + part
+	}
+
+	var b bytes.Buffer
+
+	err := t.t.ExecuteTemplate(&b, name, data)
+	if err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// formatTime переводит дату вида 2006-01-02 или 2006-01-02 15:04:05 в layout.
+func formatTime(value string, layout string) string {
+	for _, src := range []string{time.DateTime, time.DateOnly} {
+		t, err := time.Parse(src, value)
+		if err == nil {
+			return t.Format(layout)
+		}
+	}
+
+	return value
+}
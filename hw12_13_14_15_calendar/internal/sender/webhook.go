@@ -0,0 +1,60 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookMessage - тело запроса, которое WebhookChannel отправляет на адрес получателя.
+type WebhookMessage struct {
+	UserID  string `json:"userId"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// WebhookChannel отправляет сообщение POST запросом на URL из настроек пользователя.
+type WebhookChannel struct {
+	client *http.Client
+}
+
+func NewWebhookChannel(timeout time.Duration) *WebhookChannel {
+	return &WebhookChannel{client: &http.Client{Timeout: timeout}}
+}
+
+func (c *WebhookChannel) Send(ctx context.Context, to Recipient, m Message) error {
+	if to.Address == "" {
+		return ErrNoAddress
+	}
+
+	data, err := json.Marshal(WebhookMessage{UserID: to.UserID, Subject: m.Subject, Body: m.Body})
+	if err != nil {
+		return err
+	}
+
+	return postJSON(ctx, c.client, to.Address, data)
+}
+
+// postJSON отправляет data и считает ошибкой любой ответ кроме 2xx.
+func postJSON(ctx context.Context, client *http.Client, url string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package grpc
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server/grpc/openapi"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server/grpc/pb"
+)
+
+const (
+	GatewayPrefix = "/v1/"
+	OpenAPIPath   = "/v1/openapi.json"
+)
+
+// Gateway возвращает REST обработчик, сгенерированный grpc-gateway по аннотациям
+// api/EventService.proto. Запросы обрабатываются напрямую методами Server.
+func (s *Server) Gateway(ctx context.Context) (http.Handler, error) {
+	mux := runtime.NewServeMux()
+
+	err := pb.RegisterEventServiceHandlerServer(ctx, mux, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return mux, nil
+}
+
+func OpenAPIHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(openapi.Spec)
+	})
+}
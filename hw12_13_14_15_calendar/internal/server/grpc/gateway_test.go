@@ -0,0 +1,96 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+type gatewayResult struct {
+	Events []struct {
+		ID        string `json:"id"`
+		Title     string `json:"title"`
+		DateStart string `json:"dateStart"`
+	} `json:"events"`
+}
+
+func TestGateway(t *testing.T) {
+	s := memorystorage.New()
+	server := NewServer(mocks.NewLogger(t), s, nil, 8080)
+
+	gw, err := server.Gateway(context.Background())
+	require.NoError(t, err)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("create and list", func(t *testing.T) {
+		w := do(http.MethodPost, "/v1/events", `{
+			"title": "Gateway Event",
+			"dateStart": "2022-10-11 12:00:00",
+			"dateEnd": "2022-10-11 13:00:00",
+			"datePost": "2022-10-10 12:00:00",
+			"userId": "d5095366-ea13-4c9d-ae72-9c83d2d93040"
+		}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = do(http.MethodGet, "/v1/events/day/2022-10-11", "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var result gatewayResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		require.Len(t, result.Events, 1)
+		require.Equal(t, "Gateway Event", result.Events[0].Title)
+
+		id := result.Events[0].ID
+
+		w = do(http.MethodGet, "/v1/events/"+id, "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = do(http.MethodPut, "/v1/events/"+id,
+			`{"title": "Renamed", "dateStart": "2022-10-11 15:00:00", "dateEnd": "2022-10-11 16:00:00"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		e, err := s.GetEvent(context.Background(), id)
+		require.NoError(t, err)
+		require.Equal(t, "Renamed", e.Title)
+
+		w = do(http.MethodDelete, "/v1/events/"+id, "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		_, err = s.GetEvent(context.Background(), id)
+		require.ErrorIs(t, err, storage.ErrEventNotExist)
+	})
+
+	t.Run("status codes", func(t *testing.T) {
+		w := do(http.MethodGet, "/v1/events/"+testEventID, "")
+		require.Equal(t, http.StatusNotFound, w.Code)
+
+		w = do(http.MethodGet, "/v1/events/not-uuid", "")
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = do(http.MethodPost, "/v1/events", `{"title": "No dates"}`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("openapi", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		OpenAPIHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, OpenAPIPath, nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), `"/v1/events/{id}"`)
+	})
+}
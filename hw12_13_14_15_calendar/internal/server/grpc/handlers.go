@@ -10,7 +10,7 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-func (s *Server) CreateEvent(_ context.Context, event *pb.Event) (*pb.Result, error) {
+func (s *Server) CreateEvent(ctx context.Context, event *pb.Event) (*pb.Result, error) {
 	e := storage.Event{
 		Title:       event.GetTitle(),
 		DateStart:   event.GetDateStart(),
@@ -25,20 +25,38 @@ func (s *Server) CreateEvent(_ context.Context, event *pb.Event) (*pb.Result, er
 		return nil, status.Errorf(codes.InvalidArgument, "%s", err)
 	}
 
-	err = s.storage.CreateEvent(e)
+	_, err = s.storage.CreateEvent(ctx, e)
 	if err != nil {
-		return &pb.Result{}, err
+		return &pb.Result{}, statusError(err)
 	}
 	return &pb.Result{}, nil
 }
 
-func (s *Server) UpdateEvent(_ context.Context, ur *pb.UpdateRequest) (*pb.Result, error) {
+func (s *Server) GetEvent(ctx context.Context, eventID *pb.EventId) (*pb.Result, error) {
+	e := storage.Event{
+		ID: eventID.GetId(),
+	}
+
+	err := server.ValidateDeleteEvent(e)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s", err)
+	}
+
+	e, err = s.storage.GetEvent(ctx, e.ID)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &pb.Result{Events: []*pb.Event{toPb(e)}}, nil
+}
+
+func (s *Server) UpdateEvent(ctx context.Context, ur *pb.UpdateRequest) (*pb.Result, error) {
 	id := ur.GetId().GetId()
 	update := ur.GetEvent()
 
-	e, err := s.storage.GetEvent(id)
+	e, err := s.storage.GetEvent(ctx, id)
 	if err != nil {
-		return nil, err
+		return nil, statusError(err)
 	}
 
 	updateEventFields(&e, update)
@@ -48,14 +66,14 @@ func (s *Server) UpdateEvent(_ context.Context, ur *pb.UpdateRequest) (*pb.Resul
 		return nil, status.Errorf(codes.InvalidArgument, "%s", err)
 	}
 
-	err = s.storage.UpdateEvent(id, e)
+	err = s.storage.UpdateEvent(ctx, id, e)
 	if err != nil {
-		return &pb.Result{}, err
+		return &pb.Result{}, statusError(err)
 	}
 	return &pb.Result{}, nil
 }
 
-func (s *Server) DeleteEvent(_ context.Context, eventID *pb.EventId) (*pb.Result, error) {
+func (s *Server) DeleteEvent(ctx context.Context, eventID *pb.EventId) (*pb.Result, error) {
 	e := storage.Event{
 		ID: eventID.GetId(),
 	}
@@ -65,26 +83,26 @@ func (s *Server) DeleteEvent(_ context.Context, eventID *pb.EventId) (*pb.Result
 		return nil, status.Errorf(codes.InvalidArgument, "%s", err)
 	}
 
-	err = s.storage.DeleteEvent(e.ID)
+	err = s.storage.DeleteEvent(ctx, e.ID)
 	if err != nil {
-		return &pb.Result{}, err
+		return &pb.Result{}, statusError(err)
 	}
 	return &pb.Result{}, nil
 }
 
-func (s *Server) ListEventDay(_ context.Context, in *pb.ListDate) (*pb.Result, error) {
-	return listEvent(in.GetDateStart(), s.storage.ListEventDay)
+func (s *Server) ListEventDay(ctx context.Context, in *pb.ListDate) (*pb.Result, error) {
+	return listEvent(ctx, in.GetDateStart(), s.storage.ListEventDay)
 }
 
-func (s *Server) ListEventWeek(_ context.Context, in *pb.ListDate) (*pb.Result, error) {
-	return listEvent(in.GetDateStart(), s.storage.ListEventWeek)
+func (s *Server) ListEventWeek(ctx context.Context, in *pb.ListDate) (*pb.Result, error) {
+	return listEvent(ctx, in.GetDateStart(), s.storage.ListEventWeek)
 }
 
-func (s *Server) ListEventMonth(_ context.Context, in *pb.ListDate) (*pb.Result, error) {
-	return listEvent(in.GetDateStart(), s.storage.ListEventMonth)
+func (s *Server) ListEventMonth(ctx context.Context, in *pb.ListDate) (*pb.Result, error) {
+	return listEvent(ctx, in.GetDateStart(), s.storage.ListEventMonth)
 }
 
-func listEvent(date string, f func(date string) ([]storage.Event, error)) (*pb.Result, error) {
+func listEvent(ctx context.Context, date string, f func(ctx context.Context, date string) ([]storage.Event, error)) (*pb.Result, error) {
 	lm := storage.ListEventValidation{
 		DateStart: date,
 	}
@@ -96,9 +114,9 @@ func listEvent(date string, f func(date string) ([]storage.Event, error)) (*pb.R
 		return nil, status.Errorf(codes.InvalidArgument, "%s", err)
 	}
 
-	events, err := f(lm.DateStart)
+	events, err := f(ctx, lm.DateStart)
 	if err != nil {
-		return result, err
+		return result, statusError(err)
 	}
 
 	if len(events) == 0 {
@@ -108,16 +126,7 @@ func listEvent(date string, f func(date string) ([]storage.Event, error)) (*pb.R
 	storageEvents := make([]*pb.Event, len(events))
 
 	for i, event := range events {
-		e := &pb.Event{
-			Id:          event.ID,
-			Title:       event.Title,
-			DateStart:   event.DateStart,
-			DateEnd:     event.DateEnd,
-			Description: event.Description,
-			UserId:      event.UserID,
-			DatePost:    event.DatePost,
-		}
-		storageEvents[i] = e
+		storageEvents[i] = toPb(event)
 	}
 
 	result.Events = storageEvents
@@ -125,6 +134,18 @@ func listEvent(date string, f func(date string) ([]storage.Event, error)) (*pb.R
 	return result, nil
 }
 
+func toPb(e storage.Event) *pb.Event {
+	return &pb.Event{
+		Id:          e.ID,
+		Title:       e.Title,
+		DateStart:   e.DateStart,
+		DateEnd:     e.DateEnd,
+		Description: e.Description,
+		UserId:      e.UserID,
+		DatePost:    e.DatePost,
+	}
+}
+
 func updateEventFields(e *storage.Event, changed *pb.Event) {
 	if changed.Title != "" && changed.Title != e.Title {
 		e.Title = changed.Title
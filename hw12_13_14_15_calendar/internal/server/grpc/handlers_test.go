@@ -108,10 +108,10 @@ func TestCreateEventHandler(t *testing.T) {
 	t.Run("handler validation", func(t *testing.T) {
 		s := mocks.NewStorager(t)
 		l := mocks.NewLogger(t)
-		server := NewServer(l, s, 8080)
+		server := NewServer(l, s, nil, 8080)
 
 		for _, tc := range createUpdateCases {
-			s.On("CreateEvent", mock.AnythingOfType("storage.Event")).Return(nil)
+			s.On("CreateEvent", mock.Anything, mock.AnythingOfType("storage.Event")).Return("eb0af540-6f23-4305-a719-fb65271fca1f", nil)
 
 			_, err := server.CreateEvent(context.Background(), tc.event)
 			if tc.err {
@@ -120,7 +120,7 @@ func TestCreateEventHandler(t *testing.T) {
 				continue
 			}
 			assert.NoError(t, err)
-			s.AssertCalled(t, "CreateEvent", mock.AnythingOfType("storage.Event"))
+			s.AssertCalled(t, "CreateEvent", mock.Anything, mock.AnythingOfType("storage.Event"))
 		}
 	})
 }
@@ -129,14 +129,14 @@ func TestUpdateEventHandler(t *testing.T) {
 	t.Run("handler event validation", func(t *testing.T) {
 		s := mocks.NewStorager(t)
 		l := mocks.NewLogger(t)
-		server := NewServer(l, s, 8080)
+		server := NewServer(l, s, nil, 8080)
 
 		for _, tc := range createUpdateCases {
-			s.On("GetEvent", mock.AnythingOfType("string")).Return(storage.Event{
+			s.On("GetEvent", mock.Anything, mock.AnythingOfType("string")).Return(storage.Event{
 				ID: "eb0af540-6f23-4305-a719-fb65271fca1f",
 			}, nil)
 
-			s.On("UpdateEvent", mock.AnythingOfType("string"), mock.AnythingOfType("storage.Event")).Return(nil)
+			s.On("UpdateEvent", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("storage.Event")).Return(nil)
 
 			_, err := server.UpdateEvent(context.Background(), &pb.UpdateRequest{
 				Id:    &pb.EventId{Id: "eb0af540-6f23-4305-a719-fb65271fca1f"},
@@ -148,7 +148,7 @@ func TestUpdateEventHandler(t *testing.T) {
 				continue
 			}
 			assert.NoError(t, err)
-			s.AssertCalled(t, "UpdateEvent", mock.AnythingOfType("string"), mock.AnythingOfType("storage.Event"))
+			s.AssertCalled(t, "UpdateEvent", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("storage.Event"))
 		}
 	})
 }
@@ -167,10 +167,10 @@ func TestDeleteEventHandler(t *testing.T) {
 
 		s := mocks.NewStorager(t)
 		l := mocks.NewLogger(t)
-		server := NewServer(l, s, 8080)
+		server := NewServer(l, s, nil, 8080)
 
 		for _, tc := range cases {
-			s.On("DeleteEvent", mock.AnythingOfType("string")).Return(nil)
+			s.On("DeleteEvent", mock.Anything, mock.AnythingOfType("string")).Return(nil)
 
 			_, err := server.DeleteEvent(context.Background(), tc.val)
 			if tc.err {
@@ -179,7 +179,7 @@ func TestDeleteEventHandler(t *testing.T) {
 				continue
 			}
 			assert.NoError(t, err)
-			s.AssertCalled(t, "DeleteEvent", mock.AnythingOfType("string"))
+			s.AssertCalled(t, "DeleteEvent", mock.Anything, mock.AnythingOfType("string"))
 		}
 	})
 }
@@ -189,7 +189,7 @@ func TestListDayHandler(t *testing.T) {
 	s := mocks.NewStorager(t)
 	method := "ListEventDay"
 
-	server := NewServer(l, s, 8080)
+	server := NewServer(l, s, nil, 8080)
 
 	t.Run("handler validation", func(t *testing.T) {
 		listsHandlerTest(t, s, method, server.ListEventDay)
@@ -201,7 +201,7 @@ func TestListWeekHandler(t *testing.T) {
 	s := mocks.NewStorager(t)
 	method := "ListEventWeek"
 
-	server := NewServer(l, s, 8080)
+	server := NewServer(l, s, nil, 8080)
 
 	t.Run("handler validation", func(t *testing.T) {
 		listsHandlerTest(t, s, method, server.ListEventWeek)
@@ -213,7 +213,7 @@ func TestListMonthHandler(t *testing.T) {
 	s := mocks.NewStorager(t)
 	method := "ListEventMonth"
 
-	server := NewServer(l, s, 8080)
+	server := NewServer(l, s, nil, 8080)
 
 	t.Run("handler validation", func(t *testing.T) {
 		listsHandlerTest(t, s, method, server.ListEventMonth)
@@ -236,7 +236,7 @@ func listsHandlerTest(t *testing.T, s *mocks.Storager, method string, f ListHand
 	lm := pb.ListDate{}
 
 	for _, tc := range cases {
-		s.On(method, mock.AnythingOfType("string")).Return([]storage.Event{}, nil)
+		s.On(method, mock.Anything, mock.AnythingOfType("string")).Return([]storage.Event{}, nil)
 
 		lm.DateStart = tc.val
 
@@ -247,6 +247,6 @@ func listsHandlerTest(t *testing.T, s *mocks.Storager, method string, f ListHand
 			continue
 		}
 		assert.NoError(t, err)
-		s.AssertCalled(t, method, mock.AnythingOfType("string"))
+		s.AssertCalled(t, method, mock.Anything, mock.AnythingOfType("string"))
 	}
 }
@@ -0,0 +1,541 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/feed"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server/grpc/pbv2"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+type EventServiceV2 struct {
+	pbv2.UnimplementedEventServiceServer
+
+	storage app.Storager
+	hub     *feed.Hub
+}
+
+func NewEventServiceV2(storage app.Storager, hub *feed.Hub) *EventServiceV2 {
+	return &EventServiceV2{
+		storage: storage,
+		hub:     hub,
+	}
+}
+
+func (s *EventServiceV2) CreateEvent(ctx context.Context, in *pbv2.CreateEventRequest) (*pbv2.CreateEventResponse, error) {
+	e := fromPbV2(in.GetEvent())
+	e.ID = ""
+
+	err := server.ValidateCreateEvent(e)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	id, err := s.storage.CreateEvent(ctx, e)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &pbv2.CreateEventResponse{Id: id}, nil
+}
+
+func (s *EventServiceV2) GetEvent(ctx context.Context, in *pbv2.GetEventRequest) (*pbv2.GetEventResponse, error) {
+	err := server.ValidateDeleteEvent(storage.Event{ID: in.GetId()})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	e, err := s.storage.GetEvent(ctx, in.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &pbv2.GetEventResponse{Event: toPbV2(e)}, nil
+}
+
+func (s *EventServiceV2) UpdateEvent(ctx context.Context, in *pbv2.UpdateEventRequest) (*pbv2.UpdateEventResponse, error) {
+	err := server.ValidateDeleteEvent(storage.Event{ID: in.GetId()})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	e, err := s.storage.GetEvent(ctx, in.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	changed := fromPbV2(in.GetEvent())
+	mergeEventFields(&e, &changed)
+	if in.GetEvent().AllDay != nil {
+		e.AllDay = in.GetEvent().GetAllDay()
+	}
+	e.ID = in.GetId()
+
+	err = server.ValidateUpdateEvent(e)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	err = s.storage.UpdateEvent(ctx, e.ID, e)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	e.Normalize()
+
+	return &pbv2.UpdateEventResponse{Event: toPbV2(e)}, nil
+}
+
+func (s *EventServiceV2) DeleteEvent(ctx context.Context, in *pbv2.DeleteEventRequest) (*pbv2.DeleteEventResponse, error) {
+	err := server.ValidateDeleteEvent(storage.Event{ID: in.GetId()})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	err = s.storage.DeleteEvent(ctx, in.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &pbv2.DeleteEventResponse{}, nil
+}
+
+func (s *EventServiceV2) ListEventDay(ctx context.Context, in *pbv2.ListEventsRequest) (*pbv2.ListEventsResponse, error) {
+	return s.listEventV2(ctx, in, pbv2.Period_PERIOD_DAY, s.storage.ListEventDay)
+}
+
+func (s *EventServiceV2) ListEventWeek(ctx context.Context, in *pbv2.ListEventsRequest) (*pbv2.ListEventsResponse, error) {
+	return s.listEventV2(ctx, in, pbv2.Period_PERIOD_WEEK, s.storage.ListEventWeek)
+}
+
+func (s *EventServiceV2) ListEventMonth(ctx context.Context, in *pbv2.ListEventsRequest) (*pbv2.ListEventsResponse, error) {
+	return s.listEventV2(ctx, in, pbv2.Period_PERIOD_MONTH, s.storage.ListEventMonth)
+}
+
+// ApplyBatch применяет операции атомарно. При откате пакета результаты операций
+// передаются в деталях статуса ошибки.
+func (s *EventServiceV2) ApplyBatch(ctx context.Context, in *pbv2.ApplyBatchRequest) (*pbv2.ApplyBatchResponse, error) {
+	ops := make([]storage.BatchOperation, len(in.GetOperations()))
+	for i, op := range in.GetOperations() {
+		ops[i] = storage.BatchOperation{
+			Op:    batchOps[op.GetType()],
+			ID:    op.GetId(),
+			Event: fromPbV2(op.GetEvent()),
+		}
+	}
+
+	err := server.ValidateBatch(ops)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	results, err := s.storage.ApplyBatch(ctx, ops)
+
+	resp := &pbv2.ApplyBatchResponse{
+		Results: make([]*pbv2.BatchResult, len(results)),
+	}
+
+	for i, r := range results {
+		resp.Results[i] = &pbv2.BatchResult{
+			Index:  int32(r.Index),
+			Type:   in.GetOperations()[r.Index].GetType(),
+			Id:     r.ID,
+			Status: batchStatuses[r.Status],
+			Error:  r.Error,
+		}
+	}
+
+	if err != nil {
+		st := status.Convert(statusError(err))
+
+		withDetails, detailsErr := st.WithDetails(resp)
+		if detailsErr != nil {
+			return nil, st.Err()
+		}
+		return nil, withDetails.Err()
+	}
+
+	return resp, nil
+}
+
+func (s *EventServiceV2) SnoozeReminder(ctx context.Context, in *pbv2.SnoozeReminderRequest) (
+	*pbv2.ReminderStateResponse, error,
+) {
+	err := server.ValidateDeleteEvent(storage.Event{ID: in.GetEventId()})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	a := storage.ReminderAction{UserID: in.GetUserId(), Minutes: int(in.GetMinutes())}
+
+	err = server.ValidateSnooze(a)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	r, err := app.SnoozeReminder(ctx, s.storage, in.GetEventId(), a.UserID, time.Duration(a.Minutes)*time.Minute)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &pbv2.ReminderStateResponse{State: reminderStateToPbV2(r)}, nil
+}
+
+func (s *EventServiceV2) DismissReminder(ctx context.Context, in *pbv2.DismissReminderRequest) (
+	*pbv2.ReminderStateResponse, error,
+) {
+	err := server.ValidateDeleteEvent(storage.Event{ID: in.GetEventId()})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	err = server.ValidateDismiss(storage.ReminderAction{UserID: in.GetUserId()})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	r, err := app.DismissReminder(ctx, s.storage, in.GetEventId(), in.GetUserId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &pbv2.ReminderStateResponse{State: reminderStateToPbV2(r)}, nil
+}
+
+func (s *EventServiceV2) ListAttachments(ctx context.Context, in *pbv2.ListAttachmentsRequest) (
+	*pbv2.ListAttachmentsResponse, error,
+) {
+	err := server.ValidateUUID("EventID", in.GetEventId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	attachments, err := s.storage.ListAttachments(ctx, in.GetEventId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	resp := &pbv2.ListAttachmentsResponse{Attachments: make([]*pbv2.Attachment, 0, len(attachments))}
+	for _, a := range attachments {
+		resp.Attachments = append(resp.Attachments, attachmentToPbV2(a))
+	}
+
+	return resp, nil
+}
+
+func (s *EventServiceV2) GetAttachment(ctx context.Context, in *pbv2.GetAttachmentRequest) (
+	*pbv2.GetAttachmentResponse, error,
+) {
+	err := server.ValidateUUID("ID", in.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	a, err := s.storage.GetAttachment(ctx, in.GetId())
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	return &pbv2.GetAttachmentResponse{Attachment: attachmentToPbV2(a)}, nil
+}
+
+// WatchEvents отправляет изменения событий из Hub до отмены запроса.
+func (s *EventServiceV2) WatchEvents(in *pbv2.WatchEventsRequest, stream pbv2.EventService_WatchEventsServer) error {
+	if s.hub == nil {
+		return status.Error(codes.Unavailable, "change feed is not configured")
+	}
+
+	filter, err := watchFilter(in)
+	if err != nil {
+		return err
+	}
+
+	sub, err := s.hub.Subscribe(filter, in.GetLastEventId())
+	if errors.Is(err, feed.ErrHistoryExpired) {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if err != nil {
+		return statusError(err)
+	}
+	defer sub.Close()
+
+	// Подписка оформлена до чтения, поэтому изменения между чтением и
+	// подпиской не теряются, а могут лишь прийти повторно.
+	if in.GetLastEventId() == 0 && filter.DateFrom != "" {
+		events, err := s.storage.ListEventRange(stream.Context(), filter.DateFrom, filter.DateTo)
+		if err != nil {
+			return statusError(err)
+		}
+
+		for _, e := range events {
+			if !filter.Match(e) {
+				continue
+			}
+			err = stream.Send(&pbv2.WatchEventsResponse{Type: pbv2.ChangeType_CHANGE_TYPE_CREATED, Event: toPbV2(e)})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case c, ok := <-sub.C:
+			if !ok {
+				return status.Error(codes.Unavailable, "subscriber is too slow, resume with last event id")
+			}
+
+			err = stream.Send(&pbv2.WatchEventsResponse{Id: c.ID, Type: changeTypeToPbV2(c.Type), Event: toPbV2(c.Event)})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+var batchOps = map[pbv2.BatchOperationType]string{
+	pbv2.BatchOperationType_BATCH_OPERATION_TYPE_CREATE: storage.BatchCreate,
+	pbv2.BatchOperationType_BATCH_OPERATION_TYPE_UPDATE: storage.BatchUpdate,
+	pbv2.BatchOperationType_BATCH_OPERATION_TYPE_DELETE: storage.BatchDelete,
+}
+
+var batchStatuses = map[string]pbv2.BatchStatus{
+	storage.BatchStatusApplied:    pbv2.BatchStatus_BATCH_STATUS_APPLIED,
+	storage.BatchStatusFailed:     pbv2.BatchStatus_BATCH_STATUS_FAILED,
+	storage.BatchStatusRolledBack: pbv2.BatchStatus_BATCH_STATUS_ROLLED_BACK,
+	storage.BatchStatusSkipped:    pbv2.BatchStatus_BATCH_STATUS_SKIPPED,
+}
+
+func watchFilter(in *pbv2.WatchEventsRequest) (feed.Filter, error) {
+	fv := storage.FeedFilterValidation{
+		UserID: in.GetUserId(),
+	}
+
+	if in.GetDateStart() != "" {
+		from, err := time.Parse("2006-01-02", in.GetDateStart())
+		if err != nil {
+			return feed.Filter{}, statusError(server.ValidateListEvent(storage.ListEventValidation{DateStart: in.GetDateStart()}))
+		}
+
+		to, err := periodEnd(from, in.GetPeriod())
+		if err != nil {
+			return feed.Filter{}, err
+		}
+
+		fv.DateFrom = from.Format("2006-01-02")
+		fv.DateTo = to.Format("2006-01-02")
+	}
+
+	err := server.ValidateFeedFilter(fv)
+	if err != nil {
+		return feed.Filter{}, statusError(err)
+	}
+
+	return feed.Filter{UserID: fv.UserID, DateFrom: fv.DateFrom, DateTo: fv.DateTo}, nil
+}
+
+// periodEnd возвращает последний день периода, начатого в from.
+func periodEnd(from time.Time, period pbv2.Period) (time.Time, error) {
+	switch period {
+	case pbv2.Period_PERIOD_DAY:
+		return from, nil
+	case pbv2.Period_PERIOD_WEEK:
+		return from.AddDate(0, 0, 6), nil
+	case pbv2.Period_PERIOD_MONTH:
+		return from.AddDate(0, 1, -1), nil
+	case pbv2.Period_PERIOD_UNSPECIFIED:
+		return time.Time{}, status.Error(codes.InvalidArgument, "period is required")
+	default:
+		return time.Time{}, status.Errorf(codes.InvalidArgument, "unsupported period: %s", period)
+	}
+}
+
+func changeTypeToPbV2(t feed.ChangeType) pbv2.ChangeType {
+	switch t {
+	case feed.ChangeCreated:
+		return pbv2.ChangeType_CHANGE_TYPE_CREATED
+	case feed.ChangeUpdated:
+		return pbv2.ChangeType_CHANGE_TYPE_UPDATED
+	case feed.ChangeDeleted:
+		return pbv2.ChangeType_CHANGE_TYPE_DELETED
+	default:
+		return pbv2.ChangeType_CHANGE_TYPE_UNSPECIFIED
+	}
+}
+
+// listEventV2 читает события периода через f, а при заданных метках - через ListEventTagged.
+func (s *EventServiceV2) listEventV2(ctx context.Context, in *pbv2.ListEventsRequest, period pbv2.Period,
+	f func(ctx context.Context, date string) ([]storage.Event, error),
+) (*pbv2.ListEventsResponse, error) {
+	lm := storage.ListEventValidation{
+		DateStart: in.GetDateStart(),
+	}
+
+	err := server.ValidateListEvent(lm)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	tf := storage.ListEventTagValidation{Tags: in.GetTags()}
+
+	err = server.ValidateTagFilter(tf)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	var events []storage.Event
+
+	if len(tf.Tags) > 0 {
+		from, _ := time.Parse("2006-01-02", lm.DateStart)
+		to, _ := periodEnd(from, period)
+		events, err = s.storage.ListEventTagged(ctx, tf.Tags, lm.DateStart, to.Format("2006-01-02"))
+	} else {
+		events, err = f(ctx, lm.DateStart)
+	}
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	result := &pbv2.ListEventsResponse{
+		Events: make([]*pbv2.Event, len(events)),
+	}
+
+	for i, e := range events {
+		result.Events[i] = toPbV2(e)
+	}
+
+	return result, nil
+}
+
+// statusError переводит ошибки валидации и хранилища в коды gRPC.
+func statusError(err error) error {
+	var validationErr *server.ValidationError
+
+	switch {
+	case errors.As(err, &validationErr):
+		br := &errdetails.BadRequest{}
+		for _, v := range validationErr.Violations {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       v.Field,
+				Description: v.Description,
+			})
+		}
+
+		st, detailsErr := status.New(codes.InvalidArgument, "request validation failed").WithDetails(br)
+		if detailsErr != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		return st.Err()
+	case errors.Is(err, storage.ErrEventNotExist), errors.Is(err, storage.ErrTagNotExist),
+		errors.Is(err, storage.ErrAttachmentNotExist), errors.Is(err, storage.ErrResourceNotExist):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, storage.ErrDateBusy), errors.Is(err, storage.ErrEventDuplicateID):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, storage.ErrUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func fromPbV2(e *pbv2.Event) storage.Event {
+	return storage.Event{
+		ID:          e.GetId(),
+		Title:       e.GetTitle(),
+		DateStart:   e.GetDateStart(),
+		DateEnd:     e.GetDateEnd(),
+		Description: e.GetDescription(),
+		UserID:      e.GetUserId(),
+		DatePost:    e.GetDatePost(),
+		Category:    e.GetCategory(),
+		Color:       e.GetColor(),
+		Tags:        e.GetTags(),
+		AllDay:      e.GetAllDay(),
+		Resources:   e.GetResources(),
+	}
+}
+
+func toPbV2(e storage.Event) *pbv2.Event {
+	return &pbv2.Event{
+		Id:          e.ID,
+		Title:       e.Title,
+		DateStart:   e.DateStart,
+		DateEnd:     e.DateEnd,
+		Description: e.Description,
+		UserId:      e.UserID,
+		DatePost:    e.DatePost,
+		Category:    e.Category,
+		Color:       e.Color,
+		Tags:        e.Tags,
+		AllDay:      proto.Bool(e.AllDay),
+		Resources:   e.Resources,
+	}
+}
+
+func reminderStateToPbV2(r storage.ReminderState) *pbv2.ReminderState {
+	return &pbv2.ReminderState{
+		EventId:      r.EventID,
+		UserId:       r.UserID,
+		SnoozedUntil: r.SnoozedUntil,
+		Dismissed:    r.Dismissed,
+	}
+}
+
+func attachmentToPbV2(a storage.Attachment) *pbv2.Attachment {
+	return &pbv2.Attachment{
+		Id:          a.ID,
+		EventId:     a.EventID,
+		Name:        a.Name,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		Url:         a.URL,
+		Stored:      a.Stored(),
+		CreatedAt:   a.CreatedAt,
+	}
+}
+
+func mergeEventFields(e *storage.Event, changed *storage.Event) {
+	if changed.Title != "" {
+		e.Title = changed.Title
+	}
+	if changed.DateStart != "" {
+		e.DateStart = changed.DateStart
+	}
+	if changed.DateEnd != "" {
+		e.DateEnd = changed.DateEnd
+	}
+	if changed.Description != "" {
+		e.Description = changed.Description
+	}
+	if changed.UserID != "" {
+		e.UserID = changed.UserID
+	}
+	if changed.DatePost != "" {
+		e.DatePost = changed.DatePost
+	}
+	if changed.Category != "" {
+		e.Category = changed.Category
+	}
+	if changed.Color != "" {
+		e.Color = changed.Color
+	}
+	if len(changed.Tags) > 0 {
+		e.Tags = changed.Tags
+	}
+	if len(changed.Resources) > 0 {
+		e.Resources = changed.Resources
+	}
+}
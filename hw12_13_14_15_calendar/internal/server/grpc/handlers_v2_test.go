@@ -0,0 +1,440 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/feed"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server/grpc/pbv2"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const testEventID = "eb0af540-6f23-4305-a719-fb65271fca1f"
+
+func validEventV2() *pbv2.Event {
+	return &pbv2.Event{
+		Title:       "Test Event",
+		DateStart:   "2022-10-11 12:00:00",
+		DateEnd:     "2022-10-12 13:00:00",
+		Description: "Test Description",
+		UserId:      "d5095366-ea13-4c9d-ae72-9c83d2d93040",
+		DatePost:    "2022-10-12 13:00:00",
+	}
+}
+
+func TestCreateEventV2(t *testing.T) {
+	t.Run("returns created id", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("CreateEvent", mock.Anything, mock.AnythingOfType("storage.Event")).Return(testEventID, nil)
+
+		resp, err := NewEventServiceV2(s, nil).CreateEvent(context.Background(),
+			&pbv2.CreateEventRequest{Event: validEventV2()})
+		require.NoError(t, err)
+		require.Equal(t, testEventID, resp.GetId())
+	})
+
+	t.Run("validation error details", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+
+		e := validEventV2()
+		e.Title = ""
+		e.UserId = "invalid-uuid"
+
+		_, err := NewEventServiceV2(s, nil).CreateEvent(context.Background(), &pbv2.CreateEventRequest{Event: e})
+
+		st := status.Convert(err)
+		require.Equal(t, codes.InvalidArgument, st.Code())
+		require.Len(t, st.Details(), 1)
+
+		br, ok := st.Details()[0].(*errdetails.BadRequest)
+		require.True(t, ok)
+
+		fields := make([]string, 0, len(br.GetFieldViolations()))
+		for _, v := range br.GetFieldViolations() {
+			fields = append(fields, v.GetField())
+		}
+		require.ElementsMatch(t, []string{"Title", "UserID"}, fields)
+	})
+
+	t.Run("date busy", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("CreateEvent", mock.Anything, mock.AnythingOfType("storage.Event")).Return("", storage.ErrDateBusy)
+
+		_, err := NewEventServiceV2(s, nil).CreateEvent(context.Background(),
+			&pbv2.CreateEventRequest{Event: validEventV2()})
+		require.Equal(t, codes.AlreadyExists, status.Code(err))
+	})
+
+	t.Run("resource busy", func(t *testing.T) {
+		e := validEventV2()
+		e.Resources = []string{testEventID}
+
+		s := mocks.NewStorager(t)
+		s.On("CreateEvent", mock.Anything, mock.MatchedBy(func(e storage.Event) bool {
+			return len(e.Resources) == 1 && e.Resources[0] == testEventID
+		})).Return("", storage.ErrResourceBusy)
+
+		_, err := NewEventServiceV2(s, nil).CreateEvent(context.Background(), &pbv2.CreateEventRequest{Event: e})
+		require.Equal(t, codes.AlreadyExists, status.Code(err))
+	})
+}
+
+func TestGetEventV2(t *testing.T) {
+	cases := []struct {
+		name    string
+		id      string
+		err     error
+		code    codes.Code
+		storage bool
+	}{
+		{"found", testEventID, nil, codes.OK, true},
+		{"not found", testEventID, storage.ErrEventNotExist, codes.NotFound, true},
+		{"internal", testEventID, errors.New("connection refused"), codes.Internal, true},
+		{"deadline", testEventID, context.DeadlineExceeded, codes.DeadlineExceeded, true},
+		{"unavailable", testEventID, storage.ErrUnavailable, codes.Unavailable, true},
+		{"invalid id", "test", nil, codes.InvalidArgument, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := mocks.NewStorager(t)
+			if tc.storage {
+				s.On("GetEvent", mock.Anything, tc.id).Return(storage.Event{ID: tc.id}, tc.err)
+			}
+
+			resp, err := NewEventServiceV2(s, nil).GetEvent(context.Background(), &pbv2.GetEventRequest{Id: tc.id})
+			require.Equal(t, tc.code, status.Code(err))
+			if tc.code == codes.OK {
+				require.Equal(t, tc.id, resp.GetEvent().GetId())
+			}
+		})
+	}
+}
+
+func TestUpdateEventV2(t *testing.T) {
+	existing := fromPbV2(validEventV2())
+	existing.ID = testEventID
+
+	s := mocks.NewStorager(t)
+	s.On("GetEvent", mock.Anything, testEventID).Return(existing, nil)
+	s.On("UpdateEvent", mock.Anything, testEventID, mock.AnythingOfType("storage.Event")).Return(nil)
+
+	resp, err := NewEventServiceV2(s, nil).UpdateEvent(context.Background(), &pbv2.UpdateEventRequest{
+		Id:    testEventID,
+		Event: &pbv2.Event{Title: "Updated"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Updated", resp.GetEvent().GetTitle())
+	require.Equal(t, existing.DateStart, resp.GetEvent().GetDateStart())
+	require.Equal(t, testEventID, resp.GetEvent().GetId())
+	require.False(t, resp.GetEvent().GetAllDay())
+
+	resp, err = NewEventServiceV2(s, nil).UpdateEvent(context.Background(), &pbv2.UpdateEventRequest{
+		Id:    testEventID,
+		Event: &pbv2.Event{AllDay: proto.Bool(true)},
+	})
+	require.NoError(t, err)
+	require.True(t, resp.GetEvent().GetAllDay())
+	require.Equal(t, "2022-10-11 00:00:00", resp.GetEvent().GetDateStart())
+	require.Equal(t, "2022-10-13 00:00:00", resp.GetEvent().GetDateEnd())
+}
+
+func TestListEventsTaggedV2(t *testing.T) {
+	tagged := []storage.Event{{ID: testEventID, Title: "deploy", Tags: []string{"release"}}}
+
+	cases := []struct {
+		name string
+		list func(s *EventServiceV2, in *pbv2.ListEventsRequest) (*pbv2.ListEventsResponse, error)
+		to   string
+	}{
+		{"day", func(s *EventServiceV2, in *pbv2.ListEventsRequest) (*pbv2.ListEventsResponse, error) {
+			return s.ListEventDay(context.Background(), in)
+		}, "2024-02-05"},
+		{"week", func(s *EventServiceV2, in *pbv2.ListEventsRequest) (*pbv2.ListEventsResponse, error) {
+			return s.ListEventWeek(context.Background(), in)
+		}, "2024-02-11"},
+		{"month", func(s *EventServiceV2, in *pbv2.ListEventsRequest) (*pbv2.ListEventsResponse, error) {
+			return s.ListEventMonth(context.Background(), in)
+		}, "2024-03-04"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := mocks.NewStorager(t)
+			s.On("ListEventTagged", mock.Anything, []string{"release"}, "2024-02-05", tc.to).Return(tagged, nil)
+
+			resp, err := tc.list(NewEventServiceV2(s, nil),
+				&pbv2.ListEventsRequest{DateStart: "2024-02-05", Tags: []string{"release"}})
+			require.NoError(t, err)
+			require.Len(t, resp.GetEvents(), 1)
+			require.Equal(t, []string{"release"}, resp.GetEvents()[0].GetTags())
+		})
+	}
+
+	t.Run("invalid tag", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+
+		_, err := NewEventServiceV2(s, nil).ListEventDay(context.Background(),
+			&pbv2.ListEventsRequest{DateStart: "2024-02-05", Tags: []string{"a/b"}})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestDeleteEventV2(t *testing.T) {
+	s := mocks.NewStorager(t)
+	s.On("DeleteEvent", mock.Anything, testEventID).Return(storage.ErrEventNotExist)
+
+	_, err := NewEventServiceV2(s, nil).DeleteEvent(context.Background(), &pbv2.DeleteEventRequest{Id: testEventID})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestReminderV2(t *testing.T) {
+	userID := validEventV2().GetUserId()
+
+	t.Run("snooze", func(t *testing.T) {
+		s := memorystorage.New()
+		id, err := s.CreateEvent(context.Background(), fromPbV2(validEventV2()))
+		require.NoError(t, err)
+
+		resp, err := NewEventServiceV2(s, nil).SnoozeReminder(context.Background(),
+			&pbv2.SnoozeReminderRequest{EventId: id, UserId: userID, Minutes: 30})
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.GetState().GetSnoozedUntil())
+
+		r, err := s.GetReminderState(context.Background(), id, userID)
+		require.NoError(t, err)
+		require.Equal(t, resp.GetState().GetSnoozedUntil(), r.SnoozedUntil)
+	})
+
+	t.Run("snooze too long", func(t *testing.T) {
+		_, err := NewEventServiceV2(mocks.NewStorager(t), nil).SnoozeReminder(context.Background(),
+			&pbv2.SnoozeReminderRequest{EventId: testEventID, UserId: userID, Minutes: 2000})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("dismiss unknown event", func(t *testing.T) {
+		_, err := NewEventServiceV2(memorystorage.New(), nil).DismissReminder(context.Background(),
+			&pbv2.DismissReminderRequest{EventId: testEventID, UserId: userID})
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+func TestAttachmentsV2(t *testing.T) {
+	ctx := context.Background()
+
+	s := memorystorage.New()
+	eventID, err := s.CreateEvent(ctx, fromPbV2(validEventV2()))
+	require.NoError(t, err)
+
+	id, err := s.CreateAttachment(ctx, storage.Attachment{
+		EventID: eventID, Name: "slides.pdf", ContentType: "application/pdf", Size: 2048, BlobKey: "key",
+	})
+	require.NoError(t, err)
+
+	service := NewEventServiceV2(s, nil)
+
+	t.Run("list", func(t *testing.T) {
+		resp, err := service.ListAttachments(ctx, &pbv2.ListAttachmentsRequest{EventId: eventID})
+		require.NoError(t, err)
+		require.Len(t, resp.GetAttachments(), 1)
+
+		a := resp.GetAttachments()[0]
+		require.Equal(t, id, a.GetId())
+		require.Equal(t, int64(2048), a.GetSize())
+		require.True(t, a.GetStored())
+	})
+
+	t.Run("get not found", func(t *testing.T) {
+		_, err := service.GetAttachment(ctx, &pbv2.GetAttachmentRequest{Id: testEventID})
+		require.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("get invalid id", func(t *testing.T) {
+		_, err := service.GetAttachment(ctx, &pbv2.GetAttachmentRequest{Id: "1"})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+type watchStream struct {
+	grpc.ServerStream
+
+	ctx  context.Context
+	mu   sync.Mutex
+	sent []*pbv2.WatchEventsResponse
+}
+
+func (w *watchStream) Context() context.Context {
+	return w.ctx
+}
+
+func (w *watchStream) Send(resp *pbv2.WatchEventsResponse) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.sent = append(w.sent, resp)
+	return nil
+}
+
+func (w *watchStream) types() []pbv2.ChangeType {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	types := make([]pbv2.ChangeType, 0, len(w.sent))
+	for _, resp := range w.sent {
+		types = append(types, resp.GetType())
+	}
+	return types
+}
+
+func TestWatchEventsV2(t *testing.T) {
+	t.Run("validation", func(t *testing.T) {
+		svc := NewEventServiceV2(mocks.NewStorager(t), feed.NewHub(0))
+		stream := &watchStream{ctx: context.Background()}
+
+		err := svc.WatchEvents(&pbv2.WatchEventsRequest{DateStart: "2022.10.11", Period: pbv2.Period_PERIOD_DAY}, stream)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		err = svc.WatchEvents(&pbv2.WatchEventsRequest{DateStart: "2022-10-11"}, stream)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		err = svc.WatchEvents(&pbv2.WatchEventsRequest{UserId: "not-uuid"}, stream)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		err = svc.WatchEvents(&pbv2.WatchEventsRequest{LastEventId: 10}, stream)
+		require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		hub := feed.NewHub(0)
+		s := feed.NewStorage(memorystorage.New(), hub)
+		svc := NewEventServiceV2(s, hub)
+
+		first, err := s.CreateEvent(context.Background(), storage.Event{Title: "first", DateStart: "2022-10-11 10:00:00"})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		stream := &watchStream{ctx: ctx}
+		done := make(chan error)
+
+		go func() {
+			done <- svc.WatchEvents(&pbv2.WatchEventsRequest{
+				DateStart: "2022-10-11",
+				Period:    pbv2.Period_PERIOD_DAY,
+			}, stream)
+		}()
+
+		require.Eventually(t, func() bool { return len(stream.types()) == 1 }, time.Second, 5*time.Millisecond)
+
+		_, err = s.CreateEvent(context.Background(), storage.Event{Title: "second", DateStart: "2022-10-11 12:00:00"})
+		require.NoError(t, err)
+		_, err = s.CreateEvent(context.Background(), storage.Event{Title: "other day", DateStart: "2022-10-12 12:00:00"})
+		require.NoError(t, err)
+		require.NoError(t, s.DeleteEvent(context.Background(), first))
+
+		require.Eventually(t, func() bool { return len(stream.types()) == 3 }, time.Second, 5*time.Millisecond)
+
+		cancel()
+		require.NoError(t, <-done)
+
+		require.Equal(t, []pbv2.ChangeType{
+			pbv2.ChangeType_CHANGE_TYPE_CREATED,
+			pbv2.ChangeType_CHANGE_TYPE_CREATED,
+			pbv2.ChangeType_CHANGE_TYPE_DELETED,
+		}, stream.types())
+		require.Zero(t, stream.sent[0].GetId())
+		require.Equal(t, uint64(4), stream.sent[2].GetId())
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		hub := feed.NewHub(0)
+		s := feed.NewStorage(memorystorage.New(), hub)
+		svc := NewEventServiceV2(s, hub)
+
+		for i, title := range []string{"first", "second", "third"} {
+			_, err := s.CreateEvent(context.Background(), storage.Event{Title: title, DateStart: fmt.Sprintf("2022-10-11 1%d:00:00", i)})
+			require.NoError(t, err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		stream := &watchStream{ctx: ctx}
+		done := make(chan error)
+
+		go func() {
+			done <- svc.WatchEvents(&pbv2.WatchEventsRequest{LastEventId: 1}, stream)
+		}()
+
+		require.Eventually(t, func() bool { return len(stream.types()) == 2 }, time.Second, 5*time.Millisecond)
+
+		cancel()
+		require.NoError(t, <-done)
+
+		require.Equal(t, "second", stream.sent[0].GetEvent().GetTitle())
+		require.Equal(t, uint64(3), stream.sent[1].GetId())
+	})
+}
+
+func TestApplyBatchV2(t *testing.T) {
+	create := pbv2.BatchOperationType_BATCH_OPERATION_TYPE_CREATE
+
+	t.Run("applied", func(t *testing.T) {
+		s := memorystorage.New()
+
+		resp, err := NewEventServiceV2(s, nil).ApplyBatch(context.Background(), &pbv2.ApplyBatchRequest{
+			Operations: []*pbv2.BatchOperation{{Type: create, Event: validEventV2()}},
+		})
+		require.NoError(t, err)
+		require.Len(t, resp.GetResults(), 1)
+		require.Equal(t, pbv2.BatchStatus_BATCH_STATUS_APPLIED, resp.GetResults()[0].GetStatus())
+
+		_, err = s.GetEvent(context.Background(), resp.GetResults()[0].GetId())
+		require.NoError(t, err)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		_, err := NewEventServiceV2(mocks.NewStorager(t), nil).ApplyBatch(context.Background(), &pbv2.ApplyBatchRequest{
+			Operations: []*pbv2.BatchOperation{{Type: create, Event: &pbv2.Event{}}},
+		})
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("rolled back", func(t *testing.T) {
+		s := memorystorage.New()
+
+		_, err := NewEventServiceV2(s, nil).ApplyBatch(context.Background(), &pbv2.ApplyBatchRequest{
+			Operations: []*pbv2.BatchOperation{
+				{Type: create, Event: validEventV2()},
+				{Type: create, Event: validEventV2()},
+			},
+		})
+		require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+		details := status.Convert(err).Details()
+		require.Len(t, details, 1)
+
+		resp, ok := details[0].(*pbv2.ApplyBatchResponse)
+		require.True(t, ok)
+		require.Equal(t, pbv2.BatchStatus_BATCH_STATUS_ROLLED_BACK, resp.GetResults()[0].GetStatus())
+		require.Equal(t, pbv2.BatchStatus_BATCH_STATUS_FAILED, resp.GetResults()[1].GetStatus())
+
+		events, err := s.ListEventDay(context.Background(), "2022-10-11")
+		require.NoError(t, err)
+		require.Empty(t, events)
+	})
+}
@@ -23,3 +23,19 @@ func UnaryServerRequestLoggingInterceptor(logger Logger) grpc.UnaryServerInterce
 		return result, nil
 	}
 }
+
+func StreamServerRequestLoggingInterceptor(logger Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, i *grpc.StreamServerInfo, h grpc.StreamHandler) error {
+		start := time.Now()
+
+		err := h(srv, ss)
+		if err != nil {
+			return err
+		}
+
+		duration := time.Since(start)
+		logger.Info(fmt.Sprintf("grpc stream method: %s, duration: %s", i.FullMethod, duration))
+
+		return nil
+	}
+}
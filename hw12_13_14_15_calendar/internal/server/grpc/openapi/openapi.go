@@ -0,0 +1,8 @@
+package openapi
+
+import _ "embed"
+
+// Spec - OpenAPI описание REST API, сгенерированное из api/EventService.proto.
+//
+//go:embed EventService.swagger.json
+var Spec []byte
@@ -23,6 +23,7 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type EventServiceClient interface {
 	CreateEvent(ctx context.Context, in *Event, opts ...grpc.CallOption) (*Result, error)
+	GetEvent(ctx context.Context, in *EventId, opts ...grpc.CallOption) (*Result, error)
 	UpdateEvent(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*Result, error)
 	DeleteEvent(ctx context.Context, in *EventId, opts ...grpc.CallOption) (*Result, error)
 	ListEventDay(ctx context.Context, in *ListDate, opts ...grpc.CallOption) (*Result, error)
@@ -47,6 +48,15 @@ func (c *eventServiceClient) CreateEvent(ctx context.Context, in *Event, opts ..
 	return out, nil
 }
 
+func (c *eventServiceClient) GetEvent(ctx context.Context, in *EventId, opts ...grpc.CallOption) (*Result, error) {
+	out := new(Result)
+	err := c.cc.Invoke(ctx, "/event.EventService/GetEvent", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *eventServiceClient) UpdateEvent(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*Result, error) {
 	out := new(Result)
 	err := c.cc.Invoke(ctx, "/event.EventService/UpdateEvent", in, out, opts...)
@@ -97,6 +107,7 @@ func (c *eventServiceClient) ListEventMonth(ctx context.Context, in *ListDate, o
 // for forward compatibility
 type EventServiceServer interface {
 	CreateEvent(context.Context, *Event) (*Result, error)
+	GetEvent(context.Context, *EventId) (*Result, error)
 	UpdateEvent(context.Context, *UpdateRequest) (*Result, error)
 	DeleteEvent(context.Context, *EventId) (*Result, error)
 	ListEventDay(context.Context, *ListDate) (*Result, error)
@@ -112,6 +123,9 @@ type UnimplementedEventServiceServer struct {
 func (UnimplementedEventServiceServer) CreateEvent(context.Context, *Event) (*Result, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateEvent not implemented")
 }
+func (UnimplementedEventServiceServer) GetEvent(context.Context, *EventId) (*Result, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEvent not implemented")
+}
 func (UnimplementedEventServiceServer) UpdateEvent(context.Context, *UpdateRequest) (*Result, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateEvent not implemented")
 }
@@ -158,6 +172,24 @@ func _EventService_CreateEvent_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EventService_GetEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EventId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).GetEvent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/event.EventService/GetEvent",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).GetEvent(ctx, req.(*EventId))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _EventService_UpdateEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(UpdateRequest)
 	if err := dec(in); err != nil {
@@ -259,6 +291,10 @@ var EventService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CreateEvent",
 			Handler:    _EventService_CreateEvent_Handler,
 		},
+		{
+			MethodName: "GetEvent",
+			Handler:    _EventService_GetEvent_Handler,
+		},
 		{
 			MethodName: "UpdateEvent",
 			Handler:    _EventService_UpdateEvent_Handler,
@@ -1,11 +1,14 @@
 package grpc
 
 import (
+	"context"
 	"fmt"
 	"net"
 
 	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/feed"
 	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server/grpc/pb"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server/grpc/pbv2"
 	"google.golang.org/grpc"
 )
 
@@ -14,6 +17,7 @@ type Server struct {
 
 	logger  Logger
 	storage app.Storager
+	hub     *feed.Hub
 	server  *grpc.Server
 	port    int
 }
@@ -23,10 +27,12 @@ type Logger interface {
 	Error(msg string)
 }
 
-func NewServer(logger Logger, storage app.Storager, port int) *Server {
+// NewServer создает gRPC сервер. Если hub равен nil, WatchEvents недоступен.
+func NewServer(logger Logger, storage app.Storager, hub *feed.Hub, port int) *Server {
 	return &Server{
 		logger:  logger,
 		storage: storage,
+		hub:     hub,
 		port:    port,
 	}
 }
@@ -41,8 +47,12 @@ func (s *Server) Start() {
 		grpc.ChainUnaryInterceptor(
 			UnaryServerRequestLoggingInterceptor(s.logger),
 		),
+		grpc.ChainStreamInterceptor(
+			StreamServerRequestLoggingInterceptor(s.logger),
+		),
 	)
 	pb.RegisterEventServiceServer(s.server, s)
+	pbv2.RegisterEventServiceServer(s.server, NewEventServiceV2(s.storage, s.hub))
 
 	s.logger.Info(fmt.Sprintf("starting grpc server on %s", lsn.Addr().String()))
 
@@ -51,6 +61,18 @@ func (s *Server) Start() {
 	}
 }
 
-func (s *Server) Stop() {
-	s.server.Stop()
+// Stop дожидается завершения начатых вызовов, а по истечении ctx прерывает оставшиеся.
+func (s *Server) Stop(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.server.Stop()
+		<-done
+	}
 }
@@ -0,0 +1,239 @@
+package internalhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/attachment"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+const (
+	LocationAttachments       = "attachments"
+	LocationAttachmentsUpload = "attachments/upload"
+
+	locationContent = "content"
+)
+
+// uploadRate - наименьшая скорость загрузки файла в байтах в секунду, под которую
+// рассчитан срок чтения тела POST /attachments/upload.
+const uploadRate = 128 << 10
+
+var ErrAttachmentTooLarge = errors.New("attachment is too large")
+
+// AttachmentStorage - хранилище событий, которое хранит и содержимое загруженных вложений.
+type AttachmentStorage interface {
+	app.Storager
+	Upload(ctx context.Context, a storage.Attachment, r io.Reader) (storage.Attachment, error)
+	OpenContent(ctx context.Context, a storage.Attachment) (io.ReadCloser, error)
+}
+
+type attachmentHandler struct {
+	storage AttachmentStorage
+	maxSize int64
+}
+
+// AttachmentsHandler обслуживает /attachments. Ссылки создаются через POST /attachments
+// с JSON телом, файлы загружаются телом POST /attachments/upload?event_id=...&name=...
+// размером не больше maxSize байт.
+func AttachmentsHandler(s AttachmentStorage, maxSize int64) http.Handler {
+	h := &attachmentHandler{storage: s, maxSize: maxSize}
+
+	mux := http.NewServeMux()
+	mux.Handle("/"+LocationAttachments, handleResource(h.collection, s))
+	mux.Handle("/"+LocationAttachmentsUpload, handleResource(h.upload, s))
+
+	item := handleResource(h.item, s)
+	mux.Handle("/"+LocationAttachments+"/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/"+locationContent) {
+			h.content(w, r)
+			return
+		}
+		item.ServeHTTP(w, r)
+	}))
+
+	return mux
+}
+
+func (h *attachmentHandler) collection(w http.ResponseWriter, r *http.Request, s app.Storager) (
+	int, interface{}, error,
+) {
+	switch r.Method {
+	case http.MethodGet:
+		eventID := r.URL.Query().Get("event_id")
+
+		err := server.ValidateUUID("EventID", eventID)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		attachments, err := s.ListAttachments(r.Context(), eventID)
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusOK, attachments, nil
+	case http.MethodPost:
+		a := storage.Attachment{}
+
+		err := decodeJSONBody(r, &a)
+		if err != nil {
+			return 0, nil, err
+		}
+		a.ID, a.Size, a.BlobKey = "", 0, ""
+
+		err = server.ValidateAttachment(a)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		id, err := s.CreateAttachment(r.Context(), a)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		w.Header().Set("Location", fmt.Sprintf("/%s/%s", LocationAttachments, id))
+
+		return http.StatusCreated, CreatedResponse{ID: id}, nil
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		return 0, nil, &RequestError{Status: http.StatusMethodNotAllowed, Err: ErrMethodNotAllowed}
+	}
+}
+
+func (h *attachmentHandler) upload(w http.ResponseWriter, r *http.Request, _ app.Storager) (int, interface{}, error) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		return 0, nil, &RequestError{Status: http.StatusMethodNotAllowed, Err: ErrMethodNotAllowed}
+	}
+
+	query := r.URL.Query()
+
+	a := storage.Attachment{
+		EventID:     query.Get("event_id"),
+		Name:        query.Get("name"),
+		ContentType: r.Header.Get("Content-Type"),
+	}
+
+	err := server.ValidateAttachmentUpload(a)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if r.ContentLength > h.maxSize {
+		return 0, nil, &RequestError{Status: http.StatusRequestEntityTooLarge, Err: ErrAttachmentTooLarge}
+	}
+
+	// Общий срок чтения запроса не рассчитан на файлы, поэтому для загрузки он продлевается.
+	err = http.NewResponseController(w).SetReadDeadline(time.Now().Add(uploadTimeout(h.maxSize)))
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return 0, nil, err
+	}
+
+	a, err = h.storage.Upload(r.Context(), a, http.MaxBytesReader(w, r.Body, h.maxSize))
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return 0, nil, &RequestError{Status: http.StatusRequestEntityTooLarge, Err: ErrAttachmentTooLarge}
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/%s/%s", LocationAttachments, a.ID))
+
+	return http.StatusCreated, a, nil
+}
+
+// uploadTimeout - срок чтения тела загрузки размером до maxSize со скоростью не ниже uploadRate.
+func uploadTimeout(maxSize int64) time.Duration {
+	return readTimeout + time.Duration(maxSize/uploadRate)*time.Second
+}
+
+// item обслуживает /attachments/{id}.
+func (h *attachmentHandler) item(w http.ResponseWriter, r *http.Request, s app.Storager) (int, interface{}, error) {
+	id := strings.TrimPrefix(r.URL.Path, "/"+LocationAttachments+"/")
+
+	err := server.ValidateUUID("ID", id)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		a, err := s.GetAttachment(r.Context(), id)
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusOK, a, nil
+	case http.MethodDelete:
+		err := s.DeleteAttachment(r.Context(), id)
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusNoContent, nil, nil
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		return 0, nil, &RequestError{Status: http.StatusMethodNotAllowed, Err: ErrMethodNotAllowed}
+	}
+}
+
+// content отдает содержимое /attachments/{id}/content. Содержимое ссылки
+// отдается перенаправлением на ее URL.
+func (h *attachmentHandler) content(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"+LocationAttachments+"/"), "/"+locationContent)
+
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeProblem(w, r, &RequestError{Status: http.StatusMethodNotAllowed, Err: ErrMethodNotAllowed})
+		return
+	}
+
+	err := server.ValidateUUID("ID", id)
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+
+	a, err := h.storage.GetAttachment(r.Context(), id)
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+
+	if !a.Stored() {
+		http.Redirect(w, r, a.URL, http.StatusFound)
+		return
+	}
+
+	content, err := h.storage.OpenContent(r.Context(), a)
+	if errors.Is(err, attachment.ErrNotExist) {
+		err = &RequestError{Status: http.StatusNotFound, Err: err}
+	}
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+	defer content.Close()
+
+	contentType := a.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Name}))
+	w.WriteHeader(http.StatusOK)
+
+	// Ответ уже начат, поэтому обрыв копирования виден клиенту только по длине тела.
+	_, _ = io.Copy(w, content)
+}
@@ -0,0 +1,159 @@
+package internalhttp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/attachment"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachmentsHandler(t *testing.T) {
+	ctx := context.Background()
+
+	s := attachment.NewStorage(memorystorage.New(), attachment.NewFSStore(t.TempDir()), mocks.NewLogger(t))
+	h := AttachmentsHandler(s, 16)
+
+	eventID, err := s.CreateEvent(ctx, storage.Event{
+		Title:     "planning",
+		DateStart: "2024-04-02 10:00:00",
+		DateEnd:   "2024-04-02 11:00:00",
+		UserID:    testUserID,
+	})
+	require.NoError(t, err)
+
+	serve := func(method string, target string, contentType string, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, strings.NewReader(body))
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		return w
+	}
+
+	var uploaded storage.Attachment
+
+	t.Run("upload and download", func(t *testing.T) {
+		w := serve(http.MethodPost, "/attachments/upload?event_id="+eventID+"&name=agenda.txt", "text/plain", "agenda")
+
+		require.Equal(t, http.StatusCreated, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &uploaded))
+		require.Equal(t, "/attachments/"+uploaded.ID, w.Header().Get("Location"))
+		require.Equal(t, int64(6), uploaded.Size)
+		require.Empty(t, uploaded.URL)
+
+		w = serve(http.MethodGet, "/attachments/"+uploaded.ID+"/content", "", "")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+		require.Equal(t, `attachment; filename=agenda.txt`, w.Header().Get("Content-Disposition"))
+		require.Equal(t, "agenda", w.Body.String())
+	})
+
+	t.Run("upload too large", func(t *testing.T) {
+		w := serve(http.MethodPost, "/attachments/upload?event_id="+eventID+"&name=big.bin", "", strings.Repeat("x", 17))
+
+		require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		decodeProblem(t, w)
+	})
+
+	t.Run("upload validation", func(t *testing.T) {
+		w := serve(http.MethodPost, "/attachments/upload?event_id="+eventID, "", "agenda")
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		p := decodeProblem(t, w)
+		require.Equal(t, "Name", p.InvalidParams[0].Name)
+	})
+
+	t.Run("link redirects", func(t *testing.T) {
+		w := serve(http.MethodPost, "/attachments", "application/json",
+			`{"eventId": "`+eventID+`", "name": "docs", "url": "https://example.com/docs"}`)
+
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var created CreatedResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+		w = serve(http.MethodGet, "/attachments/"+created.ID+"/content", "", "")
+
+		require.Equal(t, http.StatusFound, w.Code)
+		require.Equal(t, "https://example.com/docs", w.Header().Get("Location"))
+	})
+
+	t.Run("link validation", func(t *testing.T) {
+		w := serve(http.MethodPost, "/attachments", "application/json", `{"eventId": "`+eventID+`", "name": "docs"}`)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		p := decodeProblem(t, w)
+		require.Equal(t, "URL", p.InvalidParams[0].Name)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		w := serve(http.MethodGet, "/attachments?event_id="+eventID, "", "")
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var result []storage.Attachment
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		require.Len(t, result, 2)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		w := serve(http.MethodDelete, "/attachments/"+uploaded.ID, "", "")
+		require.Equal(t, http.StatusNoContent, w.Code)
+
+		w = serve(http.MethodGet, "/attachments/"+uploaded.ID, "", "")
+		require.Equal(t, http.StatusNotFound, w.Code)
+
+		w = serve(http.MethodGet, "/attachments/"+uploaded.ID+"/content", "", "")
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestAttachmentsSlowUpload(t *testing.T) {
+	s := attachment.NewStorage(memorystorage.New(), attachment.NewFSStore(t.TempDir()), mocks.NewLogger(t))
+
+	eventID, err := s.CreateEvent(context.Background(), storage.Event{
+		Title:     "planning",
+		DateStart: "2024-04-02 10:00:00",
+		DateEnd:   "2024-04-02 11:00:00",
+		UserID:    testUserID,
+	})
+	require.NoError(t, err)
+
+	// Общий срок чтения короче загрузки: обработчик продлевает его.
+	ts := httptest.NewUnstartedServer(AttachmentsHandler(s, 16))
+	ts.Config.ReadTimeout = 50 * time.Millisecond
+	ts.Start()
+	defer ts.Close()
+
+	body, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte("agen"))
+		time.Sleep(200 * time.Millisecond)
+		pw.Write([]byte("da"))
+		pw.Close()
+	}()
+
+	resp, err := http.Post(ts.URL+"/attachments/upload?event_id="+eventID+"&name=slow.txt", "text/plain", body)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var a storage.Attachment
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&a))
+	require.Equal(t, int64(6), a.Size)
+}
@@ -0,0 +1,58 @@
+package internalhttp
+
+import (
+	"net/http"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+type BatchRequest struct {
+	Operations []storage.BatchOperation `json:"operations"`
+}
+
+type BatchResponse struct {
+	Results []storage.BatchResult `json:"results"`
+}
+
+// batchFailure передает в ответ об ошибке результаты операций откатанного пакета.
+type batchFailure struct {
+	err     error
+	results []storage.BatchResult
+}
+
+func (e *batchFailure) Error() string {
+	return e.err.Error()
+}
+
+func (e *batchFailure) Unwrap() error {
+	return e.err
+}
+
+// eventBatch применяет операции create/update/delete атомарно: либо все, либо ни одной.
+func eventBatch(w http.ResponseWriter, r *http.Request, s app.Storager) (int, interface{}, error) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		return 0, nil, &RequestError{Status: http.StatusMethodNotAllowed, Err: ErrMethodNotAllowed}
+	}
+
+	req := BatchRequest{}
+
+	err := decodeJSONBody(r, &req)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	err = server.ValidateBatch(req.Operations)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	results, err := s.ApplyBatch(r.Context(), req.Operations)
+	if err != nil {
+		return 0, nil, &batchFailure{err: err, results: results}
+	}
+
+	return http.StatusOK, BatchResponse{Results: results}, nil
+}
@@ -0,0 +1,80 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const validBatchJSON = `{"operations": [
+	{"op": "create", "event": ` + validEventJSON + `},
+	{"op": "delete", "id": "` + testEventID + `"}
+]}`
+
+func TestEventBatchResource(t *testing.T) {
+	t.Run("applied", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("ApplyBatch", mock.Anything, mock.AnythingOfType("[]storage.BatchOperation")).Return([]storage.BatchResult{
+			{Index: 0, Op: storage.BatchCreate, ID: "new", Status: storage.BatchStatusApplied},
+			{Index: 1, Op: storage.BatchDelete, ID: testEventID, Status: storage.BatchStatusApplied},
+		}, nil)
+
+		w := serveREST(s, http.MethodPost, "/"+LocationEventsBatch, validBatchJSON)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp BatchResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Results, 2)
+		require.Equal(t, "new", resp.Results[0].ID)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+
+		w := serveREST(s, http.MethodPost, "/"+LocationEventsBatch, `{"operations": [
+			{"op": "create", "event": `+validEventJSON+`},
+			{"op": "update", "id": "not-uuid", "event": `+validEventJSON+`},
+			{"op": "move"}
+		]}`)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		p := decodeProblem(t, w)
+		names := make([]string, 0, len(p.InvalidParams))
+		for _, param := range p.InvalidParams {
+			names = append(names, param.Name)
+		}
+		require.Equal(t, []string{"operations[1].ID", "operations[2].Op"}, names)
+		s.AssertNotCalled(t, "ApplyBatch")
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+
+		w := serveREST(s, http.MethodPost, "/"+LocationEventsBatch, `{"operations": []}`)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("rolled back", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("ApplyBatch", mock.Anything, mock.AnythingOfType("[]storage.BatchOperation")).Return([]storage.BatchResult{
+			{Index: 0, Op: storage.BatchCreate, Status: storage.BatchStatusRolledBack},
+			{Index: 1, Op: storage.BatchDelete, ID: testEventID, Status: storage.BatchStatusFailed, Error: "not found"},
+		}, &storage.BatchError{Index: 1, Err: storage.ErrEventNotExist})
+
+		w := serveREST(s, http.MethodPost, "/"+LocationEventsBatch, validBatchJSON)
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+
+		p := decodeProblem(t, w)
+		require.Len(t, p.Results, 2)
+		require.Equal(t, storage.BatchStatusFailed, p.Results[1].Status)
+	})
+}
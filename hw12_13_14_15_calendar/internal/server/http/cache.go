@@ -0,0 +1,22 @@
+package internalhttp
+
+import (
+	"net/http"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/cache"
+)
+
+const LocationCacheStats = "cache/stats"
+
+// CacheStatsHandler отдает статистику кэша событий: попадания, промахи, вытеснения и размер.
+func CacheStatsHandler(stats func() cache.Stats) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			writeProblem(w, r, &RequestError{Status: http.StatusMethodNotAllowed, Err: ErrMethodNotAllowed})
+			return
+		}
+
+		writeJSON(w, r, http.StatusOK, stats())
+	})
+}
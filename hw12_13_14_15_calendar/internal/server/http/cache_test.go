@@ -0,0 +1,37 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/cache"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheStatsHandler(t *testing.T) {
+	h := CacheStatsHandler(func() cache.Stats {
+		return cache.Stats{Hits: 3, Misses: 1, Evictions: 2, Size: 5, HitRatio: 0.75}
+	})
+
+	t.Run("get", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/"+LocationCacheStats, nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+		var stats cache.Stats
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+		require.Equal(t, cache.Stats{Hits: 3, Misses: 1, Evictions: 2, Size: 5, HitRatio: 0.75}, stats)
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/"+LocationCacheStats, nil))
+
+		require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		require.Equal(t, http.MethodGet, rec.Header().Get("Allow"))
+	})
+}
@@ -0,0 +1,182 @@
+package internalhttp
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+func eventCollection(w http.ResponseWriter, r *http.Request, s app.Storager) (int, interface{}, error) {
+	switch r.Method {
+	case http.MethodGet:
+		return listEvents(r, s)
+	case http.MethodPost:
+		return postEvent(w, r, s)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		return 0, nil, &RequestError{Status: http.StatusMethodNotAllowed, Err: ErrMethodNotAllowed}
+	}
+}
+
+func eventItem(w http.ResponseWriter, r *http.Request, s app.Storager) (int, interface{}, error) {
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"+LocationEvents+"/"), "/")
+	if id == "" || strings.Contains(action, "/") {
+		return 0, nil, &RequestError{Status: http.StatusNotFound, Err: ErrNotFound}
+	}
+
+	err := server.ValidateDeleteEvent(storage.Event{ID: id})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if action != "" {
+		return eventReminder(w, r, s, id, action)
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		e, err := s.GetEvent(r.Context(), id)
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusOK, e, nil
+	case http.MethodPut:
+		return putEvent(r, s, id)
+	case http.MethodPatch:
+		return patchEvent(r, s, id)
+	case http.MethodDelete:
+		err := s.DeleteEvent(r.Context(), id)
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusNoContent, nil, nil
+	default:
+		w.Header().Set("Allow", "GET, PUT, PATCH, DELETE")
+		return 0, nil, &RequestError{Status: http.StatusMethodNotAllowed, Err: ErrMethodNotAllowed}
+	}
+}
+
+func listEvents(r *http.Request, s app.Storager) (int, interface{}, error) {
+	query := r.URL.Query()
+
+	lm := storage.ListEventRangeValidation{
+		DateFrom: query.Get("from"),
+		DateTo:   query.Get("to"),
+	}
+
+	err := server.ValidateListEventRange(lm)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	tf := storage.ListEventTagValidation{Tags: query["tag"]}
+
+	err = server.ValidateTagFilter(tf)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var events []storage.Event
+
+	// Несколько параметров tag отбирают события, у которых есть все указанные метки.
+	if len(tf.Tags) > 0 {
+		events, err = s.ListEventTagged(r.Context(), tf.Tags, lm.DateFrom, lm.DateTo)
+	} else {
+		events, err = s.ListEventRange(r.Context(), lm.DateFrom, lm.DateTo)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	userID := query.Get("user_id")
+	if userID == "" {
+		return http.StatusOK, events, nil
+	}
+
+	filtered := make([]storage.Event, 0, len(events))
+	for _, e := range events {
+		if e.UserID == userID {
+			filtered = append(filtered, e)
+		}
+	}
+
+	return http.StatusOK, filtered, nil
+}
+
+func postEvent(w http.ResponseWriter, r *http.Request, s app.Storager) (int, interface{}, error) {
+	e := storage.Event{}
+
+	err := decodeJSONBody(r, &e)
+	if err != nil {
+		return 0, nil, err
+	}
+	e.ID = ""
+
+	err = server.ValidateCreateEvent(e)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	id, err := s.CreateEvent(r.Context(), e)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/%s/%s", LocationEvents, id))
+
+	return http.StatusCreated, CreatedResponse{ID: id}, nil
+}
+
+// putEvent заменяет событие целиком: поля, которых нет в теле, становятся пустыми.
+func putEvent(r *http.Request, s app.Storager, id string) (int, interface{}, error) {
+	e := storage.Event{}
+
+	err := decodeJSONBody(r, &e)
+	if err != nil {
+		return 0, nil, err
+	}
+	e.ID = id
+
+	return saveEvent(r, s, e)
+}
+
+// patchEvent меняет только переданные поля события.
+func patchEvent(r *http.Request, s app.Storager, id string) (int, interface{}, error) {
+	update := EventUpdate{}
+
+	err := decodeJSONBody(r, &update)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	e, err := s.GetEvent(r.Context(), id)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	updateEventFields(&e, &update)
+	e.ID = id
+
+	return saveEvent(r, s, e)
+}
+
+func saveEvent(r *http.Request, s app.Storager, e storage.Event) (int, interface{}, error) {
+	err := server.ValidateUpdateEvent(e)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	err = s.UpdateEvent(r.Context(), e.ID, e)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Ответ совпадает с тем, что сохранило хранилище.
+	e.Normalize()
+
+	return http.StatusOK, e, nil
+}
@@ -0,0 +1,393 @@
+package internalhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testEventID = "eb0af540-6f23-4305-a719-fb65271fca1f"
+	testUserID  = "d5095366-ea13-4c9d-ae72-9c83d2d93040"
+)
+
+const validEventJSON = `{
+	"title": "Test Event",
+	"dateStart": "2022-10-11 12:00:00",
+	"dateEnd": "2022-10-11 13:00:00",
+	"userId": "d5095366-ea13-4c9d-ae72-9c83d2d93040",
+	"datePost": "2022-10-10 12:00:00"
+}`
+
+func serveREST(s *mocks.Storager, method, target, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+
+	w := httptest.NewRecorder()
+	NewMux(s).ServeHTTP(w, r)
+
+	return w
+}
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) Problem {
+	t.Helper()
+
+	require.Equal(t, ContentTypeProblem, w.Header().Get("Content-Type"))
+
+	var p Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	require.Equal(t, w.Code, p.Status)
+
+	return p
+}
+
+func TestCreateEventResource(t *testing.T) {
+	t.Run("created", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("CreateEvent", mock.Anything, mock.AnythingOfType("storage.Event")).Return(testEventID, nil)
+
+		w := serveREST(s, http.MethodPost, "/events", validEventJSON)
+
+		require.Equal(t, http.StatusCreated, w.Code)
+		require.Equal(t, "/events/"+testEventID, w.Header().Get("Location"))
+		require.JSONEq(t, `{"id":"`+testEventID+`"}`, w.Body.String())
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+
+		w := serveREST(s, http.MethodPost, "/events", `{"title": "Test Event"}`)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		p := decodeProblem(t, w)
+		require.NotEmpty(t, p.InvalidParams)
+		s.AssertNotCalled(t, "CreateEvent")
+	})
+
+	t.Run("invalid dates", func(t *testing.T) {
+		cases := []struct {
+			name    string
+			dateEnd string
+		}{
+			{"end before start", "2022-10-11 11:00:00"},
+			{"too long", "2999-01-01 00:00:00"},
+		}
+
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				s := mocks.NewStorager(t)
+
+				body := strings.Replace(validEventJSON, "2022-10-11 13:00:00", tc.dateEnd, 1)
+				w := serveREST(s, http.MethodPost, "/events", body)
+
+				require.Equal(t, http.StatusBadRequest, w.Code)
+				p := decodeProblem(t, w)
+				require.Equal(t, "DateEnd", p.InvalidParams[0].Name)
+			})
+		}
+	})
+
+	t.Run("unsupported media type", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+
+		r := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(validEventJSON))
+		r.Header.Set("Content-Type", "text/plain")
+		w := httptest.NewRecorder()
+		NewMux(s).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		decodeProblem(t, w)
+	})
+
+	t.Run("date busy", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("CreateEvent", mock.Anything, mock.AnythingOfType("storage.Event")).Return("", storage.ErrDateBusy)
+
+		w := serveREST(s, http.MethodPost, "/events", validEventJSON)
+
+		require.Equal(t, http.StatusConflict, w.Code)
+		decodeProblem(t, w)
+	})
+}
+
+func TestEventItemResource(t *testing.T) {
+	t.Run("get", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("GetEvent", mock.Anything, testEventID).Return(storage.Event{ID: testEventID, Title: "Test Event"}, nil)
+
+		w := serveREST(s, http.MethodGet, "/events/"+testEventID, "")
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var e storage.Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &e))
+		require.Equal(t, "Test Event", e.Title)
+	})
+
+	t.Run("get not found", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("GetEvent", mock.Anything, testEventID).Return(storage.Event{}, storage.ErrEventNotExist)
+
+		w := serveREST(s, http.MethodGet, "/events/"+testEventID, "")
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		p := decodeProblem(t, w)
+		require.Equal(t, "/events/"+testEventID, p.Instance)
+	})
+
+	t.Run("get passes request context", func(t *testing.T) {
+		type ctxKey struct{}
+
+		s := mocks.NewStorager(t)
+		s.On("GetEvent", mock.MatchedBy(func(ctx context.Context) bool {
+			return ctx.Value(ctxKey{}) == "request"
+		}), testEventID).Return(storage.Event{}, context.DeadlineExceeded)
+
+		r := httptest.NewRequest(http.MethodGet, "/events/"+testEventID, nil)
+		r = r.WithContext(context.WithValue(r.Context(), ctxKey{}, "request"))
+		w := httptest.NewRecorder()
+		NewMux(s).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusGatewayTimeout, w.Code)
+	})
+
+	t.Run("get unavailable", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("GetEvent", mock.Anything, testEventID).Return(storage.Event{}, storage.ErrUnavailable)
+
+		w := serveREST(s, http.MethodGet, "/events/"+testEventID, "")
+
+		require.Equal(t, http.StatusServiceUnavailable, w.Code)
+		p := decodeProblem(t, w)
+		require.Equal(t, storage.ErrUnavailable.Error(), p.Detail)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+
+		w := serveREST(s, http.MethodGet, "/events/not-uuid", "")
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = serveREST(s, http.MethodGet, "/events/"+testEventID+"/extra", "")
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		existing := storage.Event{
+			ID:        testEventID,
+			Title:     "Test Event",
+			DateStart: "2022-10-11 12:00:00",
+			DateEnd:   "2022-10-11 13:00:00",
+			UserID:    testUserID,
+			DatePost:  "2022-10-10 12:00:00",
+		}
+
+		s := mocks.NewStorager(t)
+		s.On("GetEvent", mock.Anything, testEventID).Return(existing, nil)
+		s.On("UpdateEvent", mock.Anything, testEventID, mock.AnythingOfType("storage.Event")).Return(nil)
+
+		w := serveREST(s, http.MethodPatch, "/events/"+testEventID, `{"title": "Renamed"}`)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var e storage.Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &e))
+		require.Equal(t, "Renamed", e.Title)
+		require.Equal(t, existing.DateStart, e.DateStart)
+	})
+
+	t.Run("put replaces, patch merges", func(t *testing.T) {
+		existing := storage.Event{
+			ID:          testEventID,
+			Title:       "Test Event",
+			DateStart:   "2022-10-11 12:00:00",
+			DateEnd:     "2022-10-11 13:00:00",
+			Description: "agenda",
+			UserID:      testUserID,
+			DatePost:    "2022-10-10 12:00:00",
+			Tags:        []string{"work"},
+		}
+
+		s := mocks.NewStorager(t)
+		s.On("GetEvent", mock.Anything, testEventID).Return(existing, nil).Once()
+		s.On("UpdateEvent", mock.Anything, testEventID, mock.MatchedBy(func(e storage.Event) bool {
+			return e.Description == "agenda" && len(e.Tags) == 1
+		})).Return(nil).Once()
+		s.On("UpdateEvent", mock.Anything, testEventID, mock.MatchedBy(func(e storage.Event) bool {
+			return e.ID == testEventID && e.Description == "" && len(e.Tags) == 0
+		})).Return(nil).Once()
+
+		w := serveREST(s, http.MethodPatch, "/events/"+testEventID, `{"title": "Renamed"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = serveREST(s, http.MethodPut, "/events/"+testEventID, validEventJSON)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var e storage.Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &e))
+		require.Equal(t, "Test Event", e.Title)
+		require.Empty(t, e.Description, "put clears fields missing from the body")
+
+		// Неполное тело PUT не проходит проверку, хранилище не вызывается.
+		w = serveREST(s, http.MethodPut, "/events/"+testEventID, `{"title": "Renamed"}`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		decodeProblem(t, w)
+	})
+
+	t.Run("patch all-day", func(t *testing.T) {
+		existing := storage.Event{
+			ID:        testEventID,
+			Title:     "Test Event",
+			DateStart: "2022-10-11 12:00:00",
+			DateEnd:   "2022-10-12 13:00:00",
+			UserID:    testUserID,
+			DatePost:  "2022-10-10 12:00:00",
+			AllDay:    true,
+		}
+
+		s := mocks.NewStorager(t)
+		s.On("GetEvent", mock.Anything, testEventID).Return(existing, nil)
+		s.On("UpdateEvent", mock.Anything, testEventID, mock.MatchedBy(func(e storage.Event) bool {
+			return e.AllDay
+		})).Return(nil).Once()
+		s.On("UpdateEvent", mock.Anything, testEventID, mock.MatchedBy(func(e storage.Event) bool {
+			return !e.AllDay
+		})).Return(nil).Once()
+
+		// Отсутствующий allDay не меняет флаг.
+		w := serveREST(s, http.MethodPatch, "/events/"+testEventID, `{"title": "Renamed"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var e storage.Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &e))
+		require.True(t, e.AllDay)
+		require.Equal(t, "2022-10-11 00:00:00", e.DateStart)
+		require.Equal(t, "2022-10-13 00:00:00", e.DateEnd)
+
+		w = serveREST(s, http.MethodPatch, "/events/"+testEventID, `{"allDay": false}`)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		e = storage.Event{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &e))
+		require.False(t, e.AllDay)
+		require.Equal(t, existing.DateStart, e.DateStart)
+	})
+
+	t.Run("update date busy", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("UpdateEvent", mock.Anything, testEventID, mock.AnythingOfType("storage.Event")).Return(storage.ErrDateBusy)
+
+		w := serveREST(s, http.MethodPut, "/events/"+testEventID, validEventJSON)
+
+		require.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("DeleteEvent", mock.Anything, testEventID).Return(nil)
+
+		w := serveREST(s, http.MethodDelete, "/events/"+testEventID, "")
+
+		require.Equal(t, http.StatusNoContent, w.Code)
+		require.Empty(t, w.Body.String())
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+
+		w := serveREST(s, http.MethodPost, "/events/"+testEventID, validEventJSON)
+
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		require.Equal(t, "GET, PUT, PATCH, DELETE", w.Header().Get("Allow"))
+	})
+}
+
+func TestListEventsResource(t *testing.T) {
+	events := []storage.Event{
+		{ID: "1", UserID: testUserID, DateStart: "2022-10-11 12:00:00"},
+		{ID: "2", UserID: "2a1b6c8e-5a0e-4a2b-9c5d-0e6f7a8b9c0d", DateStart: "2022-10-12 12:00:00"},
+	}
+
+	t.Run("range", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("ListEventRange", mock.Anything, "2022-10-10", "2022-10-16").Return(events, nil)
+
+		w := serveREST(s, http.MethodGet, "/events?from=2022-10-10&to=2022-10-16", "")
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var result []storage.Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		require.Len(t, result, 2)
+	})
+
+	t.Run("user filter", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("ListEventRange", mock.Anything, "2022-10-10", "2022-10-16").Return(events, nil)
+
+		w := serveREST(s, http.MethodGet, "/events?from=2022-10-10&to=2022-10-16&user_id="+testUserID, "")
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var result []storage.Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		require.Len(t, result, 1)
+		require.Equal(t, "1", result[0].ID)
+	})
+
+	t.Run("tag filter", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("ListEventTagged", mock.Anything, []string{"release", "1:1"}, "2022-10-10", "2022-10-16").
+			Return(events[:1], nil)
+
+		w := serveREST(s, http.MethodGet, "/events?from=2022-10-10&to=2022-10-16&tag=release&tag=1:1", "")
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var result []storage.Event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		require.Len(t, result, 1)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		cases := []string{
+			"/events",
+			"/events?from=2022-10-10",
+			"/events?from=2022.10.10&to=2022-10-16",
+			"/events?from=2022-10-16&to=2022-10-10",
+			"/events?from=2022-10-10&to=2022-10-16&tag=a,b",
+			"/events?from=2022-10-10&to=2022-10-16&tag=",
+		}
+
+		for _, target := range cases {
+			s := mocks.NewStorager(t)
+
+			w := serveREST(s, http.MethodGet, target, "")
+
+			require.Equal(t, http.StatusBadRequest, w.Code, target)
+			decodeProblem(t, w)
+			s.AssertNotCalled(t, "ListEventRange")
+		}
+	})
+}
+
+func TestLegacyRoutesDeprecated(t *testing.T) {
+	s := mocks.NewStorager(t)
+	s.On("CreateEvent", mock.Anything, mock.AnythingOfType("storage.Event")).Return(testEventID, nil)
+
+	w := serveREST(s, http.MethodPost, "/"+LocationCreate, validEventJSON)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "true", w.Header().Get("Deprecation"))
+	require.JSONEq(t, `{"error":"","data":{"id":"`+testEventID+`"}}`, w.Body.String())
+}
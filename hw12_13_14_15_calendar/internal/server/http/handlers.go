@@ -1,6 +1,7 @@
 package internalhttp
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 
@@ -10,11 +11,18 @@ import (
 )
 
 type UpdateRequest struct {
-	ID    string        `json:"id"`
-	Event storage.Event `json:"event"`
+	ID    string      `json:"id"`
+	Event EventUpdate `json:"event"`
 }
 
-type ListHandlerFunc func(date string) ([]storage.Event, error)
+// EventUpdate - новые значения полей события. AllDay задан указателем,
+// чтобы отличить отсутствующее поле от false.
+type EventUpdate struct {
+	storage.Event
+	AllDay *bool `json:"allDay"`
+}
+
+type ListHandlerFunc func(ctx context.Context, date string) ([]storage.Event, error)
 
 func createEvent(w http.ResponseWriter, r *http.Request, s app.Storager) (interface{}, error) {
 	e := storage.Event{}
@@ -29,13 +37,13 @@ func createEvent(w http.ResponseWriter, r *http.Request, s app.Storager) (interf
 		return nil, err
 	}
 
-	err = s.CreateEvent(e)
+	id, err := s.CreateEvent(r.Context(), e)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return nil, err
 	}
 
-	return nil, nil
+	return CreatedResponse{ID: id}, nil
 }
 
 func updateEvent(w http.ResponseWriter, r *http.Request, s app.Storager) (interface{}, error) {
@@ -48,7 +56,7 @@ func updateEvent(w http.ResponseWriter, r *http.Request, s app.Storager) (interf
 	id := ur.ID
 	update := ur.Event
 
-	e, err := s.GetEvent(id)
+	e, err := s.GetEvent(r.Context(), id)
 	if err != nil {
 		return nil, err
 	}
@@ -61,7 +69,7 @@ func updateEvent(w http.ResponseWriter, r *http.Request, s app.Storager) (interf
 		return nil, err
 	}
 
-	err = s.UpdateEvent(e.ID, e)
+	err = s.UpdateEvent(r.Context(), e.ID, e)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return nil, err
@@ -83,7 +91,7 @@ func deleteEvent(w http.ResponseWriter, r *http.Request, s app.Storager) (interf
 		return nil, err
 	}
 
-	err = s.DeleteEvent(e.ID)
+	err = s.DeleteEvent(r.Context(), e.ID)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return nil, err
@@ -135,10 +143,10 @@ func listEvent(w http.ResponseWriter, r *http.Request, f ListHandlerFunc) ([]sto
 		return nil, err
 	}
 
-	return f(lm.DateStart)
+	return f(r.Context(), lm.DateStart)
 }
 
-func updateEventFields(e *storage.Event, changed *storage.Event) {
+func updateEventFields(e *storage.Event, changed *EventUpdate) {
 	if changed.Title != "" && changed.Title != e.Title {
 		e.Title = changed.Title
 	}
@@ -157,4 +165,21 @@ func updateEventFields(e *storage.Event, changed *storage.Event) {
 	if changed.DatePost != "" && changed.DatePost != e.DatePost {
 		e.DatePost = changed.DatePost
 	}
+	if changed.Category != "" {
+		e.Category = changed.Category
+	}
+	if changed.Color != "" {
+		e.Color = changed.Color
+	}
+	// Пустой список снимает все метки, отсутствующий оставляет их без изменений.
+	if changed.Tags != nil {
+		e.Tags = changed.Tags
+	}
+	// Ресурсы, как и метки, снимаются пустым списком.
+	if changed.Resources != nil {
+		e.Resources = changed.Resources
+	}
+	if changed.AllDay != nil {
+		e.AllDay = *changed.AllDay
+	}
 }
@@ -165,6 +165,22 @@ func TestMiddleware(t *testing.T) {
 		}
 	})
 
+	t.Run("non legacy routes are passed through", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/v1/events/9723a4b7-4c61-4ae5-97c6-6bf536badf48", nil)
+
+		mw := Middleware{}
+		mux := http.NewServeMux()
+		mux.Handle("/v1/", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		handler := MiddlewareChain(mw.requestValidatorMiddleware)(mux)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+
 	t.Run("content-type header test", func(t *testing.T) {
 		cases := []struct {
 			val string
@@ -185,7 +201,7 @@ func TestMiddleware(t *testing.T) {
 			mw := Middleware{}
 			s := mocks.NewStorager(t)
 			if !tc.err {
-				s.On("DeleteEvent", mock.AnythingOfType("string")).Return(nil)
+				s.On("DeleteEvent", mock.Anything, mock.AnythingOfType("string")).Return(nil)
 			}
 
 			mux := NewMux(s)
@@ -201,11 +217,36 @@ func TestMiddleware(t *testing.T) {
 				continue
 			}
 			assert.Equal(t, http.StatusOK, w.Code)
-			s.AssertCalled(t, "DeleteEvent", mock.AnythingOfType("string"))
+			s.AssertCalled(t, "DeleteEvent", mock.Anything, mock.AnythingOfType("string"))
 		}
 	})
 }
 
+func TestRateLimit(t *testing.T) {
+	s := NewServer(mocks.NewLogger(t), nil, "")
+	s.SetRateLimit(1, 2)
+
+	mw := Middleware{limiter: s.limiter}
+	handler := mw.rateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	codes := make([]int, 0, 3)
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+LocationListDay, nil))
+		codes = append(codes, w.Code)
+	}
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+
+	s.SetRateLimit(0, 0)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+LocationListDay, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
 func TestCreateEventHandler(t *testing.T) {
 	t.Run("handler validation", func(t *testing.T) {
 		s := mocks.NewStorager(t)
@@ -219,7 +260,7 @@ func TestCreateEventHandler(t *testing.T) {
 			r := httptest.NewRequest(http.MethodPost, "/create", strings.NewReader(string(jData)))
 			w := httptest.NewRecorder()
 
-			s.On("CreateEvent", mock.AnythingOfType("storage.Event")).Return(nil)
+			s.On("CreateEvent", mock.Anything, mock.AnythingOfType("storage.Event")).Return("eb0af540-6f23-4305-a719-fb65271fca1f", nil)
 
 			_, err = createEvent(w, r, s)
 			if tc.err {
@@ -230,7 +271,7 @@ func TestCreateEventHandler(t *testing.T) {
 			}
 			assert.Equal(t, http.StatusOK, w.Code)
 			assert.NoError(t, err)
-			s.AssertCalled(t, "CreateEvent", mock.AnythingOfType("storage.Event"))
+			s.AssertCalled(t, "CreateEvent", mock.Anything, mock.AnythingOfType("storage.Event"))
 		}
 	})
 }
@@ -242,7 +283,7 @@ func TestUpdateEventHandler(t *testing.T) {
 		for _, tc := range createUpdateCases {
 			jData, err := json.Marshal(&UpdateRequest{
 				ID:    "eb0af540-6f23-4305-a719-fb65271fca1f",
-				Event: tc.event,
+				Event: EventUpdate{Event: tc.event},
 			})
 			if err != nil {
 				t.Errorf("json encode error")
@@ -251,11 +292,11 @@ func TestUpdateEventHandler(t *testing.T) {
 			r := httptest.NewRequest(http.MethodPost, "/update", strings.NewReader(string(jData)))
 			w := httptest.NewRecorder()
 
-			s.On("GetEvent", mock.AnythingOfType("string")).Return(storage.Event{
+			s.On("GetEvent", mock.Anything, mock.AnythingOfType("string")).Return(storage.Event{
 				ID: "eb0af540-6f23-4305-a719-fb65271fca1f",
 			}, nil)
 
-			s.On("UpdateEvent", mock.AnythingOfType("string"), mock.AnythingOfType("storage.Event")).Return(nil)
+			s.On("UpdateEvent", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("storage.Event")).Return(nil)
 
 			_, err = updateEvent(w, r, s)
 			if tc.err {
@@ -266,7 +307,7 @@ func TestUpdateEventHandler(t *testing.T) {
 			}
 			assert.Equal(t, http.StatusOK, w.Code)
 			assert.NoError(t, err)
-			s.AssertCalled(t, "UpdateEvent", mock.AnythingOfType("string"), mock.AnythingOfType("storage.Event"))
+			s.AssertCalled(t, "UpdateEvent", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("storage.Event"))
 		}
 	})
 }
@@ -290,7 +331,7 @@ func TestDeleteEventHandler(t *testing.T) {
 			r := httptest.NewRequest(http.MethodDelete, "/delete", strings.NewReader(requestBody))
 			w := httptest.NewRecorder()
 
-			s.On("DeleteEvent", mock.AnythingOfType("string")).Return(nil)
+			s.On("DeleteEvent", mock.Anything, mock.AnythingOfType("string")).Return(nil)
 
 			_, err := deleteEvent(w, r, s)
 
@@ -301,7 +342,7 @@ func TestDeleteEventHandler(t *testing.T) {
 			} else {
 				assert.Equal(t, http.StatusOK, w.Code)
 				assert.NoError(t, err)
-				s.AssertCalled(t, "DeleteEvent", mock.AnythingOfType("string"))
+				s.AssertCalled(t, "DeleteEvent", mock.Anything, mock.AnythingOfType("string"))
 			}
 		}
 	})
@@ -352,7 +393,7 @@ func listsHandlerTest(t *testing.T, method string, f HandlerFunc) {
 		r := httptest.NewRequest(http.MethodGet, "/"+LocationListDay, strings.NewReader(string(jData)))
 		w := httptest.NewRecorder()
 
-		s.On(method, mock.AnythingOfType("string")).Return([]storage.Event{}, nil)
+		s.On(method, mock.Anything, mock.AnythingOfType("string")).Return([]storage.Event{}, nil)
 
 		_, err = f(w, r, s)
 		if tc.err {
@@ -363,6 +404,6 @@ func listsHandlerTest(t *testing.T, method string, f HandlerFunc) {
 		}
 		assert.Equal(t, http.StatusOK, w.Code)
 		assert.NoError(t, err)
-		s.AssertCalled(t, method, mock.AnythingOfType("string"))
+		s.AssertCalled(t, method, mock.Anything, mock.AnythingOfType("string"))
 	}
 }
@@ -0,0 +1,89 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/jobs"
+)
+
+const (
+	LocationJobs   = "jobs"
+	locationJobRun = "run"
+)
+
+// JobRunner - задачи планировщика, доступные через административный API.
+type JobRunner interface {
+	Active() bool
+	Jobs() []jobs.Status
+	Trigger(name string) error
+}
+
+type JobsResponse struct {
+	// Active - выполняет ли реплика задачи: при нескольких репликах задачи запускает только лидер.
+	Active bool          `json:"active"`
+	Jobs   []jobs.Status `json:"jobs"`
+}
+
+// JobsHandler обслуживает GET /jobs со списком задач и POST /jobs/{name}/run
+// для запуска задачи вне расписания.
+func JobsHandler(runner JobRunner) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/"+LocationJobs), "/")
+
+		if path == "" {
+			if r.Method != http.MethodGet {
+				w.Header().Set("Allow", http.MethodGet)
+				writeProblem(w, r, &RequestError{Status: http.StatusMethodNotAllowed, Err: ErrMethodNotAllowed})
+				return
+			}
+
+			writeJSON(w, r, http.StatusOK, JobsResponse{Active: runner.Active(), Jobs: runner.Jobs()})
+			return
+		}
+
+		parts := strings.Split(path, "/")
+		if len(parts) != 2 || parts[1] != locationJobRun {
+			writeProblem(w, r, &RequestError{Status: http.StatusNotFound, Err: ErrNotFound})
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeProblem(w, r, &RequestError{Status: http.StatusMethodNotAllowed, Err: ErrMethodNotAllowed})
+			return
+		}
+
+		err := runner.Trigger(parts[0])
+		if err != nil {
+			writeProblem(w, r, jobError(err))
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
+
+func jobError(err error) error {
+	switch {
+	case errors.Is(err, jobs.ErrUnknownJob):
+		return &RequestError{Status: http.StatusNotFound, Err: err}
+	case errors.Is(err, jobs.ErrJobRunning), errors.Is(err, jobs.ErrNotActive):
+		return &RequestError{Status: http.StatusConflict, Err: err}
+	default:
+		return err
+	}
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	jData, err := json.Marshal(data)
+	if err != nil {
+		writeProblem(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(jData)
+}
@@ -0,0 +1,86 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/jobs"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRunner struct {
+	active    bool
+	triggered []string
+}
+
+func (f *fakeRunner) Active() bool {
+	return f.active
+}
+
+func (f *fakeRunner) Jobs() []jobs.Status {
+	return []jobs.Status{{Name: "purge", Schedule: "@hourly", Runs: 2, LastResult: jobs.ResultOK}}
+}
+
+func (f *fakeRunner) Trigger(name string) error {
+	switch {
+	case name != "purge":
+		return fmt.Errorf("%w: %s", jobs.ErrUnknownJob, name)
+	case !f.active:
+		return jobs.ErrNotActive
+	}
+
+	f.triggered = append(f.triggered, name)
+
+	return nil
+}
+
+func TestJobsHandler(t *testing.T) {
+	runner := &fakeRunner{active: true}
+	h := JobsHandler(runner)
+
+	t.Run("list", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/"+LocationJobs, nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var resp JobsResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.True(t, resp.Active)
+		require.Equal(t, runner.Jobs(), resp.Jobs)
+	})
+
+	cases := []struct {
+		name   string
+		method string
+		target string
+		active bool
+		status int
+	}{
+		{"trigger", http.MethodPost, "/jobs/purge/run", true, http.StatusAccepted},
+		{"unknown job", http.MethodPost, "/jobs/digest/run", true, http.StatusNotFound},
+		{"standby replica", http.MethodPost, "/jobs/purge/run", false, http.StatusConflict},
+		{"trigger by get", http.MethodGet, "/jobs/purge/run", true, http.StatusMethodNotAllowed},
+		{"post list", http.MethodPost, "/jobs", true, http.StatusMethodNotAllowed},
+		{"bad path", http.MethodPost, "/jobs/purge", true, http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			runner.active = tc.active
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.target, nil))
+
+			require.Equal(t, tc.status, rec.Code)
+			if tc.status != http.StatusAccepted {
+				require.Equal(t, ContentTypeProblem, rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+
+	require.Equal(t, []string{"purge"}, runner.triggered)
+}
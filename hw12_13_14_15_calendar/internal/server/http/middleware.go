@@ -1,12 +1,16 @@
 package internalhttp
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
-	"path"
 	"strings"
 	"time"
+
+	"golang.org/x/time/rate"
 )
 
 type LogResponseWriter struct {
@@ -32,6 +36,23 @@ func (w *LogResponseWriter) WriteHeader(statusCode int) {
 	w.ResponseWriter.WriteHeader(statusCode)
 }
 
+// Unwrap нужен http.ResponseController для доступа к Flush исходного ResponseWriter.
+func (w *LogResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Hijack нужен для перехода на WebSocket.
+func (w *LogResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+
+	w.statusCode = http.StatusSwitchingProtocols
+
+	return h.Hijack()
+}
+
 func (mw *Middleware) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		lrw := NewLogResponseWriter(w)
@@ -53,15 +74,35 @@ func (mw *Middleware) loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+func (mw *Middleware) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if mw.limiter != nil && !mw.limiter.Allow() {
+			w.WriteHeader(http.StatusTooManyRequests)
+
+			err := WriteResponse(w, Response{Error: http.StatusText(http.StatusTooManyRequests)})
+			if err != nil {
+				mw.logger.Error(err.Error())
+			}
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (mw *Middleware) requestValidatorMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var err error
 
 		resp := Response{}
 
-		location := path.Base(r.URL.Path)
+		method, ok := locationVerbMap[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
 
-		if locationVerbMap[location] != r.Method {
+		if method != r.Method {
 			w.WriteHeader(http.StatusMethodNotAllowed)
 			resp.Error = http.StatusText(http.StatusMethodNotAllowed)
 
@@ -115,5 +156,6 @@ func MiddlewareChain(handlers ...func(http.Handler) http.Handler) func(http.Hand
 }
 
 type Middleware struct {
-	logger Logger
+	logger  Logger
+	limiter *rate.Limiter
 }
@@ -22,26 +22,70 @@ const (
 	LocationListDay   = "list-day"
 	LocationListWeek  = "list-week"
 	LocationListMonth = "list-month"
+
+	LocationEvents      = "events"
+	LocationEventsBatch = "events/batch"
+	LocationWebhooks    = "webhooks"
+	LocationUsers       = "users"
+	LocationTags        = "tags"
+
+	LocationResources             = "resources"
+	LocationResourcesAvailability = "resources/availability"
+	LocationResourcesFreeRooms    = "resources/free-rooms"
 )
 
 func NewMux(s app.Storager) *http.ServeMux {
 	mux := http.NewServeMux()
 
-	mux.Handle("/"+LocationCreate, handleRequest(createEvent, s))
+	mux.Handle("/"+LocationEvents, handleResource(eventCollection, s))
+
+	mux.Handle("/"+LocationEvents+"/", handleResource(eventItem, s))
+
+	mux.Handle("/"+LocationEventsBatch, handleResource(eventBatch, s))
+
+	mux.Handle("/"+LocationWebhooks, handleResource(webhookCollection, s))
+
+	mux.Handle("/"+LocationWebhooks+"/", handleResource(webhookItem, s))
+
+	mux.Handle("/"+LocationUsers+"/", handleResource(userPreferences, s))
+
+	mux.Handle("/"+LocationTags, handleResource(tagCollection, s))
+
+	mux.Handle("/"+LocationTags+"/", handleResource(tagItem, s))
+
+	mux.Handle("/"+LocationResources, handleResource(resourceCollection, s))
 
-	mux.Handle("/"+LocationUpdate, handleRequest(updateEvent, s))
+	mux.Handle("/"+LocationResources+"/", handleResource(resourceItem, s))
 
-	mux.Handle("/"+LocationDelete, handleRequest(deleteEvent, s))
+	mux.Handle("/"+LocationResourcesAvailability, handleResource(resourceAvailability, s))
 
-	mux.Handle("/"+LocationListDay, handleRequest(listEventDay, s))
+	mux.Handle("/"+LocationResourcesFreeRooms, handleResource(freeRooms, s))
 
-	mux.Handle("/"+LocationListWeek, handleRequest(listEventWeek, s))
+	// Маршруты в стиле RPC оставлены для совместимости, замена - /events.
+	mux.Handle("/"+LocationCreate, deprecated(handleRequest(createEvent, s)))
 
-	mux.Handle("/"+LocationListMonth, handleRequest(listEventMonth, s))
+	mux.Handle("/"+LocationUpdate, deprecated(handleRequest(updateEvent, s)))
+
+	mux.Handle("/"+LocationDelete, deprecated(handleRequest(deleteEvent, s)))
+
+	mux.Handle("/"+LocationListDay, deprecated(handleRequest(listEventDay, s)))
+
+	mux.Handle("/"+LocationListWeek, deprecated(handleRequest(listEventWeek, s)))
+
+	mux.Handle("/"+LocationListMonth, deprecated(handleRequest(listEventMonth, s)))
 
 	return mux
 }
 
+func deprecated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "</"+LocationEvents+">; rel=\"successor-version\"")
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 func handleRequest(handler HandlerFunc, s app.Storager) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		resp := Response{}
@@ -0,0 +1,63 @@
+package internalhttp
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+const locationPreferences = "preferences"
+
+// userPreferences обслуживает /users/{id}/preferences. Пользователь без
+// сохраненных настроек получает настройки по умолчанию.
+func userPreferences(w http.ResponseWriter, r *http.Request, s app.Storager) (int, interface{}, error) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"+LocationUsers+"/"), "/")
+	if len(parts) != 2 || parts[1] != locationPreferences {
+		return 0, nil, &RequestError{Status: http.StatusNotFound, Err: ErrNotFound}
+	}
+
+	userID := parts[0]
+
+	err := server.ValidateUUID("UserID", userID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		p, err := s.GetPreferences(r.Context(), userID)
+		if errors.Is(err, storage.ErrPreferencesNotExist) {
+			return http.StatusOK, storage.DefaultPreferences(userID), nil
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusOK, p, nil
+	case http.MethodPut:
+		p := storage.Preferences{}
+
+		err := decodeJSONBody(r, &p)
+		if err != nil {
+			return 0, nil, err
+		}
+		p.UserID = userID
+
+		err = server.ValidatePreferences(p)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		err = s.SavePreferences(r.Context(), p)
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusOK, p, nil
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		return 0, nil, &RequestError{Status: http.StatusMethodNotAllowed, Err: ErrMethodNotAllowed}
+	}
+}
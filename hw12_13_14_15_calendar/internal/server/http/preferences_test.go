@@ -0,0 +1,69 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreferencesResource(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("GetPreferences", mock.Anything, testUserID).Return(storage.Preferences{}, storage.ErrPreferencesNotExist)
+
+		w := serveREST(s, http.MethodGet, "/users/"+testUserID+"/preferences", "")
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var p storage.Preferences
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+		require.Equal(t, storage.DefaultPreferences(testUserID), p)
+	})
+
+	t.Run("save", func(t *testing.T) {
+		want := storage.Preferences{UserID: testUserID, WeeklyDigest: true, DigestTime: "07:30"}
+
+		s := mocks.NewStorager(t)
+		s.On("SavePreferences", mock.Anything, want).Return(nil)
+
+		w := serveREST(s, http.MethodPut, "/users/"+testUserID+"/preferences",
+			`{"dailyDigest": false, "weeklyDigest": true, "digestTime": "07:30"}`)
+
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("bad digest time", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+
+		w := serveREST(s, http.MethodPut, "/users/"+testUserID+"/preferences",
+			`{"dailyDigest": true, "digestTime": "25:00"}`)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		p := decodeProblem(t, w)
+		require.Len(t, p.InvalidParams, 1)
+	})
+
+	t.Run("bad address for channel", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+
+		w := serveREST(s, http.MethodPut, "/users/"+testUserID+"/preferences",
+			`{"digestTime": "07:30", "channel": "email", "address": "not email"}`)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		p := decodeProblem(t, w)
+		require.Equal(t, []InvalidParam{{Name: "Address", Reason: "address must be a valid email address"}}, p.InvalidParams)
+	})
+
+	t.Run("unknown path", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+
+		w := serveREST(s, http.MethodGet, "/users/"+testUserID, "")
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
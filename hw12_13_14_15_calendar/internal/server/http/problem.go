@@ -0,0 +1,117 @@
+package internalhttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+const ContentTypeProblem = "application/problem+json"
+
+// Problem - тело ошибки в формате RFC 7807.
+type Problem struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	Instance      string         `json:"instance,omitempty"`
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"` //nolint:tagliatelle
+
+	// Results - результаты операций откатанного пакета.
+	Results []storage.BatchResult `json:"results,omitempty"`
+}
+
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// RequestError задает HTTP статус для ошибок, не связанных с хранилищем.
+type RequestError struct {
+	Status int
+	Err    error
+}
+
+func (e *RequestError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+func errorStatus(err error) int {
+	var requestErr *RequestError
+	var validationErr *server.ValidationError
+
+	switch {
+	case errors.As(err, &requestErr):
+		return requestErr.Status
+	case errors.As(err, &validationErr):
+		return http.StatusBadRequest
+	case errors.Is(err, storage.ErrEventNotExist), errors.Is(err, storage.ErrWebhookNotExist),
+		errors.Is(err, storage.ErrTagNotExist), errors.Is(err, storage.ErrAttachmentNotExist),
+		errors.Is(err, storage.ErrResourceNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, storage.ErrDateBusy), errors.Is(err, storage.ErrEventDuplicateID):
+		return http.StatusConflict
+	case errors.Is(err, app.ErrWebhooksUnsupported):
+		return http.StatusNotImplemented
+	case errors.Is(err, storage.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func NewProblem(r *http.Request, err error) Problem {
+	status := errorStatus(err)
+
+	p := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   err.Error(),
+		Instance: r.URL.RequestURI(),
+	}
+
+	var validationErr *server.ValidationError
+	if errors.As(err, &validationErr) {
+		p.Detail = "request validation failed"
+		for _, v := range validationErr.Violations {
+			p.InvalidParams = append(p.InvalidParams, InvalidParam{Name: v.Field, Reason: v.Description})
+		}
+	}
+
+	var batchErr *batchFailure
+	if errors.As(err, &batchErr) {
+		p.Results = batchErr.results
+	}
+
+	return p
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, err error) {
+	p := NewProblem(r, err)
+
+	jData, err := json.Marshal(p)
+	if err != nil {
+		log.Printf("json problem marshal error")
+	}
+
+	w.Header().Set("Content-Type", ContentTypeProblem)
+	w.WriteHeader(p.Status)
+
+	_, err = w.Write(jData)
+	if err != nil {
+		log.Printf("json write problem failed")
+	}
+}
@@ -0,0 +1,131 @@
+package internalhttp
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/actionlink"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+const (
+	locationSnooze  = "snooze"
+	locationDismiss = "dismiss"
+)
+
+// confirmPage отправляет ту же подписанную ссылку методом POST.
+var confirmPage = template.Must(template.New("confirm").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body>
+<form method="post" action="{{.Action}}">
+<p>{{.Title}}?</p>
+<button type="submit">Confirm</button>
+</form>
+</body>
+</html>
+`))
+
+// eventReminder обслуживает POST /events/{id}/snooze и POST /events/{id}/dismiss.
+func eventReminder(w http.ResponseWriter, r *http.Request, s app.Storager, id string, action string) (
+	int, interface{}, error,
+) {
+	if action != locationSnooze && action != locationDismiss {
+		return 0, nil, &RequestError{Status: http.StatusNotFound, Err: ErrNotFound}
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		return 0, nil, &RequestError{Status: http.StatusMethodNotAllowed, Err: ErrMethodNotAllowed}
+	}
+
+	a := storage.ReminderAction{}
+
+	err := decodeJSONBody(r, &a)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var state storage.ReminderState
+
+	if action == locationSnooze {
+		err = server.ValidateSnooze(a)
+		if err != nil {
+			return 0, nil, err
+		}
+		state, err = app.SnoozeReminder(r.Context(), s, id, a.UserID, time.Duration(a.Minutes)*time.Minute)
+	} else {
+		err = server.ValidateDismiss(a)
+		if err != nil {
+			return 0, nil, err
+		}
+		state, err = app.DismissReminder(r.Context(), s, id, a.UserID)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusOK, state, nil
+}
+
+// ReminderActionHandler выполняет действие по подписанной ссылке из напоминания.
+// Почтовые сканеры и превью ссылок открывают их сами, поэтому GET только показывает
+// страницу подтверждения, а действие выполняет POST по той же ссылке.
+func ReminderActionHandler(s app.StorageReminder, signer *actionlink.Signer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			w.Header().Set("Allow", "GET, POST")
+			writeProblem(w, r, &RequestError{Status: http.StatusMethodNotAllowed, Err: ErrMethodNotAllowed})
+			return
+		}
+
+		l, err := signer.Parse(r.URL.Query(), time.Now())
+		switch {
+		case errors.Is(err, actionlink.ErrExpired):
+			writeProblem(w, r, &RequestError{Status: http.StatusGone, Err: err})
+			return
+		case err != nil:
+			writeProblem(w, r, &RequestError{Status: http.StatusForbidden, Err: err})
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			writeConfirmPage(w, r, l)
+			return
+		}
+
+		var state storage.ReminderState
+		if l.Action == actionlink.ActionSnooze {
+			state, err = app.SnoozeReminder(r.Context(), s, l.EventID, l.UserID, time.Duration(l.Minutes)*time.Minute)
+		} else {
+			state, err = app.DismissReminder(r.Context(), s, l.EventID, l.UserID)
+		}
+		if err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+
+		writeJSON(w, r, http.StatusOK, state)
+	})
+}
+
+func writeConfirmPage(w http.ResponseWriter, r *http.Request, l actionlink.Link) {
+	title := "Dismiss reminder"
+	if l.Action == actionlink.ActionSnooze {
+		title = fmt.Sprintf("Snooze reminder for %d minutes", l.Minutes)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	_ = confirmPage.Execute(w, struct {
+		Title  string
+		Action string
+	}{Title: title, Action: r.URL.RequestURI()})
+}
@@ -0,0 +1,148 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/actionlink"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventReminderResource(t *testing.T) {
+	t.Run("snooze", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("SaveReminderState", mock.Anything, mock.MatchedBy(func(r storage.ReminderState) bool {
+			return r.EventID == testEventID && r.UserID == testUserID && r.SnoozedUntil != "" && !r.Dismissed
+		})).Return(nil)
+
+		w := serveREST(s, http.MethodPost, "/events/"+testEventID+"/snooze",
+			`{"userId": "`+testUserID+`", "minutes": 15}`)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var r storage.ReminderState
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &r))
+
+		until, err := time.ParseInLocation(time.DateTime, r.SnoozedUntil, time.Local)
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now().Add(15*time.Minute), until, time.Minute)
+	})
+
+	t.Run("dismiss", func(t *testing.T) {
+		want := storage.ReminderState{EventID: testEventID, UserID: testUserID, Dismissed: true}
+
+		s := mocks.NewStorager(t)
+		s.On("SaveReminderState", mock.Anything, want).Return(nil)
+
+		w := serveREST(s, http.MethodPost, "/events/"+testEventID+"/dismiss", `{"userId": "`+testUserID+`"}`)
+
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("snooze without minutes", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+
+		w := serveREST(s, http.MethodPost, "/events/"+testEventID+"/snooze", `{"userId": "`+testUserID+`"}`)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		p := decodeProblem(t, w)
+		require.Len(t, p.InvalidParams, 1)
+	})
+
+	t.Run("no event", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("SaveReminderState", mock.Anything, mock.Anything).Return(storage.ErrEventNotExist)
+
+		w := serveREST(s, http.MethodPost, "/events/"+testEventID+"/dismiss", `{"userId": "`+testUserID+`"}`)
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("unknown action", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+
+		w := serveREST(s, http.MethodPost, "/events/"+testEventID+"/archive", `{"userId": "`+testUserID+`"}`)
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+
+		w := serveREST(s, http.MethodGet, "/events/"+testEventID+"/snooze", "")
+
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		require.Equal(t, "POST", w.Header().Get("Allow"))
+	})
+}
+
+func TestReminderActionHandler(t *testing.T) {
+	signer := actionlink.NewSigner("0123456789abcdef")
+
+	serve := func(s *mocks.Storager, method string, link actionlink.Link) *httptest.ResponseRecorder {
+		u, err := url.Parse(signer.URL("http://calendar.local", link))
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		ReminderActionHandler(s, signer).ServeHTTP(w, httptest.NewRequest(method, u.RequestURI(), nil))
+
+		return w
+	}
+
+	link := actionlink.Link{
+		Action:  actionlink.ActionDismiss,
+		EventID: testEventID,
+		UserID:  testUserID,
+		Expires: time.Now().Add(time.Hour),
+	}
+
+	t.Run("dismiss", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("SaveReminderState", mock.Anything,
+			storage.ReminderState{EventID: testEventID, UserID: testUserID, Dismissed: true}).Return(nil)
+
+		w := serve(s, http.MethodPost, link)
+
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("get only confirms", func(t *testing.T) {
+		snooze := link
+		snooze.Action = actionlink.ActionSnooze
+		snooze.Minutes = 10
+
+		// Хранилище не вызывается: ссылку мог открыть почтовый сканер.
+		w := serve(mocks.NewStorager(t), http.MethodGet, snooze)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		require.Contains(t, w.Body.String(), `<form method="post" action="`+actionlink.Path+`?`)
+		require.Contains(t, w.Body.String(), "Snooze reminder for 10 minutes")
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		expired := link
+		expired.Expires = time.Now().Add(-time.Minute)
+
+		w := serve(mocks.NewStorager(t), http.MethodGet, expired)
+
+		require.Equal(t, http.StatusGone, w.Code)
+	})
+
+	t.Run("bad signature", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, actionlink.Path+"?action=dismiss&event="+testEventID+
+			"&user="+testUserID+"&expires=4102444800&sig=00", nil)
+
+		w := httptest.NewRecorder()
+		ReminderActionHandler(mocks.NewStorager(t), signer).ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -0,0 +1,176 @@
+package internalhttp
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+func resourceCollection(w http.ResponseWriter, r *http.Request, s app.Storager) (int, interface{}, error) {
+	switch r.Method {
+	case http.MethodGet:
+		return listResources(r, s)
+	case http.MethodPost:
+		return postResource(w, r, s)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		return 0, nil, &RequestError{Status: http.StatusMethodNotAllowed, Err: ErrMethodNotAllowed}
+	}
+}
+
+func resourceItem(w http.ResponseWriter, r *http.Request, s app.Storager) (int, interface{}, error) {
+	id := strings.TrimPrefix(r.URL.Path, "/"+LocationResources+"/")
+	if id == "" || strings.Contains(id, "/") {
+		return 0, nil, &RequestError{Status: http.StatusNotFound, Err: ErrNotFound}
+	}
+
+	err := server.ValidateUUID("ID", id)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		resource, err := s.GetResource(r.Context(), id)
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusOK, resource, nil
+	case http.MethodPut:
+		resource := storage.Resource{}
+
+		err := decodeJSONBody(r, &resource)
+		if err != nil {
+			return 0, nil, err
+		}
+		resource.ID = id
+
+		err = server.ValidateResource(resource)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		err = s.UpdateResource(r.Context(), id, resource)
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusOK, resource, nil
+	case http.MethodDelete:
+		err := s.DeleteResource(r.Context(), id)
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusNoContent, nil, nil
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		return 0, nil, &RequestError{Status: http.StatusMethodNotAllowed, Err: ErrMethodNotAllowed}
+	}
+}
+
+// resourceAvailability обслуживает /resources/availability?from=&to=&kind=&capacity=:
+// ресурсы с их бронями за интервал [from, to).
+func resourceAvailability(w http.ResponseWriter, r *http.Request, s app.Storager) (int, interface{}, error) {
+	q, err := resourceQuery(w, r)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	availability, err := app.ListResourceAvailability(r.Context(), s, q)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusOK, availability, nil
+}
+
+// freeRooms обслуживает /resources/free-rooms?from=&to=&capacity=: переговорные, свободные
+// в интервале [from, to), от самой маленькой подходящей.
+func freeRooms(w http.ResponseWriter, r *http.Request, s app.Storager) (int, interface{}, error) {
+	q, err := resourceQuery(w, r)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	rooms, err := app.FindFreeRooms(r.Context(), s, q)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusOK, rooms, nil
+}
+
+func listResources(r *http.Request, s app.Storager) (int, interface{}, error) {
+	resources, err := s.ListResources(r.Context())
+	if err != nil {
+		return 0, nil, err
+	}
+
+	kind := r.URL.Query().Get("kind")
+	if kind == "" {
+		return http.StatusOK, resources, nil
+	}
+
+	filtered := make([]storage.Resource, 0, len(resources))
+	for _, resource := range resources {
+		if resource.Kind == kind {
+			filtered = append(filtered, resource)
+		}
+	}
+
+	return http.StatusOK, filtered, nil
+}
+
+func postResource(w http.ResponseWriter, r *http.Request, s app.Storager) (int, interface{}, error) {
+	resource := storage.Resource{}
+
+	err := decodeJSONBody(r, &resource)
+	if err != nil {
+		return 0, nil, err
+	}
+	resource.ID = ""
+
+	err = server.ValidateResource(resource)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	id, err := s.CreateResource(r.Context(), resource)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/%s/%s", LocationResources, id))
+
+	return http.StatusCreated, CreatedResponse{ID: id}, nil
+}
+
+func resourceQuery(w http.ResponseWriter, r *http.Request) (storage.ResourceQueryValidation, error) {
+	q := storage.ResourceQueryValidation{}
+
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		return q, &RequestError{Status: http.StatusMethodNotAllowed, Err: ErrMethodNotAllowed}
+	}
+
+	query := r.URL.Query()
+
+	q.DateFrom = query.Get("from")
+	q.DateTo = query.Get("to")
+	q.Kind = query.Get("kind")
+
+	if capacity := query.Get("capacity"); capacity != "" {
+		var err error
+
+		q.Capacity, err = strconv.Atoi(capacity)
+		if err != nil {
+			return q, &RequestError{Status: http.StatusBadRequest, Err: fmt.Errorf("invalid capacity: %w", err)}
+		}
+	}
+
+	return q, server.ValidateResourceQuery(q)
+}
@@ -0,0 +1,104 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const roomID = "7c0e7a4e-5a6e-4a53-9d5d-5c8b3c2b9f10"
+
+func TestBookableResources(t *testing.T) {
+	room := storage.Resource{ID: roomID, Name: "Orion", Kind: storage.ResourceRoom, Capacity: 8}
+	projector := storage.Resource{ID: "projector", Name: "Epson", Kind: storage.ResourceEquipment}
+
+	t.Run("create", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("CreateResource", mock.Anything, storage.Resource{Name: "Orion", Kind: "room", Capacity: 8}).
+			Return(roomID, nil).Once()
+
+		w := serveREST(s, http.MethodPost, "/resources", `{"name": "Orion", "kind": "room", "capacity": 8}`)
+
+		require.Equal(t, http.StatusCreated, w.Code)
+		require.Equal(t, "/resources/"+roomID, w.Header().Get("Location"))
+	})
+
+	t.Run("create validation", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+
+		w := serveREST(s, http.MethodPost, "/resources", `{"name": "Orion", "kind": "desk"}`)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		p := decodeProblem(t, w)
+		require.Equal(t, "Kind", p.InvalidParams[0].Name)
+	})
+
+	t.Run("list by kind", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("ListResources", mock.Anything).Return([]storage.Resource{projector, room}, nil).Once()
+
+		w := serveREST(s, http.MethodGet, "/resources?kind=room", "")
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var result []storage.Resource
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		require.Equal(t, []storage.Resource{room}, result)
+	})
+
+	t.Run("delete not found", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("DeleteResource", mock.Anything, roomID).Return(storage.ErrResourceNotExist).Once()
+
+		w := serveREST(s, http.MethodDelete, "/resources/"+roomID, "")
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		decodeProblem(t, w)
+	})
+
+	t.Run("free rooms", func(t *testing.T) {
+		hall := storage.Resource{ID: "hall", Name: "Hall", Kind: storage.ResourceRoom, Capacity: 40}
+
+		s := mocks.NewStorager(t)
+		s.On("ListResources", mock.Anything).Return([]storage.Resource{projector, hall, room}, nil).Once()
+		s.On("ListBookings", mock.Anything, "2024-05-06 10:00:00", "2024-05-06 11:00:00").
+			Return([]storage.Booking{}, nil).Once()
+
+		w := serveREST(s, http.MethodGet,
+			"/resources/free-rooms?from=2024-05-06+10:00:00&to=2024-05-06+11:00:00&capacity=6", "")
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var result []storage.Resource
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		require.Equal(t, []storage.Resource{room, hall}, result)
+	})
+
+	t.Run("availability validation", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+
+		w := serveREST(s, http.MethodGet,
+			"/resources/availability?from=2024-05-06+11:00:00&to=2024-05-06+11:00:00", "")
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		p := decodeProblem(t, w)
+		require.Equal(t, "DateTo", p.InvalidParams[0].Name)
+	})
+
+	t.Run("event books busy resource", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("CreateEvent", mock.Anything, mock.Anything).Return("", storage.ErrResourceBusy).Once()
+
+		w := serveREST(s, http.MethodPost, "/events", `{"title": "planning", "dateStart": "2024-05-06 10:00:00", `+
+			`"dateEnd": "2024-05-06 11:00:00", "datePost": "2024-05-06 09:00:00", "userId": "`+roomID+`", `+
+			`"resources": ["`+roomID+`"]}`)
+
+		require.Equal(t, http.StatusConflict, w.Code)
+		decodeProblem(t, w)
+	})
+}
@@ -0,0 +1,62 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+)
+
+// ResourceHandlerFunc возвращает HTTP статус и тело ответа либо ошибку,
+// которая отдается клиенту в формате application/problem+json.
+type ResourceHandlerFunc func(w http.ResponseWriter, r *http.Request, s app.Storager) (int, interface{}, error)
+
+type CreatedResponse struct {
+	ID string `json:"id"`
+}
+
+var (
+	ErrMethodNotAllowed     = errors.New("method not allowed")
+	ErrUnsupportedMediaType = errors.New("content type must be application/json")
+	ErrNotFound             = errors.New("resource not found")
+)
+
+func handleResource(handler ResourceHandlerFunc, s app.Storager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, data, err := handler(w, r, s)
+		if err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+
+		if data == nil {
+			w.WriteHeader(status)
+			return
+		}
+
+		jData, err := json.Marshal(data)
+		if err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write(jData)
+	})
+}
+
+func decodeJSONBody(r *http.Request, v interface{}) error {
+	if !strings.Contains(r.Header.Get("Content-Type"), "application/json") {
+		return &RequestError{Status: http.StatusUnsupportedMediaType, Err: ErrUnsupportedMediaType}
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return &RequestError{Status: http.StatusBadRequest, Err: fmt.Errorf("invalid json body: %w", err)}
+	}
+
+	return nil
+}
@@ -3,17 +3,25 @@ package internalhttp
 import (
 	"context"
 	"fmt"
+	"math"
+	"net"
 	"net/http"
 	"time"
 
 	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	"golang.org/x/time/rate"
 )
 
+// readTimeout ограничивает чтение запроса. Загрузка вложения продлевает его, см. uploadTimeout.
+const readTimeout = 5 * time.Second
+
 type Server struct {
-	logger  Logger
-	app     Application
-	server  *http.Server
-	address string
+	logger   Logger
+	app      Application
+	server   *http.Server
+	address  string
+	limiter  *rate.Limiter
+	handlers map[string]http.Handler
 }
 
 type Logger interface {
@@ -27,30 +35,61 @@ type Application interface {
 
 func NewServer(logger Logger, app Application, address string) *Server {
 	return &Server{
-		logger:  logger,
-		app:     app,
-		address: address,
+		logger:   logger,
+		app:      app,
+		address:  address,
+		limiter:  rate.NewLimiter(rate.Inf, 0),
+		handlers: make(map[string]http.Handler),
+	}
+}
+
+// Handle регистрирует дополнительный обработчик рядом с основными маршрутами.
+// Вызывается до Start.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.handlers[pattern] = handler
+}
+
+// SetRateLimit задает ограничение запросов в секунду, 0 снимает ограничение.
+// Метод безопасно вызывать на работающем сервере.
+func (s *Server) SetRateLimit(limit float64, burst int) {
+	if limit <= 0 {
+		s.limiter.SetLimit(rate.Inf)
+		return
+	}
+
+	if burst < 1 {
+		burst = int(math.Ceil(limit))
 	}
+
+	s.limiter.SetBurst(burst)
+	s.limiter.SetLimit(rate.Limit(limit))
 }
 
 func (s *Server) Start(ctx context.Context) error {
 	go func() {
 		mux := NewMux(s.app.GetStorage())
+		for pattern, handler := range s.handlers {
+			mux.Handle(pattern, handler)
+		}
 
 		mw := Middleware{
-			logger: s.logger,
+			logger:  s.logger,
+			limiter: s.limiter,
 		}
 
 		chain := MiddlewareChain(
 			mw.loggingMiddleware,
+			mw.rateLimitMiddleware,
 			mw.requestValidatorMiddleware,
 		)(mux)
 
 		s.server = &http.Server{
 			Addr:              s.address,
 			Handler:           chain,
-			ReadTimeout:       5 * time.Second,
+			ReadTimeout:       readTimeout,
 			ReadHeaderTimeout: 2 * time.Second,
+			// Долгие подписки на изменения завершаются вместе с контекстом сервера.
+			BaseContext: func(net.Listener) context.Context { return ctx },
 		}
 
 		s.logger.Info(fmt.Sprintf("starting http server on http://%s", s.address))
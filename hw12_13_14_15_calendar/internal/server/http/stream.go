@@ -0,0 +1,202 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/feed"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+const (
+	LocationEventsStream = "events/stream"
+	LocationEventsWS     = "events/ws"
+)
+
+const (
+	feedHeartbeat    = 15 * time.Second
+	feedWriteTimeout = 10 * time.Second
+	feedRetryMillis  = 3000
+)
+
+var ErrInvalidLastEventID = errors.New("last event id must be a positive integer")
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// FeedSSEHandler отдает изменения событий в формате Server-Sent Events.
+// Фильтры: user_id, from, to. Продолжение - по заголовку Last-Event-ID
+// или параметру last_event_id.
+func FeedSSEHandler(hub *feed.Hub) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			writeProblem(w, r, &RequestError{Status: http.StatusMethodNotAllowed, Err: ErrMethodNotAllowed})
+			return
+		}
+
+		sub, err := subscribeFeed(hub, r)
+		if err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+		defer sub.Close()
+
+		rc := http.NewResponseController(w)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		_, err = fmt.Fprintf(w, "retry: %d\n\n", feedRetryMillis)
+		if err != nil || rc.Flush() != nil {
+			return
+		}
+
+		ticker := time.NewTicker(feedHeartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ticker.C:
+				_, err = fmt.Fprint(w, ": ping\n\n")
+			case c, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				err = writeSSE(w, c)
+			}
+
+			if err != nil || rc.Flush() != nil {
+				return
+			}
+		}
+	})
+}
+
+// FeedWebSocketHandler отдает изменения событий JSON сообщениями по WebSocket.
+// Параметры те же, что у FeedSSEHandler.
+func FeedWebSocketHandler(hub *feed.Hub) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sub, err := subscribeFeed(hub, r)
+		if err != nil {
+			writeProblem(w, r, err)
+			return
+		}
+		defer sub.Close()
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// Входящие сообщения не ожидаются, чтение нужно для обработки ping/pong и закрытия.
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+
+		ticker := time.NewTicker(feedHeartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-closed:
+				return
+			case <-r.Context().Done():
+				writeClose(conn, websocket.CloseGoingAway, "server is shutting down")
+				return
+			case <-ticker.C:
+				err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(feedWriteTimeout))
+			case c, ok := <-sub.C:
+				if !ok {
+					writeClose(conn, websocket.CloseTryAgainLater, "subscriber is too slow")
+					return
+				}
+				_ = conn.SetWriteDeadline(time.Now().Add(feedWriteTimeout))
+				err = conn.WriteJSON(c)
+			}
+
+			if err != nil {
+				return
+			}
+		}
+	})
+}
+
+func subscribeFeed(hub *feed.Hub, r *http.Request) (*feed.Subscription, error) {
+	query := r.URL.Query()
+
+	fv := storage.FeedFilterValidation{
+		UserID:   query.Get("user_id"),
+		DateFrom: query.Get("from"),
+		DateTo:   query.Get("to"),
+	}
+
+	err := server.ValidateFeedFilter(fv)
+	if err != nil {
+		return nil, err
+	}
+
+	lastID, err := lastEventID(r)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := hub.Subscribe(feed.Filter{UserID: fv.UserID, DateFrom: fv.DateFrom, DateTo: fv.DateTo}, lastID)
+	if errors.Is(err, feed.ErrHistoryExpired) {
+		return nil, &RequestError{Status: http.StatusGone, Err: err}
+	}
+
+	return sub, err
+}
+
+func lastEventID(r *http.Request) (uint64, error) {
+	v := r.Header.Get("Last-Event-ID")
+	if v == "" {
+		v = r.URL.Query().Get("last_event_id")
+	}
+	if v == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, &RequestError{Status: http.StatusBadRequest, Err: ErrInvalidLastEventID}
+	}
+
+	return id, nil
+}
+
+func writeSSE(w http.ResponseWriter, c feed.Change) error {
+	jData, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", c.ID, c.Type, jData)
+
+	return err
+}
+
+func writeClose(conn *websocket.Conn, code int, text string) {
+	msg := websocket.FormatCloseMessage(code, text)
+	_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(feedWriteTimeout))
+}
@@ -0,0 +1,151 @@
+package internalhttp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/feed"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newFeedServer(t *testing.T, hub *feed.Hub) *httptest.Server {
+	t.Helper()
+
+	l := mocks.NewLogger(t)
+	l.On("Info", mock.AnythingOfType("string")).Maybe()
+
+	mux := http.NewServeMux()
+	mux.Handle("/"+LocationEventsStream, FeedSSEHandler(hub))
+	mux.Handle("/"+LocationEventsWS, FeedWebSocketHandler(hub))
+
+	mw := Middleware{logger: l}
+	ts := httptest.NewServer(mw.loggingMiddleware(mux))
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+// readSSE читает события потока до первой пустой строки после data.
+func readSSE(t *testing.T, r *bufio.Reader) (id string, data string) {
+	t.Helper()
+
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		case line == "" && data != "":
+			return id, data
+		}
+	}
+}
+
+func TestFeedSSE(t *testing.T) {
+	t.Run("stream and resume", func(t *testing.T) {
+		hub := feed.NewHub(0)
+		ts := newFeedServer(t, hub)
+
+		hub.Publish(feed.ChangeCreated, storage.Event{ID: "1", UserID: testUserID})
+		hub.Publish(feed.ChangeCreated, storage.Event{ID: "2", UserID: "other"})
+		hub.Publish(feed.ChangeUpdated, storage.Event{ID: "1", UserID: testUserID})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/"+LocationEventsStream+"?user_id="+testUserID, nil)
+		require.NoError(t, err)
+		req.Header.Set("Last-Event-ID", "1")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		r := bufio.NewReader(resp.Body)
+
+		id, data := readSSE(t, r)
+		require.Equal(t, "3", id)
+
+		var c feed.Change
+		require.NoError(t, json.Unmarshal([]byte(data), &c))
+		require.Equal(t, feed.ChangeUpdated, c.Type)
+
+		hub.Publish(feed.ChangeDeleted, storage.Event{ID: "1", UserID: testUserID})
+
+		id, _ = readSSE(t, r)
+		require.Equal(t, "4", id)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		hub := feed.NewHub(1)
+		ts := newFeedServer(t, hub)
+
+		for _, id := range []string{"1", "2", "3"} {
+			hub.Publish(feed.ChangeCreated, storage.Event{ID: id})
+		}
+
+		cases := []struct {
+			target string
+			status int
+		}{
+			{target: "?user_id=not-uuid", status: http.StatusBadRequest},
+			{target: "?from=2022-10-12&to=2022-10-11", status: http.StatusBadRequest},
+			{target: "?last_event_id=abc", status: http.StatusBadRequest},
+			{target: "?last_event_id=1", status: http.StatusGone},
+		}
+
+		for _, tc := range cases {
+			resp, err := http.Get(ts.URL + "/" + LocationEventsStream + tc.target) //nolint:noctx
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			require.Equal(t, tc.status, resp.StatusCode, tc.target)
+			require.Equal(t, ContentTypeProblem, resp.Header.Get("Content-Type"))
+		}
+	})
+}
+
+func TestFeedWebSocket(t *testing.T) {
+	hub := feed.NewHub(0)
+	ts := newFeedServer(t, hub)
+
+	hub.Publish(feed.ChangeCreated, storage.Event{ID: "1", DateStart: "2022-10-11 12:00:00"})
+
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/" + LocationEventsWS + "?from=2022-10-11&to=2022-10-11&last_event_id=0"
+
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	defer conn.Close()
+
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+	// Подписка регистрируется до ответа на upgrade, поэтому изменение не потеряется.
+	hub.Publish(feed.ChangeCreated, storage.Event{ID: "2", DateStart: "2022-10-12 12:00:00"})
+	hub.Publish(feed.ChangeUpdated, storage.Event{ID: "1", DateStart: "2022-10-11 13:00:00"})
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+
+	var c feed.Change
+	require.NoError(t, conn.ReadJSON(&c))
+	require.Equal(t, uint64(3), c.ID)
+	require.Equal(t, feed.ChangeUpdated, c.Type)
+	require.Equal(t, "1", c.Event.ID)
+}
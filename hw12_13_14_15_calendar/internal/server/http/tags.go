@@ -0,0 +1,63 @@
+package internalhttp
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+func tagCollection(w http.ResponseWriter, r *http.Request, s app.Storager) (int, interface{}, error) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		return 0, nil, &RequestError{Status: http.StatusMethodNotAllowed, Err: ErrMethodNotAllowed}
+	}
+
+	tags, err := s.ListTags(r.Context())
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return http.StatusOK, tags, nil
+}
+
+// tagItem обслуживает /tags/{name}: PUT переименовывает метку во всех событиях,
+// DELETE снимает ее со всех событий.
+func tagItem(w http.ResponseWriter, r *http.Request, s app.Storager) (int, interface{}, error) {
+	name := strings.TrimPrefix(r.URL.Path, "/"+LocationTags+"/")
+	if name == "" || strings.Contains(name, "/") {
+		return 0, nil, &RequestError{Status: http.StatusNotFound, Err: ErrNotFound}
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		rename := storage.TagRename{}
+
+		err := decodeJSONBody(r, &rename)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		err = server.ValidateTagRename(rename)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		err = s.RenameTag(r.Context(), name, rename.Name)
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusNoContent, nil, nil
+	case http.MethodDelete:
+		err := s.DeleteTag(r.Context(), name)
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusNoContent, nil, nil
+	default:
+		w.Header().Set("Allow", "PUT, DELETE")
+		return 0, nil, &RequestError{Status: http.StatusMethodNotAllowed, Err: ErrMethodNotAllowed}
+	}
+}
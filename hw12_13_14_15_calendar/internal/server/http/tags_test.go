@@ -0,0 +1,65 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagResources(t *testing.T) {
+	t.Run("list", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("ListTags", mock.Anything).Return([]storage.Tag{{Name: "oncall", Events: 2}}, nil)
+
+		w := serveREST(s, http.MethodGet, "/tags", "")
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var result []storage.Tag
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		require.Equal(t, []storage.Tag{{Name: "oncall", Events: 2}}, result)
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("RenameTag", mock.Anything, "1:1", "one-on-one").Return(nil).Once()
+
+		w := serveREST(s, http.MethodPut, "/tags/1:1", `{"name": "one-on-one"}`)
+
+		require.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("rename validation", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+
+		w := serveREST(s, http.MethodPut, "/tags/ops", `{"name": "a,b"}`)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		p := decodeProblem(t, w)
+		require.Equal(t, "Name", p.InvalidParams[0].Name)
+	})
+
+	t.Run("delete not found", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("DeleteTag", mock.Anything, "ops").Return(storage.ErrTagNotExist).Once()
+
+		w := serveREST(s, http.MethodDelete, "/tags/ops", "")
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		decodeProblem(t, w)
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+
+		w := serveREST(s, http.MethodPost, "/tags", "")
+
+		require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		require.Equal(t, "GET", w.Header().Get("Allow"))
+	})
+}
@@ -0,0 +1,103 @@
+package internalhttp
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/server"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+const locationDeliveries = "deliveries"
+
+func webhookCollection(w http.ResponseWriter, r *http.Request, s app.Storager) (int, interface{}, error) {
+	switch r.Method {
+	case http.MethodGet:
+		webhooks, err := s.ListWebhooks(r.Context())
+		if err != nil {
+			return 0, nil, err
+		}
+		for i := range webhooks {
+			webhooks[i].Secret = ""
+		}
+		return http.StatusOK, webhooks, nil
+	case http.MethodPost:
+		return postWebhook(w, r, s)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		return 0, nil, &RequestError{Status: http.StatusMethodNotAllowed, Err: ErrMethodNotAllowed}
+	}
+}
+
+// webhookItem обслуживает /webhooks/{id} и журнал доставок /webhooks/{id}/deliveries.
+func webhookItem(w http.ResponseWriter, r *http.Request, s app.Storager) (int, interface{}, error) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"+LocationWebhooks+"/"), "/")
+
+	id := parts[0]
+	if id == "" || len(parts) > 2 || (len(parts) == 2 && parts[1] != locationDeliveries) {
+		return 0, nil, &RequestError{Status: http.StatusNotFound, Err: ErrNotFound}
+	}
+
+	err := server.ValidateUUID("ID", id)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if len(parts) == 2 {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			return 0, nil, &RequestError{Status: http.StatusMethodNotAllowed, Err: ErrMethodNotAllowed}
+		}
+
+		deliveries, err := s.ListDeliveries(r.Context(), id)
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusOK, deliveries, nil
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		webhook, err := s.GetWebhook(r.Context(), id)
+		if err != nil {
+			return 0, nil, err
+		}
+		webhook.Secret = ""
+		return http.StatusOK, webhook, nil
+	case http.MethodDelete:
+		err := s.DeleteWebhook(r.Context(), id)
+		if err != nil {
+			return 0, nil, err
+		}
+		return http.StatusNoContent, nil, nil
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		return 0, nil, &RequestError{Status: http.StatusMethodNotAllowed, Err: ErrMethodNotAllowed}
+	}
+}
+
+func postWebhook(w http.ResponseWriter, r *http.Request, s app.Storager) (int, interface{}, error) {
+	webhook := storage.Webhook{}
+
+	err := decodeJSONBody(r, &webhook)
+	if err != nil {
+		return 0, nil, err
+	}
+	webhook.ID = ""
+
+	err = server.ValidateWebhook(webhook)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	id, err := s.CreateWebhook(r.Context(), webhook)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/%s/%s", LocationWebhooks, id))
+
+	return http.StatusCreated, CreatedResponse{ID: id}, nil
+}
@@ -0,0 +1,107 @@
+package internalhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testWebhookID = "7c0b6a2e-3f0e-4b8e-9d61-2b1f4c5a6e7d"
+
+func TestWebhookResource(t *testing.T) {
+	t.Run("create", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("CreateWebhook", mock.Anything, mock.AnythingOfType("storage.Webhook")).Return(testWebhookID, nil)
+
+		w := serveREST(s, http.MethodPost, "/webhooks",
+			`{"url": "https://example.com/hook", "secret": "0123456789abcdef", "eventTypes": ["created", "reminder"]}`)
+
+		require.Equal(t, http.StatusCreated, w.Code)
+		require.Equal(t, "/webhooks/"+testWebhookID, w.Header().Get("Location"))
+	})
+
+	t.Run("create in memory mode", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("CreateWebhook", mock.Anything, mock.Anything).Return("", app.ErrWebhooksUnsupported)
+
+		w := serveREST(s, http.MethodPost, "/webhooks",
+			`{"url": "https://example.com/hook", "secret": "0123456789abcdef", "eventTypes": ["created"]}`)
+
+		require.Equal(t, http.StatusNotImplemented, w.Code)
+		decodeProblem(t, w)
+	})
+
+	t.Run("create validation", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+
+		w := serveREST(s, http.MethodPost, "/webhooks",
+			`{"url": "not url", "secret": "short", "eventTypes": ["moved"]}`)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		p := decodeProblem(t, w)
+		require.Len(t, p.InvalidParams, 3)
+	})
+
+	t.Run("list hides secrets", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("ListWebhooks", mock.Anything).Return([]storage.Webhook{
+			{ID: testWebhookID, URL: "https://example.com/hook", Secret: "0123456789abcdef"},
+		}, nil)
+
+		w := serveREST(s, http.MethodGet, "/webhooks", "")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NotContains(t, w.Body.String(), "0123456789abcdef")
+	})
+
+	t.Run("get not found", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("GetWebhook", mock.Anything, testWebhookID).Return(storage.Webhook{}, storage.ErrWebhookNotExist)
+
+		w := serveREST(s, http.MethodGet, "/webhooks/"+testWebhookID, "")
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		decodeProblem(t, w)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("DeleteWebhook", mock.Anything, testWebhookID).Return(nil)
+
+		w := serveREST(s, http.MethodDelete, "/webhooks/"+testWebhookID, "")
+
+		require.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("deliveries", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+		s.On("ListDeliveries", mock.Anything, testWebhookID).Return([]storage.WebhookDelivery{
+			{ID: "1", WebhookID: testWebhookID, Status: storage.DeliveryFailed, Attempts: 8},
+		}, nil)
+
+		w := serveREST(s, http.MethodGet, "/webhooks/"+testWebhookID+"/deliveries", "")
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var deliveries []storage.WebhookDelivery
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &deliveries))
+		require.Len(t, deliveries, 1)
+		require.Equal(t, storage.DeliveryFailed, deliveries[0].Status)
+	})
+
+	t.Run("unknown path", func(t *testing.T) {
+		s := mocks.NewStorager(t)
+
+		w := serveREST(s, http.MethodGet, "/webhooks/"+testWebhookID+"/attempts", "")
+		require.Equal(t, http.StatusNotFound, w.Code)
+
+		w = serveREST(s, http.MethodGet, "/webhooks/not-uuid", "")
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -2,21 +2,74 @@ package server
 
 import (
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
 )
 
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+type ValidationError struct {
+	Violations []FieldViolation
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	for _, v := range e.Violations {
+		b.WriteString(v.Description + "\n")
+	}
+	return b.String()
+}
+
 func ValidateCreateEvent(e storage.Event) error {
 	validate := validator.New()
 
-	return ProcessRequestData(e, validate.StructExcept, "ID")
+	err := ProcessRequestData(e, validate.StructExcept, "ID")
+	if err != nil {
+		return err
+	}
+
+	return validateEventDates(e)
 }
 
 func ValidateUpdateEvent(e storage.Event) error {
 	validate := validator.New()
 
-	return ProcessRequestData(e, validate.StructExcept, "")
+	err := ProcessRequestData(e, validate.StructExcept, "")
+	if err != nil {
+		return err
+	}
+
+	return validateEventDates(e)
+}
+
+// validateEventDates проверяет, что событие не заканчивается раньше начала
+// и длится не дольше storage.MaxEventDuration. Формат дат уже проверен.
+func validateEventDates(e storage.Event) error {
+	start, _ := time.Parse(time.DateTime, e.DateStart)
+	end, _ := time.Parse(time.DateTime, e.DateEnd)
+
+	if end.Before(start) {
+		return &ValidationError{Violations: []FieldViolation{{
+			Field:       "DateEnd",
+			Description: "date end must not be before date start",
+		}}}
+	}
+
+	if end.Sub(start) > storage.MaxEventDuration {
+		return &ValidationError{Violations: []FieldViolation{{
+			Field:       "DateEnd",
+			Description: fmt.Sprintf("event must not last longer than %d days", storage.MaxEventDuration/(24*time.Hour)),
+		}}}
+	}
+
+	return nil
 }
 
 func ValidateDeleteEvent(e storage.Event) error {
@@ -31,17 +84,225 @@ func ValidateListEvent(lm storage.ListEventValidation) error {
 	return ProcessRequestData(lm, validate.StructExcept, "")
 }
 
+func ValidateListEventRange(lm storage.ListEventRangeValidation) error {
+	validate := validator.New()
+
+	err := ProcessRequestData(lm, validate.StructExcept, "")
+	if err != nil {
+		return err
+	}
+
+	if lm.DateTo < lm.DateFrom {
+		return &ValidationError{Violations: []FieldViolation{{
+			Field:       "DateTo",
+			Description: "date to must not be before date from",
+		}}}
+	}
+
+	return nil
+}
+
+func ValidateFeedFilter(f storage.FeedFilterValidation) error {
+	validate := validator.New()
+
+	err := ProcessRequestData(f, validate.StructExcept, "")
+	if err != nil {
+		return err
+	}
+
+	if f.DateFrom != "" && f.DateTo != "" && f.DateTo < f.DateFrom {
+		return &ValidationError{Violations: []FieldViolation{{
+			Field:       "DateTo",
+			Description: "date to must not be before date from",
+		}}}
+	}
+
+	return nil
+}
+
+// MaxBatchSize ограничивает число операций в одном пакете.
+const MaxBatchSize = 500
+
+// ValidateBatch проверяет все операции пакета. Имена полей в ошибке содержат
+// индекс операции, например operations[3].Title.
+func ValidateBatch(ops []storage.BatchOperation) error {
+	if len(ops) == 0 || len(ops) > MaxBatchSize {
+		return &ValidationError{Violations: []FieldViolation{{
+			Field:       "operations",
+			Description: fmt.Sprintf("batch must contain from 1 to %d operations", MaxBatchSize),
+		}}}
+	}
+
+	result := &ValidationError{}
+
+	for i, op := range ops {
+		var err error
+
+		switch op.Op {
+		case storage.BatchCreate:
+			err = ValidateCreateEvent(op.Event)
+		case storage.BatchUpdate:
+			e := op.Event
+			e.ID = op.ID
+			err = ValidateUpdateEvent(e)
+		case storage.BatchDelete:
+			err = ValidateDeleteEvent(storage.Event{ID: op.ID})
+		default:
+			err = &ValidationError{Violations: []FieldViolation{{
+				Field:       "Op",
+				Description: "op must be one of create, update, delete",
+			}}}
+		}
+
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			for _, v := range validationErr.Violations {
+				result.Violations = append(result.Violations, FieldViolation{
+					Field:       fmt.Sprintf("operations[%d].%s", i, v.Field),
+					Description: v.Description,
+				})
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(result.Violations) > 0 {
+		return result
+	}
+
+	return nil
+}
+
+func ValidateWebhook(w storage.Webhook) error {
+	validate := validator.New()
+
+	return ProcessRequestData(w, validate.StructExcept, "")
+}
+
+// ValidatePreferences дополнительно проверяет адрес по выбранному каналу.
+func ValidatePreferences(p storage.Preferences) error {
+	validate := validator.New()
+
+	err := ProcessRequestData(p, validate.StructExcept, "")
+	if err != nil {
+		return err
+	}
+
+	var tag string
+	switch p.Channel {
+	case storage.ChannelEmail:
+		tag = "required,email"
+	case storage.ChannelWebhook:
+		tag = "required,url"
+	case storage.ChannelBot:
+		tag = "required"
+	default:
+		return nil
+	}
+
+	if validate.Var(p.Address, tag) != nil {
+		return &ValidationError{Violations: []FieldViolation{{
+			Field:       "Address",
+			Description: "address must be a valid " + p.Channel + " address",
+		}}}
+	}
+
+	return nil
+}
+
+func ValidateSnooze(a storage.ReminderAction) error {
+	validate := validator.New()
+
+	return ProcessRequestData(a, validate.StructExcept, "")
+}
+
+func ValidateDismiss(a storage.ReminderAction) error {
+	validate := validator.New()
+
+	return ProcessRequestData(a, validate.StructPartial, "UserID")
+}
+
+func ValidateTagFilter(f storage.ListEventTagValidation) error {
+	validate := validator.New()
+
+	return ProcessRequestData(f, validate.StructExcept, "")
+}
+
+func ValidateTagRename(r storage.TagRename) error {
+	validate := validator.New()
+
+	return ProcessRequestData(r, validate.StructExcept, "")
+}
+
+func ValidateAttachment(a storage.Attachment) error {
+	validate := validator.New()
+
+	return ProcessRequestData(a, validate.StructExcept, "ID")
+}
+
+// ValidateAttachmentUpload проверяет описание загружаемого файла: ключ содержимого
+// выдает хранилище, а URL у загруженного файла не задается.
+func ValidateAttachmentUpload(a storage.Attachment) error {
+	validate := validator.New()
+
+	return ProcessRequestData(a, validate.StructExcept, "ID", "URL")
+}
+
+func ValidateResource(r storage.Resource) error {
+	validate := validator.New()
+
+	return ProcessRequestData(r, validate.StructExcept, "ID")
+}
+
+// ValidateResourceQuery проверяет интервал поиска свободных ресурсов: пустой интервал ничего не бронирует.
+func ValidateResourceQuery(q storage.ResourceQueryValidation) error {
+	validate := validator.New()
+
+	err := ProcessRequestData(q, validate.StructExcept, "")
+	if err != nil {
+		return err
+	}
+
+	if q.DateTo <= q.DateFrom {
+		return &ValidationError{Violations: []FieldViolation{{
+			Field:       "DateTo",
+			Description: "date to must be after date from",
+		}}}
+	}
+
+	return nil
+}
+
+func ValidateUUID(field string, value string) error {
+	validate := validator.New()
+
+	err := validate.Var(value, "required,uuid")
+	if err != nil {
+		return &ValidationError{Violations: []FieldViolation{{
+			Field:       field,
+			Description: field + " must be a valid uuid",
+		}}}
+	}
+
+	return nil
+}
+
 func ProcessRequestData(data interface{}, f func(s interface{}, fields ...string) error, fields ...string) error {
 	err := f(data, fields...)
 	if err != nil {
-		var str string
-
 		var validationErrs validator.ValidationErrors
 		if errors.As(err, &validationErrs) {
+			ve := &ValidationError{}
 			for _, e := range validationErrs {
-				str += e.Error() + "\n"
+				ve.Violations = append(ve.Violations, FieldViolation{
+					Field:       e.Field(),
+					Description: e.Error(),
+				})
 			}
-			return errors.New(str)
+			return ve
 		}
 
 		return err
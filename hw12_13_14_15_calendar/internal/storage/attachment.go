@@ -0,0 +1,23 @@
+package storage
+
+import "errors"
+
+var ErrAttachmentNotExist = errors.New("attachment not found in storage")
+
+// Attachment - файл или ссылка, прикрепленные к событию. Содержимое загруженного
+// файла лежит в хранилище файлов под ключом BlobKey, у ссылки задан URL.
+type Attachment struct {
+	ID          string `json:"id"`
+	EventID     string `json:"eventId" validate:"required,uuid"`
+	Name        string `json:"name" validate:"required,max=255"`
+	ContentType string `json:"contentType" validate:"max=255"`
+	Size        int64  `json:"size" validate:"min=0"`
+	URL         string `json:"url,omitempty" validate:"required_without=BlobKey,omitempty,http_url"`
+	BlobKey     string `json:"-"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+// Stored сообщает, хранится ли содержимое вложения в хранилище файлов.
+func (a *Attachment) Stored() bool {
+	return a.BlobKey != ""
+}
@@ -0,0 +1,81 @@
+package storage
+
+import "fmt"
+
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+const (
+	BatchStatusApplied    = "applied"
+	BatchStatusInvalid    = "invalid"
+	BatchStatusFailed     = "failed"
+	BatchStatusRolledBack = "rolled_back"
+	BatchStatusSkipped    = "skipped"
+)
+
+// BatchOperation - одна операция пакета. Для update и delete обязателен ID,
+// для create и update - Event.
+type BatchOperation struct {
+	Op    string `json:"op"`
+	ID    string `json:"id,omitempty"`
+	Event Event  `json:"event"`
+}
+
+// BatchResult - результат операции пакета. Event заполняется хранилищем:
+// для create и update это записанное событие, для delete - удаленное.
+type BatchResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	ID     string `json:"id,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Event  Event  `json:"-"`
+}
+
+// BatchError - ошибка операции с индексом Index, из-за которой пакет откатан.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch operation %d: %s", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// RollbackBatch отмечает результаты пакета, откатанного из-за ошибки операции index.
+func RollbackBatch(results []BatchResult, index int, err error) []BatchResult {
+	for i := range results {
+		switch {
+		case i < index:
+			results[i].Status = BatchStatusRolledBack
+		case i == index:
+			results[i].Status = BatchStatusFailed
+			results[i].Error = err.Error()
+		default:
+			results[i].Status = BatchStatusSkipped
+		}
+		if results[i].Op == BatchCreate {
+			results[i].ID = ""
+		}
+		results[i].Event = Event{}
+	}
+
+	return results
+}
+
+// NewBatchResults готовит результаты для операций пакета.
+func NewBatchResults(ops []BatchOperation) []BatchResult {
+	results := make([]BatchResult, len(ops))
+	for i, op := range ops {
+		results[i] = BatchResult{Index: i, Op: op.Op, ID: op.ID}
+	}
+
+	return results
+}
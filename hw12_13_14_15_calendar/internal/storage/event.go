@@ -2,37 +2,120 @@ package storage
 
 import (
 	"errors"
-	"strings"
 	"time"
 )
 
+const day = 24 * time.Hour
+
+// MaxEventDuration ограничивает длительность события: хранилище в памяти
+// индексирует событие по каждому дню, который оно занимает.
+const MaxEventDuration = 366 * day
+
 var (
 	ErrEventDuplicateID = errors.New("duplicate event id in storage")
 	ErrEventNotExist    = errors.New("event not found in storage")
 	ErrDateBusy         = errors.New("event date start is busy")
+
+	// ErrUnavailable - хранилище временно не отвечает, запрос можно повторить позже.
+	ErrUnavailable = errors.New("storage is temporarily unavailable")
 )
 
 type Event struct {
-	ID          string `json:"id" validate:"required,uuid"`
-	Title       string `json:"title" validate:"required"`
-	DateStart   string `json:"dateStart" validate:"required,datetime=2006-01-02 15:04:05"`
-	DateEnd     string `json:"dateEnd" validate:"required,datetime=2006-01-02 15:04:05" `
-	Description string `json:"description"`
-	UserID      string `json:"userId" validate:"required,uuid"`
-	DatePost    string `json:"datePost" validate:"datetime=2006-01-02 15:04:05"`
+	ID          string   `json:"id" validate:"required,uuid"`
+	Title       string   `json:"title" validate:"required"`
+	DateStart   string   `json:"dateStart" validate:"required,datetime=2006-01-02 15:04:05"`
+	DateEnd     string   `json:"dateEnd" validate:"required,datetime=2006-01-02 15:04:05" `
+	Description string   `json:"description"`
+	UserID      string   `json:"userId" validate:"required,uuid"`
+	DatePost    string   `json:"datePost" validate:"datetime=2006-01-02 15:04:05"`
+	Category    string   `json:"category,omitempty" validate:"max=64"`
+	Color       string   `json:"color,omitempty" validate:"omitempty,hexcolor"`
+	Tags        []string `json:"tags,omitempty" validate:"max=20,dive,required,max=32,excludesall=0x2C/"`
+	// Resources - идентификаторы забронированных событием ресурсов.
+	Resources []string `json:"resources,omitempty" validate:"max=20,dive,uuid"`
+	// AllDay - событие на целые дни: хранилище выравнивает DateStart на полночь,
+	// а DateEnd - на полночь дня, следующего за последним днем события.
+	AllDay bool `json:"allDay,omitempty"`
 }
 
 type ListEventValidation struct {
 	DateStart string `json:"dateStart" validate:"required,datetime=2006-01-02"`
 }
 
+type ListEventRangeValidation struct {
+	DateFrom string `json:"from" validate:"required,datetime=2006-01-02"`
+	DateTo   string `json:"to" validate:"required,datetime=2006-01-02"`
+}
+
+type FeedFilterValidation struct {
+	UserID   string `json:"userId" validate:"omitempty,uuid"`
+	DateFrom string `json:"from" validate:"omitempty,datetime=2006-01-02"`
+	DateTo   string `json:"to" validate:"omitempty,datetime=2006-01-02"`
+}
+
 func (e *Event) DateStartUnix() int64 {
 	t, _ := time.Parse("2006-01-02 15:04:05", e.DateStart)
 	return t.Unix()
 }
 
-func (e *Event) DateStartDayUnix() int64 {
-	s := strings.Split(e.DateStart, " ")
-	t, _ := time.Parse("2006-01-02", s[0])
-	return t.Unix()
+// Normalize приводит событие к виду, в котором его сохраняет хранилище.
+func (e *Event) Normalize() {
+	e.Tags = NormalizeTags(e.Tags)
+	e.Resources = NormalizeResources(e.Resources)
+
+	if !e.AllDay {
+		return
+	}
+
+	start, err := time.Parse(time.DateTime, e.DateStart)
+	if err != nil {
+		return
+	}
+	end, err := time.Parse(time.DateTime, e.DateEnd)
+	if err != nil {
+		return
+	}
+
+	start = start.Truncate(day)
+	if last := end.Truncate(day); last.Before(end) {
+		end = last.Add(day)
+	}
+	if !end.After(start) {
+		end = start.Add(day)
+	}
+
+	e.DateStart = start.Format(time.DateTime)
+	e.DateEnd = end.Format(time.DateTime)
+}
+
+// Overlaps сообщает, пересекается ли событие с интервалом [from, to). Событие
+// нулевой длительности пересекается с интервалом, в который попадает его начало.
+func (e *Event) Overlaps(from time.Time, to time.Time) bool {
+	start, end := e.bounds()
+
+	return start.Before(to) && (end.After(from) || !start.Before(from))
+}
+
+// Days возвращает unix-время полуночи каждого дня, который занимает событие.
+func (e *Event) Days() []int64 {
+	start, end := e.bounds()
+
+	days := []int64{start.Truncate(day).Unix()}
+	for d := start.Truncate(day).Add(day); d.Before(end); d = d.Add(day) {
+		days = append(days, d.Unix())
+	}
+
+	return days
+}
+
+// bounds возвращает начало и конец события. Конец раньше начала считается равным началу.
+func (e *Event) bounds() (time.Time, time.Time) {
+	start, _ := time.Parse(time.DateTime, e.DateStart)
+
+	end, err := time.Parse(time.DateTime, e.DateEnd)
+	if err != nil || end.Before(start) {
+		end = start
+	}
+
+	return start, end
 }
@@ -0,0 +1,88 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+// Вложения удаленных событий, как и состояния напоминаний, не видны и не попадают в снимок.
+func (s *Storage) CreateAttachment(_ context.Context, a storage.Attachment) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.eventsByID[a.EventID]; !ok {
+		return "", storage.ErrEventNotExist
+	}
+
+	a.ID = uuid.NewString()
+	a.CreatedAt = time.Now().Format(time.DateTime)
+
+	err := s.persist(attachmentPut(a))
+	if err != nil {
+		return "", err
+	}
+
+	s.attachments[a.ID] = a
+
+	return a.ID, nil
+}
+
+func (s *Storage) GetAttachment(_ context.Context, id string) (storage.Attachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	a, ok := s.attachments[id]
+	if _, exists := s.eventsByID[a.EventID]; !ok || !exists {
+		return storage.Attachment{}, storage.ErrAttachmentNotExist
+	}
+
+	return a, nil
+}
+
+func (s *Storage) ListAttachments(_ context.Context, eventID string) ([]storage.Attachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	attachments := make([]storage.Attachment, 0)
+	if _, ok := s.eventsByID[eventID]; !ok {
+		return attachments, nil
+	}
+
+	for _, a := range s.attachments {
+		if a.EventID == eventID {
+			attachments = append(attachments, a)
+		}
+	}
+
+	sort.Slice(attachments, func(i, j int) bool {
+		if attachments[i].CreatedAt != attachments[j].CreatedAt {
+			return attachments[i].CreatedAt < attachments[j].CreatedAt
+		}
+		return attachments[i].ID < attachments[j].ID
+	})
+
+	return attachments, nil
+}
+
+func (s *Storage) DeleteAttachment(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.attachments[id]
+	if _, exists := s.eventsByID[a.EventID]; !ok || !exists {
+		return storage.ErrAttachmentNotExist
+	}
+
+	err := s.persist(attachmentDelete(id))
+	if err != nil {
+		return err
+	}
+
+	delete(s.attachments, id)
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package memorystorage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+// ApplyBatch выполняет операции под одной блокировкой. При ошибке уже
+// выполненные операции отменяются в обратном порядке.
+func (s *Storage) ApplyBatch(_ context.Context, ops []storage.BatchOperation) ([]storage.BatchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := storage.NewBatchResults(ops)
+	undo := make([]func(), 0, len(ops))
+	records := make([]record, 0, len(ops))
+
+	for i, op := range ops {
+		e, err := s.applyOperation(op, &results[i], &undo)
+		if err != nil {
+			rollback(undo)
+			return storage.RollbackBatch(results, i, err), &storage.BatchError{Index: i, Err: err}
+		}
+
+		results[i].Event = e
+		results[i].Status = storage.BatchStatusApplied
+		records = append(records, operationRecord(op, e))
+	}
+
+	err := s.persist(records...)
+	if err != nil {
+		rollback(undo)
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// applyLogged выполняет одну операцию и записывает ее в журнал.
+// Если запись в журнал не удалась, операция отменяется.
+func (s *Storage) applyLogged(op storage.BatchOperation) (storage.Event, error) {
+	var r storage.BatchResult
+	var undo []func()
+
+	e, err := s.applyOperation(op, &r, &undo)
+	if err != nil {
+		return storage.Event{}, err
+	}
+
+	err = s.persist(operationRecord(op, e))
+	if err != nil {
+		rollback(undo)
+		return storage.Event{}, err
+	}
+
+	return e, nil
+}
+
+func operationRecord(op storage.BatchOperation, e storage.Event) record {
+	if op.Op == storage.BatchDelete {
+		return eventDelete(op.ID)
+	}
+
+	return eventPut(e)
+}
+
+func rollback(undo []func()) {
+	for j := len(undo) - 1; j >= 0; j-- {
+		undo[j]()
+	}
+}
+
+func (s *Storage) applyOperation(op storage.BatchOperation, r *storage.BatchResult, undo *[]func()) (storage.Event, error) {
+	switch op.Op {
+	case storage.BatchCreate:
+		id, err := s.insertEvent(op.Event)
+		if err != nil {
+			return storage.Event{}, err
+		}
+		r.ID = id
+		*undo = append(*undo, func() { _, _ = s.removeEvent(id) })
+		return *s.eventsByID[id], nil
+	case storage.BatchUpdate:
+		prev, err := s.replaceEvent(op.ID, op.Event)
+		if err != nil {
+			return storage.Event{}, err
+		}
+		*undo = append(*undo, func() {
+			s.deleteEvent(op.ID, *s.eventsByID[op.ID])
+			s.createEvent(op.ID, prev)
+		})
+		return *s.eventsByID[op.ID], nil
+	case storage.BatchDelete:
+		prev, err := s.removeEvent(op.ID)
+		if err != nil {
+			return storage.Event{}, err
+		}
+		*undo = append(*undo, func() { s.createEvent(op.ID, prev) })
+		return prev, nil
+	default:
+		return storage.Event{}, fmt.Errorf("unsupported batch operation %q", op.Op)
+	}
+}
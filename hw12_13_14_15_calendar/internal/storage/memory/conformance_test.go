@@ -0,0 +1,31 @@
+package memorystorage_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	memorystorage "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage/storagetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) app.Storager {
+		t.Helper()
+
+		return memorystorage.New()
+	})
+}
+
+func TestConformancePersistent(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) app.Storager {
+		t.Helper()
+
+		s := memorystorage.NewPersistent(memorystorage.Persistence{Dir: t.TempDir(), Sync: memorystorage.SyncNever})
+		require.NoError(t, s.Open(context.Background()))
+		t.Cleanup(func() { s.Close() })
+
+		return s
+	})
+}
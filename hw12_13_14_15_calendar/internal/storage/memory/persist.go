@@ -0,0 +1,422 @@
+package memorystorage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+// Политики сброса журнала на диск.
+const (
+	SyncAlways   = "always"
+	SyncInterval = "interval"
+	SyncNever    = "never"
+)
+
+const (
+	DefaultSnapshotEvery = 1000
+	DefaultSyncInterval  = time.Second
+
+	walFile      = "wal.log"
+	snapshotFile = "snapshot.log"
+)
+
+// ErrClosed - сохраняемое хранилище не открыто или уже закрыто, и изменение некуда записать.
+var ErrClosed = errors.New("storage is closed")
+
+// Persistence включает сохранение хранилища в каталог Dir: каждое изменение
+// дописывается в журнал, а каждые SnapshotEvery записей состояние сохраняется
+// снимком и журнал очищается.
+type Persistence struct {
+	Dir           string
+	Sync          string
+	SyncInterval  time.Duration
+	SnapshotEvery int
+}
+
+const (
+	recordEventPut         = "event_put"
+	recordEventDelete      = "event_delete"
+	recordWebhookPut       = "webhook_put"
+	recordWebhookDelete    = "webhook_delete"
+	recordDeliveryPut      = "delivery_put"
+	recordPreferencesPut   = "preferences_put"
+	recordReminderPut      = "reminder_put"
+	recordAttachmentPut    = "attachment_put"
+	recordAttachmentDelete = "attachment_delete"
+	recordResourcePut      = "resource_put"
+	recordResourceDelete   = "resource_delete"
+)
+
+// record хранит итоговое состояние объекта, поэтому повторное применение
+// записей поверх снимка не меняет результат.
+type record struct {
+	Type        string                   `json:"type"`
+	ID          string                   `json:"id,omitempty"`
+	Event       *storage.Event           `json:"event,omitempty"`
+	Webhook     *storage.Webhook         `json:"webhook,omitempty"`
+	Delivery    *storage.WebhookDelivery `json:"delivery,omitempty"`
+	DeliveryKey string                   `json:"deliveryKey,omitempty"`
+	Preferences *storage.Preferences     `json:"preferences,omitempty"`
+	Reminder    *storage.ReminderState   `json:"reminder,omitempty"`
+	Attachment  *storage.Attachment      `json:"attachment,omitempty"`
+	BlobKey     string                   `json:"blobKey,omitempty"`
+	Resource    *storage.Resource        `json:"resource,omitempty"`
+}
+
+func eventPut(e storage.Event) record {
+	return record{Type: recordEventPut, Event: &e}
+}
+
+func eventDelete(id string) record {
+	return record{Type: recordEventDelete, ID: id}
+}
+
+func webhookPut(w storage.Webhook) record {
+	return record{Type: recordWebhookPut, Webhook: &w}
+}
+
+func webhookDelete(id string) record {
+	return record{Type: recordWebhookDelete, ID: id}
+}
+
+func deliveryPut(d storage.WebhookDelivery) record {
+	return record{Type: recordDeliveryPut, Delivery: &d, DeliveryKey: d.Key}
+}
+
+func preferencesPut(p storage.Preferences) record {
+	return record{Type: recordPreferencesPut, Preferences: &p}
+}
+
+func reminderPut(r storage.ReminderState) record {
+	return record{Type: recordReminderPut, Reminder: &r}
+}
+
+func attachmentPut(a storage.Attachment) record {
+	return record{Type: recordAttachmentPut, Attachment: &a, BlobKey: a.BlobKey}
+}
+
+func attachmentDelete(id string) record {
+	return record{Type: recordAttachmentDelete, ID: id}
+}
+
+func resourcePut(r storage.Resource) record {
+	return record{Type: recordResourcePut, Resource: &r}
+}
+
+func resourceDelete(id string) record {
+	return record{Type: recordResourceDelete, ID: id}
+}
+
+// NewPersistent создает хранилище, которое восстанавливает состояние из p.Dir в Open.
+func NewPersistent(p Persistence) *Storage {
+	if p.Sync == "" {
+		p.Sync = SyncInterval
+	}
+	if p.SyncInterval <= 0 {
+		p.SyncInterval = DefaultSyncInterval
+	}
+	if p.SnapshotEvery <= 0 {
+		p.SnapshotEvery = DefaultSnapshotEvery
+	}
+
+	s := New()
+	s.persistence = p
+
+	return s
+}
+
+// open восстанавливает состояние из снимка и журнала и открывает журнал на дозапись.
+func (s *Storage) open() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := os.MkdirAll(s.persistence.Dir, 0o700)
+	if err != nil {
+		return err
+	}
+
+	err = s.loadSnapshot()
+	if err != nil {
+		return err
+	}
+
+	s.wal, err = openWAL(filepath.Join(s.persistence.Dir, walFile), func(data []byte) error {
+		s.walRecords++
+		return s.replay(data)
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.persistence.Sync == SyncInterval {
+		s.stopSync = make(chan struct{})
+		s.syncDone = make(chan struct{})
+		go s.syncLoop(s.stopSync, s.syncDone)
+	}
+
+	return nil
+}
+
+func (s *Storage) close() error {
+	if s.stopSync != nil {
+		close(s.stopSync)
+		<-s.syncDone
+		s.stopSync = nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.wal == nil {
+		return nil
+	}
+
+	err := errors.Join(s.snapshot(), s.wal.close())
+	s.wal = nil
+
+	return err
+}
+
+func (s *Storage) syncLoop(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(s.persistence.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if s.wal != nil {
+				// После ошибки сброса журнал отклоняет запись, и ошибку получит следующее изменение.
+				_ = s.wal.sync()
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// persist дописывает записи в журнал одной записью, чтобы после сбоя они
+// применились все вместе или не применились совсем. Вызывается под блокировкой s.mu.
+func (s *Storage) persist(records ...record) error {
+	if s.persistence.Dir == "" || len(records) == 0 {
+		return nil
+	}
+	if s.wal == nil {
+		return ErrClosed
+	}
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+
+	err = s.wal.write(data, s.persistence.Sync == SyncAlways)
+	if err != nil {
+		return err
+	}
+
+	s.walRecords++
+	if s.walRecords >= s.persistence.SnapshotEvery {
+		// Изменение уже в журнале, поэтому неудачный снимок только откладывает очистку журнала.
+		_ = s.snapshot()
+	}
+
+	return nil
+}
+
+func (s *Storage) replay(data []byte) error {
+	var records []record
+
+	err := json.Unmarshal(data, &records)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		err = s.applyRecord(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) applyRecord(r record) error {
+	switch r.Type {
+	case recordEventPut:
+		if prev, ok := s.eventsByID[r.Event.ID]; ok {
+			s.deleteEvent(prev.ID, *prev)
+		}
+		s.createEvent(r.Event.ID, *r.Event)
+	case recordEventDelete:
+		if prev, ok := s.eventsByID[r.ID]; ok {
+			s.deleteEvent(prev.ID, *prev)
+		}
+	case recordWebhookPut:
+		s.webhooks[r.Webhook.ID] = *r.Webhook
+	case recordWebhookDelete:
+		s.deleteWebhook(r.ID)
+	case recordDeliveryPut:
+		d := *r.Delivery
+		d.Key = r.DeliveryKey
+		s.deliveries[d.ID] = &d
+		if d.Key != "" {
+			s.deliveryKeys[d.WebhookID+d.Key] = d.ID
+		}
+	case recordPreferencesPut:
+		s.preferences[r.Preferences.UserID] = *r.Preferences
+	case recordReminderPut:
+		s.reminders[reminderKey{r.Reminder.EventID, r.Reminder.UserID}] = *r.Reminder
+	case recordAttachmentPut:
+		a := *r.Attachment
+		a.BlobKey = r.BlobKey
+		s.attachments[a.ID] = a
+	case recordAttachmentDelete:
+		delete(s.attachments, r.ID)
+	case recordResourcePut:
+		s.resources[r.Resource.ID] = *r.Resource
+	case recordResourceDelete:
+		delete(s.resources, r.ID)
+	default:
+		return fmt.Errorf("unknown log record type %q", r.Type)
+	}
+
+	return nil
+}
+
+func (s *Storage) loadSnapshot() error {
+	file, err := os.Open(filepath.Join(s.persistence.Dir, snapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Снимок записывается целиком и переименовывается, поэтому его повреждение - ошибка.
+	_, err = readEntries(file, s.replay)
+	if err != nil {
+		return fmt.Errorf("read snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// snapshot сохраняет состояние во временный файл, атомарно заменяет им снимок
+// и очищает журнал. Вызывается под блокировкой s.mu.
+func (s *Storage) snapshot() error {
+	path := filepath.Join(s.persistence.Dir, snapshotFile)
+	tmp := path + ".tmp"
+
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	err = s.writeSnapshot(file)
+	if err == nil {
+		err = file.Sync()
+	}
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return err
+	}
+
+	err = syncDir(s.persistence.Dir)
+	if err != nil {
+		return err
+	}
+
+	s.walRecords = 0
+
+	return s.wal.reset()
+}
+
+func (s *Storage) writeSnapshot(file *os.File) error {
+	records := make([]record, 0, len(s.eventsByID)+len(s.webhooks)+len(s.deliveries)+len(s.preferences)+
+		len(s.reminders)+len(s.attachments)+len(s.resources))
+
+	for _, r := range s.resources {
+		records = append(records, resourcePut(r))
+	}
+
+	for _, e := range s.eventsByID {
+		records = append(records, eventPut(*e))
+	}
+	for _, w := range s.webhooks {
+		records = append(records, webhookPut(w))
+	}
+	for _, d := range s.deliveries {
+		records = append(records, deliveryPut(*d))
+	}
+	for _, p := range s.preferences {
+		records = append(records, preferencesPut(p))
+	}
+	for k, r := range s.reminders {
+		// Состояния удаленных событий в снимок не попадают.
+		if _, ok := s.eventsByID[k.eventID]; !ok {
+			delete(s.reminders, k)
+			continue
+		}
+		records = append(records, reminderPut(r))
+	}
+	for id, a := range s.attachments {
+		if _, ok := s.eventsByID[a.EventID]; !ok {
+			delete(s.attachments, id)
+			continue
+		}
+		records = append(records, attachmentPut(a))
+	}
+
+	// Снимок пишется порциями, чтобы не держать в памяти весь его JSON.
+	const chunk = 1000
+
+	for i := 0; i < len(records); i += chunk {
+		end := i + chunk
+		if end > len(records) {
+			end = len(records)
+		}
+
+		data, err := json.Marshal(records[i:end])
+		if err != nil {
+			return err
+		}
+
+		err = writeEntry(file, data)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
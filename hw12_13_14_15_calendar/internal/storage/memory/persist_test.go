@@ -0,0 +1,338 @@
+package memorystorage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func openPersistent(t *testing.T, p Persistence) *Storage {
+	t.Helper()
+
+	s := NewPersistent(p)
+	require.NoError(t, s.Open(context.Background()))
+
+	return s
+}
+
+func createEvents(t *testing.T, s *Storage, n int) []string {
+	t.Helper()
+
+	ids := make([]string, n)
+	for i := range ids {
+		id, err := s.CreateEvent(context.Background(), storage.Event{
+			Title:     fmt.Sprintf("event %d", i),
+			DateStart: fmt.Sprintf("2022-10-11 %02d:00:00", i),
+		})
+		require.NoError(t, err)
+		ids[i] = id
+	}
+
+	return ids
+}
+
+// failingFile обрывает запись на половине или не сбрасывает данные на диск.
+type failingFile struct {
+	*os.File
+	failWrite bool
+	failSync  bool
+}
+
+func (f *failingFile) Write(p []byte) (int, error) {
+	if f.failWrite {
+		n, _ := f.File.Write(p[:len(p)/2])
+		return n, syscall.ENOSPC
+	}
+
+	return f.File.Write(p)
+}
+
+func (f *failingFile) Sync() error {
+	if f.failSync {
+		return syscall.EIO
+	}
+
+	return f.File.Sync()
+}
+
+func TestWALWriteFailure(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("torn write is cut off", func(t *testing.T) {
+		p := Persistence{Dir: t.TempDir(), Sync: SyncAlways}
+
+		s := openPersistent(t, p)
+		f := &failingFile{File: s.wal.file.(*os.File)}
+		s.wal.file = f
+
+		ids := createEvents(t, s, 1)
+
+		f.failWrite = true
+		_, err := s.CreateEvent(ctx, storage.Event{Title: "lost", DateStart: "2022-10-11 10:00:00"})
+		require.ErrorIs(t, err, syscall.ENOSPC)
+
+		f.failWrite = false
+		id, err := s.CreateEvent(ctx, storage.Event{Title: "kept", DateStart: "2022-10-11 11:00:00"})
+		require.NoError(t, err)
+		ids = append(ids, id)
+
+		// Сбой без Close: состояние собирается только из журнала.
+		restored := openPersistent(t, p)
+		defer restored.Close()
+
+		events, err := restored.ListEventDay(ctx, "2022-10-11")
+		require.NoError(t, err)
+		require.Len(t, events, 2, "write after the failed one is kept")
+		for i, e := range events {
+			require.Equal(t, ids[i], e.ID)
+		}
+	})
+
+	t.Run("failed sync is not replayed", func(t *testing.T) {
+		p := Persistence{Dir: t.TempDir(), Sync: SyncAlways}
+
+		s := openPersistent(t, p)
+		f := &failingFile{File: s.wal.file.(*os.File)}
+		s.wal.file = f
+
+		f.failSync = true
+		_, err := s.CreateEvent(ctx, storage.Event{Title: "rolled back", DateStart: "2022-10-11 10:00:00"})
+		require.ErrorIs(t, err, syscall.EIO)
+
+		f.failSync = false
+		_, err = s.CreateEvent(ctx, storage.Event{Title: "refused", DateStart: "2022-10-11 11:00:00"})
+		require.Error(t, err, "log refuses writes after failed sync")
+
+		restored := openPersistent(t, p)
+		defer restored.Close()
+
+		events, err := restored.ListEventDay(ctx, "2022-10-11")
+		require.NoError(t, err)
+		require.Empty(t, events)
+	})
+}
+
+func TestPersistence(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("restore after close", func(t *testing.T) {
+		p := Persistence{Dir: t.TempDir(), Sync: SyncAlways}
+
+		s := openPersistent(t, p)
+		ids := createEvents(t, s, 3)
+		require.NoError(t, s.UpdateEvent(ctx, ids[0], storage.Event{Title: "renamed", DateStart: "2022-10-12 10:00:00"}))
+		require.NoError(t, s.DeleteEvent(ctx, ids[1]))
+
+		webhookID, err := s.CreateWebhook(ctx, storage.Webhook{URL: "http://localhost/hook", EventTypes: []string{"created"}})
+		require.NoError(t, err)
+		_, err = s.CreateDelivery(ctx, storage.WebhookDelivery{WebhookID: webhookID, Key: "reminder:1"})
+		require.NoError(t, err)
+
+		prefs := storage.Preferences{UserID: "user", WeeklyDigest: true, DigestTime: "09:15"}
+		require.NoError(t, s.SavePreferences(ctx, prefs))
+		require.NoError(t, s.Close())
+
+		s = openPersistent(t, p)
+		defer s.Close()
+
+		e, err := s.GetEvent(ctx, ids[0])
+		require.NoError(t, err)
+		require.Equal(t, "renamed", e.Title)
+
+		_, err = s.GetEvent(ctx, ids[1])
+		require.ErrorIs(t, err, storage.ErrEventNotExist)
+
+		events, err := s.ListEventDay(ctx, "2022-10-11")
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, ids[2], events[0].ID)
+
+		_, err = s.CreateDelivery(ctx, storage.WebhookDelivery{WebhookID: webhookID, Key: "reminder:1"})
+		require.ErrorIs(t, err, storage.ErrDeliveryDuplicate)
+
+		got, err := s.GetPreferences(ctx, "user")
+		require.NoError(t, err)
+		require.Equal(t, prefs, got)
+	})
+
+	t.Run("write after close", func(t *testing.T) {
+		p := Persistence{Dir: t.TempDir(), Sync: SyncAlways}
+
+		s := openPersistent(t, p)
+		ids := createEvents(t, s, 1)
+		require.NoError(t, s.Close())
+
+		_, err := s.CreateEvent(ctx, storage.Event{Title: "lost", DateStart: "2022-10-11 10:00:00"})
+		require.ErrorIs(t, err, ErrClosed)
+		require.ErrorIs(t, s.DeleteEvent(ctx, ids[0]), ErrClosed)
+
+		_, err = s.GetEvent(ctx, ids[0])
+		require.NoError(t, err, "rejected delete is rolled back")
+
+		events, err := s.ListEventDay(ctx, "2022-10-11")
+		require.NoError(t, err)
+		require.Len(t, events, 1, "rejected create is rolled back")
+	})
+
+	t.Run("snapshot compacts log", func(t *testing.T) {
+		p := Persistence{Dir: t.TempDir(), Sync: SyncNever, SnapshotEvery: 2}
+
+		s := openPersistent(t, p)
+		ids := createEvents(t, s, 5)
+
+		// После четвертой записи сделан снимок, в журнале осталась только пятая.
+		_, err := os.Stat(filepath.Join(p.Dir, snapshotFile))
+		require.NoError(t, err)
+		require.Equal(t, 1, s.walRecords)
+
+		// Сбой без Close: состояние собирается из снимка и журнала.
+		restored := openPersistent(t, p)
+		defer restored.Close()
+
+		for _, id := range ids {
+			_, err = restored.GetEvent(ctx, id)
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("restore resources from snapshot", func(t *testing.T) {
+		p := Persistence{Dir: t.TempDir(), Sync: SyncNever, SnapshotEvery: 2}
+
+		s := openPersistent(t, p)
+		room, err := s.CreateResource(ctx, storage.Resource{Name: "Orion", Kind: storage.ResourceRoom, Capacity: 8})
+		require.NoError(t, err)
+		id, err := s.CreateEvent(ctx, storage.Event{
+			DateStart: "2022-10-11 10:00:00", DateEnd: "2022-10-11 11:00:00", Resources: []string{room},
+		})
+		require.NoError(t, err)
+		require.NoError(t, s.Close())
+
+		s = openPersistent(t, p)
+		defer s.Close()
+
+		e, err := s.GetEvent(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, []string{room}, e.Resources)
+
+		_, err = s.CreateEvent(ctx, storage.Event{
+			DateStart: "2022-10-11 10:30:00", DateEnd: "2022-10-11 11:30:00", Resources: []string{room},
+		})
+		require.ErrorIs(t, err, storage.ErrResourceBusy)
+	})
+
+	t.Run("failed batch is not logged", func(t *testing.T) {
+		p := Persistence{Dir: t.TempDir(), Sync: SyncAlways}
+
+		s := openPersistent(t, p)
+		_, err := s.ApplyBatch(ctx, []storage.BatchOperation{
+			{Op: storage.BatchCreate, Event: storage.Event{DateStart: "2022-10-11 12:00:00"}},
+			{Op: storage.BatchCreate, Event: storage.Event{DateStart: "2022-10-11 12:00:00"}},
+		})
+		require.ErrorIs(t, err, storage.ErrDateBusy)
+
+		info, err := os.Stat(filepath.Join(p.Dir, walFile))
+		require.NoError(t, err)
+		require.Zero(t, info.Size())
+	})
+}
+
+func TestCrashRecovery(t *testing.T) {
+	ctx := context.Background()
+
+	cases := []struct {
+		name    string
+		lost    bool
+		corrupt func(t *testing.T, path string)
+	}{
+		{
+			name: "truncated payload",
+			lost: true,
+			corrupt: func(t *testing.T, path string) {
+				t.Helper()
+				info, err := os.Stat(path)
+				require.NoError(t, err)
+				require.NoError(t, os.Truncate(path, info.Size()-5))
+			},
+		},
+		{
+			name: "truncated header",
+			corrupt: func(t *testing.T, path string) {
+				t.Helper()
+				f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+				require.NoError(t, err)
+				_, err = f.Write([]byte{0x10, 0x00, 0x00})
+				require.NoError(t, err)
+				require.NoError(t, f.Close())
+			},
+		},
+		{
+			name: "checksum mismatch",
+			lost: true,
+			corrupt: func(t *testing.T, path string) {
+				t.Helper()
+				data, err := os.ReadFile(path)
+				require.NoError(t, err)
+				data[len(data)-2] ^= 0xff
+				require.NoError(t, os.WriteFile(path, data, 0o600))
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := Persistence{Dir: t.TempDir(), Sync: SyncAlways}
+
+			s := openPersistent(t, p)
+			ids := createEvents(t, s, 3)
+
+			// Процесс падает без Close, последняя запись журнала повреждена.
+			path := filepath.Join(p.Dir, walFile)
+			tc.corrupt(t, path)
+
+			restored := openPersistent(t, p)
+
+			for _, id := range ids[:2] {
+				_, err := restored.GetEvent(ctx, id)
+				require.NoError(t, err)
+			}
+			_, err := restored.GetEvent(ctx, ids[2])
+			if tc.lost {
+				require.ErrorIs(t, err, storage.ErrEventNotExist)
+			} else {
+				require.NoError(t, err)
+			}
+
+			// Запись после восстановления не теряется за отброшенным хвостом.
+			id, err := restored.CreateEvent(ctx, storage.Event{DateStart: "2022-10-12 12:00:00"})
+			require.NoError(t, err)
+
+			again := openPersistent(t, p)
+			defer again.Close()
+
+			_, err = again.GetEvent(ctx, id)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCorruptSnapshot(t *testing.T) {
+	p := Persistence{Dir: t.TempDir()}
+
+	s := openPersistent(t, p)
+	createEvents(t, s, 2)
+	require.NoError(t, s.Close())
+
+	path := filepath.Join(p.Dir, snapshotFile)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, info.Size()-1))
+
+	err = NewPersistent(p).Open(context.Background())
+	require.Error(t, err)
+}
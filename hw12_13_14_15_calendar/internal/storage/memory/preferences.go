@@ -0,0 +1,33 @@
+package memorystorage
+
+import (
+	"context"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+func (s *Storage) GetPreferences(_ context.Context, userID string) (storage.Preferences, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.preferences[userID]
+	if !ok {
+		return storage.Preferences{}, storage.ErrPreferencesNotExist
+	}
+
+	return p, nil
+}
+
+func (s *Storage) SavePreferences(_ context.Context, p storage.Preferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.persist(preferencesPut(p))
+	if err != nil {
+		return err
+	}
+
+	s.preferences[p.UserID] = p
+
+	return nil
+}
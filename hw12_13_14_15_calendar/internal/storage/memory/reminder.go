@@ -0,0 +1,67 @@
+package memorystorage
+
+import (
+	"context"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+type reminderKey struct {
+	eventID string
+	userID  string
+}
+
+// Состояния напоминаний удаленных событий не видны и не попадают в снимок.
+// Их не удаляют вместе с событием, чтобы откат пакета восстанавливал и их.
+func (s *Storage) GetReminderState(_ context.Context, eventID string, userID string) (storage.ReminderState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, ok := s.reminders[reminderKey{eventID, userID}]
+	if _, exists := s.eventsByID[eventID]; !ok || !exists {
+		return storage.ReminderState{}, storage.ErrReminderStateNotExist
+	}
+
+	return r, nil
+}
+
+func (s *Storage) SaveReminderState(_ context.Context, r storage.ReminderState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.eventsByID[r.EventID]; !ok {
+		return storage.ErrEventNotExist
+	}
+
+	r.NotifiedKey = s.reminders[reminderKey{r.EventID, r.UserID}].NotifiedKey
+
+	return s.putReminder(r)
+}
+
+func (s *Storage) MarkReminderNotified(_ context.Context, eventID string, userID string, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.eventsByID[eventID]; !ok {
+		return storage.ErrEventNotExist
+	}
+
+	r, ok := s.reminders[reminderKey{eventID, userID}]
+	if !ok {
+		r = storage.ReminderState{EventID: eventID, UserID: userID}
+	}
+	r.NotifiedKey = key
+
+	return s.putReminder(r)
+}
+
+func (s *Storage) putReminder(r storage.ReminderState) error {
+	err := s.persist(reminderPut(r))
+	if err != nil {
+		return err
+	}
+
+	s.reminders[reminderKey{r.EventID, r.UserID}] = r
+
+	return nil
+}
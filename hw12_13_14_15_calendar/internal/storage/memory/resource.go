@@ -0,0 +1,178 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+func (s *Storage) CreateResource(_ context.Context, r storage.Resource) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r.ID = uuid.NewString()
+
+	err := s.persist(resourcePut(r))
+	if err != nil {
+		return "", err
+	}
+
+	s.resources[r.ID] = r
+
+	return r.ID, nil
+}
+
+func (s *Storage) GetResource(_ context.Context, id string) (storage.Resource, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, ok := s.resources[id]
+	if !ok {
+		return storage.Resource{}, storage.ErrResourceNotExist
+	}
+
+	return r, nil
+}
+
+func (s *Storage) ListResources(_ context.Context) ([]storage.Resource, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	resources := make([]storage.Resource, 0, len(s.resources))
+	for _, r := range s.resources {
+		resources = append(resources, r)
+	}
+
+	sort.Slice(resources, func(i, j int) bool {
+		if resources[i].Name != resources[j].Name {
+			return resources[i].Name < resources[j].Name
+		}
+		return resources[i].ID < resources[j].ID
+	})
+
+	return resources, nil
+}
+
+func (s *Storage) UpdateResource(_ context.Context, id string, r storage.Resource) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.resources[id]; !ok {
+		return storage.ErrResourceNotExist
+	}
+
+	r.ID = id
+
+	err := s.persist(resourcePut(r))
+	if err != nil {
+		return err
+	}
+
+	s.resources[id] = r
+
+	return nil
+}
+
+// DeleteResource удаляет ресурс и снимает его бронь со всех событий.
+func (s *Storage) DeleteResource(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.resources[id]
+	if !ok {
+		return storage.ErrResourceNotExist
+	}
+
+	records, undo, err := s.replaceEvents(s.eventsByResource[id], func(e *storage.Event) {
+		resources := make([]string, 0, len(e.Resources))
+		for _, v := range e.Resources {
+			if v != id {
+				resources = append(resources, v)
+			}
+		}
+		e.Resources = resources
+	})
+	if err != nil {
+		return err
+	}
+
+	delete(s.resources, id)
+
+	err = s.persist(append(records, resourceDelete(id))...)
+	if err != nil {
+		s.resources[id] = r
+		rollback(undo)
+		return err
+	}
+
+	return nil
+}
+
+// ListBookings возвращает брони, пересекающиеся с интервалом [dateFrom, dateTo).
+func (s *Storage) ListBookings(_ context.Context, dateFrom string, dateTo string) ([]storage.Booking, error) {
+	from, err := time.Parse(time.DateTime, dateFrom)
+	if err != nil {
+		return nil, err
+	}
+	to, err := time.Parse(time.DateTime, dateTo)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	bookings := make([]storage.Booking, 0)
+
+	for resourceID, ids := range s.eventsByResource {
+		for id := range ids {
+			e := s.eventsByID[id]
+			if e.Books(from, to) {
+				bookings = append(bookings, e.Booking(resourceID))
+			}
+		}
+	}
+
+	sort.Slice(bookings, func(i, j int) bool {
+		if bookings[i].ResourceID != bookings[j].ResourceID {
+			return bookings[i].ResourceID < bookings[j].ResourceID
+		}
+		return bookings[i].DateStart < bookings[j].DateStart
+	})
+
+	return bookings, nil
+}
+
+// checkResources проверяет, что ресурсы события существуют и не забронированы
+// другими событиями на пересекающееся время. Вызывается под блокировкой s.mu.
+func (s *Storage) checkResources(e storage.Event) error {
+	if len(e.Resources) == 0 {
+		return nil
+	}
+
+	start, err := time.Parse(time.DateTime, e.DateStart)
+	if err != nil {
+		return err
+	}
+	end, err := time.Parse(time.DateTime, e.DateEnd)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range e.Resources {
+		if _, ok := s.resources[r]; !ok {
+			return storage.ErrResourceNotExist
+		}
+
+		for id := range s.eventsByResource[r] {
+			if id != e.ID && s.eventsByID[id].Books(start, end) {
+				return storage.ErrResourceBusy
+			}
+		}
+	}
+
+	return nil
+}
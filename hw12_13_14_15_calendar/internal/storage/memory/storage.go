@@ -1,6 +1,7 @@
 package memorystorage
 
 import (
+	"context"
 	"sort"
 	"sync"
 	"time"
@@ -14,122 +15,127 @@ type Storage struct {
 	eventsByID        map[string]*storage.Event
 	eventsByDateStart map[string]*storage.Event
 	eventsByDay       map[int64][]storage.Event
+	// eventsByTag - идентификаторы событий по метке.
+	eventsByTag map[string]map[string]struct{}
+	// eventsByResource - идентификаторы событий, забронировавших ресурс.
+	eventsByResource map[string]map[string]struct{}
+	resources        map[string]storage.Resource
+
+	webhooks     map[string]storage.Webhook
+	deliveries   map[string]*storage.WebhookDelivery
+	deliveryKeys map[string]string
+
+	preferences map[string]storage.Preferences
+	reminders   map[reminderKey]storage.ReminderState
+	attachments map[string]storage.Attachment
+
+	persistence Persistence
+	wal         *wal
+	walRecords  int
+	stopSync    chan struct{}
+	syncDone    chan struct{}
 }
 
-func (s *Storage) CreateEvent(event storage.Event) error {
+func (s *Storage) CreateEvent(_ context.Context, event storage.Event) (string, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	id := uuid.NewString()
-
-	if _, ok := s.eventsByID[id]; ok {
-		return storage.ErrEventDuplicateID
-	}
-
-	e := event
-
-	if _, ok := s.eventsByDateStart[e.DateStart]; ok {
-		return storage.ErrDateBusy
-	}
+	e, err := s.applyLogged(storage.BatchOperation{Op: storage.BatchCreate, Event: event})
 
-	e.ID = id
-
-	s.createEvent(id, e)
-
-	return nil
+	return e.ID, err
 }
 
-func (s *Storage) UpdateEvent(id string, event storage.Event) error {
+func (s *Storage) UpdateEvent(_ context.Context, id string, event storage.Event) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, ok := s.eventsByID[id]; !ok {
-		return storage.ErrEventNotExist
-	}
-
-	e := event
-
-	if _, ok := s.eventsByDateStart[e.DateStart]; ok {
-		return storage.ErrDateBusy
-	}
-
-	s.deleteEvent(id, e)
-	s.createEvent(id, e)
+	_, err := s.applyLogged(storage.BatchOperation{Op: storage.BatchUpdate, ID: id, Event: event})
 
-	return nil
+	return err
 }
 
-func (s *Storage) DeleteEvent(id string) error {
+func (s *Storage) DeleteEvent(_ context.Context, id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	e, ok := s.eventsByID[id]
-	if !ok {
-		return storage.ErrEventNotExist
-	}
-
-	s.deleteEvent(id, *e)
+	_, err := s.applyLogged(storage.BatchOperation{Op: storage.BatchDelete, ID: id})
 
-	return nil
+	return err
 }
 
-func (s *Storage) GetEvent(id string) (storage.Event, error) {
+func (s *Storage) GetEvent(_ context.Context, id string) (storage.Event, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	var e *storage.Event
-
 	e, ok := s.eventsByID[id]
 	if !ok {
-		return *e, storage.ErrEventNotExist
+		return storage.Event{}, storage.ErrEventNotExist
 	}
 
 	return *e, nil
 }
 
-func (s *Storage) ListEventDay(date string) ([]storage.Event, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+func (s *Storage) ListEventDay(_ context.Context, date string) ([]storage.Event, error) {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, err
+	}
 
+	return s.listDays(t, t.AddDate(0, 0, 1)), nil
+}
+
+func (s *Storage) ListEventWeek(_ context.Context, date string) ([]storage.Event, error) {
 	t, err := time.Parse("2006-01-02", date)
 	if err != nil {
 		return nil, err
 	}
-	unixTime := t.Unix()
 
-	events := make([]storage.Event, 0)
+	return s.listDays(t, t.AddDate(0, 0, 7)), nil
+}
 
-	slice, ok := s.eventsByDay[unixTime]
-	if !ok {
-		return events, nil
+func (s *Storage) ListEventMonth(_ context.Context, date string) ([]storage.Event, error) {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, err
 	}
 
-	events = append(events, slice...)
+	return s.listDays(t, t.AddDate(0, 1, 0)), nil
+}
 
-	sort.Slice(events, func(i, j int) bool {
-		return events[i].DateStartUnix() < events[j].DateStartUnix()
-	})
+// ListEventRange возвращает события, которые занимают хотя бы один день с dateFrom по dateTo включительно.
+func (s *Storage) ListEventRange(_ context.Context, dateFrom string, dateTo string) ([]storage.Event, error) {
+	from, err := time.Parse("2006-01-02", dateFrom)
+	if err != nil {
+		return nil, err
+	}
+	to, err := time.Parse("2006-01-02", dateTo)
+	if err != nil {
+		return nil, err
+	}
 
-	return events, nil
+	return s.listDays(from, to.AddDate(0, 0, 1)), nil
 }
 
-func (s *Storage) ListEventWeek(date string) ([]storage.Event, error) {
+// listDays возвращает события, которые занимают хотя бы один день из [from, to). Многодневное
+// событие есть в индексе каждого своего дня, поэтому повторы отбрасываются.
+func (s *Storage) listDays(from time.Time, to time.Time) []storage.Event {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	events := make([]storage.Event, 0)
-
-	t, err := time.Parse("2006-01-02", date)
-	if err != nil {
-		return nil, err
-	}
-	startTimeUnix := t.Unix()
-
-	endTimeUnix := t.AddDate(0, 0, 7).Unix()
+	seen := make(map[string]struct{})
 
 	for unixTime, slice := range s.eventsByDay {
-		if unixTime >= startTimeUnix && unixTime < endTimeUnix {
-			events = append(events, slice...)
+		if unixTime < from.Unix() || unixTime >= to.Unix() {
+			continue
+		}
+
+		for _, e := range slice {
+			if _, ok := seen[e.ID]; ok {
+				continue
+			}
+			seen[e.ID] = struct{}{}
+			events = append(events, e)
 		}
 	}
 
@@ -137,76 +143,160 @@ func (s *Storage) ListEventWeek(date string) ([]storage.Event, error) {
 		return events[i].DateStartUnix() < events[j].DateStartUnix()
 	})
 
-	return events, nil
+	return events
 }
 
-func (s *Storage) ListEventMonth(date string) ([]storage.Event, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+func (s *Storage) Event(id string) (storage.Event, error) {
+	val, ok := s.eventsByID[id]
+	if !ok {
+		return storage.Event{}, storage.ErrEventNotExist
+	}
 
-	events := make([]storage.Event, 0)
+	return *val, nil
+}
 
-	t, err := time.Parse("2006-01-02", date)
+// insertEvent, replaceEvent и removeEvent вызываются под блокировкой s.mu.
+func (s *Storage) insertEvent(event storage.Event) (string, error) {
+	id := uuid.NewString()
+
+	if _, ok := s.eventsByID[id]; ok {
+		return "", storage.ErrEventDuplicateID
+	}
+
+	e := event
+	e.Normalize()
+
+	if _, ok := s.eventsByDateStart[e.DateStart]; ok && !e.AllDay {
+		return "", storage.ErrDateBusy
+	}
+
+	e.ID = id
+
+	err := s.checkResources(e)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	startTimeUnix := t.Unix()
 
-	endTimeUnix := t.AddDate(0, 1, 0).Unix()
+	s.createEvent(id, e)
 
-	for unixTime, slice := range s.eventsByDay {
-		if unixTime >= startTimeUnix && unixTime < endTimeUnix {
-			events = append(events, slice...)
-		}
+	return id, nil
+}
+
+// replaceEvent возвращает прежнюю версию события.
+func (s *Storage) replaceEvent(id string, event storage.Event) (storage.Event, error) {
+	old, ok := s.eventsByID[id]
+	if !ok {
+		return storage.Event{}, storage.ErrEventNotExist
 	}
 
-	sort.Slice(events, func(i, j int) bool {
-		return events[i].DateStartUnix() < events[j].DateStartUnix()
-	})
+	e := event
+	e.Normalize()
+
+	if cur, ok := s.eventsByDateStart[e.DateStart]; ok && cur.ID != id && !e.AllDay {
+		return storage.Event{}, storage.ErrDateBusy
+	}
 
-	return events, nil
+	prev := *old
+	e.ID = id
+
+	err := s.checkResources(e)
+	if err != nil {
+		return storage.Event{}, err
+	}
+
+	s.deleteEvent(id, prev)
+	s.createEvent(id, e)
+
+	return prev, nil
 }
 
-func (s *Storage) Event(id string) (storage.Event, error) {
-	val, ok := s.eventsByID[id]
+// removeEvent возвращает удаленное событие.
+func (s *Storage) removeEvent(id string) (storage.Event, error) {
+	e, ok := s.eventsByID[id]
 	if !ok {
 		return storage.Event{}, storage.ErrEventNotExist
 	}
 
-	return *val, nil
+	prev := *e
+	s.deleteEvent(id, prev)
+
+	return prev, nil
 }
 
+// createEvent добавляет событие в индекс каждого дня, который оно занимает.
 func (s *Storage) createEvent(id string, e storage.Event) {
-	unixTime := e.DateStartDayUnix()
-	s.eventsByDay[unixTime] = append(s.eventsByDay[unixTime], e)
+	for _, day := range e.Days() {
+		s.eventsByDay[day] = append(s.eventsByDay[day], e)
+	}
 
 	s.eventsByID[id] = &e
-	s.eventsByDateStart[e.DateStart] = &e
+	// События на целые дни не занимают время начала: их в один день может быть несколько.
+	if !e.AllDay {
+		s.eventsByDateStart[e.DateStart] = &e
+	}
+
+	for _, t := range e.Tags {
+		if s.eventsByTag[t] == nil {
+			s.eventsByTag[t] = make(map[string]struct{})
+		}
+		s.eventsByTag[t][id] = struct{}{}
+	}
+
+	for _, r := range e.Resources {
+		if s.eventsByResource[r] == nil {
+			s.eventsByResource[r] = make(map[string]struct{})
+		}
+		s.eventsByResource[r][id] = struct{}{}
+	}
 }
 
 func (s *Storage) deleteEvent(id string, e storage.Event) {
 	delete(s.eventsByID, id)
-	delete(s.eventsByDateStart, e.DateStart)
+	if cur, ok := s.eventsByDateStart[e.DateStart]; ok && cur.ID == id {
+		delete(s.eventsByDateStart, e.DateStart)
+	}
 
-	dayTimeUnix := e.DateStartDayUnix()
+	for _, t := range e.Tags {
+		delete(s.eventsByTag[t], id)
+		if len(s.eventsByTag[t]) == 0 {
+			delete(s.eventsByTag, t)
+		}
+	}
 
-	slice := s.eventsByDay[dayTimeUnix]
+	for _, r := range e.Resources {
+		delete(s.eventsByResource[r], id)
+		if len(s.eventsByResource[r]) == 0 {
+			delete(s.eventsByResource, r)
+		}
+	}
 
-	for k, v := range slice {
-		if v.ID != id {
-			continue
+	for _, day := range e.Days() {
+		slice := s.eventsByDay[day]
+
+		for k, v := range slice {
+			if v.ID != id {
+				continue
+			}
+			s.eventsByDay[day] = append(slice[:k], slice[k+1:]...)
+			break
 		}
-		s.eventsByDay[dayTimeUnix] = append(slice[:k], slice[k+1:]...)
-		break
 	}
 }
 
-func (s *Storage) Open() error {
-	return nil
+func (s *Storage) Open(_ context.Context) error {
+	if s.persistence.Dir == "" {
+		return nil
+	}
+
+	return s.open()
 }
 
 func (s *Storage) Close() error {
-	return nil
+	if s.persistence.Dir == "" {
+		return nil
+	}
+
+	return s.close()
 }
 
 func New() *Storage {
@@ -214,5 +304,14 @@ func New() *Storage {
 		eventsByID:        make(map[string]*storage.Event),
 		eventsByDateStart: make(map[string]*storage.Event),
 		eventsByDay:       make(map[int64][]storage.Event),
+		eventsByTag:       make(map[string]map[string]struct{}),
+		eventsByResource:  make(map[string]map[string]struct{}),
+		resources:         make(map[string]storage.Resource),
+		webhooks:          make(map[string]storage.Webhook),
+		deliveries:        make(map[string]*storage.WebhookDelivery),
+		deliveryKeys:      make(map[string]string),
+		preferences:       make(map[string]storage.Preferences),
+		reminders:         make(map[reminderKey]storage.ReminderState),
+		attachments:       make(map[string]storage.Attachment),
 	}
 }
@@ -1,6 +1,7 @@
 package memorystorage
 
 import (
+	"context"
 	"strconv"
 	"sync"
 	"testing"
@@ -18,8 +19,9 @@ func TestStorage(t *testing.T) {
 			DateStart: "2022-10-10 00:02:15",
 		}
 
-		err := s.CreateEvent(e)
+		id, err := s.CreateEvent(context.Background(), e)
 		require.NoError(t, err)
+		require.NotEmpty(t, id)
 	})
 
 	t.Run("create fail: date busy", func(t *testing.T) {
@@ -43,7 +45,7 @@ func TestStorage(t *testing.T) {
 			DateStart: "2022-10-10 00:02:15",
 		}
 
-		err := s.CreateEvent(e)
+		_, err := s.CreateEvent(context.Background(), e)
 		require.ErrorIs(t, err, storage.ErrDateBusy)
 	})
 
@@ -67,7 +69,7 @@ func TestStorage(t *testing.T) {
 		datetime := "2023-01-01 10:00:00"
 		updateEvent := storage.Event{DateStart: datetime}
 
-		err := s.UpdateEvent(id, updateEvent)
+		err := s.UpdateEvent(context.Background(), id, updateEvent)
 		require.NoError(t, err)
 
 		val, err := s.Event(id)
@@ -81,21 +83,24 @@ func TestStorage(t *testing.T) {
 
 		s := &Storage{
 			eventsByID: map[string]*storage.Event{
-				id: {ID: id, DateStart: "2022-10-10 00:02:15"},
+				id:  {ID: id, DateStart: "2022-10-10 00:02:15"},
+				"2": {ID: "2", DateStart: "2022-10-10 10:00:00"},
 			},
 			eventsByDateStart: map[string]*storage.Event{
 				"2022-10-10 00:02:15": {ID: id, DateStart: "2022-10-10 00:02:15"},
+				"2022-10-10 10:00:00": {ID: "2", DateStart: "2022-10-10 10:00:00"},
 			},
 			eventsByDay: map[int64][]storage.Event{
 				1665360000: {
 					{ID: id, DateStart: "2022-10-10 00:02:15"},
+					{ID: "2", DateStart: "2022-10-10 10:00:00"},
 				},
 			},
 		}
 
-		updateEvent := storage.Event{DateStart: "2022-10-10 00:02:15"}
-		err := s.UpdateEvent("1", updateEvent)
-		require.Error(t, err, storage.ErrDateBusy)
+		updateEvent := storage.Event{DateStart: "2022-10-10 10:00:00"}
+		err := s.UpdateEvent(context.Background(), "1", updateEvent)
+		require.ErrorIs(t, err, storage.ErrDateBusy)
 	})
 
 	t.Run("update fail: no event", func(t *testing.T) {
@@ -108,7 +113,7 @@ func TestStorage(t *testing.T) {
 		}
 
 		updateEvent := storage.Event{DateStart: "2023-01-01 10:00:00"}
-		err := s.UpdateEvent("2", updateEvent)
+		err := s.UpdateEvent(context.Background(), "2", updateEvent)
 		require.Error(t, err, storage.ErrEventNotExist)
 	})
 
@@ -129,7 +134,7 @@ func TestStorage(t *testing.T) {
 				},
 			},
 		}
-		err := s.DeleteEvent(id)
+		err := s.DeleteEvent(context.Background(), id)
 		require.NoError(t, err)
 
 		_, err = s.Event(id)
@@ -158,7 +163,7 @@ func TestStorage(t *testing.T) {
 		}
 
 		id = "2"
-		err := s.DeleteEvent(id)
+		err := s.DeleteEvent(context.Background(), id)
 		require.Error(t, err, storage.ErrEventNotExist)
 	})
 }
@@ -177,7 +182,7 @@ func TestStorageReadMethods(t *testing.T) {
 			},
 		}
 
-		events, err := s.ListEventDay("2022-10-10")
+		events, err := s.ListEventDay(context.Background(), "2022-10-10")
 		require.NoError(t, err)
 
 		require.Truef(t, len(events) == 2, "expected length: %d, actual: %d", 2, len(events))
@@ -196,7 +201,7 @@ func TestStorageReadMethods(t *testing.T) {
 			},
 		}
 
-		events, err := s.ListEventDay("2022-11-10")
+		events, err := s.ListEventDay(context.Background(), "2022-11-10")
 		require.NoError(t, err)
 
 		require.Truef(t, len(events) == 0, "expected length: %d, actual: %d", 0, len(events))
@@ -218,7 +223,7 @@ func TestStorageReadMethods(t *testing.T) {
 			},
 		}
 
-		events, err := s.ListEventWeek("2022-10-10")
+		events, err := s.ListEventWeek(context.Background(), "2022-10-10")
 		require.NoError(t, err)
 
 		require.Truef(t, len(events) == 3, "expected length: %d, actual: %d", 3, len(events))
@@ -240,7 +245,7 @@ func TestStorageReadMethods(t *testing.T) {
 			},
 		}
 
-		events, err := s.ListEventWeek("2022-11-10")
+		events, err := s.ListEventWeek(context.Background(), "2022-11-10")
 		require.NoError(t, err)
 
 		require.Truef(t, len(events) == 0, "expected length: %d, actual: %d", 0, len(events))
@@ -265,7 +270,7 @@ func TestStorageReadMethods(t *testing.T) {
 			},
 		}
 
-		events, err := s.ListEventMonth("2022-10-10")
+		events, err := s.ListEventMonth(context.Background(), "2022-10-10")
 		require.NoError(t, err)
 
 		require.Truef(t, len(events) == 4, "expected length: %d, actual: %d", 4, len(events))
@@ -290,11 +295,37 @@ func TestStorageReadMethods(t *testing.T) {
 			},
 		}
 
-		events, err := s.ListEventMonth("2022-12-10")
+		events, err := s.ListEventMonth(context.Background(), "2022-12-10")
 		require.NoError(t, err)
 
 		require.Truef(t, len(events) == 0, "expected length: %d, actual: %d", 0, len(events))
 	})
+
+	t.Run("list range success", func(t *testing.T) {
+		s := &Storage{
+			eventsByDay: map[int64][]storage.Event{
+				1665360000: {
+					{ID: "2", DateStart: "2022-10-10 00:04:15"},
+					{ID: "1", DateStart: "2022-10-10 00:02:15"},
+				},
+				1665532800: {
+					{ID: "3", DateStart: "2022-10-12 00:02:15"},
+				},
+				1666051200: {
+					{ID: "4", DateStart: "2022-10-18 00:02:15"},
+				},
+			},
+		}
+
+		events, err := s.ListEventRange(context.Background(), "2022-10-10", "2022-10-12")
+		require.NoError(t, err)
+
+		ids := make([]string, 0, len(events))
+		for _, e := range events {
+			ids = append(ids, e.ID)
+		}
+		require.Equal(t, []string{"1", "2", "3"}, ids)
+	})
 }
 
 func TestStorageMethodsConcurrency(t *testing.T) {
@@ -312,7 +343,7 @@ func TestStorageMethodsConcurrency(t *testing.T) {
 
 				dateStartTime := time.Date(2022, time.January, 1, 0, 0, i, 0, time.UTC)
 
-				_ = s.CreateEvent(storage.Event{
+				_, _ = s.CreateEvent(context.Background(), storage.Event{
 					Title:       "Concurrent Event",
 					DateStart:   dateStartTime.Format(time.RFC3339),
 					DateEnd:     dateStartTime.Add(2 * time.Hour).Format(time.RFC3339),
@@ -349,7 +380,7 @@ func TestStorageMethodsConcurrency(t *testing.T) {
 			go func(i int) {
 				defer wg.Done()
 
-				err := s.DeleteEvent(strconv.Itoa(i))
+				err := s.DeleteEvent(context.Background(), strconv.Itoa(i))
 
 				s.mu.Lock()
 				if err == nil {
@@ -387,7 +418,7 @@ func TestStorageMethodsConcurrency(t *testing.T) {
 		for i := 0; i < numGoroutines; i++ {
 			go func(i int) {
 				defer wg.Done()
-				result, _ := s.ListEventDay("2022-11-10")
+				result, _ := s.ListEventDay(context.Background(), "2022-11-10")
 				results[i] = result
 			}(i)
 		}
@@ -399,3 +430,106 @@ func TestStorageMethodsConcurrency(t *testing.T) {
 			"events lists expected: %d, actual: %d", numGoroutines, len(results))
 	})
 }
+
+func TestWebhookStorage(t *testing.T) {
+	t.Run("deliveries", func(t *testing.T) {
+		s := New()
+
+		id, err := s.CreateWebhook(context.Background(), storage.Webhook{URL: "http://localhost/hook", EventTypes: []string{"created"}})
+		require.NoError(t, err)
+
+		_, err = s.CreateDelivery(context.Background(), storage.WebhookDelivery{WebhookID: id, Key: "k"})
+		require.NoError(t, err)
+
+		_, err = s.CreateDelivery(context.Background(), storage.WebhookDelivery{WebhookID: id, Key: "k"})
+		require.ErrorIs(t, err, storage.ErrDeliveryDuplicate)
+
+		_, err = s.CreateDelivery(context.Background(), storage.WebhookDelivery{WebhookID: "unknown"})
+		require.ErrorIs(t, err, storage.ErrWebhookNotExist)
+
+		now := time.Now().Add(time.Second).Format(time.DateTime)
+		lease := time.Now().Add(time.Minute).Format(time.DateTime)
+
+		claimed, err := s.ClaimDeliveries(context.Background(), now, lease, 10, 3)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		require.Equal(t, storage.DeliverySending, claimed[0].Status)
+		require.Equal(t, 1, claimed[0].Attempts)
+
+		claimed, err = s.ClaimDeliveries(context.Background(), now, lease, 10, 3)
+		require.NoError(t, err)
+		require.Empty(t, claimed)
+
+		require.NoError(t, s.DeleteWebhook(context.Background(), id))
+
+		_, err = s.ListDeliveries(context.Background(), id)
+		require.ErrorIs(t, err, storage.ErrWebhookNotExist)
+		require.Empty(t, s.deliveries)
+	})
+}
+
+func TestApplyBatch(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		s := New()
+
+		id, err := s.CreateEvent(context.Background(), storage.Event{Title: "old", DateStart: "2022-10-10 10:00:00"})
+		require.NoError(t, err)
+
+		results, err := s.ApplyBatch(context.Background(), []storage.BatchOperation{
+			{Op: storage.BatchCreate, Event: storage.Event{Title: "new", DateStart: "2022-10-11 10:00:00"}},
+			{Op: storage.BatchUpdate, ID: id, Event: storage.Event{Title: "renamed", DateStart: "2022-10-12 10:00:00"}},
+		})
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		require.Equal(t, storage.BatchStatusApplied, results[0].Status)
+		require.NotEmpty(t, results[0].ID)
+		require.Equal(t, "renamed", results[1].Event.Title)
+
+		events, err := s.ListEventRange(context.Background(), "2022-10-10", "2022-10-12")
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		s := New()
+
+		first, err := s.CreateEvent(context.Background(), storage.Event{Title: "first", DateStart: "2022-10-10 10:00:00"})
+		require.NoError(t, err)
+		second, err := s.CreateEvent(context.Background(), storage.Event{Title: "second", DateStart: "2022-10-10 12:00:00"})
+		require.NoError(t, err)
+
+		results, err := s.ApplyBatch(context.Background(), []storage.BatchOperation{
+			{Op: storage.BatchCreate, Event: storage.Event{Title: "new", DateStart: "2022-10-11 10:00:00"}},
+			{Op: storage.BatchUpdate, ID: first, Event: storage.Event{Title: "moved", DateStart: "2022-10-11 12:00:00"}},
+			{Op: storage.BatchDelete, ID: second},
+			{Op: storage.BatchCreate, Event: storage.Event{Title: "busy", DateStart: "2022-10-11 12:00:00"}},
+			{Op: storage.BatchDelete, ID: first},
+		})
+
+		var batchErr *storage.BatchError
+		require.ErrorAs(t, err, &batchErr)
+		require.Equal(t, 3, batchErr.Index)
+		require.ErrorIs(t, err, storage.ErrDateBusy)
+
+		statuses := make([]string, 0, len(results))
+		for _, r := range results {
+			statuses = append(statuses, r.Status)
+		}
+		require.Equal(t, []string{
+			storage.BatchStatusRolledBack,
+			storage.BatchStatusRolledBack,
+			storage.BatchStatusRolledBack,
+			storage.BatchStatusFailed,
+			storage.BatchStatusSkipped,
+		}, statuses)
+		require.Empty(t, results[0].ID)
+
+		events, err := s.ListEventRange(context.Background(), "2022-10-10", "2022-10-11")
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		require.Equal(t, "first", events[0].Title)
+		require.Equal(t, "2022-10-10 10:00:00", events[0].DateStart)
+		require.Equal(t, "second", events[1].Title)
+		require.Len(t, s.eventsByDateStart, 2)
+	})
+}
@@ -0,0 +1,170 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+func (s *Storage) ListTags(_ context.Context) ([]storage.Tag, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tags := make([]storage.Tag, 0, len(s.eventsByTag))
+	for name, ids := range s.eventsByTag {
+		tags = append(tags, storage.Tag{Name: name, Events: len(ids)})
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+
+	return tags, nil
+}
+
+// ListEventTagged возвращает события, которые занимают хотя бы один день с dateFrom по dateTo
+// включительно и у которых есть все метки tags.
+// Кандидаты берутся из индекса по самой редкой метке.
+func (s *Storage) ListEventTagged(ctx context.Context, tags []string, dateFrom string, dateTo string) (
+	[]storage.Event, error,
+) {
+	tags = storage.NormalizeTags(tags)
+	if len(tags) == 0 {
+		return s.ListEventRange(ctx, dateFrom, dateTo)
+	}
+
+	from, err := time.Parse("2006-01-02", dateFrom)
+	if err != nil {
+		return nil, err
+	}
+	to, err := time.Parse("2006-01-02", dateTo)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := s.eventsByTag[tags[0]]
+	for _, t := range tags[1:] {
+		if len(s.eventsByTag[t]) < len(ids) {
+			ids = s.eventsByTag[t]
+		}
+	}
+
+	events := make([]storage.Event, 0)
+
+	for id := range ids {
+		e := s.eventsByID[id]
+
+		if !e.Overlaps(from, to.AddDate(0, 0, 1)) || !e.HasTags(tags) {
+			continue
+		}
+
+		events = append(events, *e)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].DateStartUnix() < events[j].DateStartUnix()
+	})
+
+	return events, nil
+}
+
+// RenameTag переименовывает метку во всех событиях. Если метка newName уже есть, метки объединяются.
+func (s *Storage) RenameTag(_ context.Context, name string, newName string) error {
+	name = storage.NormalizeTag(name)
+	newName = storage.NormalizeTag(newName)
+
+	return s.retag(name, func(tags []string) []string {
+		result := make([]string, 0, len(tags))
+		for _, t := range tags {
+			if t == name {
+				t = newName
+			}
+			result = append(result, t)
+		}
+
+		return result
+	})
+}
+
+// DeleteTag снимает метку со всех событий.
+func (s *Storage) DeleteTag(_ context.Context, name string) error {
+	name = storage.NormalizeTag(name)
+
+	return s.retag(name, func(tags []string) []string {
+		result := make([]string, 0, len(tags))
+		for _, t := range tags {
+			if t != name {
+				result = append(result, t)
+			}
+		}
+
+		return result
+	})
+}
+
+// retag меняет метки всех событий с меткой name через change и записывает события в журнал.
+func (s *Storage) retag(name string, change func(tags []string) []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index, ok := s.eventsByTag[name]
+	if !ok {
+		return storage.ErrTagNotExist
+	}
+
+	records, undo, err := s.replaceEvents(index, func(e *storage.Event) {
+		e.Tags = change(e.Tags)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.persist(records...)
+	if err != nil {
+		rollback(undo)
+		return err
+	}
+
+	return nil
+}
+
+// replaceEvents меняет события из index через change и возвращает записи для журнала
+// и функции отмены. При ошибке уже замененные события восстанавливаются.
+// Вызывается под блокировкой s.mu.
+func (s *Storage) replaceEvents(index map[string]struct{}, change func(e *storage.Event)) (
+	[]record, []func(), error,
+) {
+	// Замена события меняет индекс, поэтому идентификаторы копируются заранее.
+	ids := make([]string, 0, len(index))
+	for id := range index {
+		ids = append(ids, id)
+	}
+
+	var undo []func()
+	records := make([]record, 0, len(ids))
+
+	for _, id := range ids {
+		id := id
+		e := *s.eventsByID[id]
+		change(&e)
+
+		prev, err := s.replaceEvent(id, e)
+		if err != nil {
+			rollback(undo)
+			return nil, nil, err
+		}
+
+		undo = append(undo, func() {
+			s.deleteEvent(id, *s.eventsByID[id])
+			s.createEvent(id, prev)
+		})
+		records = append(records, eventPut(*s.eventsByID[id]))
+	}
+
+	return records, undo, nil
+}
@@ -0,0 +1,216 @@
+package memorystorage
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+const (
+	walHeaderSize = 8
+	maxEntrySize  = 64 << 20
+)
+
+var (
+	crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+	errCorruptEntry = errors.New("corrupt log entry")
+)
+
+// Запись журнала: длина данных и их CRC32 (по 4 байта, little-endian), затем сами данные.
+func writeEntry(w io.Writer, data []byte) error {
+	var header [walHeaderSize]byte
+	binary.LittleEndian.PutUint32(header[0:4], uint32(len(data)))
+	binary.LittleEndian.PutUint32(header[4:8], crc32.Checksum(data, crcTable))
+
+	buf := make([]byte, 0, walHeaderSize+len(data))
+	buf = append(buf, header[:]...)
+	buf = append(buf, data...)
+
+	_, err := w.Write(buf)
+	return err
+}
+
+// readEntries передает в f данные записей по порядку и возвращает смещение конца
+// последней целой записи. Оборванная или поврежденная запись прерывает чтение
+// с ошибкой errCorruptEntry.
+func readEntries(r io.Reader, f func(data []byte) error) (int64, error) {
+	br := bufio.NewReader(r)
+
+	var offset int64
+	var header [walHeaderSize]byte
+
+	for {
+		_, err := io.ReadFull(br, header[:])
+		if errors.Is(err, io.EOF) {
+			return offset, nil
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return offset, errCorruptEntry
+		}
+		if err != nil {
+			return offset, err
+		}
+
+		size := binary.LittleEndian.Uint32(header[0:4])
+		if size > maxEntrySize {
+			return offset, errCorruptEntry
+		}
+
+		data := make([]byte, size)
+		_, err = io.ReadFull(br, data)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return offset, errCorruptEntry
+		}
+		if err != nil {
+			return offset, err
+		}
+
+		if crc32.Checksum(data, crcTable) != binary.LittleEndian.Uint32(header[4:8]) {
+			return offset, errCorruptEntry
+		}
+
+		err = f(data)
+		if err != nil {
+			return offset, err
+		}
+
+		offset += walHeaderSize + int64(size)
+	}
+}
+
+// logFile - файл журнала. В тестах подменяется, чтобы проверить обработку ошибок записи.
+type logFile interface {
+	io.Writer
+	io.Seeker
+	Sync() error
+	Truncate(size int64) error
+	Close() error
+}
+
+// wal - файл журнала, открытый на дозапись. offset - конец последней целой записи.
+// После ошибки, которую не удалось откатить, журнал отклоняет запись до повторного открытия.
+type wal struct {
+	file   logFile
+	offset int64
+	dirty  bool
+	err    error
+}
+
+// openWAL читает журнал, отбрасывает оборванный хвост и открывает файл на дозапись.
+func openWAL(path string, f func(data []byte) error) (*wal, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	offset, err := readEntries(file, f)
+	if err != nil && !errors.Is(err, errCorruptEntry) {
+		file.Close()
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	w := &wal{file: file}
+
+	// Хвост после последней целой записи остается от прерванной записи и отбрасывается.
+	err = w.truncate(offset)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// write дописывает запись и при syncNow сбрасывает ее на диск. Если запись или сброс
+// не удались, файл обрезается до начала записи, чтобы за оборванной записью не оказались
+// следующие: при открытии журнал читается только до первой поврежденной записи.
+func (w *wal) write(data []byte, syncNow bool) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	err := writeEntry(w.file, data)
+	if err == nil {
+		w.dirty = true
+		if syncNow {
+			err = w.sync()
+		}
+	}
+	if err != nil {
+		truncateErr := w.truncate(w.offset)
+		if truncateErr != nil {
+			w.err = fmt.Errorf("write-ahead log is broken, reopen storage: %w", truncateErr)
+		}
+		return err
+	}
+
+	w.offset += walHeaderSize + int64(len(data))
+
+	return nil
+}
+
+// sync сбрасывает журнал на диск. После неудачного сброса неизвестно, какие записи
+// сохранились, поэтому журнал перестает принимать записи.
+func (w *wal) sync() error {
+	if !w.dirty || w.err != nil {
+		return w.err
+	}
+
+	err := w.file.Sync()
+	if err != nil {
+		w.err = fmt.Errorf("write-ahead log sync failed, reopen storage: %w", err)
+		return err
+	}
+
+	w.dirty = false
+	return nil
+}
+
+func (w *wal) truncate(offset int64) error {
+	err := w.file.Truncate(offset)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	w.offset = offset
+
+	return nil
+}
+
+// reset очищает журнал после записи снимка. Снимок содержит все состояние,
+// поэтому удачная очистка снова открывает сломанный журнал для записи.
+func (w *wal) reset() error {
+	err := w.truncate(0)
+	if err == nil {
+		err = w.file.Sync()
+	}
+	if err != nil {
+		w.err = fmt.Errorf("write-ahead log is broken, reopen storage: %w", err)
+		return err
+	}
+
+	w.dirty = false
+	w.err = nil
+
+	return nil
+}
+
+func (w *wal) close() error {
+	err := w.sync()
+	if err != nil {
+		w.file.Close()
+		return err
+	}
+
+	return w.file.Close()
+}
@@ -0,0 +1,227 @@
+package memorystorage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+func (s *Storage) CreateWebhook(_ context.Context, w storage.Webhook) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.ID = uuid.NewString()
+	w.CreatedAt = time.Now().Format(time.DateTime)
+	w.EventTypes = append([]string(nil), w.EventTypes...)
+
+	err := s.persist(webhookPut(w))
+	if err != nil {
+		return "", err
+	}
+
+	s.webhooks[w.ID] = w
+
+	return w.ID, nil
+}
+
+func (s *Storage) GetWebhook(_ context.Context, id string) (storage.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	w, ok := s.webhooks[id]
+	if !ok {
+		return storage.Webhook{}, storage.ErrWebhookNotExist
+	}
+
+	return w, nil
+}
+
+func (s *Storage) ListWebhooks(_ context.Context) ([]storage.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	webhooks := make([]storage.Webhook, 0, len(s.webhooks))
+	for _, w := range s.webhooks {
+		webhooks = append(webhooks, w)
+	}
+
+	sort.Slice(webhooks, func(i, j int) bool {
+		if webhooks[i].CreatedAt != webhooks[j].CreatedAt {
+			return webhooks[i].CreatedAt < webhooks[j].CreatedAt
+		}
+		return webhooks[i].ID < webhooks[j].ID
+	})
+
+	return webhooks, nil
+}
+
+func (s *Storage) DeleteWebhook(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.webhooks[id]; !ok {
+		return storage.ErrWebhookNotExist
+	}
+
+	err := s.persist(webhookDelete(id))
+	if err != nil {
+		return err
+	}
+
+	s.deleteWebhook(id)
+
+	return nil
+}
+
+// deleteWebhook удаляет webhook вместе с его доставками. Вызывается под блокировкой s.mu.
+func (s *Storage) deleteWebhook(id string) {
+	delete(s.webhooks, id)
+
+	for deliveryID, d := range s.deliveries {
+		if d.WebhookID == id {
+			delete(s.deliveries, deliveryID)
+			delete(s.deliveryKeys, d.WebhookID+d.Key)
+		}
+	}
+}
+
+func (s *Storage) CreateDelivery(_ context.Context, d storage.WebhookDelivery) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.webhooks[d.WebhookID]; !ok {
+		return "", storage.ErrWebhookNotExist
+	}
+
+	if d.Key != "" {
+		if _, ok := s.deliveryKeys[d.WebhookID+d.Key]; ok {
+			return "", storage.ErrDeliveryDuplicate
+		}
+	}
+
+	now := time.Now().Format(time.DateTime)
+
+	d.ID = uuid.NewString()
+	d.CreatedAt = now
+	d.UpdatedAt = now
+	if d.Status == "" {
+		d.Status = storage.DeliveryPending
+	}
+	if d.NextAttemptAt == "" {
+		d.NextAttemptAt = now
+	}
+
+	err := s.persist(deliveryPut(d))
+	if err != nil {
+		return "", err
+	}
+
+	s.deliveries[d.ID] = &d
+	if d.Key != "" {
+		s.deliveryKeys[d.WebhookID+d.Key] = d.ID
+	}
+
+	return d.ID, nil
+}
+
+func (s *Storage) ListDeliveries(_ context.Context, webhookID string) ([]storage.WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.webhooks[webhookID]; !ok {
+		return nil, storage.ErrWebhookNotExist
+	}
+
+	deliveries := make([]storage.WebhookDelivery, 0)
+	for _, d := range s.deliveries {
+		if d.WebhookID == webhookID {
+			deliveries = append(deliveries, *d)
+		}
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		if deliveries[i].CreatedAt != deliveries[j].CreatedAt {
+			return deliveries[i].CreatedAt > deliveries[j].CreatedAt
+		}
+		return deliveries[i].ID > deliveries[j].ID
+	})
+
+	return deliveries, nil
+}
+
+// ClaimDeliveries помечает подошедшие к отправке доставки как отправляемые до leaseUntil.
+// Доставка с исчерпанными попытками выбирается последний раз, чтобы ее можно было пометить failed.
+func (s *Storage) ClaimDeliveries(_ context.Context, now string, leaseUntil string, limit int, maxAttempts int) (
+	[]storage.WebhookDelivery, error,
+) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	due := make([]*storage.WebhookDelivery, 0)
+	for _, d := range s.deliveries {
+		pending := d.Status == storage.DeliveryPending || d.Status == storage.DeliverySending
+		if pending && d.NextAttemptAt <= now && d.Attempts <= maxAttempts {
+			due = append(due, d)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].NextAttemptAt < due[j].NextAttemptAt
+	})
+
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]storage.WebhookDelivery, 0, len(due))
+	records := make([]record, 0, len(due))
+	for _, d := range due {
+		c := *d
+		c.Status = storage.DeliverySending
+		c.Attempts++
+		c.NextAttemptAt = leaseUntil
+		c.UpdatedAt = now
+		claimed = append(claimed, c)
+		records = append(records, deliveryPut(c))
+	}
+
+	err := s.persist(records...)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, d := range due {
+		*d = claimed[i]
+	}
+
+	return claimed, nil
+}
+
+func (s *Storage) UpdateDelivery(_ context.Context, d storage.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.deliveries[d.ID]
+	if !ok || existing.Attempts != d.Attempts || existing.Status != storage.DeliverySending {
+		return storage.ErrDeliveryNotExist
+	}
+
+	updated := *existing
+	updated.Status = d.Status
+	updated.NextAttemptAt = d.NextAttemptAt
+	updated.LastError = d.LastError
+	updated.ResponseStatus = d.ResponseStatus
+	updated.UpdatedAt = time.Now().Format(time.DateTime)
+
+	err := s.persist(deliveryPut(updated))
+	if err != nil {
+		return err
+	}
+
+	*existing = updated
+
+	return nil
+}
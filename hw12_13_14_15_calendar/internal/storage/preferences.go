@@ -0,0 +1,41 @@
+package storage
+
+import "errors"
+
+var ErrPreferencesNotExist = errors.New("user preferences not found in storage")
+
+// DefaultDigestTime - время сводки для пользователей без сохраненных настроек.
+const DefaultDigestTime = "08:00"
+
+// Каналы доставки уведомлений. Пустой канал означает канал по умолчанию сервиса рассылки.
+const (
+	ChannelLog     = "log"
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelFile    = "file"
+	ChannelBot     = "bot"
+)
+
+var Channels = []string{ChannelLog, ChannelEmail, ChannelWebhook, ChannelFile, ChannelBot}
+
+// Preferences - настройки уведомлений пользователя. DigestTime задает локальное
+// время сервера, к которому пользователь получает сводки за день и за неделю.
+// Address - адрес в канале: email, URL вебхука или идентификатор чата бота.
+type Preferences struct {
+	UserID       string `json:"userId" validate:"required,uuid"`
+	DailyDigest  bool   `json:"dailyDigest"`
+	WeeklyDigest bool   `json:"weeklyDigest"`
+	DigestTime   string `json:"digestTime" validate:"required,datetime=15:04"`
+	Channel      string `json:"channel,omitempty" validate:"omitempty,oneof=log email webhook file bot"`
+	Address      string `json:"address,omitempty" validate:"max=255"`
+}
+
+// DefaultPreferences возвращает настройки пользователя, который их не сохранял.
+func DefaultPreferences(userID string) Preferences {
+	return Preferences{
+		UserID:       userID,
+		DailyDigest:  true,
+		WeeklyDigest: true,
+		DigestTime:   DefaultDigestTime,
+	}
+}
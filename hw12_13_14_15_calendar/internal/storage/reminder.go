@@ -0,0 +1,28 @@
+package storage
+
+import "errors"
+
+var ErrReminderStateNotExist = errors.New("reminder state not found in storage")
+
+// MaxSnoozeMinutes ограничивает откладывание напоминания одними сутками.
+const MaxSnoozeMinutes = 1440
+
+// ReminderState - реакция пользователя на напоминание о событии.
+// SnoozedUntil - время в формате "2006-01-02 15:04:05", до которого
+// напоминание отложено; пустая строка означает, что оно не откладывалось.
+// NotifiedKey - ключ последнего напоминания, переданного сервису рассылки.
+// Его меняет только MarkReminderNotified, SaveReminderState его сохраняет.
+type ReminderState struct {
+	EventID      string `json:"eventId"`
+	UserID       string `json:"userId"`
+	SnoozedUntil string `json:"snoozedUntil,omitempty"`
+	Dismissed    bool   `json:"dismissed"`
+	NotifiedKey  string `json:"notifiedKey,omitempty"`
+}
+
+// ReminderAction - запрос пользователя отложить или отклонить напоминание.
+// Minutes используется только при откладывании.
+type ReminderAction struct {
+	UserID  string `json:"userId" validate:"required,uuid"`
+	Minutes int    `json:"minutes,omitempty" validate:"required,min=1,max=1440"`
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Виды ресурсов.
+const (
+	ResourceRoom      = "room"
+	ResourceEquipment = "equipment"
+)
+
+var (
+	ErrResourceNotExist = errors.New("resource not found in storage")
+	// ErrResourceBusy продолжает ErrDateBusy: время занято, но не у календаря, а у ресурса.
+	ErrResourceBusy = fmt.Errorf("%w: resource is booked by another event", ErrDateBusy)
+)
+
+// Resource - переговорная или оборудование, которые бронируются событиями.
+// Capacity - число мест, для оборудования обычно 0.
+type Resource struct {
+	ID       string `json:"id"`
+	Name     string `json:"name" validate:"required,max=255"`
+	Kind     string `json:"kind" validate:"required,oneof=room equipment"`
+	Capacity int    `json:"capacity" validate:"min=0,max=100000"`
+}
+
+// Booking - бронь ресурса событием на время [DateStart, DateEnd).
+type Booking struct {
+	ResourceID string `json:"resourceId"`
+	EventID    string `json:"eventId"`
+	Title      string `json:"title"`
+	DateStart  string `json:"dateStart"`
+	DateEnd    string `json:"dateEnd"`
+}
+
+// ResourceAvailability - ресурс и его брони за запрошенный интервал.
+type ResourceAvailability struct {
+	Resource Resource  `json:"resource"`
+	Bookings []Booking `json:"bookings"`
+	Free     bool      `json:"free"`
+}
+
+type ResourceQueryValidation struct {
+	DateFrom string `json:"from" validate:"required,datetime=2006-01-02 15:04:05"`
+	DateTo   string `json:"to" validate:"required,datetime=2006-01-02 15:04:05"`
+	Kind     string `json:"kind" validate:"omitempty,oneof=room equipment"`
+	Capacity int    `json:"capacity" validate:"min=0,max=100000"`
+}
+
+// NormalizeResources убирает повторы и сортирует идентификаторы ресурсов.
+func NormalizeResources(ids []string) []string {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]struct{}, len(ids))
+	result := make([]string, 0, len(ids))
+
+	for _, id := range ids {
+		if _, ok := seen[id]; ok || id == "" {
+			continue
+		}
+		seen[id] = struct{}{}
+		result = append(result, id)
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+// Books сообщает, занимает ли бронь события время из [from, to). Брони встык не пересекаются.
+func (e *Event) Books(from time.Time, to time.Time) bool {
+	start, end := e.bounds()
+
+	return start.Before(to) && end.After(from)
+}
+
+// Booking возвращает бронь ресурса resourceID событием.
+func (e *Event) Booking(resourceID string) Booking {
+	return Booking{
+		ResourceID: resourceID,
+		EventID:    e.ID,
+		Title:      e.Title,
+		DateStart:  e.DateStart,
+		DateEnd:    e.DateEnd,
+	}
+}
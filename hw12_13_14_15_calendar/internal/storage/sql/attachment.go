@@ -0,0 +1,107 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+const selectFieldsFromAttachments = "select id, event_id, name, content_type, size, coalesce(url, ''), " +
+	"coalesce(blob_key, ''), to_char(created_at, 'YYYY-MM-DD HH24:MI:SS') from attachments"
+
+func (s *Storage) CreateAttachment(ctx context.Context, a storage.Attachment) (string, error) {
+	var id string
+
+	query := "insert into attachments (event_id, name, content_type, size, url, blob_key) " +
+		"values ($1, $2, $3, $4, nullif($5, ''), nullif($6, '')) returning id"
+
+	err := s.exec(ctx, func(ctx context.Context) error {
+		return s.Conn.QueryRowContext(ctx, query, a.EventID, a.Name, a.ContentType, a.Size, a.URL, a.BlobKey).
+			Scan(&id)
+	})
+
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) && pgErr.SQLState() == foreignKeyViolation {
+		return "", storage.ErrEventNotExist
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (s *Storage) GetAttachment(ctx context.Context, id string) (storage.Attachment, error) {
+	var a storage.Attachment
+
+	err := s.read(ctx, func(ctx context.Context) (err error) {
+		a, err = scanAttachment(s.Conn.QueryRowContext(ctx, selectFieldsFromAttachments+" where id = $1", id))
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return a, storage.ErrAttachmentNotExist
+	}
+
+	return a, err
+}
+
+func (s *Storage) ListAttachments(ctx context.Context, eventID string) ([]storage.Attachment, error) {
+	return s.listAttachments(ctx, " where event_id = $1 order by created_at, id", eventID)
+}
+
+func (s *Storage) DeleteAttachment(ctx context.Context, id string) error {
+	var result sql.Result
+
+	err := s.exec(ctx, func(ctx context.Context) (err error) {
+		result, err = s.Conn.ExecContext(ctx, "delete from attachments where id = $1", id)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(result, storage.ErrAttachmentNotExist)
+}
+
+func (s *Storage) listAttachments(ctx context.Context, where string, args ...interface{}) (
+	[]storage.Attachment, error,
+) {
+	var attachments []storage.Attachment
+
+	err := s.read(ctx, func(ctx context.Context) error {
+		rows, err := s.Conn.QueryContext(ctx, selectFieldsFromAttachments+where, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		attachments, err = scanAttachments(rows)
+		return err
+	})
+
+	return attachments, err
+}
+
+func scanAttachments(rows *sql.Rows) ([]storage.Attachment, error) {
+	attachments := make([]storage.Attachment, 0)
+
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+
+	return attachments, rows.Err()
+}
+
+func scanAttachment(row rowScanner) (storage.Attachment, error) {
+	var a storage.Attachment
+
+	err := row.Scan(&a.ID, &a.EventID, &a.Name, &a.ContentType, &a.Size, &a.URL, &a.BlobKey, &a.CreatedAt)
+
+	return a, err
+}
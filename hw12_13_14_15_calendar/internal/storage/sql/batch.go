@@ -0,0 +1,134 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+const BatchTimeout = time.Second * 30
+
+// eventFields приводит даты к формату storage.Event, пустые необязательные поля читаются как "",
+// метки и ресурсы - одной строкой через storage.TagSeparator.
+const eventFields = "id, title, to_char(date_start, 'YYYY-MM-DD HH24:MI:SS'), " +
+	"to_char(date_end, 'YYYY-MM-DD HH24:MI:SS'), coalesce(description, ''), user_id, " +
+	"coalesce(to_char(date_post, 'YYYY-MM-DD HH24:MI:SS'), ''), coalesce(category, ''), coalesce(color, ''), all_day, " +
+	"coalesce((select string_agg(tag, ',' order by tag) from event_tags where event_id = events.id), ''), " +
+	"coalesce((select string_agg(resource_id::text, ',' order by resource_id) " +
+	"from event_resources where event_id = events.id), '')"
+
+// ApplyBatch выполняет операции в одной транзакции.
+func (s *Storage) ApplyBatch(ctx context.Context, ops []storage.BatchOperation) ([]storage.BatchResult, error) {
+	results := storage.NewBatchResults(ops)
+
+	err := s.run(ctx, s.timeouts.Batch, func(ctx context.Context) error {
+		tx, err := s.Conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		for i, op := range ops {
+			e, err := applyOperation(ctx, tx, op)
+			if err != nil {
+				storage.RollbackBatch(results, i, err)
+				return &storage.BatchError{Index: i, Err: err}
+			}
+
+			results[i].ID = e.ID
+			results[i].Event = e
+			results[i].Status = storage.BatchStatusApplied
+		}
+
+		return tx.Commit()
+	})
+
+	var batchErr *storage.BatchError
+	if errors.As(err, &batchErr) {
+		return results, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// applyOne выполняет операцию в отдельной транзакции, чтобы событие и его метки менялись вместе.
+func (s *Storage) applyOne(ctx context.Context, op storage.BatchOperation) (storage.Event, error) {
+	var e storage.Event
+
+	err := s.transaction(ctx, func(ctx context.Context, tx *sql.Tx) (err error) {
+		e, err = applyOperation(ctx, tx, op)
+		return err
+	})
+
+	return e, err
+}
+
+// transaction выполняет fn в транзакции с таймаутом одного запроса и фиксирует ее, если fn не вернула ошибку.
+func (s *Storage) transaction(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	return s.exec(ctx, func(ctx context.Context) error {
+		tx, err := s.Conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		err = fn(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	})
+}
+
+func applyOperation(ctx context.Context, tx *sql.Tx, op storage.BatchOperation) (storage.Event, error) {
+	var row *sql.Row
+
+	e := op.Event
+	e.Normalize()
+
+	switch op.Op {
+	case storage.BatchCreate:
+		row = tx.QueryRowContext(ctx, "insert into events "+
+			"(title, date_start, date_end, description, user_id, date_post, category, color, all_day) "+
+			"values ($1, $2, $3, $4, $5, nullif($6, '')::timestamp, nullif($7, ''), nullif($8, ''), $9) "+
+			"returning "+eventFields,
+			e.Title, e.DateStart, e.DateEnd, e.Description, e.UserID, e.DatePost, e.Category, e.Color, e.AllDay)
+	case storage.BatchUpdate:
+		row = tx.QueryRowContext(ctx, "update events "+
+			"set title = $2, date_start = $3, date_end = $4, description = $5, user_id = $6, "+
+			"date_post = nullif($7, '')::timestamp, category = nullif($8, ''), color = nullif($9, ''), "+
+			"all_day = $10 where id = $1 returning "+eventFields,
+			op.ID, e.Title, e.DateStart, e.DateEnd, e.Description, e.UserID, e.DatePost, e.Category, e.Color, e.AllDay)
+	case storage.BatchDelete:
+		row = tx.QueryRowContext(ctx, "delete from events where id = $1 returning "+eventFields, op.ID)
+	default:
+		return storage.Event{}, fmt.Errorf("unsupported batch operation %q", op.Op)
+	}
+
+	result, err := scanEvent(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Event{}, storage.ErrEventNotExist
+	}
+	if err != nil || op.Op == storage.BatchDelete {
+		return result, eventError(err)
+	}
+
+	// returning видит метки и ресурсы до изменения, поэтому результат берет их из операции.
+	result.Tags = e.Tags
+	result.Resources = e.Resources
+
+	err = setEventTags(ctx, tx, result.ID, result.Tags)
+	if err != nil {
+		return storage.Event{}, err
+	}
+
+	return result, setEventResources(ctx, tx, result)
+}
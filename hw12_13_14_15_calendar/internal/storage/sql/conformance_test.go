@@ -0,0 +1,77 @@
+package sqlstorage_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	sqlstorage "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage/sql"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage/storagetest"
+	"github.com/stretchr/testify/require"
+)
+
+// Тесты работают с локальной базой (make run-docker-postgres test-postgres):
+// применяют встроенные миграции и очищают таблицы перед каждой проверкой.
+func TestConformance(t *testing.T) {
+	dsn := testDSN(t)
+
+	storagetest.Run(t, func(t *testing.T) app.Storager {
+		t.Helper()
+
+		s := sqlstorage.New(dsn, sqlstorage.Timeouts{})
+		s.AutoMigrate = true
+		require.NoError(t, s.Open(context.Background()))
+		t.Cleanup(func() { s.Close() })
+
+		_, err := s.Conn.Exec("truncate events, webhooks, leases, user_preferences, reminder_states, tags, attachments, " +
+			"resources cascade")
+		require.NoError(t, err)
+
+		return s
+	})
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+
+	s := sqlstorage.New(testDSN(t), sqlstorage.Timeouts{})
+	require.NoError(t, s.Open(ctx))
+	defer s.Close()
+
+	_, err := s.MigrateUp(ctx)
+	require.NoError(t, err)
+
+	statuses, err := s.MigrationStatus(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, statuses)
+	for _, status := range statuses {
+		require.NotEmpty(t, status.AppliedAt, status.Name)
+	}
+
+	last := statuses[len(statuses)-1]
+
+	reverted, err := s.MigrateDown(ctx)
+	require.NoError(t, err)
+	require.Equal(t, last.Name, reverted.Name)
+
+	statuses, err = s.MigrationStatus(ctx)
+	require.NoError(t, err)
+	require.Empty(t, statuses[len(statuses)-1].AppliedAt)
+
+	applied, err := s.MigrateUp(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	require.Equal(t, last.Name, applied[0].Name)
+}
+
+func testDSN(t *testing.T) string {
+	t.Helper()
+
+	dsn := os.Getenv("CALENDAR_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("CALENDAR_TEST_POSTGRES_DSN is not set")
+	}
+
+	return dsn
+}
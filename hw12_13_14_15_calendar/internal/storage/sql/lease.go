@@ -0,0 +1,42 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// AcquireLease захватывает или продлевает аренду name на ttl. Аренду получает holder,
+// если она свободна, истекла или уже принадлежит ему. Время отсчитывается по часам БД,
+// поэтому расхождение часов реплик не влияет на результат.
+func (s *Storage) AcquireLease(ctx context.Context, name string, holder string, ttl time.Duration) (bool, error) {
+	query := "insert into leases (name, holder, expires_at) " +
+		"values ($1, $2, now() + $3::bigint * interval '1 millisecond') " +
+		"on conflict (name) do update set holder = excluded.holder, expires_at = excluded.expires_at " +
+		"where leases.expires_at < now() or leases.holder = excluded.holder " +
+		"returning holder"
+
+	err := s.exec(ctx, func(ctx context.Context) error {
+		var got string
+		return s.Conn.QueryRowContext(ctx, query, name, holder, ttl.Milliseconds()).Scan(&got)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ReleaseLease освобождает аренду name, если она принадлежит holder.
+func (s *Storage) ReleaseLease(ctx context.Context, name string, holder string) error {
+	query := "delete from leases where name = $1 and holder = $2"
+
+	return s.exec(ctx, func(ctx context.Context) error {
+		_, err := s.Conn.ExecContext(ctx, query, name, holder)
+		return err
+	})
+}
@@ -0,0 +1,191 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/migrations"
+)
+
+// migrationLock - ключ advisory lock, под которым реплики по очереди применяют миграции.
+const migrationLock int64 = 4715294301
+
+var ErrNoMigrations = errors.New("no applied migrations to roll back")
+
+// MigrationStatus - состояние одной встроенной миграции. AppliedAt пуст, если миграция не применена.
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	AppliedAt string
+}
+
+// MigrateUp применяет все встроенные миграции, которых еще нет в goose_db_version,
+// и возвращает примененные. Таблица совместима с утилитой goose.
+func (s *Storage) MigrateUp(ctx context.Context) ([]migrations.Migration, error) {
+	list, err := migrations.Load(migrations.FS)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make([]migrations.Migration, 0)
+
+	err = s.withMigrationLock(ctx, func(conn *sql.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range list {
+			if _, ok := versions[m.Version]; ok {
+				continue
+			}
+
+			err = runMigration(ctx, conn, m.Up,
+				"insert into goose_db_version (version_id, is_applied) values ($1, true)", m.Version)
+			if err != nil {
+				return fmt.Errorf("migration %s: %w", m.Name, err)
+			}
+			applied = append(applied, m)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// MigrateDown откатывает последнюю примененную миграцию и возвращает ее.
+func (s *Storage) MigrateDown(ctx context.Context) (migrations.Migration, error) {
+	list, err := migrations.Load(migrations.FS)
+	if err != nil {
+		return migrations.Migration{}, err
+	}
+
+	var reverted migrations.Migration
+
+	err = s.withMigrationLock(ctx, func(conn *sql.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(list) - 1; i >= 0; i-- {
+			m := list[i]
+			if _, ok := versions[m.Version]; !ok {
+				continue
+			}
+
+			err = runMigration(ctx, conn, m.Down, "delete from goose_db_version where version_id = $1", m.Version)
+			if err != nil {
+				return fmt.Errorf("migration %s: %w", m.Name, err)
+			}
+			reverted = m
+
+			return nil
+		}
+
+		return ErrNoMigrations
+	})
+
+	return reverted, err
+}
+
+// MigrationStatus возвращает состояние встроенных миграций по возрастанию версии.
+func (s *Storage) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	list, err := migrations.Load(migrations.FS)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(list))
+
+	err = s.withMigrationLock(ctx, func(conn *sql.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range list {
+			statuses = append(statuses, MigrationStatus{Version: m.Version, Name: m.Name, AppliedAt: versions[m.Version]})
+		}
+
+		return nil
+	})
+
+	return statuses, err
+}
+
+// withMigrationLock выполняет fn на отдельном соединении, удерживая advisory lock:
+// блокировка сессионная, поэтому снимается и при обрыве соединения.
+func (s *Storage) withMigrationLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := s.Conn.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, "select pg_advisory_lock($1)", migrationLock)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = conn.ExecContext(context.Background(), "select pg_advisory_unlock($1)", migrationLock)
+	}()
+
+	_, err = conn.ExecContext(ctx, "create table if not exists goose_db_version ("+
+		"id serial primary key, version_id bigint not null, "+
+		"is_applied boolean not null, tstamp timestamp null default now())")
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// appliedVersions возвращает время применения каждой версии из goose_db_version.
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]string, error) {
+	rows, err := conn.QueryContext(ctx, "select version_id, coalesce(to_char(tstamp, 'YYYY-MM-DD HH24:MI:SS'), '') "+
+		"from goose_db_version where is_applied and version_id > 0 order by id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make(map[int64]string)
+
+	for rows.Next() {
+		var version int64
+		var appliedAt string
+
+		err = rows.Scan(&version, &appliedAt)
+		if err != nil {
+			return nil, err
+		}
+		versions[version] = appliedAt
+	}
+
+	return versions, rows.Err()
+}
+
+// runMigration выполняет текст миграции и запись в goose_db_version в одной транзакции.
+func runMigration(ctx context.Context, conn *sql.Conn, statements string, record string, version int64) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.ExecContext(ctx, statements)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, record, version)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,39 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+func (s *Storage) GetPreferences(ctx context.Context, userID string) (storage.Preferences, error) {
+	query := "select user_id, daily_digest, weekly_digest, digest_time, channel, address " +
+		"from user_preferences where user_id = $1"
+
+	var p storage.Preferences
+
+	err := s.read(ctx, func(ctx context.Context) error {
+		return s.Conn.QueryRowContext(ctx, query, userID).Scan(&p.UserID, &p.DailyDigest, &p.WeeklyDigest, &p.DigestTime,
+			&p.Channel, &p.Address)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Preferences{}, storage.ErrPreferencesNotExist
+	}
+
+	return p, err
+}
+
+func (s *Storage) SavePreferences(ctx context.Context, p storage.Preferences) error {
+	query := "insert into user_preferences (user_id, daily_digest, weekly_digest, digest_time, channel, address) " +
+		"values ($1, $2, $3, $4, $5, $6) on conflict (user_id) do update set " +
+		"daily_digest = excluded.daily_digest, weekly_digest = excluded.weekly_digest, " +
+		"digest_time = excluded.digest_time, channel = excluded.channel, address = excluded.address"
+
+	return s.exec(ctx, func(ctx context.Context) error {
+		_, err := s.Conn.ExecContext(ctx, query, p.UserID, p.DailyDigest, p.WeeklyDigest, p.DigestTime,
+			p.Channel, p.Address)
+		return err
+	})
+}
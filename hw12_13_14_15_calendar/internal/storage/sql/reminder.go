@@ -0,0 +1,63 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+// foreignKeyViolation - код ошибки Postgres при ссылке на несуществующую строку.
+const foreignKeyViolation = "23503"
+
+func (s *Storage) GetReminderState(ctx context.Context, eventID string, userID string) (storage.ReminderState, error) {
+	query := "select event_id, user_id, coalesce(to_char(snoozed_until, 'YYYY-MM-DD HH24:MI:SS'), ''), dismissed, " +
+		"notified_key from reminder_states where event_id = $1 and user_id = $2"
+
+	var r storage.ReminderState
+
+	err := s.read(ctx, func(ctx context.Context) error {
+		return s.Conn.QueryRowContext(ctx, query, eventID, userID).Scan(&r.EventID, &r.UserID, &r.SnoozedUntil,
+			&r.Dismissed, &r.NotifiedKey)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.ReminderState{}, storage.ErrReminderStateNotExist
+	}
+
+	return r, err
+}
+
+func (s *Storage) SaveReminderState(ctx context.Context, r storage.ReminderState) error {
+	query := "insert into reminder_states (event_id, user_id, snoozed_until, dismissed) " +
+		"values ($1, $2, nullif($3, '')::timestamp, $4) on conflict (event_id, user_id) do update set " +
+		"snoozed_until = excluded.snoozed_until, dismissed = excluded.dismissed, updated_at = now()"
+
+	err := s.exec(ctx, func(ctx context.Context) error {
+		_, err := s.Conn.ExecContext(ctx, query, r.EventID, r.UserID, r.SnoozedUntil, r.Dismissed)
+		return err
+	})
+
+	return reminderError(err)
+}
+
+func (s *Storage) MarkReminderNotified(ctx context.Context, eventID string, userID string, key string) error {
+	query := "insert into reminder_states (event_id, user_id, notified_key) values ($1, $2, $3) " +
+		"on conflict (event_id, user_id) do update set notified_key = excluded.notified_key, updated_at = now()"
+
+	err := s.exec(ctx, func(ctx context.Context) error {
+		_, err := s.Conn.ExecContext(ctx, query, eventID, userID, key)
+		return err
+	})
+
+	return reminderError(err)
+}
+
+func reminderError(err error) error {
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) && pgErr.SQLState() == foreignKeyViolation {
+		return storage.ErrEventNotExist
+	}
+
+	return err
+}
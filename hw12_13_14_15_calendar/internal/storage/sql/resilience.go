@@ -0,0 +1,252 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+const (
+	DefaultConnectBackoff = time.Second
+	DefaultReadBackoff    = time.Millisecond * 100
+	maxBackoff            = time.Second * 30
+)
+
+// Pool задает ограничения пула соединений. Нулевые значения оставляют настройки database/sql.
+type Pool struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// Retry задает повторы: подключения при Open и идемпотентных чтений при временных ошибках.
+// Пауза между попытками удваивается.
+type Retry struct {
+	ConnectAttempts int
+	ConnectBackoff  time.Duration
+	ReadAttempts    int
+	ReadBackoff     time.Duration
+}
+
+// Breaker задает circuit breaker: после Failures временных ошибок подряд запросы
+// Cooldown отклоняются сразу с storage.ErrUnavailable. Failures = 0 отключает breaker.
+type Breaker struct {
+	Failures int
+	Cooldown time.Duration
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+type circuitBreaker struct {
+	mu       sync.Mutex
+	settings Breaker
+	state    circuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+	now      func() time.Time
+}
+
+func newCircuitBreaker(settings Breaker) *circuitBreaker {
+	return &circuitBreaker{settings: settings, now: time.Now}
+}
+
+// allow сообщает, можно ли выполнить запрос. После Cooldown пропускается один пробный запрос.
+func (b *circuitBreaker) allow() bool {
+	if b == nil || b.settings.Failures <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if b.now().Sub(b.openedAt) < b.settings.Cooldown {
+			return false
+		}
+		b.state = circuitHalfOpen
+		b.probing = true
+		return true
+	case circuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record учитывает результат запроса. Запросы, отмененные вызывающим, не учитываются.
+func (b *circuitBreaker) record(ctx context.Context, err error) {
+	if b == nil || b.settings.Failures <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+
+	switch {
+	case ctx.Err() != nil:
+		return
+	case !isTransient(err):
+		b.state = circuitClosed
+		b.failures = 0
+	case b.state == circuitHalfOpen:
+		b.state = circuitOpen
+		b.openedAt = b.now()
+	default:
+		b.failures++
+		if b.failures >= b.settings.Failures {
+			b.state = circuitOpen
+			b.openedAt = b.now()
+		}
+	}
+}
+
+// allowsRetry сообщает, имеет ли смысл повторять запрос: при открытом breaker повтор бесполезен.
+func (b *circuitBreaker) allowsRetry() bool {
+	if b == nil || b.settings.Failures <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state == circuitClosed
+}
+
+// isTransient отличает временные сбои связи с БД от ошибок самого запроса.
+func isTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var netErr net.Error
+	var pgErr interface{ SQLState() string }
+
+	switch {
+	case errors.Is(err, storage.ErrUnavailable), errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, driver.ErrBadConn), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF),
+		errors.As(err, &netErr):
+		return true
+	case errors.As(err, &pgErr):
+		code := pgErr.SQLState()
+		// 08 - ошибки соединения, 53 - нехватка ресурсов, 57P - остановка сервера.
+		return strings.HasPrefix(code, "08") || strings.HasPrefix(code, "53") || strings.HasPrefix(code, "57P")
+	default:
+		return false
+	}
+}
+
+// run выполняет fn с таймаутом timeout, если circuit breaker пропускает запросы.
+// Временные сбои связи возвращаются как storage.ErrUnavailable.
+func (s *Storage) run(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	if !s.circuit.allow() {
+		return fmt.Errorf("%w: circuit breaker is open", storage.ErrUnavailable)
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, timeout)
+	err := fn(queryCtx)
+	cancel()
+
+	s.circuit.record(ctx, err)
+
+	if isTransient(err) && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, storage.ErrUnavailable) {
+		return fmt.Errorf("%w: %w", storage.ErrUnavailable, err)
+	}
+
+	return err
+}
+
+// exec выполняет запрос с таймаутом одного запроса без повторов.
+func (s *Storage) exec(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.run(ctx, s.timeouts.Query, fn)
+}
+
+// read выполняет идемпотентное чтение, повторяя его при временных ошибках.
+func (s *Storage) read(ctx context.Context, fn func(ctx context.Context) error) error {
+	backoff := s.Retry.ReadBackoff
+	if backoff <= 0 {
+		backoff = DefaultReadBackoff
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := s.exec(ctx, fn)
+		if err == nil || attempt >= s.Retry.ReadAttempts || !isTransient(err) || !s.circuit.allowsRetry() {
+			return err
+		}
+
+		err = sleep(ctx, backoff)
+		if err != nil {
+			return err
+		}
+		backoff = nextBackoff(backoff)
+	}
+}
+
+// connect проверяет соединение, повторяя попытки с растущей паузой, пока БД не станет доступна.
+func (s *Storage) connect(ctx context.Context) error {
+	backoff := s.Retry.ConnectBackoff
+	if backoff <= 0 {
+		backoff = DefaultConnectBackoff
+	}
+
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+		err := s.Conn.PingContext(pingCtx)
+		cancel()
+
+		if err == nil {
+			return nil
+		}
+		if attempt >= s.Retry.ConnectAttempts {
+			return fmt.Errorf("connect after %d attempts: %w", attempt, err)
+		}
+
+		err = sleep(ctx, backoff)
+		if err != nil {
+			return err
+		}
+		backoff = nextBackoff(backoff)
+	}
+}
+
+func nextBackoff(backoff time.Duration) time.Duration {
+	backoff *= 2
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+
+	return backoff
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,178 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+const selectFieldsFromResources = "select id, name, kind, capacity from resources"
+
+func (s *Storage) CreateResource(ctx context.Context, r storage.Resource) (string, error) {
+	var id string
+
+	query := "insert into resources (name, kind, capacity) values ($1, $2, $3) returning id"
+
+	err := s.exec(ctx, func(ctx context.Context) error {
+		return s.Conn.QueryRowContext(ctx, query, r.Name, r.Kind, r.Capacity).Scan(&id)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (s *Storage) GetResource(ctx context.Context, id string) (storage.Resource, error) {
+	var r storage.Resource
+
+	err := s.read(ctx, func(ctx context.Context) error {
+		return s.Conn.QueryRowContext(ctx, selectFieldsFromResources+" where id = $1", id).
+			Scan(&r.ID, &r.Name, &r.Kind, &r.Capacity)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return r, storage.ErrResourceNotExist
+	}
+
+	return r, err
+}
+
+func (s *Storage) ListResources(ctx context.Context) ([]storage.Resource, error) {
+	var resources []storage.Resource
+
+	err := s.read(ctx, func(ctx context.Context) error {
+		rows, err := s.Conn.QueryContext(ctx, selectFieldsFromResources+" order by name, id")
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		resources = make([]storage.Resource, 0)
+
+		for rows.Next() {
+			var r storage.Resource
+
+			err = rows.Scan(&r.ID, &r.Name, &r.Kind, &r.Capacity)
+			if err != nil {
+				return err
+			}
+			resources = append(resources, r)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resources, nil
+}
+
+func (s *Storage) UpdateResource(ctx context.Context, id string, r storage.Resource) error {
+	query := "update resources set name = $2, kind = $3, capacity = $4 where id = $1"
+
+	var result sql.Result
+
+	err := s.exec(ctx, func(ctx context.Context) (err error) {
+		result, err = s.Conn.ExecContext(ctx, query, id, r.Name, r.Kind, r.Capacity)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(result, storage.ErrResourceNotExist)
+}
+
+// DeleteResource удаляет ресурс, брони событий удаляются каскадно.
+func (s *Storage) DeleteResource(ctx context.Context, id string) error {
+	var result sql.Result
+
+	err := s.exec(ctx, func(ctx context.Context) (err error) {
+		result, err = s.Conn.ExecContext(ctx, "delete from resources where id = $1", id)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(result, storage.ErrResourceNotExist)
+}
+
+// ListBookings возвращает брони, пересекающиеся с интервалом [dateFrom, dateTo).
+func (s *Storage) ListBookings(ctx context.Context, dateFrom string, dateTo string) ([]storage.Booking, error) {
+	query := "select er.resource_id, e.id, e.title, to_char(e.date_start, 'YYYY-MM-DD HH24:MI:SS'), " +
+		"to_char(e.date_end, 'YYYY-MM-DD HH24:MI:SS') from event_resources er join events e on e.id = er.event_id " +
+		"where e.date_start < $2 and e.date_end > $1 order by er.resource_id, e.date_start"
+
+	var bookings []storage.Booking
+
+	err := s.read(ctx, func(ctx context.Context) error {
+		rows, err := s.Conn.QueryContext(ctx, query, dateFrom, dateTo)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		bookings = make([]storage.Booking, 0)
+
+		for rows.Next() {
+			var b storage.Booking
+
+			err = rows.Scan(&b.ResourceID, &b.EventID, &b.Title, &b.DateStart, &b.DateEnd)
+			if err != nil {
+				return err
+			}
+			bookings = append(bookings, b)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
+// setEventResources заменяет брони события. Строка ресурса блокируется до конца транзакции,
+// поэтому конкурентные брони одного ресурса проверяются по очереди.
+func setEventResources(ctx context.Context, tx *sql.Tx, e storage.Event) error {
+	_, err := tx.ExecContext(ctx, "delete from event_resources where event_id = $1", e.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range e.Resources {
+		var id string
+
+		err = tx.QueryRowContext(ctx, "select id from resources where id = $1 for update", r).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrResourceNotExist
+		}
+		if err != nil {
+			return err
+		}
+
+		var busy bool
+
+		err = tx.QueryRowContext(ctx, "select exists(select 1 from event_resources er "+
+			"join events e on e.id = er.event_id where er.resource_id = $1 and e.date_start < $3 and e.date_end > $2)",
+			r, e.DateStart, e.DateEnd).Scan(&busy)
+		if err != nil {
+			return err
+		}
+		if busy {
+			return storage.ErrResourceBusy
+		}
+
+		_, err = tx.ExecContext(ctx, "insert into event_resources (event_id, resource_id) values ($1, $2)", e.ID, r)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
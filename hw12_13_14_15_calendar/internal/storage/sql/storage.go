@@ -3,6 +3,7 @@ package sqlstorage
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
@@ -11,240 +12,241 @@ import (
 )
 
 type Storage struct {
-	dsn  string
-	Conn *sql.DB
+	dsn      string
+	timeouts Timeouts
+	Conn     *sql.DB
+	circuit  *circuitBreaker
+
+	// AutoMigrate включает применение встроенных миграций при Open.
+	AutoMigrate bool
+	Pool        Pool
+	Retry       Retry
+	Breaker     Breaker
 }
 
 const QueryTimeout = time.Second * 3
 
-const selectFieldsFromEvents = "select id, title, date_start, date_end, description, user_id, date_post from events"
-
-func (s *Storage) CreateEvent(event storage.Event) error {
-	query := "insert into events (title, date_start, date_end, description, user_id, date_post) " +
-		"values ($1, $2 ,$3 ,$4 ,$5 ,$6)"
+// Timeouts задает предельное время выполнения операций с БД.
+type Timeouts struct {
+	Query time.Duration
+	Batch time.Duration
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), QueryTimeout)
-	defer cancel()
+// uniqueViolation - код ошибки Postgres при нарушении уникального индекса.
+const uniqueViolation = "23505"
 
-	_, err := s.Conn.ExecContext(ctx,
-		query, event.Title, event.DateStart, event.DateEnd, event.Description, event.UserID, event.DatePost)
-	if err != nil {
-		return err
-	}
-	return nil
-}
+const selectFieldsFromEvents = "select " + eventFields + " from events"
 
-func (s *Storage) UpdateEvent(id string, event storage.Event) error {
-	query := "update events " +
-		"set title = $2, date_start = $3, date_end = $4, description = $5, user_id = $6, date_post = $7 where id = $1"
+func (s *Storage) CreateEvent(ctx context.Context, event storage.Event) (string, error) {
+	e, err := s.applyOne(ctx, storage.BatchOperation{Op: storage.BatchCreate, Event: event})
 
-	ctx, cancel := context.WithTimeout(context.Background(), QueryTimeout)
-	defer cancel()
+	return e.ID, err
+}
 
-	result, err := s.Conn.ExecContext(ctx,
-		query, id, event.Title, event.DateStart, event.DateEnd, event.Description, event.UserID, event.DatePost)
-	if err != nil {
-		return err
-	}
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if rows == 0 {
-		return fmt.Errorf("event with id: %s was not found in DB", id)
-	}
+func (s *Storage) UpdateEvent(ctx context.Context, id string, event storage.Event) error {
+	_, err := s.applyOne(ctx, storage.BatchOperation{Op: storage.BatchUpdate, ID: id, Event: event})
 
-	return nil
+	return err
 }
 
-func (s *Storage) DeleteEvent(id string) error {
+func (s *Storage) DeleteEvent(ctx context.Context, id string) error {
 	query := "delete from events where id = $1"
 
-	ctx, cancel := context.WithTimeout(context.Background(), QueryTimeout)
-	defer cancel()
+	var result sql.Result
 
-	result, err := s.Conn.ExecContext(ctx, query, id)
-	if err != nil {
+	err := s.exec(ctx, func(ctx context.Context) (err error) {
+		result, err = s.Conn.ExecContext(ctx, query, id)
 		return err
-	}
-	rows, err := result.RowsAffected()
+	})
 	if err != nil {
 		return err
 	}
-	if rows == 0 {
-		return fmt.Errorf("event with id: %s was not found in DB", id)
-	}
-	return nil
+
+	return checkAffected(result, storage.ErrEventNotExist)
 }
 
-func (s *Storage) ListEventDay(date string) ([]storage.Event, error) {
-	var events []storage.Event
+func (s *Storage) ListEventDay(ctx context.Context, date string) ([]storage.Event, error) {
+	return s.listEvents(ctx, overlapping("DATE($1)", "DATE($1) + 1")+" order by date_start", date)
+}
+
+func (s *Storage) ListEventWeek(ctx context.Context, date string) ([]storage.Event, error) {
+	return s.listEvents(ctx, overlapping("DATE($1)", "DATE($1) + INTERVAL '7 DAY'")+" order by date_start", date)
+}
 
-	query := selectFieldsFromEvents + " where DATE(date_start) = DATE($1)"
+func (s *Storage) ListEventMonth(ctx context.Context, date string) ([]storage.Event, error) {
+	return s.listEvents(ctx, overlapping("DATE($1)", "DATE($1) + INTERVAL '1 MONTH'")+" order by date_start", date)
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), QueryTimeout)
-	defer cancel()
+func (s *Storage) ListEventRange(ctx context.Context, dateFrom string, dateTo string) ([]storage.Event, error) {
+	return s.listEvents(ctx, overlapping("DATE($1)", "DATE($2) + 1")+" order by date_start", dateFrom, dateTo)
+}
 
-	rows, err := s.Conn.QueryContext(ctx, query, date)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+func (s *Storage) GetEvent(ctx context.Context, id string) (storage.Event, error) {
+	var e storage.Event
 
-	for rows.Next() {
-		var e storage.Event
-		err = rows.Scan(&e.ID, &e.Title, &e.DateStart, &e.DateEnd, &e.Description, &e.UserID, &e.DatePost)
-		if err != nil {
-			return nil, err
-		}
-		events = append(events, e)
+	query := selectFieldsFromEvents + " where id = $1"
+
+	err := s.read(ctx, func(ctx context.Context) (err error) {
+		e, err = scanEvent(s.Conn.QueryRowContext(ctx, query, id))
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Event{}, storage.ErrEventNotExist
 	}
 
-	return events, nil
+	return e, err
 }
 
-func (s *Storage) ListEventWeek(date string) ([]storage.Event, error) {
-	var events []storage.Event
-
-	query := selectFieldsFromEvents +
-		" where DATE(date_start) >= $1 and DATE(date_start) < DATE($1) + INTERVAL '7 DAY'"
+// DeleteEventsBeforeDate удаляет события, начавшиеся раньше date, и возвращает их вложения.
+// Запрос видит вложения до каскадного удаления, поэтому список совпадает с удаленным.
+func (s *Storage) DeleteEventsBeforeDate(ctx context.Context, date string) ([]storage.Attachment, error) {
+	query := "with deleted as (delete from events where date_start < $1 returning id) " +
+		selectFieldsFromAttachments + " where event_id in (select id from deleted) order by created_at, id"
 
-	ctx, cancel := context.WithTimeout(context.Background(), QueryTimeout)
-	defer cancel()
+	var attachments []storage.Attachment
 
-	rows, err := s.Conn.QueryContext(ctx, query, date)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var e storage.Event
-		err = rows.Scan(&e.ID, &e.Title, &e.DateStart, &e.DateEnd, &e.Description, &e.UserID, &e.DatePost)
+	err := s.exec(ctx, func(ctx context.Context) error {
+		rows, err := s.Conn.QueryContext(ctx, query, date)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		events = append(events, e)
-	}
+		defer rows.Close()
 
-	return events, nil
+		attachments, err = scanAttachments(rows)
+		return err
+	})
+
+	return attachments, err
+}
+
+func (s *Storage) ListEventWithNotification(ctx context.Context) ([]storage.Event, error) {
+	return s.listEvents(ctx, " where date_post is not null and date_post < CURRENT_DATE order by date_start")
 }
 
-func (s *Storage) ListEventMonth(date string) ([]storage.Event, error) {
+func (s *Storage) listEvents(ctx context.Context, where string, args ...interface{}) ([]storage.Event, error) {
 	var events []storage.Event
 
-	query := selectFieldsFromEvents +
-		" where DATE(date_start) >= $1 and DATE(date_start) < DATE($1) + INTERVAL '1 MONTH'"
+	err := s.read(ctx, func(ctx context.Context) error {
+		rows, err := s.Conn.QueryContext(ctx, selectFieldsFromEvents+where, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
 
-	ctx, cancel := context.WithTimeout(context.Background(), QueryTimeout)
-	defer cancel()
+		events = make([]storage.Event, 0)
 
-	rows, err := s.Conn.QueryContext(ctx, query, date)
+		for rows.Next() {
+			e, err := scanEvent(rows)
+			if err != nil {
+				return err
+			}
+			events = append(events, e)
+		}
+
+		return rows.Err()
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var e storage.Event
-		err = rows.Scan(&e.ID, &e.Title, &e.DateStart, &e.DateEnd, &e.Description, &e.UserID, &e.DatePost)
-		if err != nil {
-			return nil, err
-		}
-		events = append(events, e)
-	}
 
 	return events, nil
 }
 
-func (s *Storage) GetEvent(id string) (storage.Event, error) {
-	var e storage.Event
-
-	query := selectFieldsFromEvents + " where id = $1"
-
-	ctx, cancel := context.WithTimeout(context.Background(), QueryTimeout)
-	defer cancel()
-
-	row := s.Conn.QueryRowContext(ctx, query, id)
-	err := row.Scan(&e.ID, &e.Title, &e.DateStart, &e.DateEnd, &e.Description, &e.UserID, &e.DatePost)
-	if err == sql.ErrNoRows {
-		return e, storage.ErrEventNotExist
+func New(dsn string, timeouts Timeouts) *Storage {
+	if timeouts.Query <= 0 {
+		timeouts.Query = QueryTimeout
 	}
-	if err != nil {
-		return e, err
+	if timeouts.Batch <= 0 {
+		timeouts.Batch = BatchTimeout
 	}
 
-	return e, nil
+	return &Storage{
+		dsn:      dsn,
+		timeouts: timeouts,
+	}
 }
 
-func (s *Storage) DeleteEventsBeforeDate(date string) error {
-	query := "delete from events where date_start < $1"
-
-	ctx, cancel := context.WithTimeout(context.Background(), QueryTimeout)
-	defer cancel()
-
-	_, err := s.Conn.ExecContext(ctx, query, date)
+// Open настраивает пул и ждет доступности БД согласно Retry.ConnectAttempts.
+func (s *Storage) Open(ctx context.Context) error {
+	var err error
+	s.Conn, err = sql.Open("pgx", s.dsn)
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
-
-func (s *Storage) ListEventWithNotification() ([]storage.Event, error) {
-	var events []storage.Event
-
-	query := selectFieldsFromEvents +
-		" where date_post is not null and date_post < CURRENT_DATE"
+	if s.Pool.MaxOpenConns > 0 {
+		s.Conn.SetMaxOpenConns(s.Pool.MaxOpenConns)
+	}
+	if s.Pool.MaxIdleConns > 0 {
+		s.Conn.SetMaxIdleConns(s.Pool.MaxIdleConns)
+	}
+	if s.Pool.ConnMaxLifetime > 0 {
+		s.Conn.SetConnMaxLifetime(s.Pool.ConnMaxLifetime)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), QueryTimeout)
-	defer cancel()
+	s.circuit = newCircuitBreaker(s.Breaker)
 
-	rows, err := s.Conn.QueryContext(ctx, query)
+	err = s.connect(ctx)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var e storage.Event
-		err = rows.Scan(&e.ID, &e.Title, &e.DateStart, &e.DateEnd, &e.Description, &e.UserID, &e.DatePost)
+	if s.AutoMigrate {
+		_, err = s.MigrateUp(ctx)
 		if err != nil {
-			return nil, err
+			return fmt.Errorf("migrate: %w", err)
 		}
-		events = append(events, e)
 	}
 
-	return events, nil
+	return nil
 }
 
-func New(dsn string) *Storage {
-	return &Storage{
-		dsn: dsn,
+func (s *Storage) Close() error {
+	err := s.Conn.Close()
+	if err != nil {
+		return err
 	}
+
+	return nil
 }
 
-func (s *Storage) Open() error {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
-	defer cancel()
+func scanEvent(row rowScanner) (storage.Event, error) {
+	var e storage.Event
 
-	var err error
-	s.Conn, err = sql.Open("pgx", s.dsn)
+	var tags, resources string
+
+	err := row.Scan(&e.ID, &e.Title, &e.DateStart, &e.DateEnd, &e.Description, &e.UserID, &e.DatePost,
+		&e.Category, &e.Color, &e.AllDay, &tags, &resources)
+	e.Tags = storage.SplitTags(tags)
+	e.Resources = storage.SplitTags(resources)
+
+	return e, err
+}
+
+// overlapping отбирает события, пересекающиеся с интервалом [from, to), где from и to - выражения SQL.
+// Событие нулевой длительности пересекается с интервалом, в который попадает его начало.
+func overlapping(from string, to string) string {
+	return " where date_start < " + to + " and (date_end > " + from + " or date_start >= " + from + ")"
+}
+
+func checkAffected(result sql.Result, notFound error) error {
+	rows, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
-	err = s.Conn.PingContext(ctx)
-	if err != nil {
-		return err
+	if rows == 0 {
+		return notFound
 	}
+
 	return nil
 }
 
-func (s *Storage) Close() error {
-	err := s.Conn.Close()
-	if err != nil {
-		return err
+// eventError переводит нарушение уникальности даты начала в storage.ErrDateBusy.
+func eventError(err error) error {
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) && pgErr.SQLState() == uniqueViolation {
+		return storage.ErrDateBusy
 	}
 
-	return nil
+	return err
 }
@@ -0,0 +1,239 @@
+package sqlstorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+const selectFieldsFromWebhooks = "select id, url, secret, event_types, " +
+	"to_char(created_at, 'YYYY-MM-DD HH24:MI:SS') from webhooks"
+
+const deliveryFields = "id, webhook_id, coalesce(delivery_key, ''), event_type, event_id, payload, status, attempts, " +
+	"to_char(next_attempt_at, 'YYYY-MM-DD HH24:MI:SS'), last_error, response_status, " +
+	"to_char(created_at, 'YYYY-MM-DD HH24:MI:SS'), to_char(updated_at, 'YYYY-MM-DD HH24:MI:SS')"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (s *Storage) CreateWebhook(ctx context.Context, w storage.Webhook) (string, error) {
+	var id string
+
+	query := "insert into webhooks (url, secret, event_types) values ($1, $2, $3) returning id"
+
+	err := s.exec(ctx, func(ctx context.Context) error {
+		return s.Conn.QueryRowContext(ctx, query, w.URL, w.Secret, strings.Join(w.EventTypes, ",")).Scan(&id)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (s *Storage) GetWebhook(ctx context.Context, id string) (storage.Webhook, error) {
+	var w storage.Webhook
+
+	query := selectFieldsFromWebhooks + " where id = $1"
+
+	err := s.read(ctx, func(ctx context.Context) (err error) {
+		w, err = scanWebhook(s.Conn.QueryRowContext(ctx, query, id))
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return w, storage.ErrWebhookNotExist
+	}
+
+	return w, err
+}
+
+func (s *Storage) ListWebhooks(ctx context.Context) ([]storage.Webhook, error) {
+	var webhooks []storage.Webhook
+
+	query := selectFieldsFromWebhooks + " order by created_at, id"
+
+	err := s.read(ctx, func(ctx context.Context) error {
+		rows, err := s.Conn.QueryContext(ctx, query)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		webhooks = make([]storage.Webhook, 0)
+
+		for rows.Next() {
+			w, err := scanWebhook(rows)
+			if err != nil {
+				return err
+			}
+			webhooks = append(webhooks, w)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (s *Storage) DeleteWebhook(ctx context.Context, id string) error {
+	query := "delete from webhooks where id = $1"
+
+	var result sql.Result
+
+	err := s.exec(ctx, func(ctx context.Context) (err error) {
+		result, err = s.Conn.ExecContext(ctx, query, id)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(result, storage.ErrWebhookNotExist)
+}
+
+func (s *Storage) CreateDelivery(ctx context.Context, d storage.WebhookDelivery) (string, error) {
+	var id string
+
+	if d.Status == "" {
+		d.Status = storage.DeliveryPending
+	}
+
+	query := "insert into webhook_deliveries " +
+		"(webhook_id, delivery_key, event_type, event_id, payload, status, next_attempt_at) " +
+		"select $1, nullif($2, ''), $3, $4, $5, $6, coalesce(nullif($7, '')::timestamp, now()) " +
+		"where exists (select 1 from webhooks where id = $1) " +
+		"on conflict (webhook_id, delivery_key) do nothing returning id"
+
+	err := s.exec(ctx, func(ctx context.Context) error {
+		return s.Conn.QueryRowContext(ctx, query,
+			d.WebhookID, d.Key, d.EventType, d.EventID, d.Payload, d.Status, d.NextAttemptAt).Scan(&id)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Пустой результат - либо нет webhook, либо запись с таким ключом уже есть.
+		_, err = s.GetWebhook(ctx, d.WebhookID)
+		if err != nil {
+			return "", err
+		}
+		return "", storage.ErrDeliveryDuplicate
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (s *Storage) ListDeliveries(ctx context.Context, webhookID string) ([]storage.WebhookDelivery, error) {
+	_, err := s.GetWebhook(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []storage.WebhookDelivery
+
+	query := "select " + deliveryFields + " from webhook_deliveries where webhook_id = $1 order by created_at desc, id desc"
+
+	err = s.read(ctx, func(ctx context.Context) error {
+		rows, err := s.Conn.QueryContext(ctx, query, webhookID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		deliveries, err = scanDeliveries(rows)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// ClaimDeliveries помечает подошедшие к отправке доставки как отправляемые до leaseUntil.
+// Если результат отправки не придет до leaseUntil, доставка будет выбрана снова, но не больше
+// maxAttempts + 1 раз: последний выбор только сообщает, что попытки исчерпаны.
+func (s *Storage) ClaimDeliveries(ctx context.Context, now string, leaseUntil string, limit int, maxAttempts int) (
+	[]storage.WebhookDelivery, error,
+) {
+	query := "update webhook_deliveries " +
+		"set status = 'sending', attempts = attempts + 1, next_attempt_at = $2, updated_at = $1 " +
+		"where id in (select id from webhook_deliveries " +
+		"where status in ('pending', 'sending') and next_attempt_at <= $1 and attempts <= $4 " +
+		"order by next_attempt_at limit $3 for update skip locked) " +
+		"returning " + deliveryFields
+
+	var deliveries []storage.WebhookDelivery
+
+	err := s.exec(ctx, func(ctx context.Context) error {
+		rows, err := s.Conn.QueryContext(ctx, query, now, leaseUntil, limit, maxAttempts)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		deliveries, err = scanDeliveries(rows)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (s *Storage) UpdateDelivery(ctx context.Context, d storage.WebhookDelivery) error {
+	query := "update webhook_deliveries " +
+		"set status = $2, next_attempt_at = $3, last_error = $4, response_status = $5, updated_at = now() " +
+		"where id = $1 and attempts = $6 and status = $7"
+
+	var result sql.Result
+
+	err := s.exec(ctx, func(ctx context.Context) (err error) {
+		result, err = s.Conn.ExecContext(ctx, query, d.ID, d.Status, d.NextAttemptAt, d.LastError, d.ResponseStatus,
+			d.Attempts, storage.DeliverySending)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(result, storage.ErrDeliveryNotExist)
+}
+
+func scanWebhook(row rowScanner) (storage.Webhook, error) {
+	var w storage.Webhook
+	var eventTypes string
+
+	err := row.Scan(&w.ID, &w.URL, &w.Secret, &eventTypes, &w.CreatedAt)
+	if err != nil {
+		return storage.Webhook{}, err
+	}
+
+	w.EventTypes = strings.Split(eventTypes, ",")
+
+	return w, nil
+}
+
+func scanDeliveries(rows *sql.Rows) ([]storage.WebhookDelivery, error) {
+	deliveries := make([]storage.WebhookDelivery, 0)
+
+	for rows.Next() {
+		var d storage.WebhookDelivery
+		err := rows.Scan(&d.ID, &d.WebhookID, &d.Key, &d.EventType, &d.EventID, &d.Payload, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.LastError, &d.ResponseStatus, &d.CreatedAt, &d.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
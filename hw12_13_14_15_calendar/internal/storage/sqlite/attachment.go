@@ -0,0 +1,101 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+const selectFieldsFromAttachments = "select id, event_id, name, content_type, size, coalesce(url, ''), " +
+	"coalesce(blob_key, ''), created_at from attachments"
+
+func (s *Storage) CreateAttachment(ctx context.Context, a storage.Attachment) (string, error) {
+	id := uuid.NewString()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	_, err := s.Conn.ExecContext(ctx, "insert into attachments (id, event_id, name, content_type, size, url, blob_key) "+
+		"values (?, ?, ?, ?, ?, nullif(?, ''), nullif(?, ''))",
+		id, a.EventID, a.Name, a.ContentType, a.Size, a.URL, a.BlobKey)
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY {
+		return "", storage.ErrEventNotExist
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (s *Storage) GetAttachment(ctx context.Context, id string) (storage.Attachment, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	a, err := scanAttachment(s.Conn.QueryRowContext(ctx, selectFieldsFromAttachments+" where id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return a, storage.ErrAttachmentNotExist
+	}
+
+	return a, err
+}
+
+func (s *Storage) ListAttachments(ctx context.Context, eventID string) ([]storage.Attachment, error) {
+	return s.listAttachments(ctx, " where event_id = ? order by created_at, id", eventID)
+}
+
+func (s *Storage) DeleteAttachment(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	result, err := s.Conn.ExecContext(ctx, "delete from attachments where id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(result, storage.ErrAttachmentNotExist)
+}
+
+func (s *Storage) listAttachments(ctx context.Context, where string, args ...interface{}) (
+	[]storage.Attachment, error,
+) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	rows, err := s.Conn.QueryContext(ctx, selectFieldsFromAttachments+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAttachments(rows)
+}
+
+func scanAttachments(rows *sql.Rows) ([]storage.Attachment, error) {
+	attachments := make([]storage.Attachment, 0)
+
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+
+	return attachments, rows.Err()
+}
+
+func scanAttachment(row rowScanner) (storage.Attachment, error) {
+	var a storage.Attachment
+
+	err := row.Scan(&a.ID, &a.EventID, &a.Name, &a.ContentType, &a.Size, &a.URL, &a.BlobKey, &a.CreatedAt)
+
+	return a, err
+}
@@ -0,0 +1,134 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+// ApplyBatch выполняет операции в одной транзакции.
+func (s *Storage) ApplyBatch(ctx context.Context, ops []storage.BatchOperation) ([]storage.BatchResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Batch)
+	defer cancel()
+
+	results := storage.NewBatchResults(ops)
+
+	tx, err := s.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for i, op := range ops {
+		e, err := applyOperation(ctx, tx, op)
+		if err != nil {
+			return storage.RollbackBatch(results, i, err), &storage.BatchError{Index: i, Err: err}
+		}
+
+		results[i].ID = e.ID
+		results[i].Event = e
+		results[i].Status = storage.BatchStatusApplied
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// applyOne выполняет операцию в отдельной транзакции, чтобы событие и его метки менялись вместе.
+func (s *Storage) applyOne(ctx context.Context, op storage.BatchOperation) (storage.Event, error) {
+	var e storage.Event
+
+	err := s.transaction(ctx, func(ctx context.Context, tx *sql.Tx) (err error) {
+		e, err = applyOperation(ctx, tx, op)
+		return err
+	})
+
+	return e, err
+}
+
+// transaction выполняет fn в транзакции с таймаутом одного запроса и фиксирует ее, если fn не вернула ошибку.
+func (s *Storage) transaction(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	tx, err := s.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	err = fn(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func applyOperation(ctx context.Context, tx *sql.Tx, op storage.BatchOperation) (storage.Event, error) {
+	var row *sql.Row
+	var tags, resources string
+
+	e := op.Event
+	e.Normalize()
+
+	switch op.Op {
+	case storage.BatchCreate:
+		row = tx.QueryRowContext(ctx, "insert into events "+
+			"(id, title, date_start, date_end, description, user_id, date_post, category, color, all_day) "+
+			"values (?, ?, ?, ?, ?, ?, nullif(?, ''), nullif(?, ''), nullif(?, ''), ?) returning "+eventFields,
+			uuid.NewString(), e.Title, e.DateStart, e.DateEnd, e.Description, e.UserID, e.DatePost, e.Category, e.Color,
+			e.AllDay)
+	case storage.BatchUpdate:
+		row = tx.QueryRowContext(ctx, "update events "+
+			"set title = ?, date_start = ?, date_end = ?, description = ?, user_id = ?, date_post = nullif(?, ''), "+
+			"category = nullif(?, ''), color = nullif(?, ''), all_day = ? "+
+			"where id = ? returning "+eventFields,
+			e.Title, e.DateStart, e.DateEnd, e.Description, e.UserID, e.DatePost, e.Category, e.Color, e.AllDay, op.ID)
+	case storage.BatchDelete:
+		// Каскадное удаление меток и броней выполняется раньше returning, поэтому они читаются заранее.
+		err := tx.QueryRowContext(ctx, "select coalesce((select group_concat(tag, ',') "+
+			"from (select tag from event_tags where event_id = ?1 order by tag)), ''), "+
+			"coalesce((select group_concat(resource_id, ',') "+
+			"from (select resource_id from event_resources where event_id = ?1 order by resource_id)), '')",
+			op.ID).Scan(&tags, &resources)
+		if err != nil {
+			return storage.Event{}, err
+		}
+		row = tx.QueryRowContext(ctx, "delete from events where id = ? returning "+eventFields, op.ID)
+	default:
+		return storage.Event{}, fmt.Errorf("unsupported batch operation %q", op.Op)
+	}
+
+	result, err := scanEvent(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Event{}, storage.ErrEventNotExist
+	}
+	if err != nil {
+		return storage.Event{}, eventError(err)
+	}
+	if op.Op == storage.BatchDelete {
+		result.Tags = storage.SplitTags(tags)
+		result.Resources = storage.SplitTags(resources)
+		return result, nil
+	}
+
+	// returning видит метки и ресурсы до изменения, поэтому результат берет их из операции.
+	result.Tags = e.Tags
+	result.Resources = e.Resources
+
+	err = setEventTags(ctx, tx, result.ID, result.Tags)
+	if err != nil {
+		return storage.Event{}, err
+	}
+
+	return result, setEventResources(ctx, tx, result)
+}
@@ -0,0 +1,25 @@
+package sqlitestorage_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	sqlstorage "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage/sql"
+	sqlitestorage "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage/sqlite"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage/storagetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) app.Storager {
+		t.Helper()
+
+		s := sqlitestorage.New(filepath.Join(t.TempDir(), "calendar.db"), sqlstorage.Timeouts{})
+		require.NoError(t, s.Open(context.Background()))
+		t.Cleanup(func() { s.Close() })
+
+		return s
+	})
+}
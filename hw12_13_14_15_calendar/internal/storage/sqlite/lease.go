@@ -0,0 +1,43 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// AcquireLease захватывает или продлевает аренду name на ttl. Аренду получает holder,
+// если она свободна, истекла или уже принадлежит ему.
+func (s *Storage) AcquireLease(ctx context.Context, name string, holder string, ttl time.Duration) (bool, error) {
+	query := "insert into leases (name, holder, expires_at) values (?1, ?2, ?3) " +
+		"on conflict (name) do update set holder = excluded.holder, expires_at = excluded.expires_at " +
+		"where leases.expires_at < ?4 or leases.holder = excluded.holder " +
+		"returning holder"
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	now := time.Now()
+
+	var got string
+	err := s.Conn.QueryRowContext(ctx, query, name, holder, now.Add(ttl).UnixMilli(), now.UnixMilli()).Scan(&got)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ReleaseLease освобождает аренду name, если она принадлежит holder.
+func (s *Storage) ReleaseLease(ctx context.Context, name string, holder string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	_, err := s.Conn.ExecContext(ctx, "delete from leases where name = ? and holder = ?", name, holder)
+
+	return err
+}
@@ -0,0 +1,75 @@
+package sqlitestorage
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/migrations"
+)
+
+// Миграции повторяют migrations/*.sql для Postgres с поправкой на диалект SQLite
+// и записываются в таблицу goose_db_version, поэтому базу можно обслуживать и утилитой goose.
+//
+//go:embed migrations/*.sql
+var embedded embed.FS
+
+func (s *Storage) migrate(ctx context.Context) error {
+	_, err := s.Conn.ExecContext(ctx, "create table if not exists goose_db_version ("+
+		"id integer primary key autoincrement, version_id integer not null, "+
+		"is_applied integer not null, tstamp timestamp default current_timestamp)")
+	if err != nil {
+		return err
+	}
+
+	var current int64
+	err = s.Conn.QueryRowContext(ctx,
+		"select coalesce(max(version_id), 0) from goose_db_version where is_applied = 1").Scan(&current)
+	if err != nil {
+		return err
+	}
+
+	dir, err := fs.Sub(embedded, "migrations")
+	if err != nil {
+		return err
+	}
+
+	list, err := migrations.Load(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range list {
+		if m.Version <= current {
+			continue
+		}
+
+		err = s.applyMigration(ctx, m)
+		if err != nil {
+			return fmt.Errorf("migration %d: %w", m.Version, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Storage) applyMigration(ctx context.Context, m migrations.Migration) error {
+	tx, err := s.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.ExecContext(ctx, m.Up)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "insert into goose_db_version (version_id, is_applied) values (?, 1)", m.Version)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE events(
+    id TEXT PRIMARY KEY,
+    title VARCHAR(255) NOT NULL,
+    date_start TEXT NOT NULL,
+    date_end TEXT NOT NULL,
+    description TEXT DEFAULT NULL,
+    user_id TEXT NOT NULL,
+    date_post TEXT DEFAULT NULL
+);
+
+CREATE UNIQUE INDEX events_date_start_idx ON events (date_start);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE events;
+-- +goose StatementEnd
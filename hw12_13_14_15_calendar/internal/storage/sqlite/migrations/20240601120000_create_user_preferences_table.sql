@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE user_preferences(
+    user_id TEXT PRIMARY KEY,
+    daily_digest INTEGER NOT NULL DEFAULT 1,
+    weekly_digest INTEGER NOT NULL DEFAULT 1,
+    digest_time TEXT NOT NULL DEFAULT '08:00'
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_preferences;
+-- +goose StatementEnd
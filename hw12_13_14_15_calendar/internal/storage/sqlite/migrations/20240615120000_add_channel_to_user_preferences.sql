@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_preferences ADD COLUMN channel TEXT NOT NULL DEFAULT '';
+ALTER TABLE user_preferences ADD COLUMN address TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_preferences DROP COLUMN address;
+ALTER TABLE user_preferences DROP COLUMN channel;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE events ADD COLUMN all_day INTEGER NOT NULL DEFAULT 0;
+
+-- События на весь день начинаются в полночь и не занимают время начала.
+DROP INDEX events_date_start_idx;
+CREATE UNIQUE INDEX events_date_start_idx ON events (date_start) WHERE all_day = 0;
+
+CREATE INDEX events_date_end_idx ON events (date_end);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX events_date_end_idx;
+DROP INDEX events_date_start_idx;
+CREATE UNIQUE INDEX events_date_start_idx ON events (date_start);
+ALTER TABLE events DROP COLUMN all_day;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE resources(
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    capacity INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE event_resources(
+    event_id TEXT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    resource_id TEXT NOT NULL REFERENCES resources(id) ON DELETE CASCADE,
+    PRIMARY KEY (event_id, resource_id)
+);
+
+CREATE INDEX event_resources_resource_id_idx ON event_resources (resource_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE event_resources;
+DROP TABLE resources;
+-- +goose StatementEnd
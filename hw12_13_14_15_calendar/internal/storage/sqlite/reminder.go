@@ -0,0 +1,68 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+func (s *Storage) GetReminderState(ctx context.Context, eventID string, userID string) (storage.ReminderState, error) {
+	query := "select event_id, user_id, coalesce(snoozed_until, ''), dismissed, notified_key " +
+		"from reminder_states where event_id = ? and user_id = ?"
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	var r storage.ReminderState
+
+	err := s.Conn.QueryRowContext(ctx, query, eventID, userID).Scan(&r.EventID, &r.UserID, &r.SnoozedUntil,
+		&r.Dismissed, &r.NotifiedKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.ReminderState{}, storage.ErrReminderStateNotExist
+	}
+	if err != nil {
+		return storage.ReminderState{}, err
+	}
+
+	return r, nil
+}
+
+func (s *Storage) SaveReminderState(ctx context.Context, r storage.ReminderState) error {
+	query := "insert into reminder_states (event_id, user_id, snoozed_until, dismissed) " +
+		"values (?, ?, nullif(?, ''), ?) on conflict (event_id, user_id) do update set " +
+		"snoozed_until = excluded.snoozed_until, dismissed = excluded.dismissed, " +
+		"updated_at = datetime('now', 'localtime')"
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	_, err := s.Conn.ExecContext(ctx, query, r.EventID, r.UserID, r.SnoozedUntil, r.Dismissed)
+
+	return reminderError(err)
+}
+
+func (s *Storage) MarkReminderNotified(ctx context.Context, eventID string, userID string, key string) error {
+	query := "insert into reminder_states (event_id, user_id, notified_key) values (?, ?, ?) " +
+		"on conflict (event_id, user_id) do update set notified_key = excluded.notified_key, " +
+		"updated_at = datetime('now', 'localtime')"
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	_, err := s.Conn.ExecContext(ctx, query, eventID, userID, key)
+
+	return reminderError(err)
+}
+
+func reminderError(err error) error {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY {
+		return storage.ErrEventNotExist
+	}
+
+	return err
+}
@@ -0,0 +1,156 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+const selectFieldsFromResources = "select id, name, kind, capacity from resources"
+
+func (s *Storage) CreateResource(ctx context.Context, r storage.Resource) (string, error) {
+	id := uuid.NewString()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	_, err := s.Conn.ExecContext(ctx, "insert into resources (id, name, kind, capacity) values (?, ?, ?, ?)",
+		id, r.Name, r.Kind, r.Capacity)
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (s *Storage) GetResource(ctx context.Context, id string) (storage.Resource, error) {
+	var r storage.Resource
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	err := s.Conn.QueryRowContext(ctx, selectFieldsFromResources+" where id = ?", id).
+		Scan(&r.ID, &r.Name, &r.Kind, &r.Capacity)
+	if errors.Is(err, sql.ErrNoRows) {
+		return r, storage.ErrResourceNotExist
+	}
+
+	return r, err
+}
+
+func (s *Storage) ListResources(ctx context.Context) ([]storage.Resource, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	rows, err := s.Conn.QueryContext(ctx, selectFieldsFromResources+" order by name, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	resources := make([]storage.Resource, 0)
+
+	for rows.Next() {
+		var r storage.Resource
+
+		err = rows.Scan(&r.ID, &r.Name, &r.Kind, &r.Capacity)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, r)
+	}
+
+	return resources, rows.Err()
+}
+
+func (s *Storage) UpdateResource(ctx context.Context, id string, r storage.Resource) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	result, err := s.Conn.ExecContext(ctx, "update resources set name = ?, kind = ?, capacity = ? where id = ?",
+		r.Name, r.Kind, r.Capacity, id)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(result, storage.ErrResourceNotExist)
+}
+
+// DeleteResource удаляет ресурс, брони событий удаляются каскадно.
+func (s *Storage) DeleteResource(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	result, err := s.Conn.ExecContext(ctx, "delete from resources where id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(result, storage.ErrResourceNotExist)
+}
+
+// ListBookings возвращает брони, пересекающиеся с интервалом [dateFrom, dateTo).
+func (s *Storage) ListBookings(ctx context.Context, dateFrom string, dateTo string) ([]storage.Booking, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	rows, err := s.Conn.QueryContext(ctx, "select er.resource_id, e.id, e.title, e.date_start, e.date_end "+
+		"from event_resources er join events e on e.id = er.event_id "+
+		"where e.date_start < datetime(?2) and e.date_end > datetime(?1) order by er.resource_id, e.date_start",
+		dateFrom, dateTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bookings := make([]storage.Booking, 0)
+
+	for rows.Next() {
+		var b storage.Booking
+
+		err = rows.Scan(&b.ResourceID, &b.EventID, &b.Title, &b.DateStart, &b.DateEnd)
+		if err != nil {
+			return nil, err
+		}
+		bookings = append(bookings, b)
+	}
+
+	return bookings, rows.Err()
+}
+
+// setEventResources заменяет брони события. Писатель в SQLite один, поэтому проверка занятости
+// и вставка брони не пересекаются с другими транзакциями.
+func setEventResources(ctx context.Context, tx *sql.Tx, e storage.Event) error {
+	_, err := tx.ExecContext(ctx, "delete from event_resources where event_id = ?", e.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range e.Resources {
+		var exists, busy bool
+
+		err = tx.QueryRowContext(ctx, "select exists(select 1 from resources where id = ?1), "+
+			"exists(select 1 from event_resources er join events e on e.id = er.event_id "+
+			"where er.resource_id = ?1 and e.date_start < ?3 and e.date_end > ?2)",
+			r, e.DateStart, e.DateEnd).Scan(&exists, &busy)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return storage.ErrResourceNotExist
+		}
+		if busy {
+			return storage.ErrResourceBusy
+		}
+
+		_, err = tx.ExecContext(ctx, "insert into event_resources (event_id, resource_id) values (?, ?)", e.ID, r)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
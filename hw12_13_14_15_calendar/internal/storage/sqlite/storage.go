@@ -0,0 +1,233 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	sqlstorage "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage/sql"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Storage хранит данные в файле SQLite. Запросы выполняются через одно соединение:
+// SQLite все равно допускает только одного писателя.
+type Storage struct {
+	path     string
+	timeouts sqlstorage.Timeouts
+	Conn     *sql.DB
+}
+
+const eventFields = "id, title, date_start, date_end, coalesce(description, ''), user_id, coalesce(date_post, ''), " +
+	"coalesce(category, ''), coalesce(color, ''), all_day, " +
+	"coalesce((select group_concat(tag, ',') from " +
+	"(select tag from event_tags where event_id = events.id order by tag)), ''), " +
+	"coalesce((select group_concat(resource_id, ',') from " +
+	"(select resource_id from event_resources where event_id = events.id order by resource_id)), '')"
+
+const selectFieldsFromEvents = "select " + eventFields + " from events"
+
+func New(path string, timeouts sqlstorage.Timeouts) *Storage {
+	if timeouts.Query <= 0 {
+		timeouts.Query = sqlstorage.QueryTimeout
+	}
+	if timeouts.Batch <= 0 {
+		timeouts.Batch = sqlstorage.BatchTimeout
+	}
+
+	return &Storage{
+		path:     path,
+		timeouts: timeouts,
+	}
+}
+
+func (s *Storage) CreateEvent(ctx context.Context, event storage.Event) (string, error) {
+	e, err := s.applyOne(ctx, storage.BatchOperation{Op: storage.BatchCreate, Event: event})
+
+	return e.ID, err
+}
+
+func (s *Storage) UpdateEvent(ctx context.Context, id string, event storage.Event) error {
+	_, err := s.applyOne(ctx, storage.BatchOperation{Op: storage.BatchUpdate, ID: id, Event: event})
+
+	return err
+}
+
+func (s *Storage) DeleteEvent(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	result, err := s.Conn.ExecContext(ctx, "delete from events where id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(result, storage.ErrEventNotExist)
+}
+
+func (s *Storage) GetEvent(ctx context.Context, id string) (storage.Event, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	e, err := scanEvent(s.Conn.QueryRowContext(ctx, selectFieldsFromEvents+" where id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Event{}, storage.ErrEventNotExist
+	}
+
+	return e, err
+}
+
+func (s *Storage) ListEventDay(ctx context.Context, date string) ([]storage.Event, error) {
+	return s.listEvents(ctx, overlapping("datetime(?1)", "datetime(?1, '+1 day')")+" order by date_start", date)
+}
+
+func (s *Storage) ListEventWeek(ctx context.Context, date string) ([]storage.Event, error) {
+	return s.listEvents(ctx, overlapping("datetime(?1)", "datetime(?1, '+7 days')")+" order by date_start", date)
+}
+
+func (s *Storage) ListEventMonth(ctx context.Context, date string) ([]storage.Event, error) {
+	return s.listEvents(ctx, overlapping("datetime(?1)", "datetime(?1, '+1 month')")+" order by date_start", date)
+}
+
+func (s *Storage) ListEventRange(ctx context.Context, dateFrom string, dateTo string) ([]storage.Event, error) {
+	return s.listEvents(ctx,
+		overlapping("datetime(?1)", "datetime(?2, '+1 day')")+" order by date_start", dateFrom, dateTo)
+}
+
+// DeleteEventsBeforeDate удаляет события, начавшиеся раньше date, и возвращает их вложения.
+// Вложения читаются в той же транзакции до каскадного удаления.
+func (s *Storage) DeleteEventsBeforeDate(ctx context.Context, date string) ([]storage.Attachment, error) {
+	var attachments []storage.Attachment
+
+	err := s.transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, selectFieldsFromAttachments+
+			" where event_id in (select id from events where date_start < ?) order by created_at, id", date)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		attachments, err = scanAttachments(rows)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "delete from events where date_start < ?", date)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+func (s *Storage) ListEventWithNotification(ctx context.Context) ([]storage.Event, error) {
+	return s.listEvents(ctx, " where date_post is not null and date_post < date('now', 'localtime') order by date_start")
+}
+
+func (s *Storage) listEvents(ctx context.Context, where string, args ...interface{}) ([]storage.Event, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	rows, err := s.Conn.QueryContext(ctx, selectFieldsFromEvents+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]storage.Event, 0)
+
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+func (s *Storage) Open(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	// Внешние ключи нужны для каскадного удаления доставок вместе с webhook.
+	dsn := "file:" + s.path + "?" + url.Values{
+		"_pragma": {"foreign_keys(1)", "busy_timeout(5000)", "journal_mode(WAL)"},
+	}.Encode()
+
+	var err error
+	s.Conn, err = sql.Open("sqlite", dsn)
+	if err != nil {
+		return err
+	}
+	s.Conn.SetMaxOpenConns(1)
+
+	err = s.Conn.PingContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return s.migrate(ctx)
+}
+
+func (s *Storage) Close() error {
+	return s.Conn.Close()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanEvent(row rowScanner) (storage.Event, error) {
+	var e storage.Event
+
+	var tags, resources string
+
+	err := row.Scan(&e.ID, &e.Title, &e.DateStart, &e.DateEnd, &e.Description, &e.UserID, &e.DatePost,
+		&e.Category, &e.Color, &e.AllDay, &tags, &resources)
+	e.Tags = storage.SplitTags(tags)
+	e.Resources = storage.SplitTags(resources)
+
+	return e, err
+}
+
+// overlapping отбирает события, пересекающиеся с интервалом [from, to), где from и to - выражения SQL.
+// Даты хранятся строками в формате time.DateTime, поэтому границы приводятся к нему через datetime.
+func overlapping(from string, to string) string {
+	return " where date_start < " + to + " and (date_end > " + from + " or date_start >= " + from + ")"
+}
+
+func checkAffected(result sql.Result, notFound error) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return notFound
+	}
+
+	return nil
+}
+
+// eventError переводит нарушение уникальности даты начала в storage.ErrDateBusy.
+func eventError(err error) error {
+	if isUniqueViolation(err) {
+		return storage.ErrDateBusy
+	}
+
+	return err
+}
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+
+	return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
@@ -0,0 +1,89 @@
+package sqlitestorage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	sqlstorage "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage/sql"
+	"github.com/stretchr/testify/require"
+)
+
+func newStorage(t *testing.T, path string) *Storage {
+	t.Helper()
+
+	s := New(path, sqlstorage.Timeouts{})
+	require.NoError(t, s.Open(context.Background()))
+	t.Cleanup(func() { s.Close() })
+
+	return s
+}
+
+func TestWebhooks(t *testing.T) {
+	ctx := context.Background()
+	s := newStorage(t, filepath.Join(t.TempDir(), "calendar.db"))
+
+	id, err := s.CreateWebhook(ctx, storage.Webhook{
+		URL: "http://localhost/hook", Secret: "0123456789abcdef", EventTypes: []string{"created", "deleted"},
+	})
+	require.NoError(t, err)
+
+	w, err := s.GetWebhook(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, []string{"created", "deleted"}, w.EventTypes)
+
+	_, err = s.CreateDelivery(ctx, storage.WebhookDelivery{WebhookID: id, Key: "k", EventType: "created", Payload: "{}"})
+	require.NoError(t, err)
+	_, err = s.CreateDelivery(ctx, storage.WebhookDelivery{WebhookID: id, Key: "k", EventType: "created", Payload: "{}"})
+	require.ErrorIs(t, err, storage.ErrDeliveryDuplicate)
+	_, err = s.CreateDelivery(ctx, storage.WebhookDelivery{WebhookID: "unknown", EventType: "created", Payload: "{}"})
+	require.ErrorIs(t, err, storage.ErrWebhookNotExist)
+
+	now := time.Now()
+	claimed, err := s.ClaimDeliveries(ctx,
+		now.Format(time.DateTime), now.Add(time.Minute).Format(time.DateTime), 10, 3)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	require.Equal(t, storage.DeliverySending, claimed[0].Status)
+	require.Equal(t, 1, claimed[0].Attempts)
+
+	claimed[0].Status = storage.DeliveryDelivered
+	require.NoError(t, s.UpdateDelivery(ctx, claimed[0]))
+	require.ErrorIs(t, s.UpdateDelivery(ctx, storage.WebhookDelivery{ID: "unknown"}), storage.ErrDeliveryNotExist)
+
+	deliveries, err := s.ListDeliveries(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, storage.DeliveryDelivered, deliveries[0].Status)
+
+	require.NoError(t, s.DeleteWebhook(ctx, id))
+	require.ErrorIs(t, s.DeleteWebhook(ctx, id), storage.ErrWebhookNotExist)
+
+	var n int
+	require.NoError(t, s.Conn.QueryRow("select count(*) from webhook_deliveries").Scan(&n))
+	require.Zero(t, n)
+}
+
+func TestReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "calendar.db")
+
+	s := New(path, sqlstorage.Timeouts{})
+	require.NoError(t, s.Open(ctx))
+	id, err := s.CreateEvent(ctx, storage.Event{Title: "kept", DateStart: "2022-10-11 12:00:00"})
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	// Повторное открытие не применяет миграции заново.
+	s = newStorage(t, path)
+
+	e, err := s.GetEvent(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "kept", e.Title)
+
+	var versions int
+	require.NoError(t, s.Conn.QueryRow("select count(*) from goose_db_version").Scan(&versions))
+	require.Equal(t, 11, versions)
+}
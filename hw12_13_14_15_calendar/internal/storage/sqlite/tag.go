@@ -0,0 +1,140 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+func (s *Storage) ListTags(ctx context.Context) ([]storage.Tag, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	rows, err := s.Conn.QueryContext(ctx, "select tag, count(*) from event_tags group by tag order by tag")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make([]storage.Tag, 0)
+
+	for rows.Next() {
+		var t storage.Tag
+
+		err = rows.Scan(&t.Name, &t.Events)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+
+	return tags, rows.Err()
+}
+
+// ListEventTagged возвращает события, которые занимают хотя бы один день с dateFrom по dateTo
+// включительно и у которых есть все метки tags.
+func (s *Storage) ListEventTagged(ctx context.Context, tags []string, dateFrom string, dateTo string) (
+	[]storage.Event, error,
+) {
+	tags = storage.NormalizeTags(tags)
+	if len(tags) == 0 {
+		return s.ListEventRange(ctx, dateFrom, dateTo)
+	}
+
+	args := []interface{}{dateFrom, dateTo, len(tags)}
+	params := make([]string, len(tags))
+
+	for i, t := range tags {
+		args = append(args, t)
+		params[i] = "?" + strconv.Itoa(len(args))
+	}
+
+	return s.listEvents(ctx, overlapping("datetime(?1)", "datetime(?2, '+1 day')")+" "+
+		"and id in (select event_id from event_tags where tag in ("+strings.Join(params, ", ")+") "+
+		"group by event_id having count(*) = ?3) order by date_start", args...)
+}
+
+// RenameTag переименовывает метку во всех событиях. Если метка newName уже есть, метки объединяются.
+func (s *Storage) RenameTag(ctx context.Context, name string, newName string) error {
+	name = storage.NormalizeTag(name)
+	newName = storage.NormalizeTag(newName)
+
+	return s.transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		err := tagExists(ctx, tx, name)
+		if err != nil || name == newName {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "insert into tags (name) values (?) on conflict do nothing", newName)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "insert into event_tags (event_id, tag) "+
+			"select event_id, ? from event_tags where tag = ? on conflict do nothing", newName, name)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "delete from tags where name = ?", name)
+
+		return err
+	})
+}
+
+// DeleteTag снимает метку со всех событий.
+func (s *Storage) DeleteTag(ctx context.Context, name string) error {
+	name = storage.NormalizeTag(name)
+
+	return s.transaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		err := tagExists(ctx, tx, name)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "delete from tags where name = ?", name)
+
+		return err
+	})
+}
+
+// tagExists проверяет, что метка есть хотя бы у одного события: строки tags
+// после удаления последнего события с меткой не удаляются.
+func tagExists(ctx context.Context, tx *sql.Tx, name string) error {
+	var exists bool
+
+	err := tx.QueryRowContext(ctx, "select exists(select 1 from event_tags where tag = ?)", name).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return storage.ErrTagNotExist
+	}
+
+	return nil
+}
+
+// setEventTags заменяет метки события на tags.
+func setEventTags(ctx context.Context, tx *sql.Tx, id string, tags []string) error {
+	_, err := tx.ExecContext(ctx, "delete from event_tags where event_id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range tags {
+		_, err = tx.ExecContext(ctx, "insert into tags (name) values (?) on conflict do nothing", t)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "insert into event_tags (event_id, tag) values (?, ?)", id, t)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
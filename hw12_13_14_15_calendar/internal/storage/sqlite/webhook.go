@@ -0,0 +1,199 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+const selectFieldsFromWebhooks = "select id, url, secret, event_types, created_at from webhooks"
+
+const deliveryFields = "id, webhook_id, coalesce(delivery_key, ''), event_type, event_id, payload, status, attempts, " +
+	"next_attempt_at, last_error, response_status, created_at, updated_at"
+
+func (s *Storage) CreateWebhook(ctx context.Context, w storage.Webhook) (string, error) {
+	id := uuid.NewString()
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	_, err := s.Conn.ExecContext(ctx, "insert into webhooks (id, url, secret, event_types) values (?, ?, ?, ?)",
+		id, w.URL, w.Secret, strings.Join(w.EventTypes, ","))
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (s *Storage) GetWebhook(ctx context.Context, id string) (storage.Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	w, err := scanWebhook(s.Conn.QueryRowContext(ctx, selectFieldsFromWebhooks+" where id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return w, storage.ErrWebhookNotExist
+	}
+
+	return w, err
+}
+
+func (s *Storage) ListWebhooks(ctx context.Context) ([]storage.Webhook, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	rows, err := s.Conn.QueryContext(ctx, selectFieldsFromWebhooks+" order by created_at, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := make([]storage.Webhook, 0)
+
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+
+	return webhooks, rows.Err()
+}
+
+func (s *Storage) DeleteWebhook(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	result, err := s.Conn.ExecContext(ctx, "delete from webhooks where id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(result, storage.ErrWebhookNotExist)
+}
+
+func (s *Storage) CreateDelivery(ctx context.Context, d storage.WebhookDelivery) (string, error) {
+	id := uuid.NewString()
+
+	if d.Status == "" {
+		d.Status = storage.DeliveryPending
+	}
+
+	query := "insert into webhook_deliveries " +
+		"(id, webhook_id, delivery_key, event_type, event_id, payload, status, next_attempt_at) " +
+		"values (?, ?, nullif(?, ''), ?, ?, ?, ?, coalesce(nullif(?, ''), datetime('now', 'localtime')))"
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	_, err := s.GetWebhook(ctx, d.WebhookID)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.Conn.ExecContext(ctx, query,
+		id, d.WebhookID, d.Key, d.EventType, d.EventID, d.Payload, d.Status, d.NextAttemptAt)
+	if isUniqueViolation(err) {
+		return "", storage.ErrDeliveryDuplicate
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (s *Storage) ListDeliveries(ctx context.Context, webhookID string) ([]storage.WebhookDelivery, error) {
+	_, err := s.GetWebhook(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	rows, err := s.Conn.QueryContext(ctx, "select "+deliveryFields+
+		" from webhook_deliveries where webhook_id = ? order by created_at desc, id desc", webhookID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanDeliveries(rows)
+}
+
+// ClaimDeliveries помечает подошедшие к отправке доставки как отправляемые до leaseUntil.
+// Доставка с исчерпанными попытками выбирается последний раз, чтобы ее можно было пометить failed.
+func (s *Storage) ClaimDeliveries(ctx context.Context, now string, leaseUntil string, limit int, maxAttempts int) (
+	[]storage.WebhookDelivery, error,
+) {
+	query := "update webhook_deliveries " +
+		"set status = 'sending', attempts = attempts + 1, next_attempt_at = ?2, updated_at = ?1 " +
+		"where id in (select id from webhook_deliveries " +
+		"where status in ('pending', 'sending') and next_attempt_at <= ?1 and attempts <= ?4 " +
+		"order by next_attempt_at limit ?3) " +
+		"returning " + deliveryFields
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	rows, err := s.Conn.QueryContext(ctx, query, now, leaseUntil, limit, maxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanDeliveries(rows)
+}
+
+func (s *Storage) UpdateDelivery(ctx context.Context, d storage.WebhookDelivery) error {
+	query := "update webhook_deliveries " +
+		"set status = ?, next_attempt_at = ?, last_error = ?, response_status = ?, " +
+		"updated_at = datetime('now', 'localtime') where id = ? and attempts = ? and status = ?"
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Query)
+	defer cancel()
+
+	result, err := s.Conn.ExecContext(ctx, query, d.Status, d.NextAttemptAt, d.LastError, d.ResponseStatus, d.ID,
+		d.Attempts, storage.DeliverySending)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(result, storage.ErrDeliveryNotExist)
+}
+
+func scanWebhook(row rowScanner) (storage.Webhook, error) {
+	var w storage.Webhook
+	var eventTypes string
+
+	err := row.Scan(&w.ID, &w.URL, &w.Secret, &eventTypes, &w.CreatedAt)
+	if err != nil {
+		return storage.Webhook{}, err
+	}
+
+	w.EventTypes = strings.Split(eventTypes, ",")
+
+	return w, nil
+}
+
+func scanDeliveries(rows *sql.Rows) ([]storage.WebhookDelivery, error) {
+	deliveries := make([]storage.WebhookDelivery, 0)
+
+	for rows.Next() {
+		var d storage.WebhookDelivery
+		err := rows.Scan(&d.ID, &d.WebhookID, &d.Key, &d.EventType, &d.EventID, &d.Payload, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.LastError, &d.ResponseStatus, &d.CreatedAt, &d.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
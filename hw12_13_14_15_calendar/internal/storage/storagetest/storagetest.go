@@ -0,0 +1,1091 @@
+// Package storagetest содержит общий набор тестов, который должна проходить любая реализация app.Storager.
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/app"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/webhook"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory возвращает открытое пустое хранилище. Закрыть его фабрика может через t.Cleanup.
+type Factory func(t *testing.T) app.Storager
+
+// Run проверяет хранилище на соответствие общему контракту. Тесты планировщика и аренд
+// выполняются, только если хранилище реализует app.StorageScheduler и app.StorageLeader.
+func Run(t *testing.T, newStorage Factory) {
+	t.Helper()
+
+	t.Run("events", func(t *testing.T) { testEvents(t, newStorage) })
+	t.Run("lists", func(t *testing.T) { testLists(t, newStorage) })
+	t.Run("spans", func(t *testing.T) { testSpans(t, newStorage) })
+	t.Run("batch", func(t *testing.T) { testBatch(t, newStorage) })
+	t.Run("webhooks", func(t *testing.T) { testWebhooks(t, newStorage) })
+	t.Run("scheduler", func(t *testing.T) { testScheduler(t, newStorage) })
+	t.Run("leases", func(t *testing.T) { testLeases(t, newStorage) })
+	t.Run("preferences", func(t *testing.T) { testPreferences(t, newStorage) })
+	t.Run("reminders", func(t *testing.T) { testReminders(t, newStorage) })
+	t.Run("tags", func(t *testing.T) { testTags(t, newStorage) })
+	t.Run("attachments", func(t *testing.T) { testAttachments(t, newStorage) })
+	t.Run("resources", func(t *testing.T) { testResources(t, newStorage) })
+}
+
+// NewEvent возвращает событие со всеми заполненными полями, которое примет любое хранилище.
+func NewEvent(title string, dateStart string) storage.Event {
+	start, err := time.Parse(time.DateTime, dateStart)
+	if err != nil {
+		panic(err)
+	}
+
+	return storage.Event{
+		Title:       title,
+		DateStart:   dateStart,
+		DateEnd:     start.Add(time.Hour).Format(time.DateTime),
+		Description: "description of " + title,
+		UserID:      uuid.NewString(),
+	}
+}
+
+func create(t *testing.T, s app.Storager, e storage.Event) storage.Event {
+	t.Helper()
+
+	id, err := s.CreateEvent(context.Background(), e)
+	require.NoError(t, err)
+	require.NotEmpty(t, id)
+
+	e.ID = id
+
+	return e
+}
+
+func titles(events []storage.Event) []string {
+	list := make([]string, 0, len(events))
+	for _, e := range events {
+		list = append(list, e.Title)
+	}
+
+	return list
+}
+
+func testEvents(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	t.Run("create and get", func(t *testing.T) {
+		s := newStorage(t)
+
+		e := NewEvent("meeting", "2022-10-11 12:00:00")
+		e.DatePost = "2022-10-10 12:00:00"
+		e = create(t, s, e)
+
+		got, err := s.GetEvent(ctx, e.ID)
+		require.NoError(t, err)
+		require.Equal(t, e, got)
+	})
+
+	t.Run("create without notification", func(t *testing.T) {
+		s := newStorage(t)
+
+		e := create(t, s, NewEvent("meeting", "2022-10-11 12:00:00"))
+
+		got, err := s.GetEvent(ctx, e.ID)
+		require.NoError(t, err)
+		require.Empty(t, got.DatePost)
+	})
+
+	t.Run("create fail: date busy", func(t *testing.T) {
+		s := newStorage(t)
+
+		create(t, s, NewEvent("first", "2022-10-11 12:00:00"))
+
+		_, err := s.CreateEvent(ctx, NewEvent("second", "2022-10-11 12:00:00"))
+		require.ErrorIs(t, err, storage.ErrDateBusy)
+	})
+
+	t.Run("get fail: no event", func(t *testing.T) {
+		s := newStorage(t)
+
+		_, err := s.GetEvent(ctx, uuid.NewString())
+		require.ErrorIs(t, err, storage.ErrEventNotExist)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		s := newStorage(t)
+
+		e := create(t, s, NewEvent("meeting", "2022-10-11 12:00:00"))
+
+		updated := NewEvent("moved", "2022-10-12 12:00:00")
+		updated.DatePost = "2022-10-11 09:00:00"
+		require.NoError(t, s.UpdateEvent(ctx, e.ID, updated))
+
+		got, err := s.GetEvent(ctx, e.ID)
+		require.NoError(t, err)
+		updated.ID = e.ID
+		require.Equal(t, updated, got)
+
+		// Старая дата освобождается.
+		create(t, s, NewEvent("new", "2022-10-11 12:00:00"))
+	})
+
+	t.Run("update keeping date start", func(t *testing.T) {
+		s := newStorage(t)
+
+		e := create(t, s, NewEvent("meeting", "2022-10-11 12:00:00"))
+		e.Title = "renamed"
+
+		require.NoError(t, s.UpdateEvent(ctx, e.ID, e))
+
+		got, err := s.GetEvent(ctx, e.ID)
+		require.NoError(t, err)
+		require.Equal(t, "renamed", got.Title)
+	})
+
+	t.Run("update fail: date busy", func(t *testing.T) {
+		s := newStorage(t)
+
+		create(t, s, NewEvent("first", "2022-10-11 12:00:00"))
+		e := create(t, s, NewEvent("second", "2022-10-12 12:00:00"))
+
+		err := s.UpdateEvent(ctx, e.ID, NewEvent("second", "2022-10-11 12:00:00"))
+		require.ErrorIs(t, err, storage.ErrDateBusy)
+
+		got, err := s.GetEvent(ctx, e.ID)
+		require.NoError(t, err)
+		require.Equal(t, e, got)
+	})
+
+	t.Run("update fail: no event", func(t *testing.T) {
+		s := newStorage(t)
+
+		err := s.UpdateEvent(ctx, uuid.NewString(), NewEvent("meeting", "2022-10-11 12:00:00"))
+		require.ErrorIs(t, err, storage.ErrEventNotExist)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		s := newStorage(t)
+
+		e := create(t, s, NewEvent("meeting", "2022-10-11 12:00:00"))
+
+		require.NoError(t, s.DeleteEvent(ctx, e.ID))
+
+		_, err := s.GetEvent(ctx, e.ID)
+		require.ErrorIs(t, err, storage.ErrEventNotExist)
+		require.ErrorIs(t, s.DeleteEvent(ctx, e.ID), storage.ErrEventNotExist)
+
+		create(t, s, NewEvent("new", "2022-10-11 12:00:00"))
+	})
+}
+
+func testLists(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	s := newStorage(t)
+
+	dayBefore := NewEvent("day before", "2022-10-10 23:59:59")
+	dayBefore.DateEnd = dayBefore.DateStart
+
+	// События создаются не по порядку, списки должны быть упорядочены по началу.
+	for _, e := range []storage.Event{
+		NewEvent("month end", "2022-10-31 23:00:00"),
+		NewEvent("day evening", "2022-10-11 18:00:00"),
+		NewEvent("next month", "2022-11-11 10:00:00"),
+		NewEvent("day morning", "2022-10-11 09:00:00"),
+		NewEvent("week end", "2022-10-17 23:59:59"),
+		NewEvent("next week", "2022-10-18 00:00:00"),
+		dayBefore,
+	} {
+		create(t, s, e)
+	}
+
+	cases := []struct {
+		name   string
+		list   func() ([]storage.Event, error)
+		titles []string
+	}{
+		{
+			name:   "day",
+			list:   func() ([]storage.Event, error) { return s.ListEventDay(ctx, "2022-10-11") },
+			titles: []string{"day morning", "day evening"},
+		},
+		{
+			name:   "week",
+			list:   func() ([]storage.Event, error) { return s.ListEventWeek(ctx, "2022-10-11") },
+			titles: []string{"day morning", "day evening", "week end"},
+		},
+		{
+			name:   "month",
+			list:   func() ([]storage.Event, error) { return s.ListEventMonth(ctx, "2022-10-11") },
+			titles: []string{"day morning", "day evening", "week end", "next week", "month end"},
+		},
+		{
+			name:   "range",
+			list:   func() ([]storage.Event, error) { return s.ListEventRange(ctx, "2022-10-10", "2022-10-17") },
+			titles: []string{"day before", "day morning", "day evening", "week end"},
+		},
+		{
+			name:   "empty day",
+			list:   func() ([]storage.Event, error) { return s.ListEventDay(ctx, "2022-10-12") },
+			titles: []string{},
+		},
+		{
+			name:   "empty week",
+			list:   func() ([]storage.Event, error) { return s.ListEventWeek(ctx, "2023-10-11") },
+			titles: []string{},
+		},
+		{
+			name:   "empty month",
+			list:   func() ([]storage.Event, error) { return s.ListEventMonth(ctx, "2023-10-01") },
+			titles: []string{},
+		},
+		{
+			name:   "empty range",
+			list:   func() ([]storage.Event, error) { return s.ListEventRange(ctx, "2023-10-01", "2023-10-31") },
+			titles: []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			events, err := tc.list()
+			require.NoError(t, err)
+			require.NotNil(t, events)
+			require.Equal(t, tc.titles, titles(events))
+		})
+	}
+}
+
+func testBatch(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	t.Run("success", func(t *testing.T) {
+		s := newStorage(t)
+
+		existing := create(t, s, NewEvent("existing", "2022-10-11 10:00:00"))
+		removed := create(t, s, NewEvent("removed", "2022-10-11 11:00:00"))
+
+		created := NewEvent("created", "2022-10-11 12:00:00")
+		updated := NewEvent("updated", "2022-10-11 13:00:00")
+
+		results, err := s.ApplyBatch(ctx, []storage.BatchOperation{
+			{Op: storage.BatchCreate, Event: created},
+			{Op: storage.BatchUpdate, ID: existing.ID, Event: updated},
+			{Op: storage.BatchDelete, ID: removed.ID},
+		})
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+
+		for _, r := range results {
+			require.Equal(t, storage.BatchStatusApplied, r.Status)
+		}
+
+		created.ID = results[0].ID
+		require.Equal(t, created, results[0].Event)
+		updated.ID = existing.ID
+		require.Equal(t, updated, results[1].Event)
+		require.Equal(t, removed, results[2].Event)
+
+		events, err := s.ListEventDay(ctx, "2022-10-11")
+		require.NoError(t, err)
+		require.Equal(t, []storage.Event{created, updated}, events)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		s := newStorage(t)
+
+		existing := create(t, s, NewEvent("existing", "2022-10-11 10:00:00"))
+
+		results, err := s.ApplyBatch(ctx, []storage.BatchOperation{
+			{Op: storage.BatchCreate, Event: NewEvent("created", "2022-10-11 12:00:00")},
+			{Op: storage.BatchDelete, ID: existing.ID},
+			{Op: storage.BatchCreate, Event: NewEvent("busy", "2022-10-11 12:00:00")},
+			{Op: storage.BatchDelete, ID: uuid.NewString()},
+		})
+		require.ErrorIs(t, err, storage.ErrDateBusy)
+
+		var batchErr *storage.BatchError
+		require.ErrorAs(t, err, &batchErr)
+		require.Equal(t, 2, batchErr.Index)
+
+		require.Len(t, results, 4)
+		require.Equal(t, storage.BatchStatusRolledBack, results[0].Status)
+		require.Equal(t, storage.BatchStatusRolledBack, results[1].Status)
+		require.Equal(t, storage.BatchStatusFailed, results[2].Status)
+		require.Equal(t, storage.BatchStatusSkipped, results[3].Status)
+
+		events, err := s.ListEventDay(ctx, "2022-10-11")
+		require.NoError(t, err)
+		require.Equal(t, []storage.Event{existing}, events)
+	})
+
+	t.Run("rollback: no event", func(t *testing.T) {
+		s := newStorage(t)
+
+		_, err := s.ApplyBatch(ctx, []storage.BatchOperation{
+			{Op: storage.BatchCreate, Event: NewEvent("created", "2022-10-11 12:00:00")},
+			{Op: storage.BatchUpdate, ID: uuid.NewString(), Event: NewEvent("updated", "2022-10-11 13:00:00")},
+		})
+		require.ErrorIs(t, err, storage.ErrEventNotExist)
+
+		events, err := s.ListEventDay(ctx, "2022-10-11")
+		require.NoError(t, err)
+		require.Empty(t, events)
+	})
+}
+
+func newWebhook(eventTypes ...string) storage.Webhook {
+	return storage.Webhook{
+		URL:        "http://localhost/hook",
+		Secret:     "0123456789abcdef",
+		EventTypes: eventTypes,
+	}
+}
+
+func testWebhooks(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	t.Run("create, get, list and delete", func(t *testing.T) {
+		s := newStorage(t)
+
+		webhooks, err := s.ListWebhooks(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, webhooks)
+		require.Empty(t, webhooks)
+
+		first, err := s.CreateWebhook(ctx, newWebhook(storage.WebhookEventCreated, storage.WebhookEventDeleted))
+		require.NoError(t, err)
+		second, err := s.CreateWebhook(ctx, newWebhook(storage.WebhookEventReminder))
+		require.NoError(t, err)
+
+		w, err := s.GetWebhook(ctx, first)
+		require.NoError(t, err)
+		require.Equal(t, first, w.ID)
+		require.Equal(t, "http://localhost/hook", w.URL)
+		require.Equal(t, []string{storage.WebhookEventCreated, storage.WebhookEventDeleted}, w.EventTypes)
+		require.NotEmpty(t, w.CreatedAt)
+
+		webhooks, err = s.ListWebhooks(ctx)
+		require.NoError(t, err)
+		require.Len(t, webhooks, 2)
+		require.ElementsMatch(t, []string{first, second}, []string{webhooks[0].ID, webhooks[1].ID})
+
+		require.NoError(t, s.DeleteWebhook(ctx, first))
+		require.ErrorIs(t, s.DeleteWebhook(ctx, first), storage.ErrWebhookNotExist)
+
+		_, err = s.GetWebhook(ctx, first)
+		require.ErrorIs(t, err, storage.ErrWebhookNotExist)
+	})
+
+	t.Run("deliveries", func(t *testing.T) {
+		s := newStorage(t)
+
+		id, err := s.CreateWebhook(ctx, newWebhook(storage.WebhookEventCreated))
+		require.NoError(t, err)
+
+		deliveries, err := s.ListDeliveries(ctx, id)
+		require.NoError(t, err)
+		require.NotNil(t, deliveries)
+		require.Empty(t, deliveries)
+
+		d := storage.WebhookDelivery{
+			WebhookID: id,
+			Key:       "created:1",
+			EventType: storage.WebhookEventCreated,
+			EventID:   "1",
+			Payload:   "{}",
+		}
+
+		deliveryID, err := s.CreateDelivery(ctx, d)
+		require.NoError(t, err)
+
+		_, err = s.CreateDelivery(ctx, d)
+		require.ErrorIs(t, err, storage.ErrDeliveryDuplicate)
+
+		// Доставки без ключа не считаются повторами.
+		d.Key = ""
+		_, err = s.CreateDelivery(ctx, d)
+		require.NoError(t, err)
+		_, err = s.CreateDelivery(ctx, d)
+		require.NoError(t, err)
+
+		d.WebhookID = uuid.NewString()
+		_, err = s.CreateDelivery(ctx, d)
+		require.ErrorIs(t, err, storage.ErrWebhookNotExist)
+
+		_, err = s.ListDeliveries(ctx, uuid.NewString())
+		require.ErrorIs(t, err, storage.ErrWebhookNotExist)
+
+		deliveries, err = s.ListDeliveries(ctx, id)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 3)
+
+		for _, d := range deliveries {
+			require.Equal(t, storage.DeliveryPending, d.Status)
+			require.Zero(t, d.Attempts)
+			if d.ID == deliveryID {
+				require.Equal(t, "created:1", d.Key)
+			}
+		}
+	})
+
+	t.Run("claim and update", func(t *testing.T) {
+		s := newStorage(t)
+
+		id, err := s.CreateWebhook(ctx, newWebhook(storage.WebhookEventCreated))
+		require.NoError(t, err)
+
+		now := time.Now()
+
+		dueID, err := s.CreateDelivery(ctx, storage.WebhookDelivery{
+			WebhookID: id, EventType: storage.WebhookEventCreated, Payload: "{}",
+			NextAttemptAt: now.Add(-time.Minute).Format(time.DateTime),
+		})
+		require.NoError(t, err)
+		_, err = s.CreateDelivery(ctx, storage.WebhookDelivery{
+			WebhookID: id, EventType: storage.WebhookEventCreated, Payload: "{}",
+			NextAttemptAt: now.Add(time.Hour).Format(time.DateTime),
+		})
+		require.NoError(t, err)
+
+		leaseUntil := now.Add(time.Minute).Format(time.DateTime)
+
+		claimed, err := s.ClaimDeliveries(ctx, now.Format(time.DateTime), leaseUntil, 10, 3)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		require.Equal(t, dueID, claimed[0].ID)
+		require.Equal(t, storage.DeliverySending, claimed[0].Status)
+		require.Equal(t, 1, claimed[0].Attempts)
+		require.Equal(t, leaseUntil, claimed[0].NextAttemptAt)
+
+		// Захваченная доставка не выдается повторно до окончания аренды.
+		claimed, err = s.ClaimDeliveries(ctx, now.Format(time.DateTime), leaseUntil, 10, 3)
+		require.NoError(t, err)
+		require.NotNil(t, claimed)
+		require.Empty(t, claimed)
+
+		require.NoError(t, s.UpdateDelivery(ctx, storage.WebhookDelivery{
+			ID: dueID, Attempts: 1, Status: storage.DeliveryDelivered, NextAttemptAt: leaseUntil, ResponseStatus: 200,
+		}))
+
+		// Итог уже завершенной попытки устарел и не меняет доставку.
+		err = s.UpdateDelivery(ctx, storage.WebhookDelivery{
+			ID: dueID, Attempts: 1, Status: storage.DeliveryPending, NextAttemptAt: leaseUntil,
+		})
+		require.ErrorIs(t, err, storage.ErrDeliveryNotExist)
+
+		err = s.UpdateDelivery(ctx, storage.WebhookDelivery{
+			ID: uuid.NewString(), Status: storage.DeliveryFailed, NextAttemptAt: leaseUntil,
+		})
+		require.ErrorIs(t, err, storage.ErrDeliveryNotExist)
+
+		deliveries, err := s.ListDeliveries(ctx, id)
+		require.NoError(t, err)
+
+		for _, d := range deliveries {
+			if d.ID == dueID {
+				require.Equal(t, storage.DeliveryDelivered, d.Status)
+				require.Equal(t, 200, d.ResponseStatus)
+				require.Equal(t, 1, d.Attempts)
+			}
+		}
+	})
+
+	t.Run("claims without result are capped", func(t *testing.T) {
+		s := newStorage(t)
+
+		id, err := s.CreateWebhook(ctx, newWebhook(storage.WebhookEventCreated))
+		require.NoError(t, err)
+		require.NoError(t, webhook.Enqueue(ctx, s, storage.WebhookEventCreated, NewEvent("lost", "2024-03-01 10:00:00"), ""))
+
+		// Нулевые аренда и задержка: без результата доставка выбирается на каждом вызове Dispatch.
+		p := &publisher{}
+		d := webhook.NewDispatcher(dispatchStorage{s}, p, nopLogger{}, webhook.DispatchConfig{MaxAttempts: 3, BatchSize: 10})
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, d.Dispatch(ctx))
+		}
+
+		require.Equal(t, 3, p.published)
+
+		deliveries, err := s.ListDeliveries(ctx, id)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		require.Equal(t, storage.DeliveryFailed, deliveries[0].Status)
+		require.Equal(t, 4, deliveries[0].Attempts)
+	})
+
+	t.Run("delete removes deliveries", func(t *testing.T) {
+		s := newStorage(t)
+
+		id, err := s.CreateWebhook(ctx, newWebhook(storage.WebhookEventCreated))
+		require.NoError(t, err)
+		_, err = s.CreateDelivery(ctx, storage.WebhookDelivery{
+			WebhookID: id, Key: "created:1", EventType: storage.WebhookEventCreated, Payload: "{}",
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, s.DeleteWebhook(ctx, id))
+
+		now := time.Now().Add(time.Minute)
+		claimed, err := s.ClaimDeliveries(ctx, now.Format(time.DateTime), now.Format(time.DateTime), 10, 3)
+		require.NoError(t, err)
+		require.Empty(t, claimed)
+	})
+}
+
+// dispatchStorage дает Dispatch хранилище без напоминаний: они не нужны для проверки доставок.
+type dispatchStorage struct {
+	app.Storager
+}
+
+func (dispatchStorage) ListEventWithNotification(context.Context) ([]storage.Event, error) {
+	return nil, nil
+}
+
+type publisher struct {
+	published int
+}
+
+func (p *publisher) Publish(context.Context, string, interface{}) error {
+	p.published++
+	return nil
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string)  {}
+func (nopLogger) Error(string) {}
+
+func testScheduler(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	s := newStorage(t)
+
+	scheduler, ok := s.(app.StorageScheduler)
+	if !ok {
+		t.Skip("storage does not implement app.StorageScheduler")
+	}
+
+	past := NewEvent("past", "2022-10-11 12:00:00")
+	past.DatePost = time.Now().AddDate(0, 0, -2).Format(time.DateTime)
+	past = create(t, s, past)
+
+	future := NewEvent("future", "2022-10-12 12:00:00")
+	future.DatePost = time.Now().AddDate(0, 0, 2).Format(time.DateTime)
+	future = create(t, s, future)
+
+	silent := create(t, s, NewEvent("silent", "2022-10-13 12:00:00"))
+
+	events, err := scheduler.ListEventWithNotification(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []storage.Event{past}, events)
+
+	file := storage.Attachment{
+		EventID: past.ID, Name: "slides.pdf", ContentType: "application/pdf", Size: 1024, BlobKey: uuid.NewString(),
+	}
+	file.ID, err = s.CreateAttachment(ctx, file)
+	require.NoError(t, err)
+	_, err = s.CreateAttachment(ctx, storage.Attachment{EventID: future.ID, Name: "notes", URL: "https://example.com"})
+	require.NoError(t, err)
+
+	attachments, err := scheduler.DeleteEventsBeforeDate(ctx, "2022-10-12 12:00:00")
+	require.NoError(t, err)
+	require.Len(t, attachments, 1, "attachments of deleted events")
+	require.Equal(t, file.ID, attachments[0].ID)
+	require.Equal(t, file.BlobKey, attachments[0].BlobKey)
+
+	_, err = s.GetAttachment(ctx, file.ID)
+	require.ErrorIs(t, err, storage.ErrAttachmentNotExist)
+
+	_, err = s.GetEvent(ctx, past.ID)
+	require.ErrorIs(t, err, storage.ErrEventNotExist)
+
+	events, err = s.ListEventRange(ctx, "2022-10-01", "2022-10-31")
+	require.NoError(t, err)
+	require.Equal(t, []storage.Event{future, silent}, events)
+
+	events, err = scheduler.ListEventWithNotification(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, events)
+	require.Empty(t, events)
+}
+
+func testLeases(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	leases, ok := newStorage(t).(app.StorageLeader)
+	if !ok {
+		t.Skip("storage does not implement app.StorageLeader")
+	}
+
+	acquired, err := leases.AcquireLease(ctx, "test", "a", time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	acquired, err = leases.AcquireLease(ctx, "test", "b", time.Minute)
+	require.NoError(t, err)
+	require.False(t, acquired, "lease is held by another holder")
+
+	acquired, err = leases.AcquireLease(ctx, "test", "a", 50*time.Millisecond)
+	require.NoError(t, err)
+	require.True(t, acquired, "holder renews its lease")
+
+	acquired, err = leases.AcquireLease(ctx, "other", "b", time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired, "leases are independent")
+
+	time.Sleep(100 * time.Millisecond)
+
+	acquired, err = leases.AcquireLease(ctx, "test", "b", time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired, "expired lease is taken over")
+
+	require.NoError(t, leases.ReleaseLease(ctx, "test", "a"), "release by former holder is ignored")
+
+	acquired, err = leases.AcquireLease(ctx, "test", "a", time.Minute)
+	require.NoError(t, err)
+	require.False(t, acquired)
+
+	require.NoError(t, leases.ReleaseLease(ctx, "test", "b"))
+
+	acquired, err = leases.AcquireLease(ctx, "test", "a", time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired, "released lease is free")
+}
+
+func testPreferences(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+	s := newStorage(t)
+	userID := uuid.NewString()
+
+	_, err := s.GetPreferences(ctx, userID)
+	require.ErrorIs(t, err, storage.ErrPreferencesNotExist)
+
+	p := storage.Preferences{UserID: userID, DailyDigest: true, DigestTime: "07:30"}
+	require.NoError(t, s.SavePreferences(ctx, p))
+
+	got, err := s.GetPreferences(ctx, userID)
+	require.NoError(t, err)
+	require.Equal(t, p, got)
+
+	p = storage.Preferences{
+		UserID:       userID,
+		WeeklyDigest: true,
+		DigestTime:   "21:00",
+		Channel:      storage.ChannelEmail,
+		Address:      "user@example.com",
+	}
+	require.NoError(t, s.SavePreferences(ctx, p), "save replaces preferences")
+
+	got, err = s.GetPreferences(ctx, userID)
+	require.NoError(t, err)
+	require.Equal(t, p, got)
+}
+
+func testReminders(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+	s := newStorage(t)
+	e := create(t, s, NewEvent("reminder", "2024-01-10 10:00:00"))
+	userID := uuid.NewString()
+
+	_, err := s.GetReminderState(ctx, e.ID, userID)
+	require.ErrorIs(t, err, storage.ErrReminderStateNotExist)
+
+	r := storage.ReminderState{EventID: e.ID, UserID: userID, SnoozedUntil: "2024-01-10 09:30:00"}
+	require.NoError(t, s.SaveReminderState(ctx, r))
+
+	got, err := s.GetReminderState(ctx, e.ID, userID)
+	require.NoError(t, err)
+	require.Equal(t, r, got)
+
+	r = storage.ReminderState{EventID: e.ID, UserID: userID, Dismissed: true}
+	require.NoError(t, s.SaveReminderState(ctx, r), "save replaces state")
+
+	got, err = s.GetReminderState(ctx, e.ID, userID)
+	require.NoError(t, err)
+	require.Equal(t, r, got)
+
+	_, err = s.GetReminderState(ctx, e.ID, uuid.NewString())
+	require.ErrorIs(t, err, storage.ErrReminderStateNotExist, "state is per user")
+
+	require.NoError(t, s.MarkReminderNotified(ctx, e.ID, userID, "reminder:1"))
+	require.NoError(t, s.SaveReminderState(ctx, storage.ReminderState{EventID: e.ID, UserID: userID}))
+
+	got, err = s.GetReminderState(ctx, e.ID, userID)
+	require.NoError(t, err)
+	require.Equal(t, storage.ReminderState{EventID: e.ID, UserID: userID, NotifiedKey: "reminder:1"}, got,
+		"save keeps notified key")
+
+	other := uuid.NewString()
+	require.NoError(t, s.MarkReminderNotified(ctx, e.ID, other, "reminder:2"))
+
+	got, err = s.GetReminderState(ctx, e.ID, other)
+	require.NoError(t, err)
+	require.Equal(t, storage.ReminderState{EventID: e.ID, UserID: other, NotifiedKey: "reminder:2"}, got)
+
+	err = s.MarkReminderNotified(ctx, uuid.NewString(), userID, "reminder:3")
+	require.ErrorIs(t, err, storage.ErrEventNotExist)
+
+	err = s.SaveReminderState(ctx, storage.ReminderState{EventID: uuid.NewString(), UserID: userID})
+	require.ErrorIs(t, err, storage.ErrEventNotExist)
+
+	require.NoError(t, s.DeleteEvent(ctx, e.ID))
+
+	_, err = s.GetReminderState(ctx, e.ID, userID)
+	require.ErrorIs(t, err, storage.ErrReminderStateNotExist, "state is removed with event")
+}
+
+func testSpans(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	s := newStorage(t)
+
+	conference := NewEvent("conference", "2024-03-04 09:00:00")
+	conference.DateEnd = "2024-03-06 18:00:00"
+
+	vacation := NewEvent("vacation", "2024-03-07 15:00:00")
+	vacation.DateEnd = "2024-03-11 10:00:00"
+	vacation.AllDay = true
+
+	holiday := NewEvent("holiday", "2024-03-08 00:00:00")
+	holiday.AllDay = true
+
+	meeting := NewEvent("meeting", "2024-03-08 00:00:00")
+
+	for _, e := range []storage.Event{conference, vacation, holiday, meeting} {
+		create(t, s, e)
+	}
+
+	events, err := s.ListEventDay(ctx, "2024-03-08")
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	require.ElementsMatch(t, []string{"vacation", "holiday", "meeting"}, titles(events))
+	require.Equal(t, "vacation", events[0].Title)
+
+	got := events[0]
+	require.True(t, got.AllDay)
+	require.Equal(t, "2024-03-07 00:00:00", got.DateStart, "all-day start is aligned to midnight")
+	require.Equal(t, "2024-03-12 00:00:00", got.DateEnd, "all-day end is aligned to the next midnight")
+
+	cases := []struct {
+		name   string
+		list   func() ([]storage.Event, error)
+		titles []string
+	}{
+		{
+			name:   "middle day of multi-day event",
+			list:   func() ([]storage.Event, error) { return s.ListEventDay(ctx, "2024-03-05") },
+			titles: []string{"conference"},
+		},
+		{
+			name:   "last day of all-day event",
+			list:   func() ([]storage.Event, error) { return s.ListEventDay(ctx, "2024-03-11") },
+			titles: []string{"vacation"},
+		},
+		{
+			name:   "day after all-day event",
+			list:   func() ([]storage.Event, error) { return s.ListEventDay(ctx, "2024-03-12") },
+			titles: []string{},
+		},
+		{
+			name:   "week ended inside event",
+			list:   func() ([]storage.Event, error) { return s.ListEventWeek(ctx, "2024-03-01") },
+			titles: []string{"conference", "vacation"},
+		},
+		{
+			name:   "month started inside event",
+			list:   func() ([]storage.Event, error) { return s.ListEventMonth(ctx, "2024-03-10") },
+			titles: []string{"vacation"},
+		},
+		{
+			name:   "range",
+			list:   func() ([]storage.Event, error) { return s.ListEventRange(ctx, "2024-03-05", "2024-03-07") },
+			titles: []string{"conference", "vacation"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			events, err := tc.list()
+			require.NoError(t, err)
+			require.NotNil(t, events)
+			require.Equal(t, tc.titles, titles(events))
+		})
+	}
+
+	t.Run("all-day events do not take date start", func(t *testing.T) {
+		second := NewEvent("second holiday", "2024-03-08 12:00:00")
+		second.AllDay = true
+		create(t, s, second)
+
+		_, err := s.CreateEvent(ctx, NewEvent("busy", "2024-03-08 00:00:00"))
+		require.ErrorIs(t, err, storage.ErrDateBusy)
+	})
+}
+
+func testTags(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	tagged := func(title string, dateStart string, tags ...string) storage.Event {
+		e := NewEvent(title, dateStart)
+		e.Category = "work"
+		e.Color = "#ff8800"
+		e.Tags = tags
+		return e
+	}
+
+	t.Run("create, update and filter", func(t *testing.T) {
+		s := newStorage(t)
+
+		standup := create(t, s, tagged("standup", "2024-02-05 10:00:00", "Release", " oncall", "release"))
+		oneOnOne := create(t, s, tagged("1:1", "2024-02-06 10:00:00", "1:1"))
+		deploy := create(t, s, tagged("deploy", "2024-02-07 10:00:00", "release"))
+		create(t, s, NewEvent("untagged", "2024-02-07 12:00:00"))
+
+		standup.Tags = []string{"oncall", "release"}
+
+		got, err := s.GetEvent(ctx, standup.ID)
+		require.NoError(t, err)
+		require.Equal(t, standup, got, "tags are normalized")
+
+		events, err := s.ListEventTagged(ctx, []string{"release"}, "2024-02-01", "2024-02-29")
+		require.NoError(t, err)
+		require.Equal(t, []string{"standup", "deploy"}, titles(events))
+
+		events, err = s.ListEventTagged(ctx, []string{"RELEASE", "oncall"}, "2024-02-01", "2024-02-29")
+		require.NoError(t, err)
+		require.Equal(t, []storage.Event{standup}, events, "all tags must match")
+
+		events, err = s.ListEventTagged(ctx, []string{"release"}, "2024-02-06", "2024-02-07")
+		require.NoError(t, err)
+		require.Equal(t, []string{"deploy"}, titles(events))
+
+		events, err = s.ListEventTagged(ctx, []string{"unknown"}, "2024-02-01", "2024-02-29")
+		require.NoError(t, err)
+		require.Empty(t, events)
+
+		deploy.Tags = []string{"oncall"}
+		deploy.Category = ""
+		deploy.Color = ""
+		require.NoError(t, s.UpdateEvent(ctx, deploy.ID, deploy))
+
+		got, err = s.GetEvent(ctx, deploy.ID)
+		require.NoError(t, err)
+		require.Equal(t, deploy, got)
+
+		require.NoError(t, s.DeleteEvent(ctx, oneOnOne.ID))
+
+		tags, err := s.ListTags(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []storage.Tag{{Name: "oncall", Events: 2}, {Name: "release", Events: 1}}, tags)
+	})
+
+	t.Run("rename and delete", func(t *testing.T) {
+		s := newStorage(t)
+
+		first := create(t, s, tagged("first", "2024-02-05 10:00:00", "release", "oncall"))
+		second := create(t, s, tagged("second", "2024-02-06 10:00:00", "ops"))
+
+		require.NoError(t, s.RenameTag(ctx, "ops", "OnCall"))
+		require.NoError(t, s.RenameTag(ctx, "release", "ship"))
+
+		tags, err := s.ListTags(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []storage.Tag{{Name: "oncall", Events: 2}, {Name: "ship", Events: 1}}, tags)
+
+		got, err := s.GetEvent(ctx, first.ID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"oncall", "ship"}, got.Tags)
+
+		require.NoError(t, s.DeleteTag(ctx, "oncall"))
+
+		got, err = s.GetEvent(ctx, second.ID)
+		require.NoError(t, err)
+		require.Empty(t, got.Tags)
+
+		require.ErrorIs(t, s.DeleteTag(ctx, "oncall"), storage.ErrTagNotExist)
+		require.ErrorIs(t, s.RenameTag(ctx, "ops", "other"), storage.ErrTagNotExist)
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		s := newStorage(t)
+
+		existing := create(t, s, tagged("existing", "2024-02-05 10:00:00", "release"))
+
+		created := tagged("created", "2024-02-05 12:00:00", "Oncall")
+		results, err := s.ApplyBatch(ctx, []storage.BatchOperation{
+			{Op: storage.BatchCreate, Event: created},
+			{Op: storage.BatchDelete, ID: existing.ID},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"oncall"}, results[0].Event.Tags)
+		require.Equal(t, existing, results[1].Event, "deleted event keeps its tags")
+
+		tags, err := s.ListTags(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []storage.Tag{{Name: "oncall", Events: 1}}, tags)
+	})
+}
+
+func testAttachments(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+	s := newStorage(t)
+	e := create(t, s, NewEvent("planning", "2024-04-02 10:00:00"))
+	other := create(t, s, NewEvent("review", "2024-04-20 10:00:00"))
+
+	link := storage.Attachment{EventID: e.ID, Name: "agenda", URL: "https://example.com/agenda"}
+	linkID, err := s.CreateAttachment(ctx, link)
+	require.NoError(t, err)
+
+	file := storage.Attachment{
+		EventID: e.ID, Name: "slides.pdf", ContentType: "application/pdf", Size: 1024, BlobKey: uuid.NewString(),
+	}
+	fileID, err := s.CreateAttachment(ctx, file)
+	require.NoError(t, err)
+
+	_, err = s.CreateAttachment(ctx, storage.Attachment{EventID: other.ID, Name: "notes", URL: "https://example.com"})
+	require.NoError(t, err)
+
+	got, err := s.GetAttachment(ctx, fileID)
+	require.NoError(t, err)
+	require.NotEmpty(t, got.CreatedAt)
+	file.ID, file.CreatedAt = fileID, got.CreatedAt
+	require.Equal(t, file, got)
+
+	attachments, err := s.ListAttachments(ctx, e.ID)
+	require.NoError(t, err)
+	require.Len(t, attachments, 2)
+	require.ElementsMatch(t, []string{linkID, fileID}, []string{attachments[0].ID, attachments[1].ID})
+
+	lost := storage.Attachment{EventID: uuid.NewString(), Name: "lost", URL: "https://example.com"}
+	_, err = s.CreateAttachment(ctx, lost)
+	require.ErrorIs(t, err, storage.ErrEventNotExist)
+
+	require.NoError(t, s.DeleteAttachment(ctx, linkID))
+	require.ErrorIs(t, s.DeleteAttachment(ctx, linkID), storage.ErrAttachmentNotExist)
+
+	_, err = s.GetAttachment(ctx, linkID)
+	require.ErrorIs(t, err, storage.ErrAttachmentNotExist)
+
+	require.NoError(t, s.DeleteEvent(ctx, e.ID))
+
+	_, err = s.GetAttachment(ctx, fileID)
+	require.ErrorIs(t, err, storage.ErrAttachmentNotExist, "attachments are removed with event")
+
+	attachments, err = s.ListAttachments(ctx, e.ID)
+	require.NoError(t, err)
+	require.NotNil(t, attachments)
+	require.Empty(t, attachments)
+}
+
+func testResources(t *testing.T, newStorage Factory) {
+	ctx := context.Background()
+
+	booked := func(title string, dateStart string, resources ...string) storage.Event {
+		e := NewEvent(title, dateStart)
+		e.Resources = resources
+		return e
+	}
+
+	t.Run("create, update and delete", func(t *testing.T) {
+		s := newStorage(t)
+
+		room := storage.Resource{Name: "Orion", Kind: storage.ResourceRoom, Capacity: 8}
+		id, err := s.CreateResource(ctx, room)
+		require.NoError(t, err)
+		room.ID = id
+
+		projector := storage.Resource{Name: "Epson", Kind: storage.ResourceEquipment}
+		projector.ID, err = s.CreateResource(ctx, projector)
+		require.NoError(t, err)
+
+		got, err := s.GetResource(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, room, got)
+
+		room.Capacity = 10
+		require.NoError(t, s.UpdateResource(ctx, id, room))
+
+		resources, err := s.ListResources(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []storage.Resource{projector, room}, resources)
+
+		missing := uuid.NewString()
+		require.ErrorIs(t, s.UpdateResource(ctx, missing, room), storage.ErrResourceNotExist)
+		require.ErrorIs(t, s.DeleteResource(ctx, missing), storage.ErrResourceNotExist)
+
+		_, err = s.GetResource(ctx, missing)
+		require.ErrorIs(t, err, storage.ErrResourceNotExist)
+	})
+
+	t.Run("bookings", func(t *testing.T) {
+		s := newStorage(t)
+
+		room, err := s.CreateResource(ctx, storage.Resource{Name: "Orion", Kind: storage.ResourceRoom, Capacity: 8})
+		require.NoError(t, err)
+		projector, err := s.CreateResource(ctx, storage.Resource{Name: "Epson", Kind: storage.ResourceEquipment})
+		require.NoError(t, err)
+
+		planning := create(t, s, booked("planning", "2024-05-06 10:00:00", room, projector, room))
+		planning.Resources = storage.NormalizeResources([]string{room, projector})
+
+		got, err := s.GetEvent(ctx, planning.ID)
+		require.NoError(t, err)
+		require.Equal(t, planning, got, "resources are normalized")
+
+		_, err = s.CreateEvent(ctx, booked("review", "2024-05-06 10:30:00", room))
+		require.ErrorIs(t, err, storage.ErrResourceBusy)
+		require.ErrorIs(t, err, storage.ErrDateBusy, "resource busy extends date busy")
+
+		_, err = s.CreateEvent(ctx, booked("lost", "2024-05-06 15:00:00", uuid.NewString()))
+		require.ErrorIs(t, err, storage.ErrResourceNotExist)
+
+		events, err := s.ListEventDay(ctx, "2024-05-06")
+		require.NoError(t, err)
+		require.Equal(t, []string{"planning"}, titles(events), "failed events are not stored")
+
+		review := create(t, s, booked("review", "2024-05-06 11:00:00", room))
+		free := create(t, s, booked("free", "2024-05-06 10:30:00"))
+
+		moved := review
+		moved.DateStart, moved.DateEnd = "2024-05-06 10:45:00", "2024-05-06 11:45:00"
+		require.ErrorIs(t, s.UpdateEvent(ctx, review.ID, moved), storage.ErrResourceBusy)
+
+		planning.DateEnd = "2024-05-06 10:45:00"
+		require.NoError(t, s.UpdateEvent(ctx, planning.ID, planning), "event does not conflict with itself")
+
+		bookings, err := s.ListBookings(ctx, "2024-05-06 10:40:00", "2024-05-06 11:30:00")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []storage.Booking{
+			planning.Booking(room), planning.Booking(projector), review.Booking(room),
+		}, bookings)
+
+		bookings, err = s.ListBookings(ctx, "2024-05-06 10:45:00", "2024-05-06 11:00:00")
+		require.NoError(t, err)
+		require.Empty(t, bookings, "bookings back to back do not overlap")
+
+		require.NoError(t, s.DeleteResource(ctx, room))
+
+		got, err = s.GetEvent(ctx, review.ID)
+		require.NoError(t, err)
+		require.Empty(t, got.Resources, "resource is removed from events")
+
+		got, err = s.GetEvent(ctx, planning.ID)
+		require.NoError(t, err)
+		require.Equal(t, []string{projector}, got.Resources)
+
+		free.Resources = []string{projector}
+		require.ErrorIs(t, s.UpdateEvent(ctx, free.ID, free), storage.ErrResourceBusy)
+
+		results, err := s.ApplyBatch(ctx, []storage.BatchOperation{
+			{Op: storage.BatchDelete, ID: planning.ID},
+			{Op: storage.BatchUpdate, ID: free.ID, Event: free},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{projector}, results[0].Event.Resources, "deleted event keeps its resources")
+		require.Equal(t, []string{projector}, results[1].Event.Resources)
+	})
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+var ErrTagNotExist = errors.New("tag not found in storage")
+
+// TagSeparator разделяет метки при хранении в одной строке, поэтому в метках запрещен.
+const TagSeparator = ","
+
+// Tag - метка и число событий с ней.
+type Tag struct {
+	Name   string `json:"name"`
+	Events int    `json:"events"`
+}
+
+type TagRename struct {
+	Name string `json:"name" validate:"required,max=32,excludesall=0x2C/"`
+}
+
+type ListEventTagValidation struct {
+	Tags []string `json:"tags" validate:"max=20,dive,required,max=32,excludesall=0x2C/"`
+}
+
+// NormalizeTags приводит метки к нижнему регистру, убирает пустые и повторы и сортирует.
+func NormalizeTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]struct{}, len(tags))
+	result := make([]string, 0, len(tags))
+
+	for _, t := range tags {
+		t = NormalizeTag(t)
+		if _, ok := seen[t]; ok || t == "" {
+			continue
+		}
+		seen[t] = struct{}{}
+		result = append(result, t)
+	}
+
+	if len(result) == 0 {
+		return nil
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// SplitTags разбирает метки, сохраненные через TagSeparator.
+func SplitTags(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, TagSeparator)
+}
+
+// HasTags сообщает, есть ли у события все метки tags.
+func (e *Event) HasTags(tags []string) bool {
+	for _, t := range tags {
+		i := sort.SearchStrings(e.Tags, t)
+		if i == len(e.Tags) || e.Tags[i] != t {
+			return false
+		}
+	}
+
+	return true
+}
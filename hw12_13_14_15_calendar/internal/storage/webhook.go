@@ -0,0 +1,60 @@
+package storage
+
+import "errors"
+
+var (
+	ErrWebhookNotExist   = errors.New("webhook not found in storage")
+	ErrDeliveryNotExist  = errors.New("webhook delivery not found in storage")
+	ErrDeliveryDuplicate = errors.New("webhook delivery with this key already exists")
+)
+
+const (
+	WebhookEventCreated  = "created"
+	WebhookEventUpdated  = "updated"
+	WebhookEventDeleted  = "deleted"
+	WebhookEventReminder = "reminder"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySending   = "sending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook - подписка внешней системы на изменения событий календаря.
+type Webhook struct {
+	ID         string   `json:"id"`
+	URL        string   `json:"url" validate:"required,url"`
+	Secret     string   `json:"secret,omitempty" validate:"required,min=16"`
+	EventTypes []string `json:"eventTypes" validate:"required,min=1,dive,oneof=created updated deleted reminder"`
+	CreatedAt  string   `json:"createdAt"`
+}
+
+func (w *Webhook) Subscribed(eventType string) bool {
+	for _, t := range w.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WebhookDelivery - попытка доставки одного изменения на один webhook.
+// Key не дает поставить в очередь одно и то же уведомление дважды.
+type WebhookDelivery struct {
+	ID             string `json:"id"`
+	WebhookID      string `json:"webhookId"`
+	Key            string `json:"-"`
+	EventType      string `json:"eventType"`
+	EventID        string `json:"eventId"`
+	Payload        string `json:"payload"`
+	Status         string `json:"status"`
+	Attempts       int    `json:"attempts"`
+	NextAttemptAt  string `json:"nextAttemptAt"`
+	LastError      string `json:"lastError"`
+	ResponseStatus int    `json:"responseStatus"`
+	CreatedAt      string `json:"createdAt"`
+	UpdatedAt      string `json:"updatedAt"`
+}
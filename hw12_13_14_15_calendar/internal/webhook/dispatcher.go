@@ -0,0 +1,222 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+type DispatchStorage interface {
+	EnqueueStorage
+	GetWebhook(ctx context.Context, id string) (storage.Webhook, error)
+	ClaimDeliveries(ctx context.Context, now string, leaseUntil string, limit int, maxAttempts int) (
+		[]storage.WebhookDelivery, error,
+	)
+	UpdateDelivery(ctx context.Context, d storage.WebhookDelivery) error
+	ListEventWithNotification(ctx context.Context) ([]storage.Event, error)
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, queueName string, v interface{}) error
+}
+
+type DispatchConfig struct {
+	Queue       string
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	Lease       time.Duration
+	BatchSize   int
+}
+
+// Dispatcher работает в scheduler: ставит в очередь напоминания, передает
+// подошедшие доставки в брокер и по результатам отправки назначает повторы.
+type Dispatcher struct {
+	storage   DispatchStorage
+	publisher Publisher
+	logger    Logger
+	config    DispatchConfig
+}
+
+func NewDispatcher(s DispatchStorage, publisher Publisher, logger Logger, config DispatchConfig) *Dispatcher {
+	return &Dispatcher{
+		storage:   s,
+		publisher: publisher,
+		logger:    logger,
+		config:    config,
+	}
+}
+
+func (d *Dispatcher) Run(ctx context.Context, pollTime time.Duration) {
+	ticker := time.NewTicker(pollTime)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := d.EnqueueReminders(ctx)
+			if err != nil {
+				d.logger.Error("failed to enqueue webhook reminders: " + err.Error())
+			}
+
+			err = d.Dispatch(ctx)
+			if err != nil {
+				d.logger.Error("failed to dispatch webhooks: " + err.Error())
+			}
+		}
+	}
+}
+
+// EnqueueReminders ставит в очередь напоминания о событиях, по которым пора уведомлять.
+// Ключ доставки не дает отправить одно напоминание повторно на следующих опросах.
+func (d *Dispatcher) EnqueueReminders(ctx context.Context) error {
+	events, err := d.storage.ListEventWithNotification(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range events {
+		key := fmt.Sprintf("%s:%s:%s", storage.WebhookEventReminder, e.ID, e.DatePost)
+
+		err = Enqueue(ctx, d.storage, storage.WebhookEventReminder, e, key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) Dispatch(ctx context.Context) error {
+	now := time.Now()
+
+	deliveries, err := d.storage.ClaimDeliveries(ctx, now.Format(time.DateTime),
+		now.Add(d.config.Lease).Format(time.DateTime), d.config.BatchSize, d.config.MaxAttempts)
+	if err != nil {
+		return err
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	d.logger.Info(fmt.Sprintf("dispatching webhooks: %d", len(deliveries)))
+
+	webhooks := make(map[string]storage.Webhook)
+
+	for _, delivery := range deliveries {
+		// Аренда последней попытки истекла, а результат так и не пришел.
+		if delivery.Attempts > d.config.MaxAttempts {
+			delivery.Status = storage.DeliveryFailed
+			if delivery.LastError == "" {
+				delivery.LastError = "no result of the last attempt"
+			}
+			d.updateDelivery(ctx, delivery)
+			continue
+		}
+
+		w, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			w, err = d.storage.GetWebhook(ctx, delivery.WebhookID)
+			if errors.Is(err, storage.ErrWebhookNotExist) {
+				delivery.Status = storage.DeliveryFailed
+				delivery.LastError = err.Error()
+				d.updateDelivery(ctx, delivery)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			webhooks[w.ID] = w
+		}
+
+		// Если результат не придет, повтор откладывается так же, как после неудачной попытки.
+		delivery.NextAttemptAt = now.Add(d.config.Lease + d.backoff(delivery.Attempts)).Format(time.DateTime)
+
+		err = d.storage.UpdateDelivery(ctx, delivery)
+		if err != nil {
+			return err
+		}
+
+		err = d.publisher.Publish(ctx, d.config.Queue, Message{
+			DeliveryID: delivery.ID,
+			URL:        w.URL,
+			Secret:     w.Secret,
+			EventType:  delivery.EventType,
+			Payload:    delivery.Payload,
+			Attempt:    delivery.Attempts,
+		})
+		if err != nil {
+			// Доставка будет выбрана повторно после истечения аренды.
+			return err
+		}
+	}
+
+	return nil
+}
+
+// HandleResult фиксирует результат отправки и при ошибке назначает повтор с экспоненциальной задержкой.
+func (d *Dispatcher) HandleResult(ctx context.Context, r Result) error {
+	delivery := storage.WebhookDelivery{
+		ID:             r.DeliveryID,
+		Attempts:       r.Attempt,
+		ResponseStatus: r.StatusCode,
+		LastError:      r.Error,
+		NextAttemptAt:  time.Now().Format(time.DateTime),
+	}
+
+	switch {
+	case r.Success():
+		delivery.Status = storage.DeliveryDelivered
+	case r.Attempt >= d.config.MaxAttempts:
+		delivery.Status = storage.DeliveryFailed
+	default:
+		delivery.Status = storage.DeliveryPending
+		delivery.NextAttemptAt = time.Now().Add(d.backoff(r.Attempt)).Format(time.DateTime)
+	}
+
+	if delivery.LastError == "" && !r.Success() {
+		delivery.LastError = fmt.Sprintf("unexpected response status %d", r.StatusCode)
+	}
+
+	err := d.storage.UpdateDelivery(ctx, delivery)
+	if errors.Is(err, storage.ErrDeliveryNotExist) {
+		// Результат опоздавшей попытки не должен перезаписать итог более новой.
+		d.logger.Info(fmt.Sprintf("ignore stale result of webhook delivery %s attempt %d", r.DeliveryID, r.Attempt))
+		return nil
+	}
+
+	return err
+}
+
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	return Backoff(attempt, d.config.Backoff, d.config.MaxBackoff)
+}
+
+func (d *Dispatcher) updateDelivery(ctx context.Context, delivery storage.WebhookDelivery) {
+	err := d.storage.UpdateDelivery(ctx, delivery)
+	if err != nil {
+		d.logger.Error(fmt.Sprintf("failed to update webhook delivery %s: %s", delivery.ID, err))
+	}
+}
+
+// Backoff возвращает задержку перед следующей попыткой: base * 2^(attempt-1), но не больше maxBackoff.
+func Backoff(attempt int, base time.Duration, maxBackoff time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+
+	if delay > maxBackoff {
+		return maxBackoff
+	}
+
+	return delay
+}
@@ -0,0 +1,139 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/feed"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+type EnqueueStorage interface {
+	ListWebhooks(ctx context.Context) ([]storage.Webhook, error)
+	CreateDelivery(ctx context.Context, d storage.WebhookDelivery) (string, error)
+}
+
+// Enqueue ставит в очередь доставку события на все подписанные на eventType webhooks.
+// Непустой key защищает от повторной постановки того же уведомления.
+func Enqueue(ctx context.Context, s EnqueueStorage, eventType string, event storage.Event, key string) error {
+	webhooks, err := s.ListWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+
+	for _, w := range webhooks {
+		if !w.Subscribed(eventType) {
+			continue
+		}
+
+		if payload == nil {
+			payload, err = json.Marshal(Payload{
+				Type:       eventType,
+				Event:      event,
+				OccurredAt: time.Now().Format(time.DateTime),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = s.CreateDelivery(ctx, storage.WebhookDelivery{
+			WebhookID: w.ID,
+			Key:       key,
+			EventType: eventType,
+			EventID:   event.ID,
+			Payload:   string(payload),
+		})
+		if err != nil && !errors.Is(err, storage.ErrDeliveryDuplicate) && !errors.Is(err, storage.ErrWebhookNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// enqueueRetryDelay - пауза перед повтором изменения, которое не удалось поставить в очередь.
+const enqueueRetryDelay = 5 * time.Second
+
+// Enqueuer ставит в очередь доставки для изменений событий из feed.Hub.
+type Enqueuer struct {
+	storage    EnqueueStorage
+	hub        *feed.Hub
+	logger     Logger
+	retryDelay time.Duration
+}
+
+func NewEnqueuer(s EnqueueStorage, hub *feed.Hub, logger Logger) *Enqueuer {
+	return &Enqueuer{
+		storage:    s,
+		hub:        hub,
+		logger:     logger,
+		retryDelay: enqueueRetryDelay,
+	}
+}
+
+// Run ставит в очередь доставки, пока не отменен ctx. Курсор берется до подписки и не сбрасывается
+// в ноль, поэтому изменения, пришедшие между переподписками, досылаются из истории hub.
+// Курсор не сдвигается за изменение, которое не удалось поставить в очередь: после паузы
+// оно повторяется из истории. Если ошибка случилась после части webhooks, им доставка уйдет дважды.
+func (e *Enqueuer) Run(ctx context.Context) {
+	lastID := e.hub.LastID()
+
+	for {
+		sub, err := e.hub.SubscribeAfter(feed.Filter{}, lastID)
+		if errors.Is(err, feed.ErrHistoryExpired) {
+			next := e.hub.LastID()
+			e.logger.Error(fmt.Sprintf("webhook enqueuer lost changes from id %d to %d", lastID+1, next))
+			lastID = next
+			continue
+		}
+		if err != nil {
+			e.logger.Error("webhook enqueuer subscribe failed: " + err.Error())
+			return
+		}
+
+		lastID, err = e.consume(ctx, sub, lastID)
+		sub.Close()
+
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			continue
+		}
+
+		e.logger.Error(fmt.Sprintf("failed to enqueue webhook for change %d: %s", lastID+1, err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(e.retryDelay):
+		}
+	}
+}
+
+// consume возвращает id последнего изменения, поставленного в очередь, и ошибку следующего.
+func (e *Enqueuer) consume(ctx context.Context, sub *feed.Subscription, lastID uint64) (uint64, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return lastID, nil
+		case c, ok := <-sub.C:
+			if !ok {
+				return lastID, nil
+			}
+
+			err := Enqueue(ctx, e.storage, string(c.Type), c.Event, "")
+			if err != nil {
+				return lastID, err
+			}
+
+			lastID = c.ID
+		}
+	}
+}
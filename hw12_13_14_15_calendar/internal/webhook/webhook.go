@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+)
+
+const (
+	HeaderEvent     = "X-Calendar-Event"
+	HeaderDelivery  = "X-Calendar-Delivery"
+	HeaderTimestamp = "X-Calendar-Timestamp"
+	HeaderSignature = "X-Calendar-Signature"
+
+	signaturePrefix = "sha256="
+)
+
+type Logger interface {
+	Info(msg string)
+	Error(msg string)
+}
+
+// Payload - тело запроса, которое получает подписчик.
+type Payload struct {
+	Type       string        `json:"type"`
+	Event      storage.Event `json:"event"`
+	OccurredAt string        `json:"occurredAt"`
+}
+
+// Message - задание на отправку, которое scheduler передает sender через брокер.
+type Message struct {
+	DeliveryID string `json:"deliveryId"`
+	URL        string `json:"url"`
+	Secret     string `json:"secret"`
+	EventType  string `json:"eventType"`
+	Payload    string `json:"payload"`
+	Attempt    int    `json:"attempt"`
+}
+
+// Result - результат отправки, который sender возвращает scheduler через брокер.
+type Result struct {
+	DeliveryID string `json:"deliveryId"`
+	Attempt    int    `json:"attempt"`
+	StatusCode int    `json:"statusCode"`
+	Error      string `json:"error"`
+}
+
+func (r Result) Success() bool {
+	return r.Error == "" && r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// Sign возвращает подпись HMAC-SHA256 от строки "timestamp.body".
+// Подписчик проверяет ее по заголовкам X-Calendar-Timestamp и X-Calendar-Signature.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+func Verify(secret string, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
@@ -0,0 +1,418 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/feed"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
+	memorystorage "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage/memory"
+	"github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/mocks"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "0123456789abcdef"
+
+type dispatchStorage struct {
+	*memorystorage.Storage
+	reminders []storage.Event
+}
+
+func (s *dispatchStorage) ListEventWithNotification(_ context.Context) ([]storage.Event, error) {
+	return s.reminders, nil
+}
+
+type publisher struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func (p *publisher) Publish(_ context.Context, _ string, v interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.messages = append(p.messages, v.(Message))
+	return nil
+}
+
+func newWebhook(t *testing.T, s *memorystorage.Storage, eventTypes ...string) string {
+	t.Helper()
+
+	id, err := s.CreateWebhook(context.Background(), storage.Webhook{URL: "http://localhost/hook", Secret: testSecret, EventTypes: eventTypes})
+	require.NoError(t, err)
+
+	return id
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"type":"created"}`)
+
+	signature := Sign(testSecret, "1700000000", body)
+
+	require.Equal(t, "sha256=", signature[:7])
+	require.True(t, Verify(testSecret, "1700000000", body, signature))
+	require.False(t, Verify(testSecret, "1700000001", body, signature))
+	require.False(t, Verify("another secret", "1700000000", body, signature))
+}
+
+func TestBackoff(t *testing.T) {
+	base := 10 * time.Second
+	maxBackoff := time.Minute
+
+	require.Equal(t, 10*time.Second, Backoff(1, base, maxBackoff))
+	require.Equal(t, 20*time.Second, Backoff(2, base, maxBackoff))
+	require.Equal(t, 40*time.Second, Backoff(3, base, maxBackoff))
+	require.Equal(t, time.Minute, Backoff(4, base, maxBackoff))
+	require.Equal(t, time.Minute, Backoff(50, base, maxBackoff))
+}
+
+func TestEnqueue(t *testing.T) {
+	s := memorystorage.New()
+	created := newWebhook(t, s, storage.WebhookEventCreated)
+	reminder := newWebhook(t, s, storage.WebhookEventReminder)
+
+	e := storage.Event{ID: "1", Title: "event"}
+
+	require.NoError(t, Enqueue(context.Background(), s, storage.WebhookEventCreated, e, ""))
+	require.NoError(t, Enqueue(context.Background(), s, storage.WebhookEventReminder, e, "reminder:1"))
+	require.NoError(t, Enqueue(context.Background(), s, storage.WebhookEventReminder, e, "reminder:1"))
+
+	deliveries, err := s.ListDeliveries(context.Background(), created)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+
+	var p Payload
+	require.NoError(t, json.Unmarshal([]byte(deliveries[0].Payload), &p))
+	require.Equal(t, storage.WebhookEventCreated, p.Type)
+	require.Equal(t, "event", p.Event.Title)
+
+	deliveries, err = s.ListDeliveries(context.Background(), reminder)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, storage.DeliveryPending, deliveries[0].Status)
+}
+
+func TestEnqueuer(t *testing.T) {
+	l := mocks.NewLogger(t)
+	s := memorystorage.New()
+	id := newWebhook(t, s, storage.WebhookEventDeleted)
+
+	hub := feed.NewHub(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		NewEnqueuer(s, hub, l).Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		hub.Publish(feed.ChangeDeleted, storage.Event{ID: "1"})
+		deliveries, err := s.ListDeliveries(context.Background(), id)
+		return err == nil && len(deliveries) > 0
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+}
+
+// slowStorage задерживает первый ListWebhooks, пока тест не заполнит буфер подписки.
+type slowStorage struct {
+	*memorystorage.Storage
+	blocked chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (s *slowStorage) ListWebhooks(ctx context.Context) ([]storage.Webhook, error) {
+	s.once.Do(func() {
+		close(s.blocked)
+		<-s.release
+	})
+
+	return s.Storage.ListWebhooks(ctx)
+}
+
+func TestEnqueuerSlowStorage(t *testing.T) {
+	l := mocks.NewLogger(t)
+	s := &slowStorage{Storage: memorystorage.New(), blocked: make(chan struct{}), release: make(chan struct{})}
+	id := newWebhook(t, s.Storage, storage.WebhookEventUpdated)
+
+	hub := feed.NewHub(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		NewEnqueuer(s, hub, l).Run(ctx)
+	}()
+
+	// Первое изменение публикуется, пока Enqueuer не подпишется и не начнет его обрабатывать.
+	require.Eventually(t, func() bool {
+		hub.Publish(feed.ChangeUpdated, storage.Event{ID: "first"})
+
+		select {
+		case <-s.blocked:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+
+	// Буфер подписки переполняется, пока первое изменение еще не обработано.
+	const changes = 200
+	for i := 0; i < changes; i++ {
+		hub.Publish(feed.ChangeUpdated, storage.Event{ID: strconv.Itoa(i)})
+	}
+	close(s.release)
+
+	require.Eventually(t, func() bool {
+		deliveries, err := s.ListDeliveries(context.Background(), id)
+		if err != nil {
+			return false
+		}
+
+		enqueued := make(map[string]struct{}, len(deliveries))
+		for _, d := range deliveries {
+			enqueued[d.EventID] = struct{}{}
+		}
+
+		return len(enqueued) == changes+1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+}
+
+// flakyStorage не может поставить в очередь первую доставку и запоминает ее событие.
+type flakyStorage struct {
+	*memorystorage.Storage
+	once   sync.Once
+	failed chan string
+}
+
+func (s *flakyStorage) CreateDelivery(ctx context.Context, d storage.WebhookDelivery) (string, error) {
+	var err error
+	s.once.Do(func() {
+		err = storage.ErrUnavailable
+		s.failed <- d.EventID
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return s.Storage.CreateDelivery(ctx, d)
+}
+
+func TestEnqueuerRetry(t *testing.T) {
+	l := mocks.NewLogger(t)
+	l.On("Error", mock.MatchedBy(func(msg string) bool {
+		return strings.HasPrefix(msg, "failed to enqueue webhook for change")
+	})).Once()
+
+	s := &flakyStorage{Storage: memorystorage.New(), failed: make(chan string, 1)}
+	id := newWebhook(t, s.Storage, storage.WebhookEventCreated)
+
+	hub := feed.NewHub(0)
+	e := NewEnqueuer(s, hub, l)
+	e.retryDelay = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		e.Run(ctx)
+	}()
+
+	var failed string
+	require.Eventually(t, func() bool {
+		hub.Publish(feed.ChangeCreated, storage.Event{ID: strconv.Itoa(int(hub.LastID()))})
+
+		select {
+		case failed = <-s.failed:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		deliveries, err := s.ListDeliveries(context.Background(), id)
+		if err != nil {
+			return false
+		}
+
+		for _, d := range deliveries {
+			if d.EventID == failed {
+				return true
+			}
+		}
+
+		return false
+	}, time.Second, 10*time.Millisecond, "failed change is retried")
+
+	cancel()
+	<-done
+}
+
+func TestDispatcher(t *testing.T) {
+	config := DispatchConfig{
+		Queue:       "webhooks",
+		MaxAttempts: 2,
+		Backoff:     time.Minute,
+		MaxBackoff:  time.Hour,
+		Lease:       time.Minute,
+		BatchSize:   10,
+	}
+
+	t.Run("dispatch and retry", func(t *testing.T) {
+		s := &dispatchStorage{
+			Storage:   memorystorage.New(),
+			reminders: []storage.Event{{ID: "1", DatePost: "2022-10-10 12:00:00"}},
+		}
+		id := newWebhook(t, s.Storage, storage.WebhookEventReminder)
+		p := &publisher{}
+
+		l := mocks.NewLogger(t)
+		l.On("Info", mock.AnythingOfType("string")).Maybe()
+
+		d := NewDispatcher(s, p, l, config)
+
+		require.NoError(t, d.EnqueueReminders(context.Background()))
+		require.NoError(t, d.EnqueueReminders(context.Background()))
+		require.NoError(t, d.Dispatch(context.Background()))
+
+		require.Len(t, p.messages, 1)
+		m := p.messages[0]
+		require.Equal(t, "http://localhost/hook", m.URL)
+		require.Equal(t, testSecret, m.Secret)
+		require.Equal(t, 1, m.Attempt)
+
+		// Доставка арендована, повторно до истечения аренды не отправляется.
+		require.NoError(t, d.Dispatch(context.Background()))
+		require.Len(t, p.messages, 1)
+
+		deliveries, err := s.ListDeliveries(context.Background(), id)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, deliveries[0].NextAttemptAt,
+			time.Now().Add(config.Lease+config.Backoff).Add(-time.Second).Format(time.DateTime), "lease includes backoff")
+
+		require.NoError(t, d.HandleResult(context.Background(), Result{DeliveryID: m.DeliveryID, Attempt: 1, StatusCode: http.StatusBadGateway}))
+
+		deliveries, err = s.ListDeliveries(context.Background(), id)
+		require.NoError(t, err)
+		require.Equal(t, storage.DeliveryPending, deliveries[0].Status)
+		require.Equal(t, "unexpected response status 502", deliveries[0].LastError)
+		require.Greater(t, deliveries[0].NextAttemptAt, time.Now().Format(time.DateTime))
+
+		// Повторный результат той же попытки устарел и не меняет доставку.
+		require.NoError(t, d.HandleResult(context.Background(),
+			Result{DeliveryID: m.DeliveryID, Attempt: 1, StatusCode: http.StatusOK}))
+
+		deliveries, err = s.ListDeliveries(context.Background(), id)
+		require.NoError(t, err)
+		require.Equal(t, storage.DeliveryPending, deliveries[0].Status)
+	})
+
+	t.Run("last attempt fails", func(t *testing.T) {
+		s := &dispatchStorage{Storage: memorystorage.New()}
+		id := newWebhook(t, s.Storage, storage.WebhookEventCreated)
+		require.NoError(t, Enqueue(context.Background(), s, storage.WebhookEventCreated, storage.Event{ID: "1"}, ""))
+
+		p := &publisher{}
+
+		l := mocks.NewLogger(t)
+		l.On("Info", mock.AnythingOfType("string")).Maybe()
+
+		last := config
+		last.MaxAttempts = 1
+		d := NewDispatcher(s, p, l, last)
+
+		require.NoError(t, d.Dispatch(context.Background()))
+		require.Len(t, p.messages, 1)
+
+		require.NoError(t, d.HandleResult(context.Background(),
+			Result{DeliveryID: p.messages[0].DeliveryID, Attempt: 1, Error: "timeout"}))
+
+		deliveries, err := s.ListDeliveries(context.Background(), id)
+		require.NoError(t, err)
+		require.Equal(t, storage.DeliveryFailed, deliveries[0].Status)
+	})
+
+	t.Run("delivered", func(t *testing.T) {
+		s := &dispatchStorage{Storage: memorystorage.New()}
+		id := newWebhook(t, s.Storage, storage.WebhookEventCreated)
+		require.NoError(t, Enqueue(context.Background(), s, storage.WebhookEventCreated, storage.Event{ID: "1"}, ""))
+
+		p := &publisher{}
+
+		l := mocks.NewLogger(t)
+		l.On("Info", mock.AnythingOfType("string")).Maybe()
+
+		d := NewDispatcher(s, p, l, config)
+
+		require.NoError(t, d.Dispatch(context.Background()))
+		require.Len(t, p.messages, 1)
+
+		require.NoError(t, d.HandleResult(context.Background(), Result{DeliveryID: p.messages[0].DeliveryID, Attempt: 1, StatusCode: http.StatusOK}))
+
+		// Опоздавший результат не возвращает доставленный webhook в очередь.
+		require.NoError(t, d.HandleResult(context.Background(),
+			Result{DeliveryID: p.messages[0].DeliveryID, Attempt: 1, StatusCode: http.StatusBadGateway}))
+
+		deliveries, err := s.ListDeliveries(context.Background(), id)
+		require.NoError(t, err)
+		require.Equal(t, storage.DeliveryDelivered, deliveries[0].Status)
+		require.Equal(t, http.StatusOK, deliveries[0].ResponseStatus)
+	})
+}
+
+func TestClient(t *testing.T) {
+	payload := `{"type":"created"}`
+
+	var header http.Header
+	var body []byte
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	c := NewClient(time.Second)
+
+	r := c.Deliver(context.Background(), Message{
+		DeliveryID: "d1",
+		URL:        ts.URL,
+		Secret:     testSecret,
+		EventType:  "created",
+		Payload:    payload,
+		Attempt:    3,
+	})
+	require.True(t, r.Success())
+	require.Equal(t, http.StatusAccepted, r.StatusCode)
+	require.Equal(t, 3, r.Attempt)
+
+	require.Equal(t, payload, string(body))
+	require.Equal(t, "created", header.Get(HeaderEvent))
+	require.Equal(t, "d1", header.Get(HeaderDelivery))
+	require.True(t, Verify(testSecret, header.Get(HeaderTimestamp), body, header.Get(HeaderSignature)))
+
+	r = c.Deliver(context.Background(), Message{DeliveryID: "d2", URL: "http://127.0.0.1:1"})
+	require.False(t, r.Success())
+	require.NotEmpty(t, r.Error)
+}
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Совпадающие даты начала миграция не удаляет, а останавливается с ошибкой:
+-- такие события нужно перенести или удалить вручную и повторить миграцию.
+DO $$
+DECLARE
+    conflicts bigint;
+BEGIN
+    SELECT count(*) INTO conflicts
+    FROM (SELECT date_start FROM events GROUP BY date_start HAVING count(*) > 1) AS d;
+
+    IF conflicts > 0 THEN
+        RAISE EXCEPTION 'cannot create unique index: % date_start values are used by several events', conflicts
+            USING HINT = 'SELECT date_start, array_agg(id) FROM events GROUP BY date_start HAVING count(*) > 1';
+    END IF;
+END
+$$;
+
+CREATE UNIQUE INDEX events_date_start_idx ON events (date_start);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX events_date_start_idx;
+-- +goose StatementEnd
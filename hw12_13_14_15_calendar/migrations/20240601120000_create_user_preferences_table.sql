@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE user_preferences(
+    user_id UUID PRIMARY KEY,
+    daily_digest BOOLEAN NOT NULL DEFAULT true,
+    weekly_digest BOOLEAN NOT NULL DEFAULT true,
+    digest_time VARCHAR(5) NOT NULL DEFAULT '08:00'
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_preferences;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE user_preferences ADD COLUMN channel VARCHAR(16) NOT NULL DEFAULT '';
+ALTER TABLE user_preferences ADD COLUMN address VARCHAR(255) NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_preferences DROP COLUMN address;
+ALTER TABLE user_preferences DROP COLUMN channel;
+-- +goose StatementEnd
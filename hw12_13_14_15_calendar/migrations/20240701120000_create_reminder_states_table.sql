@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE reminder_states(
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    snoozed_until timestamp DEFAULT NULL,
+    dismissed BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at timestamp NOT NULL DEFAULT now(),
+    PRIMARY KEY (event_id, user_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE reminder_states;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE events ADD COLUMN category VARCHAR(64) DEFAULT NULL;
+ALTER TABLE events ADD COLUMN color VARCHAR(9) DEFAULT NULL;
+
+CREATE TABLE tags(
+    name VARCHAR(32) PRIMARY KEY
+);
+
+CREATE TABLE event_tags(
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    tag VARCHAR(32) NOT NULL REFERENCES tags(name) ON UPDATE CASCADE ON DELETE CASCADE,
+    PRIMARY KEY (event_id, tag)
+);
+
+CREATE INDEX event_tags_tag_idx ON event_tags (tag);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE event_tags;
+DROP TABLE tags;
+ALTER TABLE events DROP COLUMN color;
+ALTER TABLE events DROP COLUMN category;
+-- +goose StatementEnd
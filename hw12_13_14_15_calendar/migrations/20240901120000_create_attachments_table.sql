@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE attachments(
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL DEFAULT '',
+    size BIGINT NOT NULL DEFAULT 0,
+    url TEXT DEFAULT NULL,
+    -- blob_key - ключ содержимого в хранилище файлов, у ссылок пуст.
+    blob_key TEXT DEFAULT NULL,
+    created_at timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX attachments_event_id_idx ON attachments (event_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE attachments;
+-- +goose StatementEnd
@@ -0,0 +1,110 @@
+// Package migrations встраивает миграции Postgres в бинарник и разбирает файлы в формате goose.
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed *.sql
+var FS embed.FS
+
+var ErrBadName = errors.New("migration name must contain only latin letters, digits and underscores")
+
+var nameRe = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+const versionLayout = "20060102150405"
+
+const template = `-- +goose Up
+-- +goose StatementBegin
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- +goose StatementEnd
+`
+
+// Migration - одна миграция: версия берется из префикса имени файла.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Load читает *.sql из корня fsys и возвращает миграции по возрастанию версии.
+func Load(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Migration, 0, len(files))
+	seen := make(map[int64]string, len(files))
+
+	for _, f := range files {
+		name := path.Base(f)
+		version, err := strconv.ParseInt(strings.SplitN(name, "_", 2)[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: bad version: %w", name, err)
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migration %s: version %d is already used by %s", name, version, other)
+		}
+		seen[version] = name
+
+		data, err := fs.ReadFile(fsys, f)
+		if err != nil {
+			return nil, err
+		}
+
+		up, down := sections(string(data))
+		list = append(list, Migration{Version: version, Name: name, Up: up, Down: down})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+
+	return list, nil
+}
+
+// sections делит файл goose на части после "-- +goose Up" и "-- +goose Down".
+func sections(data string) (string, string) {
+	_, rest, _ := strings.Cut(data, "-- +goose Up")
+	up, down, _ := strings.Cut(rest, "-- +goose Down")
+
+	return up, down
+}
+
+// Create создает в dir пустую миграцию с версией по времени now и возвращает путь к файлу.
+func Create(dir string, name string, now time.Time) (string, error) {
+	if !nameRe.MatchString(name) {
+		return "", ErrBadName
+	}
+
+	file := filepath.Join(dir, now.UTC().Format(versionLayout)+"_"+strings.ToLower(name)+".sql")
+
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = f.WriteString(template)
+	if err != nil {
+		f.Close()
+		return "", err
+	}
+
+	return file, f.Close()
+}
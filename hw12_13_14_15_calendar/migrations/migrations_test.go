@@ -0,0 +1,65 @@
+package migrations
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("embedded", func(t *testing.T) {
+		list, err := Load(FS)
+		require.NoError(t, err)
+		require.NotEmpty(t, list)
+
+		for i, m := range list {
+			if i > 0 {
+				require.Greater(t, m.Version, list[i-1].Version)
+			}
+			require.Regexp(t, "CREATE|ALTER", m.Up)
+			require.NotContains(t, m.Up, "DROP TABLE")
+			require.NotEmpty(t, strings.TrimSpace(m.Down), m.Name)
+		}
+	})
+
+	t.Run("bad files", func(t *testing.T) {
+		cases := []struct {
+			name string
+			fsys fstest.MapFS
+		}{
+			{"bad version", fstest.MapFS{"first_create.sql": {}}},
+			{"duplicate version", fstest.MapFS{"1_create.sql": {}, "1_update.sql": {}}},
+		}
+
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				_, err := Load(tc.fsys)
+				require.Error(t, err)
+			})
+		}
+	})
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	file, err := Create(dir, "Add_Rooms", now)
+	require.NoError(t, err)
+	require.Contains(t, file, "20240506070809_add_rooms.sql")
+
+	list, err := Load(os.DirFS(dir))
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, int64(20240506070809), list[0].Version)
+
+	_, err = Create(dir, "add_rooms", now)
+	require.ErrorIs(t, err, os.ErrExist)
+
+	_, err = Create(dir, "add rooms", now)
+	require.ErrorIs(t, err, ErrBadName)
+}
@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	context "context"
+
 	storage "github.com/spmadness/otus-go-hw/hw12_13_14_15_calendar/internal/storage"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -12,6 +14,66 @@ type Storager struct {
 	mock.Mock
 }
 
+// ApplyBatch provides a mock function with given fields: ctx, ops
+func (_m *Storager) ApplyBatch(ctx context.Context, ops []storage.BatchOperation) ([]storage.BatchResult, error) {
+	ret := _m.Called(ctx, ops)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ApplyBatch")
+	}
+
+	var r0 []storage.BatchResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []storage.BatchOperation) ([]storage.BatchResult, error)); ok {
+		return rf(ctx, ops)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []storage.BatchOperation) []storage.BatchResult); ok {
+		r0 = rf(ctx, ops)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.BatchResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []storage.BatchOperation) error); ok {
+		r1 = rf(ctx, ops)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ClaimDeliveries provides a mock function with given fields: ctx, now, leaseUntil, limit, maxAttempts
+func (_m *Storager) ClaimDeliveries(ctx context.Context, now string, leaseUntil string, limit int, maxAttempts int) ([]storage.WebhookDelivery, error) {
+	ret := _m.Called(ctx, now, leaseUntil, limit, maxAttempts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimDeliveries")
+	}
+
+	var r0 []storage.WebhookDelivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int, int) ([]storage.WebhookDelivery, error)); ok {
+		return rf(ctx, now, leaseUntil, limit, maxAttempts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int, int) []storage.WebhookDelivery); ok {
+		r0 = rf(ctx, now, leaseUntil, limit, maxAttempts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.WebhookDelivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int, int) error); ok {
+		r1 = rf(ctx, now, leaseUntil, limit, maxAttempts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Close provides a mock function with given fields:
 func (_m *Storager) Close() error {
 	ret := _m.Called()
@@ -30,17 +92,157 @@ func (_m *Storager) Close() error {
 	return r0
 }
 
-// CreateEvent provides a mock function with given fields: event
-func (_m *Storager) CreateEvent(event storage.Event) error {
-	ret := _m.Called(event)
+// CreateAttachment provides a mock function with given fields: ctx, a
+func (_m *Storager) CreateAttachment(ctx context.Context, a storage.Attachment) (string, error) {
+	ret := _m.Called(ctx, a)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAttachment")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.Attachment) (string, error)); ok {
+		return rf(ctx, a)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.Attachment) string); ok {
+		r0 = rf(ctx, a)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.Attachment) error); ok {
+		r1 = rf(ctx, a)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateDelivery provides a mock function with given fields: ctx, d
+func (_m *Storager) CreateDelivery(ctx context.Context, d storage.WebhookDelivery) (string, error) {
+	ret := _m.Called(ctx, d)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateDelivery")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.WebhookDelivery) (string, error)); ok {
+		return rf(ctx, d)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.WebhookDelivery) string); ok {
+		r0 = rf(ctx, d)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.WebhookDelivery) error); ok {
+		r1 = rf(ctx, d)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateEvent provides a mock function with given fields: ctx, event
+func (_m *Storager) CreateEvent(ctx context.Context, event storage.Event) (string, error) {
+	ret := _m.Called(ctx, event)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreateEvent")
 	}
 
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.Event) (string, error)); ok {
+		return rf(ctx, event)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.Event) string); ok {
+		r0 = rf(ctx, event)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.Event) error); ok {
+		r1 = rf(ctx, event)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateResource provides a mock function with given fields: ctx, r
+func (_m *Storager) CreateResource(ctx context.Context, r storage.Resource) (string, error) {
+	ret := _m.Called(ctx, r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateResource")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.Resource) (string, error)); ok {
+		return rf(ctx, r)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.Resource) string); ok {
+		r0 = rf(ctx, r)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.Resource) error); ok {
+		r1 = rf(ctx, r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateWebhook provides a mock function with given fields: ctx, w
+func (_m *Storager) CreateWebhook(ctx context.Context, w storage.Webhook) (string, error) {
+	ret := _m.Called(ctx, w)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWebhook")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.Webhook) (string, error)); ok {
+		return rf(ctx, w)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.Webhook) string); ok {
+		r0 = rf(ctx, w)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.Webhook) error); ok {
+		r1 = rf(ctx, w)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteAttachment provides a mock function with given fields: ctx, id
+func (_m *Storager) DeleteAttachment(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteAttachment")
+	}
+
 	var r0 error
-	if rf, ok := ret.Get(0).(func(storage.Event) error); ok {
-		r0 = rf(event)
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -48,17 +250,35 @@ func (_m *Storager) CreateEvent(event storage.Event) error {
 	return r0
 }
 
-// DeleteEvent provides a mock function with given fields: id
-func (_m *Storager) DeleteEvent(id string) error {
-	ret := _m.Called(id)
+// DeleteEvent provides a mock function with given fields: ctx, id
+func (_m *Storager) DeleteEvent(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
 		panic("no return value specified for DeleteEvent")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteResource provides a mock function with given fields: ctx, id
+func (_m *Storager) DeleteResource(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteResource")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -66,9 +286,73 @@ func (_m *Storager) DeleteEvent(id string) error {
 	return r0
 }
 
-// GetEvent provides a mock function with given fields: id
-func (_m *Storager) GetEvent(id string) (storage.Event, error) {
-	ret := _m.Called(id)
+// DeleteTag provides a mock function with given fields: ctx, name
+func (_m *Storager) DeleteTag(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteTag")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteWebhook provides a mock function with given fields: ctx, id
+func (_m *Storager) DeleteWebhook(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteWebhook")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAttachment provides a mock function with given fields: ctx, id
+func (_m *Storager) GetAttachment(ctx context.Context, id string) (storage.Attachment, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAttachment")
+	}
+
+	var r0 storage.Attachment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (storage.Attachment, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) storage.Attachment); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(storage.Attachment)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetEvent provides a mock function with given fields: ctx, id
+func (_m *Storager) GetEvent(ctx context.Context, id string) (storage.Event, error) {
+	ret := _m.Called(ctx, id)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetEvent")
@@ -76,17 +360,219 @@ func (_m *Storager) GetEvent(id string) (storage.Event, error) {
 
 	var r0 storage.Event
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (storage.Event, error)); ok {
-		return rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (storage.Event, error)); ok {
+		return rf(ctx, id)
 	}
-	if rf, ok := ret.Get(0).(func(string) storage.Event); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string) storage.Event); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Get(0).(storage.Event)
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPreferences provides a mock function with given fields: ctx, userID
+func (_m *Storager) GetPreferences(ctx context.Context, userID string) (storage.Preferences, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPreferences")
+	}
+
+	var r0 storage.Preferences
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (storage.Preferences, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) storage.Preferences); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(storage.Preferences)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetReminderState provides a mock function with given fields: ctx, eventID, userID
+func (_m *Storager) GetReminderState(ctx context.Context, eventID string, userID string) (storage.ReminderState, error) {
+	ret := _m.Called(ctx, eventID, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetReminderState")
+	}
+
+	var r0 storage.ReminderState
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (storage.ReminderState, error)); ok {
+		return rf(ctx, eventID, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) storage.ReminderState); ok {
+		r0 = rf(ctx, eventID, userID)
+	} else {
+		r0 = ret.Get(0).(storage.ReminderState)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, eventID, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetResource provides a mock function with given fields: ctx, id
+func (_m *Storager) GetResource(ctx context.Context, id string) (storage.Resource, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetResource")
+	}
+
+	var r0 storage.Resource
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (storage.Resource, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) storage.Resource); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(storage.Resource)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWebhook provides a mock function with given fields: ctx, id
+func (_m *Storager) GetWebhook(ctx context.Context, id string) (storage.Webhook, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWebhook")
+	}
+
+	var r0 storage.Webhook
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (storage.Webhook, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) storage.Webhook); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(storage.Webhook)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListAttachments provides a mock function with given fields: ctx, eventID
+func (_m *Storager) ListAttachments(ctx context.Context, eventID string) ([]storage.Attachment, error) {
+	ret := _m.Called(ctx, eventID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAttachments")
+	}
+
+	var r0 []storage.Attachment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]storage.Attachment, error)); ok {
+		return rf(ctx, eventID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []storage.Attachment); ok {
+		r0 = rf(ctx, eventID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Attachment)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, eventID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListBookings provides a mock function with given fields: ctx, dateFrom, dateTo
+func (_m *Storager) ListBookings(ctx context.Context, dateFrom string, dateTo string) ([]storage.Booking, error) {
+	ret := _m.Called(ctx, dateFrom, dateTo)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListBookings")
+	}
+
+	var r0 []storage.Booking
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]storage.Booking, error)); ok {
+		return rf(ctx, dateFrom, dateTo)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []storage.Booking); ok {
+		r0 = rf(ctx, dateFrom, dateTo)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Booking)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, dateFrom, dateTo)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListDeliveries provides a mock function with given fields: ctx, webhookID
+func (_m *Storager) ListDeliveries(ctx context.Context, webhookID string) ([]storage.WebhookDelivery, error) {
+	ret := _m.Called(ctx, webhookID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListDeliveries")
+	}
+
+	var r0 []storage.WebhookDelivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]storage.WebhookDelivery, error)); ok {
+		return rf(ctx, webhookID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []storage.WebhookDelivery); ok {
+		r0 = rf(ctx, webhookID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.WebhookDelivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, webhookID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -94,9 +580,9 @@ func (_m *Storager) GetEvent(id string) (storage.Event, error) {
 	return r0, r1
 }
 
-// ListEventDay provides a mock function with given fields: date
-func (_m *Storager) ListEventDay(date string) ([]storage.Event, error) {
-	ret := _m.Called(date)
+// ListEventDay provides a mock function with given fields: ctx, date
+func (_m *Storager) ListEventDay(ctx context.Context, date string) ([]storage.Event, error) {
+	ret := _m.Called(ctx, date)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ListEventDay")
@@ -104,19 +590,19 @@ func (_m *Storager) ListEventDay(date string) ([]storage.Event, error) {
 
 	var r0 []storage.Event
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) ([]storage.Event, error)); ok {
-		return rf(date)
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]storage.Event, error)); ok {
+		return rf(ctx, date)
 	}
-	if rf, ok := ret.Get(0).(func(string) []storage.Event); ok {
-		r0 = rf(date)
+	if rf, ok := ret.Get(0).(func(context.Context, string) []storage.Event); ok {
+		r0 = rf(ctx, date)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]storage.Event)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(date)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, date)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -124,9 +610,9 @@ func (_m *Storager) ListEventDay(date string) ([]storage.Event, error) {
 	return r0, r1
 }
 
-// ListEventMonth provides a mock function with given fields: date
-func (_m *Storager) ListEventMonth(date string) ([]storage.Event, error) {
-	ret := _m.Called(date)
+// ListEventMonth provides a mock function with given fields: ctx, date
+func (_m *Storager) ListEventMonth(ctx context.Context, date string) ([]storage.Event, error) {
+	ret := _m.Called(ctx, date)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ListEventMonth")
@@ -134,19 +620,19 @@ func (_m *Storager) ListEventMonth(date string) ([]storage.Event, error) {
 
 	var r0 []storage.Event
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) ([]storage.Event, error)); ok {
-		return rf(date)
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]storage.Event, error)); ok {
+		return rf(ctx, date)
 	}
-	if rf, ok := ret.Get(0).(func(string) []storage.Event); ok {
-		r0 = rf(date)
+	if rf, ok := ret.Get(0).(func(context.Context, string) []storage.Event); ok {
+		r0 = rf(ctx, date)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]storage.Event)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(date)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, date)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -154,9 +640,69 @@ func (_m *Storager) ListEventMonth(date string) ([]storage.Event, error) {
 	return r0, r1
 }
 
-// ListEventWeek provides a mock function with given fields: date
-func (_m *Storager) ListEventWeek(date string) ([]storage.Event, error) {
-	ret := _m.Called(date)
+// ListEventRange provides a mock function with given fields: ctx, dateFrom, dateTo
+func (_m *Storager) ListEventRange(ctx context.Context, dateFrom string, dateTo string) ([]storage.Event, error) {
+	ret := _m.Called(ctx, dateFrom, dateTo)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListEventRange")
+	}
+
+	var r0 []storage.Event
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]storage.Event, error)); ok {
+		return rf(ctx, dateFrom, dateTo)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []storage.Event); ok {
+		r0 = rf(ctx, dateFrom, dateTo)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Event)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, dateFrom, dateTo)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListEventTagged provides a mock function with given fields: ctx, tags, dateFrom, dateTo
+func (_m *Storager) ListEventTagged(ctx context.Context, tags []string, dateFrom string, dateTo string) ([]storage.Event, error) {
+	ret := _m.Called(ctx, tags, dateFrom, dateTo)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListEventTagged")
+	}
+
+	var r0 []storage.Event
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string, string, string) ([]storage.Event, error)); ok {
+		return rf(ctx, tags, dateFrom, dateTo)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string, string, string) []storage.Event); ok {
+		r0 = rf(ctx, tags, dateFrom, dateTo)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Event)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string, string, string) error); ok {
+		r1 = rf(ctx, tags, dateFrom, dateTo)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListEventWeek provides a mock function with given fields: ctx, date
+func (_m *Storager) ListEventWeek(ctx context.Context, date string) ([]storage.Event, error) {
+	ret := _m.Called(ctx, date)
 
 	if len(ret) == 0 {
 		panic("no return value specified for ListEventWeek")
@@ -164,19 +710,19 @@ func (_m *Storager) ListEventWeek(date string) ([]storage.Event, error) {
 
 	var r0 []storage.Event
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) ([]storage.Event, error)); ok {
-		return rf(date)
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]storage.Event, error)); ok {
+		return rf(ctx, date)
 	}
-	if rf, ok := ret.Get(0).(func(string) []storage.Event); ok {
-		r0 = rf(date)
+	if rf, ok := ret.Get(0).(func(context.Context, string) []storage.Event); ok {
+		r0 = rf(ctx, date)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]storage.Event)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(date)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, date)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -184,17 +730,143 @@ func (_m *Storager) ListEventWeek(date string) ([]storage.Event, error) {
 	return r0, r1
 }
 
-// Open provides a mock function with given fields:
-func (_m *Storager) Open() error {
-	ret := _m.Called()
+// ListResources provides a mock function with given fields: ctx
+func (_m *Storager) ListResources(ctx context.Context) ([]storage.Resource, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListResources")
+	}
+
+	var r0 []storage.Resource
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]storage.Resource, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []storage.Resource); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Resource)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListTags provides a mock function with given fields: ctx
+func (_m *Storager) ListTags(ctx context.Context) ([]storage.Tag, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListTags")
+	}
+
+	var r0 []storage.Tag
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]storage.Tag, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []storage.Tag); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Tag)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListWebhooks provides a mock function with given fields: ctx
+func (_m *Storager) ListWebhooks(ctx context.Context) ([]storage.Webhook, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListWebhooks")
+	}
+
+	var r0 []storage.Webhook
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]storage.Webhook, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []storage.Webhook); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Webhook)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkReminderNotified provides a mock function with given fields: ctx, eventID, userID, key
+func (_m *Storager) MarkReminderNotified(ctx context.Context, eventID string, userID string, key string) error {
+	ret := _m.Called(ctx, eventID, userID, key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkReminderNotified")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, eventID, userID, key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Open provides a mock function with given fields: ctx
+func (_m *Storager) Open(ctx context.Context) error {
+	ret := _m.Called(ctx)
 
 	if len(ret) == 0 {
 		panic("no return value specified for Open")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func() error); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RenameTag provides a mock function with given fields: ctx, name, newName
+func (_m *Storager) RenameTag(ctx context.Context, name string, newName string) error {
+	ret := _m.Called(ctx, name, newName)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RenameTag")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, name, newName)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -202,17 +874,89 @@ func (_m *Storager) Open() error {
 	return r0
 }
 
-// UpdateEvent provides a mock function with given fields: id, event
-func (_m *Storager) UpdateEvent(id string, event storage.Event) error {
-	ret := _m.Called(id, event)
+// SavePreferences provides a mock function with given fields: ctx, p
+func (_m *Storager) SavePreferences(ctx context.Context, p storage.Preferences) error {
+	ret := _m.Called(ctx, p)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SavePreferences")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.Preferences) error); ok {
+		r0 = rf(ctx, p)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveReminderState provides a mock function with given fields: ctx, r
+func (_m *Storager) SaveReminderState(ctx context.Context, r storage.ReminderState) error {
+	ret := _m.Called(ctx, r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveReminderState")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.ReminderState) error); ok {
+		r0 = rf(ctx, r)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateDelivery provides a mock function with given fields: ctx, d
+func (_m *Storager) UpdateDelivery(ctx context.Context, d storage.WebhookDelivery) error {
+	ret := _m.Called(ctx, d)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateDelivery")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.WebhookDelivery) error); ok {
+		r0 = rf(ctx, d)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateEvent provides a mock function with given fields: ctx, id, event
+func (_m *Storager) UpdateEvent(ctx context.Context, id string, event storage.Event) error {
+	ret := _m.Called(ctx, id, event)
 
 	if len(ret) == 0 {
 		panic("no return value specified for UpdateEvent")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, storage.Event) error); ok {
-		r0 = rf(id, event)
+	if rf, ok := ret.Get(0).(func(context.Context, string, storage.Event) error); ok {
+		r0 = rf(ctx, id, event)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateResource provides a mock function with given fields: ctx, id, r
+func (_m *Storager) UpdateResource(ctx context.Context, id string, r storage.Resource) error {
+	ret := _m.Called(ctx, id, r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateResource")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, storage.Resource) error); ok {
+		r0 = rf(ctx, id, r)
 	} else {
 		r0 = ret.Error(0)
 	}